@@ -0,0 +1,662 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/danieldreier/mcp-flashcards/internal/storage"
+	"github.com/google/uuid"
+	gofsrs "github.com/open-spaced-repetition/go-fsrs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// addReviewDirectly records a review in storage without running the scheduler.
+func addReviewDirectly(t *testing.T, s *FlashcardService, cardID string, rating gofsrs.Rating, at time.Time) storage.Review {
+	t.Helper()
+	review := storage.Review{
+		ID:        uuid.NewString(),
+		CardID:    cardID,
+		Rating:    rating,
+		Timestamp: at,
+	}
+	if err := s.Storage.AddReviewDirect(review); err != nil {
+		t.Fatalf("Failed to add review directly: %v", err)
+	}
+	return review
+}
+
+// TestGetRatingDistribution tests counting ratings across reviews of a tag's cards
+func TestGetRatingDistribution(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Now()
+
+	bio1 := createCardDirectly(t, service, "Bio 1", "A", []string{"biology"})
+	bio2 := createCardDirectly(t, service, "Bio 2", "A", []string{"biology", "hard"})
+	other := createCardDirectly(t, service, "Other", "A", []string{"history"})
+
+	addReviewDirectly(t, service, bio1.ID, gofsrs.Again, now)
+	addReviewDirectly(t, service, bio1.ID, gofsrs.Good, now)
+	addReviewDirectly(t, service, bio1.ID, gofsrs.Easy, now)
+	addReviewDirectly(t, service, bio2.ID, gofsrs.Again, now)
+	addReviewDirectly(t, service, bio2.ID, gofsrs.Hard, now)
+	addReviewDirectly(t, service, other.ID, gofsrs.Easy, now) // Different tag, not counted
+
+	dist, err := service.GetRatingDistribution("biology")
+	require.NoError(t, err)
+	assert.Equal(t, RatingDistribution{
+		Tag:          "biology",
+		CardCount:    2,
+		TotalReviews: 5,
+		Again:        2,
+		Hard:         1,
+		Good:         1,
+		Easy:         1,
+	}, dist)
+
+	_, err = service.GetRatingDistribution("")
+	assert.Error(t, err, "Empty tag should be rejected")
+}
+
+// TestGetMaturityBreakdown tests counting new, young and mature cards by interval
+func TestGetMaturityBreakdown(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	setInterval := func(card storage.Card, days uint64) {
+		card.FSRS.State = gofsrs.Review
+		card.FSRS.ScheduledDays = days
+		updateCardDirectly(t, service, card)
+	}
+
+	createCardDirectly(t, service, "New", "A", []string{"math"})
+	setInterval(createCardDirectly(t, service, "Young", "A", []string{"math"}), 5)
+	setInterval(createCardDirectly(t, service, "Almost mature", "A", nil), MatureIntervalDays-1)
+	setInterval(createCardDirectly(t, service, "Mature", "A", []string{"math"}), MatureIntervalDays)
+	setInterval(createCardDirectly(t, service, "Very mature", "A", nil), 120)
+
+	breakdown, err := service.GetMaturityBreakdown(nil)
+	require.NoError(t, err)
+	assert.Equal(t, MaturityBreakdown{Total: 5, New: 1, Young: 2, Mature: 2}, breakdown)
+
+	breakdown, err = service.GetMaturityBreakdown([]string{"math"})
+	require.NoError(t, err)
+	assert.Equal(t, MaturityBreakdown{Total: 3, New: 1, Young: 1, Mature: 1}, breakdown)
+
+	// list_cards responses carry the computed label
+	cards, _, err := service.ListCards([]string{"math"}, false)
+	require.NoError(t, err)
+	labels := map[string]string{}
+	for _, card := range cards {
+		labels[card.Front] = card.Maturity
+	}
+	assert.Equal(t, map[string]string{"New": MaturityNew, "Young": MaturityYoung, "Mature": MaturityMature}, labels)
+}
+
+// TestAutotagByDifficulty tests that reviewed cards are tagged with their difficulty band
+func TestAutotagByDifficulty(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	setDifficulty := func(card storage.Card, difficulty float64) storage.Card {
+		card.FSRS.State = gofsrs.Review
+		card.FSRS.Difficulty = difficulty
+		updateCardDirectly(t, service, card)
+		return card
+	}
+
+	hard := setDifficulty(createCardDirectly(t, service, "Hard", "A", []string{"math"}), 8.5)
+	easy := setDifficulty(createCardDirectly(t, service, "Easy", "A", nil), 2)
+	fresh := createCardDirectly(t, service, "Unreviewed", "A", nil)
+
+	result, err := service.AutotagByDifficulty(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, result.UpdatedCards)
+	assert.Equal(t, 1, result.Bands[DifficultyTagHigh])
+	assert.Equal(t, 1, result.Bands[DifficultyTagLow])
+
+	stored, err := service.Storage.GetCard(hard.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"math", DifficultyTagHigh}, stored.Tags)
+	stored, err = service.Storage.GetCard(fresh.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.Tags, "Unreviewed cards get no band")
+
+	// Refreshing after the difficulty changes replaces the old band
+	stored, err = service.Storage.GetCard(easy.ID)
+	require.NoError(t, err)
+	setDifficulty(stored, 5)
+	result, err = service.AutotagByDifficulty(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.UpdatedCards)
+	stored, err = service.Storage.GetCard(easy.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{DifficultyTagMedium}, stored.Tags)
+}
+
+// TestSessionCards tests that only cards reviewed inside the window are returned
+func TestSessionCards(t *testing.T) {
+	service, _ := setupTestService(t)
+	sessionStart := time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC)
+	sessionEnd := sessionStart.Add(time.Hour)
+
+	inWindow := createCardDirectly(t, service, "In window", "A", nil)
+	mixed := createCardDirectly(t, service, "Mixed", "A", nil)
+	outside := createCardDirectly(t, service, "Outside", "A", nil)
+
+	addReviewDirectly(t, service, inWindow.ID, gofsrs.Again, sessionStart.Add(5*time.Minute))
+	addReviewDirectly(t, service, inWindow.ID, gofsrs.Good, sessionStart.Add(20*time.Minute))
+	addReviewDirectly(t, service, mixed.ID, gofsrs.Hard, sessionStart.Add(-24*time.Hour))
+	addReviewDirectly(t, service, mixed.ID, gofsrs.Easy, sessionStart.Add(10*time.Minute))
+	addReviewDirectly(t, service, outside.ID, gofsrs.Good, sessionEnd.Add(time.Minute))
+
+	cards, err := service.SessionCards(sessionStart, sessionEnd)
+	require.NoError(t, err)
+	require.Len(t, cards, 2)
+	assert.Equal(t, inWindow.ID, cards[0].Card.ID)
+	assert.Equal(t, []gofsrs.Rating{gofsrs.Again, gofsrs.Good}, cards[0].Ratings)
+	assert.Equal(t, mixed.ID, cards[1].Card.ID)
+	assert.Equal(t, []gofsrs.Rating{gofsrs.Easy}, cards[1].Ratings, "Reviews outside the window are excluded")
+
+	_, err = service.SessionCards(sessionEnd, sessionStart)
+	assert.Error(t, err)
+}
+
+// TestTagStateSummary tests the fraction of a tag's cards in each FSRS state
+func TestTagStateSummary(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	states := []gofsrs.State{gofsrs.New, gofsrs.Learning, gofsrs.Review, gofsrs.Review, gofsrs.Review, gofsrs.Relearning, gofsrs.New, gofsrs.Review}
+	for i, state := range states {
+		card := createCardDirectly(t, service, fmt.Sprintf("Card %d", i), "A", []string{"chemistry"})
+		stored, err := service.Storage.GetCard(card.ID)
+		require.NoError(t, err)
+		stored.FSRS.State = state
+		updateCardDirectly(t, service, stored)
+	}
+	createCardDirectly(t, service, "Other", "A", []string{"history"})
+
+	summary, err := service.TagStateSummary("chemistry")
+	require.NoError(t, err)
+	assert.Equal(t, TagStateSummary{Tag: "chemistry", CardCount: 8, New: 0.25, Learning: 0.125, Review: 0.5, Relearning: 0.125}, summary)
+
+	summary, err = service.TagStateSummary("unused")
+	require.NoError(t, err)
+	assert.Equal(t, TagStateSummary{Tag: "unused"}, summary)
+}
+
+// TestForgettingCurve tests that sampled retrievability decays over the card's interval
+func TestForgettingCurve(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	card := createCardDirectly(t, service, "Reviewed", "A", nil)
+	stored, err := service.Storage.GetCard(card.ID)
+	require.NoError(t, err)
+	stored.FSRS.State = gofsrs.Review
+	stored.FSRS.Stability = 5
+	stored.FSRS.LastReview = now.AddDate(0, 0, -1)
+	stored.FSRS.Due = now.AddDate(0, 0, 4)
+	updateCardDirectly(t, service, stored)
+
+	curve, err := service.ForgettingCurve(card.ID, 6)
+	require.NoError(t, err)
+	require.Len(t, curve.Points, 6)
+	assert.Equal(t, 0.0, curve.Points[0].DaysFromNow)
+	assert.InDelta(t, 5.0, curve.Points[5].DaysFromNow, 1e-9, "Samples should span the 5-day interval")
+	for i := 1; i < len(curve.Points); i++ {
+		assert.Less(t, curve.Points[i].Retrievability, curve.Points[i-1].Retrievability, "Retrievability should decrease at point %d", i)
+	}
+
+	unreviewed := createCardDirectly(t, service, "New", "A", nil)
+	_, err = service.ForgettingCurve(unreviewed.ID, 6)
+	assert.Error(t, err, "New cards have no forgetting curve")
+}
+
+// TestDecayForecast tests that the number of at-risk mastered cards grows over the horizon
+func TestDecayForecast(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	// Mastered cards (last rated Easy) with increasing stability decay at different rates
+	for i, stability := range []float64{2, 10, 40, 400} {
+		card := createCardDirectly(t, service, fmt.Sprintf("Mastered %d", i), "A", nil)
+		card.FSRS.State = gofsrs.Review
+		card.FSRS.Stability = stability
+		card.FSRS.LastReview = now
+		card.FSRS.Due = now.AddDate(0, 0, int(stability))
+		updateCardDirectly(t, service, card)
+		addReviewDirectly(t, service, card.ID, gofsrs.Easy, now)
+	}
+	// Not mastered, so never counted
+	unmastered := createCardDirectly(t, service, "Unmastered", "A", nil)
+	unmastered.FSRS.State = gofsrs.Review
+	unmastered.FSRS.Stability = 1
+	unmastered.FSRS.LastReview = now
+	updateCardDirectly(t, service, unmastered)
+	addReviewDirectly(t, service, unmastered.ID, gofsrs.Good, now)
+
+	ctx := context.WithValue(context.Background(), "service", service)
+	text, result := callHandlerDirectly(t, ctx, handleDecayForecast, map[string]interface{}{"days": float64(60)})
+	require.False(t, result.IsError, text)
+	var forecast DecayForecast
+	require.NoError(t, json.Unmarshal([]byte(text), &forecast), text)
+	assert.Equal(t, 4, forecast.Mastered)
+	require.Len(t, forecast.Days, 60)
+	assert.Equal(t, "2024-03-02", forecast.Days[0].Date)
+
+	for i := 1; i < len(forecast.Days); i++ {
+		assert.GreaterOrEqual(t, forecast.Days[i].AtRisk, forecast.Days[i-1].AtRisk, "day %d", i)
+	}
+	assert.Equal(t, 0, forecast.Days[0].AtRisk)
+	assert.Equal(t, 1, forecast.Days[6].AtRisk, "The 2-day card is at risk within a week")
+	assert.Equal(t, 3, forecast.Days[59].AtRisk, "Only the 400-day card is still safe after 60 days")
+}
+
+// TestTagStreaks tests the longest consecutive-day run of reviews per tag
+func TestTagStreaks(t *testing.T) {
+	service, _ := setupTestService(t)
+	day := func(d, hour int) time.Time { return time.Date(2024, 3, d, hour, 0, 0, 0, time.Local) }
+
+	spanish := createCardDirectly(t, service, "Hola", "Hello", []string{"spanish"})
+	spanish2 := createCardDirectly(t, service, "Adiós", "Goodbye", []string{"spanish", "greetings"})
+	math := createCardDirectly(t, service, "2+2", "4", []string{"math"})
+	createCardDirectly(t, service, "Unreviewed", "A", []string{"history"})
+
+	// Spanish: Mar 1-2, then Mar 4-7 across two cards (two reviews on Mar 5)
+	for _, at := range []time.Time{day(1, 9), day(2, 9), day(4, 9), day(5, 8), day(6, 9)} {
+		addReviewDirectly(t, service, spanish.ID, gofsrs.Good, at)
+	}
+	addReviewDirectly(t, service, spanish2.ID, gofsrs.Good, day(5, 20))
+	addReviewDirectly(t, service, spanish2.ID, gofsrs.Hard, day(7, 9))
+	// Math: isolated days only
+	addReviewDirectly(t, service, math.ID, gofsrs.Good, day(1, 9))
+	addReviewDirectly(t, service, math.ID, gofsrs.Good, day(3, 9))
+
+	streaks, err := service.TagStreaks()
+	require.NoError(t, err)
+	require.Len(t, streaks, 3, "Tags without reviews should be omitted")
+
+	assert.Equal(t, TagStreak{Tag: "spanish", LongestStreak: 4, StreakStart: "2024-03-04", StreakEnd: "2024-03-07"}, streaks[0])
+	assert.Equal(t, TagStreak{Tag: "greetings", LongestStreak: 1, StreakStart: "2024-03-05", StreakEnd: "2024-03-05"}, streaks[1])
+	assert.Equal(t, TagStreak{Tag: "math", LongestStreak: 1, StreakStart: "2024-03-01", StreakEnd: "2024-03-01"}, streaks[2])
+}
+
+// TestTagCloud tests that overdue cards make a tag weigh more than its card count alone
+func TestTagCloud(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Date(2024, 3, 10, 9, 0, 0, 0, time.Local)
+	defer mockTimeNow(now)()
+
+	for i, due := range []time.Time{now.AddDate(0, 0, -4), now.AddDate(0, 0, -2)} {
+		card := createCardDirectly(t, service, fmt.Sprintf("Urgent %d", i), "A", []string{"urgent"})
+		setDueDateDirectly(t, service, card.ID, due)
+	}
+	for i := 0; i < 2; i++ {
+		card := createCardDirectly(t, service, fmt.Sprintf("Calm %d", i), "A", []string{"calm"})
+		setDueDateDirectly(t, service, card.ID, now.AddDate(0, 0, 5))
+	}
+
+	entries, err := service.TagCloud()
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+
+	assert.Equal(t, TagCloudEntry{Tag: "urgent", Cards: 2, OverdueCards: 2, OverdueDays: 6, Weight: 8}, entries[0])
+	assert.Equal(t, TagCloudEntry{Tag: "calm", Cards: 2, Weight: 2}, entries[1])
+	assert.Greater(t, entries[0].Weight, entries[1].Weight)
+}
+
+// TestRegressionAlerts tests that an Again after a long Good interval is flagged
+func TestRegressionAlerts(t *testing.T) {
+	service, _ := setupTestService(t)
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.Local)
+
+	// Mature card: Good reviews stretching out, then Again after 30 days
+	regressed := createCardDirectly(t, service, "Mitochondria", "Powerhouse of the cell", []string{"biology"})
+	addReviewDirectly(t, service, regressed.ID, gofsrs.Good, start)
+	addReviewDirectly(t, service, regressed.ID, gofsrs.Good, start.AddDate(0, 0, 5))
+	addReviewDirectly(t, service, regressed.ID, gofsrs.Again, start.AddDate(0, 0, 35))
+
+	// Fresh lapse after a short interval is ordinary forgetting
+	shortLapse := createCardDirectly(t, service, "Ribosome", "Makes proteins", []string{"biology"})
+	addReviewDirectly(t, service, shortLapse.ID, gofsrs.Good, start)
+	addReviewDirectly(t, service, shortLapse.ID, gofsrs.Again, start.AddDate(0, 0, 2))
+
+	// Long interval, but the previous review was already shaky
+	shaky := createCardDirectly(t, service, "Golgi", "Packages proteins", []string{"biology"})
+	addReviewDirectly(t, service, shaky.ID, gofsrs.Hard, start)
+	addReviewDirectly(t, service, shaky.ID, gofsrs.Again, start.AddDate(0, 0, 30))
+
+	// Recovered since the lapse
+	recovered := createCardDirectly(t, service, "Nucleus", "Holds DNA", []string{"biology"})
+	addReviewDirectly(t, service, recovered.ID, gofsrs.Easy, start)
+	addReviewDirectly(t, service, recovered.ID, gofsrs.Again, start.AddDate(0, 0, 40))
+	addReviewDirectly(t, service, recovered.ID, gofsrs.Good, start.AddDate(0, 0, 41))
+
+	alerts, err := service.RegressionAlerts(DefaultRegressionIntervalDays, nil)
+	require.NoError(t, err)
+	require.Len(t, alerts, 1)
+	assert.Equal(t, regressed.ID, alerts[0].CardID)
+	assert.Equal(t, gofsrs.Good, alerts[0].PreviousRating)
+	assert.Equal(t, 30.0, alerts[0].IntervalDays)
+	assert.Equal(t, 3, alerts[0].TotalReviews)
+
+	// A lower threshold also catches the short lapse
+	alerts, err = service.RegressionAlerts(1, nil)
+	require.NoError(t, err)
+	assert.Len(t, alerts, 2)
+}
+
+// TestIntervalAdherence tests the delay between scheduled and actual review intervals
+func TestIntervalAdherence(t *testing.T) {
+	service, _ := setupTestService(t)
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	seed := func(cardID string, logs ...storage.Review) {
+		for i, review := range logs {
+			review.ID = uuid.NewString()
+			review.CardID = cardID
+			review.Rating = gofsrs.Good
+			review.Timestamp = start.Add(time.Duration(i) * time.Hour)
+			require.NoError(t, service.Storage.AddReviewDirect(review))
+		}
+	}
+
+	// Scheduled 3 days, reviewed after 5 (late by 2); then scheduled 7, reviewed after 7 (on time)
+	late := createCardDirectly(t, service, "Late", "A", []string{"math"})
+	seed(late.ID,
+		storage.Review{ScheduledDays: 3},
+		storage.Review{ScheduledDays: 7, ElapsedDays: 5},
+		storage.Review{ScheduledDays: 15, ElapsedDays: 7},
+	)
+	// Scheduled 10 days, reviewed after 4 (early by 6); the practice review in between
+	// has no schedule and isn't measured
+	early := createCardDirectly(t, service, "Early", "A", []string{"history"})
+	seed(early.ID,
+		storage.Review{ScheduledDays: 10},
+		storage.Review{Practice: true},
+		storage.Review{ScheduledDays: 12, ElapsedDays: 4},
+	)
+	// A single review has nothing to compare against
+	single := createCardDirectly(t, service, "Single", "A", []string{"math"})
+	seed(single.ID, storage.Review{ScheduledDays: 2})
+
+	adherence, err := service.IntervalAdherence(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 3, adherence.ReviewsMeasured)
+	assert.Equal(t, 1, adherence.LateReviews)
+	assert.Equal(t, 1, adherence.OnTimeReviews)
+	assert.Equal(t, 1, adherence.EarlyReviews)
+	assert.InDelta(t, -4.0/3.0, adherence.AverageDelayDays, 0.001)        // (2 + 0 - 6) / 3
+	assert.InDelta(t, 8.0/3.0, adherence.AverageAbsoluteDelayDays, 0.001) // (2 + 0 + 6) / 3
+
+	adherence, err = service.IntervalAdherence([]string{"math"})
+	require.NoError(t, err)
+	assert.Equal(t, 2, adherence.ReviewsMeasured)
+	assert.InDelta(t, 1.0, adherence.AverageDelayDays, 0.001)
+}
+
+// TestSessionAnalytics tests grouping reviews into sessions separated by idle gaps
+func TestSessionAnalytics(t *testing.T) {
+	service, _ := setupTestService(t)
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	a := createCardDirectly(t, service, "A", "A", nil)
+	b := createCardDirectly(t, service, "B", "B", nil)
+	c := createCardDirectly(t, service, "C", "C", nil)
+
+	// Morning session: 3 cards, 4 reviews over 10 minutes
+	addReviewDirectly(t, service, a.ID, gofsrs.Again, start)
+	addReviewDirectly(t, service, b.ID, gofsrs.Good, start.Add(3*time.Minute))
+	addReviewDirectly(t, service, a.ID, gofsrs.Good, start.Add(6*time.Minute))
+	addReviewDirectly(t, service, c.ID, gofsrs.Good, start.Add(10*time.Minute))
+	// Evening session, hours later: 1 card, 2 reviews over 20 minutes
+	evening := start.Add(8 * time.Hour)
+	addReviewDirectly(t, service, b.ID, gofsrs.Hard, evening)
+	addReviewDirectly(t, service, b.ID, gofsrs.Good, evening.Add(20*time.Minute))
+
+	analytics, err := service.SessionAnalytics(30 * time.Minute)
+	require.NoError(t, err)
+	require.Equal(t, 2, analytics.SessionCount)
+	assert.Equal(t, 3, analytics.Sessions[0].Cards)
+	assert.Equal(t, 4, analytics.Sessions[0].Reviews)
+	assert.InDelta(t, 10.0, analytics.Sessions[0].DurationMinutes, 0.001)
+	assert.Equal(t, 1, analytics.Sessions[1].Cards)
+	assert.InDelta(t, 20.0, analytics.Sessions[1].DurationMinutes, 0.001)
+	assert.InDelta(t, 15.0, analytics.AverageDurationMinutes, 0.001)
+	assert.InDelta(t, 2.0, analytics.AverageCardsPerSession, 0.001)
+	assert.InDelta(t, 3.0, analytics.AverageReviewsPerSession, 0.001)
+
+	// A shorter idle threshold splits the evening session in two
+	analytics, err = service.SessionAnalytics(15 * time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 3, analytics.SessionCount)
+}
+
+// TestSlowCards tests that cards with a high average answer time are flagged, slowest first
+func TestSlowCards(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Now()
+
+	addTimedReview := func(cardID string, durationMS int64) {
+		review := storage.Review{
+			ID:         uuid.NewString(),
+			CardID:     cardID,
+			Rating:     gofsrs.Good,
+			Timestamp:  now,
+			DurationMS: durationMS,
+		}
+		require.NoError(t, service.Storage.AddReviewDirect(review))
+	}
+	slowest := createCardDirectly(t, service, "Slowest", "A", nil)
+	addTimedReview(slowest.ID, 90000)
+	addTimedReview(slowest.ID, 110000)
+	slow := createCardDirectly(t, service, "Slow", "A", nil)
+	addTimedReview(slow.ID, 70000)
+	addReviewDirectly(t, service, slow.ID, gofsrs.Good, now) // Untimed; ignored in the average
+	quick := createCardDirectly(t, service, "Quick", "A", nil)
+	addTimedReview(quick.ID, 5000)
+	addTimedReview(quick.ID, 90000)
+	createCardDirectly(t, service, "Never timed", "A", nil)
+
+	// Durations reported through submit_review are stored on the review
+	ctx := context.WithValue(context.Background(), "service", service)
+	text, result := callHandlerDirectly(t, ctx, handleSubmitReview, map[string]interface{}{
+		"card_id":     quick.ID,
+		"rating":      float64(3),
+		"duration_ms": float64(1000),
+	})
+	require.False(t, result.IsError, text)
+
+	text, result = callHandlerDirectly(t, ctx, handleSlowCards, map[string]interface{}{})
+	require.False(t, result.IsError, text)
+	var cards []SlowCard
+	require.NoError(t, json.Unmarshal([]byte(text), &cards), text)
+	require.Len(t, cards, 2)
+	assert.Equal(t, slowest.ID, cards[0].Card.ID)
+	assert.Equal(t, int64(100000), cards[0].AvgAnswerMS)
+	assert.Equal(t, slow.ID, cards[1].Card.ID)
+	assert.Equal(t, 1, cards[1].TimedReviews)
+
+	// quick's three timed reviews average 32000ms
+	cards, err := service.SlowCards(30000, nil)
+	require.NoError(t, err)
+	assert.Len(t, cards, 3)
+}
+
+// TestAnswerTimeStats tests that answer durations surface in stats and help_analyze_learning
+func TestAnswerTimeStats(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+	now := time.Now()
+
+	addTimedReview := func(cardID string, rating gofsrs.Rating, durationMS int64) {
+		review := storage.Review{
+			ID:         uuid.NewString(),
+			CardID:     cardID,
+			Rating:     rating,
+			Timestamp:  now,
+			DurationMS: durationMS,
+		}
+		require.NoError(t, service.Storage.AddReviewDirect(review))
+	}
+	hard := createCardDirectly(t, service, "Hard", "A", nil)
+	addTimedReview(hard.ID, gofsrs.Again, 20000)
+	addTimedReview(hard.ID, gofsrs.Again, 40000)
+	easy := createCardDirectly(t, service, "Easy", "A", nil)
+	addTimedReview(easy.ID, gofsrs.Good, 3000)
+	addReviewDirectly(t, service, easy.ID, gofsrs.Good, now) // Untimed; ignored in the average
+	// Struggling in practice doesn't make a card low scoring
+	require.NoError(t, service.Storage.AddReviewDirect(storage.Review{ID: uuid.NewString(), CardID: easy.ID, Rating: gofsrs.Again, Timestamp: now.Add(time.Minute), Practice: true}))
+	insight, err := service.AnalyzeLearning()
+	require.NoError(t, err)
+	assert.Contains(t, insight, "'Hard'")
+
+	_, stats, err := service.ListCards(nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, int64(21000), stats.AvgAnswerTimeMS)
+
+	text, result := callHandlerDirectly(t, ctx, handleHelpAnalyzeLearning, map[string]interface{}{})
+	require.False(t, result.IsError, text)
+	var response AnalyzeLearningResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &response), text)
+	require.Len(t, response.LowScoringCards, 1)
+	assert.Equal(t, hard.ID, response.LowScoringCards[0].Card.ID)
+	assert.Equal(t, int64(30000), response.LowScoringCards[0].AvgAnswerTimeMS)
+	assert.Equal(t, int64(21000), response.Stats.AvgAnswerTimeMS)
+}
+
+// TestEffortReport tests the effort metrics for a session mixing answered and unanswered reviews
+func TestEffortReport(t *testing.T) {
+	service, _ := setupTestService(t)
+	start := time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+
+	addSessionReview := func(cardID, answer string, at time.Time, durationMS int64) {
+		review := storage.Review{
+			ID:         uuid.NewString(),
+			CardID:     cardID,
+			Rating:     gofsrs.Good,
+			Timestamp:  at,
+			Answer:     answer,
+			DurationMS: durationMS,
+		}
+		require.NoError(t, service.Storage.AddReviewDirect(review))
+	}
+	cards := make([]storage.Card, 3)
+	for i := range cards {
+		cards[i] = createCardDirectly(t, service, fmt.Sprintf("Q%d", i), "A", nil)
+	}
+	// Ten reviews in the session; six answered, four left blank
+	for i := 0; i < 10; i++ {
+		answer := ""
+		if i < 6 {
+			answer = "an answer"
+		}
+		addSessionReview(cards[i%3].ID, answer, start.Add(time.Duration(i)*time.Minute), 20000)
+	}
+	addSessionReview(cards[0].ID, "   ", start.Add(30*time.Minute), 0) // Whitespace counts as unanswered
+	addSessionReview(cards[1].ID, "before", start.Add(-time.Minute), 20000)
+
+	ctx := context.WithValue(context.Background(), "service", service)
+	text, result := callHandlerDirectly(t, ctx, handleEffortReport, map[string]interface{}{
+		"start": start.Format(time.RFC3339),
+		"end":   end.Format(time.RFC3339),
+	})
+	require.False(t, result.IsError, text)
+	var report EffortReport
+	require.NoError(t, json.Unmarshal([]byte(text), &report), text)
+	assert.Equal(t, 3, report.Cards)
+	assert.Equal(t, 11, report.Reviews)
+	assert.Equal(t, 6, report.Answered)
+	assert.InDelta(t, 6.0/11.0, report.AnsweredRatio, 0.0001)
+	assert.Equal(t, int64(200000), report.TimeSpentMS)
+	assert.Equal(t, 10, report.TimedReviews)
+	assert.Equal(t, 55, report.EffortScore)
+
+	// A short session is scaled down even when every review was answered
+	short, err := service.EffortReport(start.Add(-2*time.Minute), start.Add(-time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, short.Reviews)
+	assert.Equal(t, 10, short.EffortScore)
+}
+
+// TestGetStatistics tests the card counts, average interval and retention windows of get_statistics
+func TestGetStatistics(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Date(2024, 3, 10, 15, 0, 0, 0, time.Local)
+	defer mockTimeNow(now)()
+	ctx := context.WithValue(context.Background(), "service", service)
+
+	fresh := createCardDirectly(t, service, "New", "A", nil)
+	setDueDateDirectly(t, service, fresh.ID, now.Add(-time.Hour))
+	learning := createCardDirectly(t, service, "Learning", "A", nil)
+	learning.FSRS = gofsrs.Card{State: gofsrs.Relearning, Due: now.Add(time.Hour)}
+	updateCardDirectly(t, service, learning)
+	for i, days := range []uint64{4, 10} {
+		card := createCardDirectly(t, service, fmt.Sprintf("Review %d", i), "A", nil)
+		card.FSRS = gofsrs.Card{State: gofsrs.Review, ScheduledDays: days, Due: now.AddDate(0, 0, int(days))}
+		updateCardDirectly(t, service, card)
+	}
+	suspended := createCardDirectly(t, service, "Suspended", "A", nil)
+	suspended.Suspended = true
+	suspended.FSRS = gofsrs.Card{State: gofsrs.Review, ScheduledDays: 100, Due: now.Add(-time.Hour)}
+	updateCardDirectly(t, service, suspended)
+	archived := createCardDirectly(t, service, "Archived", "A", nil)
+	archived.Archived = true
+	updateCardDirectly(t, service, archived)
+
+	// Today: 1 of 2 correct; last 7 days adds 2 correct; 30 days adds a lapse; older adds one more
+	addReviewDirectly(t, service, learning.ID, gofsrs.Good, now.Add(-time.Hour))
+	addReviewDirectly(t, service, learning.ID, gofsrs.Again, localDay(now))
+	addReviewDirectly(t, service, learning.ID, gofsrs.Easy, localDay(now).Add(-time.Minute))
+	addReviewDirectly(t, service, suspended.ID, gofsrs.Good, now.AddDate(0, 0, -6))
+	addReviewDirectly(t, service, suspended.ID, gofsrs.Again, now.AddDate(0, 0, -20))
+	addReviewDirectly(t, service, suspended.ID, gofsrs.Hard, now.AddDate(0, 0, -90))
+
+	get := func(args map[string]interface{}) Statistics {
+		t.Helper()
+		text, result := callHandlerDirectly(t, ctx, handleGetStatistics, args)
+		require.False(t, result.IsError, text)
+		var stats Statistics
+		require.NoError(t, json.Unmarshal([]byte(text), &stats), text)
+		return stats
+	}
+	stats := get(nil)
+	assert.Equal(t, 6, stats.TotalCards)
+	assert.Equal(t, 1, stats.DueCards, "Only the new card is due; the suspended one never is")
+	assert.Equal(t, 1, stats.NewCards)
+	assert.Equal(t, 1, stats.LearningCards)
+	assert.Equal(t, 2, stats.ReviewCards)
+	assert.Equal(t, 1, stats.SuspendedCards)
+	assert.Equal(t, 1, stats.ArchivedCards)
+	assert.Equal(t, map[string]int{"new": 1, "learning": 0, "review": 2, "relearning": 1}, stats.CardsByState)
+	assert.InDelta(t, 7.0, stats.AverageIntervalDays, 1e-9)
+	assert.Equal(t, 6, stats.TotalReviews)
+
+	require.Len(t, stats.Retention, 4)
+	want := []RetentionWindow{
+		{Window: "today", Days: 1, Reviews: 2, Correct: 1, RetentionRate: 50},
+		{Window: "7d", Days: 7, Reviews: 4, Correct: 3, RetentionRate: 75},
+		{Window: "30d", Days: 30, Reviews: 5, Correct: 3, RetentionRate: 60},
+		{Window: "all_time", Reviews: 6, Correct: 3, RetentionRate: 50},
+	}
+	assert.Equal(t, want, stats.Retention)
+
+	// Custom windows replace the defaults
+	stats = get(map[string]interface{}{"windows": []interface{}{float64(2)}})
+	require.Len(t, stats.Retention, 2)
+	assert.Equal(t, "2d", stats.Retention[0].Window)
+	assert.Equal(t, 3, stats.Retention[0].Reviews)
+	assert.Equal(t, 2, stats.Retention[0].Correct)
+	assert.InDelta(t, 200.0/3, stats.Retention[0].RetentionRate, 1e-9)
+	assert.Equal(t, "all_time", stats.Retention[1].Window)
+
+	for _, windows := range []interface{}{"7", []interface{}{float64(0)}, []interface{}{1.5}} {
+		_, result := callHandlerDirectly(t, ctx, handleGetStatistics, map[string]interface{}{"windows": windows})
+		assert.True(t, result.IsError, "windows %v", windows)
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danieldreier/mcp-flashcards/internal/storage"
+	gofsrs "github.com/open-spaced-repetition/go-fsrs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExportCardsInclusion tests that suspended and archived cards are excluded from exports by default
+func TestExportCardsInclusion(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	active := createCardDirectly(t, service, "Active", "A", nil)
+	archived := createCardDirectly(t, service, "Archived", "A", nil)
+	archived.Archived = true
+	updateCardDirectly(t, service, archived)
+	suspended := createCardDirectly(t, service, "Suspended", "A", nil)
+	suspended.Suspended = true
+	updateCardDirectly(t, service, suspended)
+
+	exportedIDs := func(includeSuspended, includeArchived bool) []string {
+		export, err := service.ExportCards(nil, includeSuspended, includeArchived)
+		require.NoError(t, err)
+		var ids []string
+		for _, card := range export.Cards {
+			ids = append(ids, card.ID)
+		}
+		return ids
+	}
+
+	assert.ElementsMatch(t, []string{active.ID}, exportedIDs(false, false), "Archived and suspended cards are excluded by default")
+	assert.ElementsMatch(t, []string{active.ID, archived.ID}, exportedIDs(false, true))
+	assert.ElementsMatch(t, []string{active.ID, suspended.ID}, exportedIDs(true, false))
+	assert.ElementsMatch(t, []string{active.ID, archived.ID, suspended.ID}, exportedIDs(true, true))
+
+	// Neither is served for review
+	card, _, err := service.GetDueCard(nil)
+	require.NoError(t, err)
+	assert.Equal(t, active.ID, card.ID)
+}
+
+// TestExportImportFullRoundTrip tests that import_full restores exactly what export_full produced
+func TestExportImportFullRoundTrip(t *testing.T) {
+	source, _ := setupTestService(t)
+	card := createCardDirectly(t, source, "Hola", "Hello", []string{"spanish"})
+	_, err := source.SubmitReview(card.ID, gofsrs.Good, "hello")
+	require.NoError(t, err)
+	createCardDirectly(t, source, "Adiós", "Goodbye", []string{"spanish"})
+	require.NoError(t, source.AddDueDate(storage.DueDate{
+		ID:      "quiz",
+		Topic:   "Spanish Quiz",
+		DueDate: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		Tag:     "test-spanish-quiz-20240501",
+	}))
+	_, err = source.UpdateConfig(func(config *storage.Config) { config.MaxReviewsPerDay = 40 })
+	require.NoError(t, err)
+
+	sourceCtx := context.WithValue(context.Background(), "service", source)
+	exported, result := callHandlerDirectly(t, sourceCtx, handleExportFull, map[string]interface{}{})
+	require.False(t, result.IsError, exported)
+
+	target, _ := setupTestService(t)
+	createCardDirectly(t, target, "Overwritten", "A", nil)
+	targetCtx := context.WithValue(context.Background(), "service", target)
+	text, result := callHandlerDirectly(t, targetCtx, handleImportFull, map[string]interface{}{"data": exported})
+	require.False(t, result.IsError, text)
+	assert.Equal(t, 40, target.MaxReviewsPerDay, "Imported config should be applied")
+
+	// The restored store matches the original exactly, apart from the save timestamp
+	original, err := source.Storage.ExportStore()
+	require.NoError(t, err)
+	restored, err := target.Storage.ExportStore()
+	require.NoError(t, err)
+	original.LastUpdated, restored.LastUpdated = time.Time{}, time.Time{}
+	originalBytes, err := json.Marshal(original)
+	require.NoError(t, err)
+	restoredBytes, err := json.Marshal(restored)
+	require.NoError(t, err)
+	assert.Equal(t, string(originalBytes), string(restoredBytes))
+
+	// A mismatched schema version is refused and leaves the store untouched
+	var export FullExport
+	require.NoError(t, json.Unmarshal([]byte(exported), &export))
+	export.SchemaVersion = storage.StoreSchemaVersion + 1
+	export.Store.Cards = nil
+	mismatched, err := json.Marshal(export)
+	require.NoError(t, err)
+	_, result = callHandlerDirectly(t, targetCtx, handleImportFull, map[string]interface{}{"data": string(mismatched)})
+	assert.True(t, result.IsError)
+	cards, err := target.Storage.ListCards(nil)
+	require.NoError(t, err)
+	assert.Len(t, cards, 2)
+}
+
+// TestParseCardsCSV tests that CSV rows become import cards, with invalid rows reported by row number
+func TestParseCardsCSV(t *testing.T) {
+	cards, rowErrors, err := ParseCardsCSV(strings.NewReader(
+		"\ufeffFront,Back,Tags\n" +
+			"Hola,Hello,spanish; greetings\n" +
+			",Missing front,spanish\n" +
+			"\"Multi\nline\",\"Quoted, with comma\",\n" +
+			"Short row\n"))
+	require.NoError(t, err)
+	require.Len(t, cards, 2)
+	assert.Equal(t, ImportCard{Front: "Hola", Back: "Hello", Tags: []string{"spanish", "greetings"}}, cards[0])
+	assert.Equal(t, ImportCard{Front: "Multi\nline", Back: "Quoted, with comma"}, cards[1])
+	assert.Equal(t, []ImportRowError{
+		{Row: 3, Error: "front and back must not be empty"},
+		{Row: 5, Error: "front and back must not be empty"},
+	}, rowErrors)
+
+	// Scheduling columns restore the FSRS state of rows that have one
+	cards, rowErrors, err = ParseCardsCSV(strings.NewReader(
+		"front,back,tags,state,due,stability,difficulty\n" +
+			"Hola,Hello,,review,2024-03-01T15:00:00Z,12.5,4.2\n" +
+			"Adiós,Goodbye,,,,,\n" +
+			"Gato,Cat,,asleep,2024-03-01T15:00:00Z,1,1\n" +
+			"Perro,Dog,,review,next week,1,1\n"))
+	require.NoError(t, err)
+	require.Len(t, cards, 2)
+	assert.Equal(t, &ImportSchedule{
+		State:      gofsrs.Review,
+		Due:        time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC),
+		Stability:  12.5,
+		Difficulty: 4.2,
+	}, cards[0].Schedule)
+	assert.Nil(t, cards[1].Schedule, "Rows without a state import as New")
+	require.Len(t, rowErrors, 2)
+	assert.Equal(t, 4, rowErrors[0].Row)
+	assert.Contains(t, rowErrors[0].Error, "invalid state")
+	assert.Equal(t, 5, rowErrors[1].Row)
+	assert.Contains(t, rowErrors[1].Error, "invalid due")
+
+	// Problems with the file as a whole are errors
+	for _, data := range []string{"", "front,tags\nHola,spanish\n", "front,back,state\nHola,Hello,new\n"} {
+		_, _, err := ParseCardsCSV(strings.NewReader(data))
+		assert.Error(t, err, "CSV %q", data)
+	}
+}
+
+// TestCSVRoundTrip tests that export_cards CSV with scheduling can be re-imported with import_cards
+func TestCSVRoundTrip(t *testing.T) {
+	source, _ := setupTestService(t)
+	card := createCardDirectly(t, source, "Hola", "Hello, \"friend\"", []string{"spanish", "greetings"})
+	_, err := source.SubmitReview(card.ID, gofsrs.Easy, "hello")
+	require.NoError(t, err)
+	createCardDirectly(t, source, "Adiós", "Goodbye", nil)
+
+	sourceCtx := context.WithValue(context.Background(), "service", source)
+	exported, result := callHandlerDirectly(t, sourceCtx, handleExportCards, map[string]interface{}{
+		"format":             "csv",
+		"include_scheduling": true,
+	})
+	require.False(t, result.IsError, exported)
+	assert.True(t, strings.HasPrefix(exported, "front,back,tags,state,due,stability,difficulty,last_review\n"), exported)
+
+	target, _ := setupTestService(t)
+	targetCtx := context.WithValue(context.Background(), "service", target)
+	text, result := callHandlerDirectly(t, targetCtx, handleImportCards, map[string]interface{}{"csv": exported + ",Orphan back,\n"})
+	require.False(t, result.IsError, text)
+
+	var response ImportCardsResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &response))
+	assert.Equal(t, 2, response.Imported)
+	assert.Equal(t, []ImportRowError{{Row: 4, Error: "front and back must not be empty"}}, response.Errors)
+
+	original, err := source.Storage.GetCard(card.ID)
+	require.NoError(t, err)
+	restored, err := target.Storage.GetCard(response.CardIDs[0])
+	require.NoError(t, err)
+	assert.Equal(t, original.Front, restored.Front)
+	assert.Equal(t, original.Back, restored.Back)
+	assert.Equal(t, original.Tags, restored.Tags)
+	assert.Equal(t, gofsrs.Review, restored.FSRS.State)
+	assert.Equal(t, original.FSRS.Stability, restored.FSRS.Stability)
+	assert.Equal(t, original.FSRS.Difficulty, restored.FSRS.Difficulty)
+	assert.WithinDuration(t, original.FSRS.Due, restored.FSRS.Due, time.Second)
+	assert.WithinDuration(t, original.FSRS.LastReview, restored.FSRS.LastReview, time.Second)
+	assert.Equal(t, original.FSRS.ScheduledDays, restored.FSRS.ScheduledDays)
+
+	// cards and csv are mutually exclusive
+	_, result = callHandlerDirectly(t, targetCtx, handleImportCards, map[string]interface{}{})
+	assert.True(t, result.IsError)
+	_, result = callHandlerDirectly(t, sourceCtx, handleExportCards, map[string]interface{}{"format": "xml"})
+	assert.True(t, result.IsError)
+}
+
+// TestImportScheduleWithoutLastReview tests that a scheduled card from an export
+// without the last_review column gets a sane next interval when reviewed
+func TestImportScheduleWithoutLastReview(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	cards, rowErrors, err := ParseCardsCSV(strings.NewReader(
+		"front,back,tags,state,due,stability,difficulty\nHola,Hello,,review,2024-03-03T09:00:00Z,10,5\n"))
+	require.NoError(t, err)
+	require.Empty(t, rowErrors)
+	response, err := service.ImportCards(cards, nil)
+	require.NoError(t, err)
+
+	imported, err := service.Storage.GetCard(response.CardIDs[0])
+	require.NoError(t, err)
+	assert.Equal(t, uint64(10), imported.FSRS.ScheduledDays)
+	assert.Equal(t, uint64(1), imported.FSRS.Reps)
+	assert.True(t, imported.FSRS.LastReview.Equal(now.AddDate(0, 0, -8)), "The last review is the interval before the due time")
+
+	updated, err := service.SubmitReview(imported.ID, gofsrs.Good, "Hello")
+	require.NoError(t, err)
+	interval := updated.FSRS.Due.Sub(now).Hours() / 24
+	assert.Greater(t, interval, 1.0)
+	assert.Less(t, interval, 60.0, "A Good review of a 10-day card shouldn't jump to a year")
+}
@@ -4,6 +4,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math"
@@ -14,6 +15,7 @@ import (
 	"github.com/danieldreier/mcp-flashcards/internal/storage"
 	"github.com/google/uuid"
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
 	gofsrs "github.com/open-spaced-repetition/go-fsrs"
 )
 
@@ -23,9 +25,9 @@ import (
 // If no cards are due, it returns a friendly error message.
 func handleGetDueCard(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// Get the service from context
-	s, ok := ctx.Value("service").(*FlashcardService)
-	if !ok || s == nil {
-		return mcp.NewToolResultText("Error: Service not available"), nil
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
 	}
 
 	// Extract optional parameters
@@ -38,8 +40,26 @@ func handleGetDueCard(ctx context.Context, request mcp.CallToolRequest) (*mcp.Ca
 		}
 	}
 
+	render, _ := request.Params.Arguments["render"].(string)
+	if render == "" {
+		render = RenderJSON
+	}
+	if render != RenderJSON && render != RenderMarkdown {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid render: %s. Must be '%s' or '%s'", render, RenderJSON, RenderMarkdown)), nil
+	}
+
+	tagMatch, errResult := tagMatchArg(request)
+	if errResult != nil {
+		return errResult, nil
+	}
+
+	state, errResult := dueStateArg(request)
+	if errResult != nil {
+		return errResult, nil
+	}
+
 	// Call service method to get due card, passing filter tags
-	card, stats, err := s.GetDueCard(filterTags)
+	card, stats, err := s.GetDueCardMatching(filterTags, tagMatch, state)
 	if err != nil {
 		// Create a standard error response structure that includes stats
 		type ErrorResponseWithStats struct {
@@ -50,7 +70,13 @@ func handleGetDueCard(ctx context.Context, request mcp.CallToolRequest) (*mcp.Ca
 		errorMsg := fmt.Sprintf("Error getting due card: %v", err)
 
 		// *** Check for specific tag error FIRST ***
-		if strings.Contains(err.Error(), "no cards found with the specified tags") {
+		if errors.Is(err, ErrDailyReviewLimitReached) {
+			errorMsg = fmt.Sprintf("Daily review limit reached: %d of %d reviews completed today. Great work, come back tomorrow!", stats.ReviewsToday, s.MaxReviewsPerDay)
+		} else if errors.Is(err, ErrNewCardLimitReached) {
+			errorMsg = fmt.Sprintf("New card limit reached: %d of %d new cards introduced today, and no other cards are due. Come back tomorrow for more new cards!", stats.NewCardsIntroducedToday, s.NewCardsPerDay)
+		} else if errors.Is(err, ErrNoDueCardsInState) {
+			errorMsg = fmt.Sprintf("No %s cards due for review", stateNames[*state])
+		} else if strings.Contains(err.Error(), "no cards found with the specified tags") {
 			// Use the specific error message from the service layer
 			errorMsg = fmt.Sprintf("No cards found with the specified tags: %v", filterTags)
 		} else if strings.Contains(err.Error(), "no cards due for review") { // Now check for generic "no cards due"
@@ -74,12 +100,44 @@ func handleGetDueCard(ctx context.Context, request mcp.CallToolRequest) (*mcp.Ca
 		return mcp.NewToolResultText(string(jsonBytes)), nil // Return error with stats
 	}
 
+	// Hints are revealed separately through get_hint, only if the student needs one
+	card.Hint = ""
+
 	// Create response
 	response := CardResponse{
 		Card:  card,
 		Stats: stats,
 	}
 
+	// In mixed_direction mode, some cards are asked back-to-front
+	if mixed, _ := request.Params.Arguments["mixed_direction"].(bool); mixed {
+		seed := s.DirectionSeed
+		if seedVal, ok := request.Params.Arguments["seed"].(float64); ok {
+			seed = int64(seedVal)
+		}
+		direction, err := s.AssignDirection(card.ID, seed)
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error choosing card direction: %v"}`, err)), nil
+		}
+		response.Direction = direction
+		if direction == DirectionReverse {
+			response.Card.Front, response.Card.Back = card.Back, card.Front
+		}
+	}
+
+	// Sibling fronts give the LLM context about related cards without revealing answers
+	if includeSiblings, _ := request.Params.Arguments["include_siblings"].(bool); includeSiblings {
+		siblings, err := s.SiblingFronts(card)
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error finding sibling cards: %v"}`, err)), nil
+		}
+		response.Siblings = siblings
+	}
+
+	if render == RenderMarkdown {
+		return mcp.NewToolResultText(renderDueCardMarkdown(response)), nil
+	}
+
 	// Convert to JSON
 	jsonBytes, err := json.MarshalIndent(response, "", "  ")
 	if err != nil {
@@ -90,6 +148,80 @@ func handleGetDueCard(ctx context.Context, request mcp.CallToolRequest) (*mcp.Ca
 	return mcp.NewToolResultText(string(jsonBytes)), nil
 }
 
+// Output formats accepted by get_due_card's render parameter
+const (
+	RenderJSON     = "json"
+	RenderMarkdown = "markdown"
+)
+
+// renderDueCardMarkdown formats a due card for clients that render Markdown.
+// Like the JSON response shown to the student, it contains only the question;
+// the back of the card is deliberately left out.
+func renderDueCardMarkdown(response CardResponse) string {
+	var b strings.Builder
+	b.WriteString("## Question\n\n")
+	b.WriteString(response.Card.Front)
+	b.WriteString("\n\n")
+	b.WriteString("> _Answer hidden until the student responds. Call submit_review to reveal it._\n\n")
+	b.WriteString("### Card details\n\n")
+	fmt.Fprintf(&b, "- **Card ID:** `%s`\n", response.Card.ID)
+	if len(response.Card.Tags) > 0 {
+		fmt.Fprintf(&b, "- **Tags:** %s\n", strings.Join(response.Card.Tags, ", "))
+	}
+	if response.Direction != "" {
+		fmt.Fprintf(&b, "- **Direction:** %s\n", response.Direction)
+	}
+	if len(response.Siblings) > 0 {
+		b.WriteString("\n### Related questions\n\n")
+		for _, front := range response.Siblings {
+			fmt.Fprintf(&b, "- %s\n", front)
+		}
+	}
+	b.WriteString("\n### Progress\n\n")
+	fmt.Fprintf(&b, "- **Due cards:** %d of %d\n", response.Stats.DueCards, response.Stats.TotalCards)
+	fmt.Fprintf(&b, "- **Reviews today:** %d\n", response.Stats.ReviewsToday)
+	fmt.Fprintf(&b, "- **Retention rate:** %.0f%%\n", response.Stats.RetentionRate)
+	return b.String()
+}
+
+// handleGetCard handles the get_card tool request by returning one card by ID
+// with the current review statistics, and optionally its review history.
+func handleGetCard(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	cardID, ok := request.Params.Arguments["card_id"].(string)
+	if !ok || cardID == "" {
+		return mcp.NewToolResultError("Missing required parameter: card_id"), nil
+	}
+	includeReviews, _ := request.Params.Arguments["include_reviews"].(bool)
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	card, stats, err := s.GetCard(cardID)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error getting card: %v"}`, err)), nil
+	}
+	response := CardResponse{
+		Card:  card,
+		Stats: stats,
+	}
+	if includeReviews {
+		reviews, err := s.CardReviews(cardID)
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error getting card reviews: %v"}`, err)), nil
+		}
+		response.Reviews = reviews
+	}
+
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
 // handleSubmitReview handles the submit_review tool request by processing a review
 // for a flashcard with the given rating (1-4) and optional answer text.
 // It updates the card's FSRS scheduling data based on the review result.
@@ -122,6 +254,10 @@ func handleSubmitReview(ctx context.Context, request mcp.CallToolRequest) (*mcp.
 	// Extract optional parameters
 	answer, _ := request.Params.Arguments["answer"].(string)
 	fmt.Printf("[DEBUG] Review answer: %s\n", answer)
+	durationMS, _ := request.Params.Arguments["duration_ms"].(float64)
+	if durationMS < 0 {
+		return mcp.NewToolResultText("duration_ms must not be negative"), nil
+	}
 
 	// Check for optional timestamp (for testing)
 	var reviewTime time.Time
@@ -140,19 +276,24 @@ func handleSubmitReview(ctx context.Context, request mcp.CallToolRequest) (*mcp.
 	}
 
 	// Get the service from context
-	s, ok := ctx.Value("service").(*FlashcardService)
-	if !ok || s == nil {
-		fmt.Printf("[DEBUG] Error: Service not available\n")
-		return mcp.NewToolResultText("Error: Service not available"), nil
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
 	}
 	fmt.Printf("[DEBUG] Retrieved service from context\n")
 
 	// Convert rating to fsrs.Rating
 	fsrsRating := gofsrs.Rating(rating)
 
+	// Practice reviews are logged without touching the card's schedule
+	submit := s.SubmitTimedReview
+	if practice, _ := request.Params.Arguments["practice"].(bool); practice {
+		submit = s.SubmitPracticeReview
+	}
+
 	// Call service method to submit review
 	fmt.Printf("[DEBUG] Calling service.SubmitReviewWithTime() at %v\n", time.Now().Format(time.RFC3339Nano))
-	updatedCard, err := s.SubmitReviewWithTime(cardID, fsrsRating, answer, reviewTime)
+	updatedCard, err := submit(cardID, fsrsRating, answer, reviewTime, int64(durationMS))
 	fmt.Printf("[DEBUG] service.SubmitReviewWithTime() completed at %v\n", time.Now().Format(time.RFC3339Nano))
 
 	if err != nil {
@@ -180,10 +321,191 @@ func handleSubmitReview(ctx context.Context, request mcp.CallToolRequest) (*mcp.
 	return mcp.NewToolResultText(string(jsonBytes)), nil
 }
 
+// handleUndoReview handles the undo_review tool request by removing the card's
+// most recent review and restoring the schedule it had before that review.
+func handleUndoReview(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	cardID, ok := request.Params.Arguments["card_id"].(string)
+	if !ok || cardID == "" {
+		return mcp.NewToolResultError("Missing required parameter: card_id"), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	card, err := s.UndoLastReview(cardID)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error undoing review: %v"}`, err)), nil
+	}
+	response := ReviewResponse{
+		Success: true,
+		Message: "Last review undone",
+		Card:    card,
+	}
+
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleCreateCards handles the create_cards tool request by creating every card
+// in the cards array with one batched write. Entries that aren't objects are
+// reported as per-card errors alongside the ones that fail validation.
+func handleCreateCards(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	rawCards, ok := request.Params.Arguments["cards"].([]interface{})
+	if !ok || len(rawCards) == 0 {
+		return mcp.NewToolResultError("Missing required parameter: cards (a non-empty array of card objects)"), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	// Malformed entries are replaced by empty inputs, which fail validation at their index
+	inputs := make([]storage.CardInput, len(rawCards))
+	malformed := make(map[int]bool)
+	for i, rawCard := range rawCards {
+		fields, ok := rawCard.(map[string]interface{})
+		if !ok {
+			malformed[i] = true
+			continue
+		}
+		inputs[i].Front, _ = fields["front"].(string)
+		inputs[i].Back, _ = fields["back"].(string)
+		if tags, ok := fields["tags"].([]interface{}); ok {
+			for _, tag := range tags {
+				if tagStr, ok := tag.(string); ok {
+					inputs[i].Tags = append(inputs[i].Tags, tagStr)
+				}
+			}
+		}
+	}
+
+	response, err := s.CreateCards(inputs)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error creating cards: %v"}`, err)), nil
+	}
+	for i := range response.Errors {
+		if malformed[response.Errors[i].Index] {
+			response.Errors[i].Error = "card must be an object"
+		}
+	}
+
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleBulkReview handles the bulk_review tool request by applying several reviews
+// in order with a single save, reporting each review's outcome separately.
+func handleBulkReview(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	rawReviews, ok := request.Params.Arguments["reviews"].([]interface{})
+	if !ok || len(rawReviews) == 0 {
+		return mcp.NewToolResultError("Missing required parameter: reviews (a non-empty array of review objects)"), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	// Entries that can't be parsed keep a zero rating, which fails at their index
+	inputs := make([]ReviewInput, len(rawReviews))
+	invalid := make(map[int]string)
+	for i, rawReview := range rawReviews {
+		fields, ok := rawReview.(map[string]interface{})
+		if !ok {
+			invalid[i] = "review must be an object"
+			continue
+		}
+		inputs[i].CardID, _ = fields["card_id"].(string)
+		inputs[i].Answer, _ = fields["answer"].(string)
+		if timestampStr, ok := fields["timestamp"].(string); ok {
+			parsedTime, err := time.Parse(time.RFC3339, timestampStr)
+			if err != nil {
+				invalid[i] = fmt.Sprintf("Invalid timestamp format: %v", err)
+				continue
+			}
+			inputs[i].Timestamp = parsedTime
+		}
+		if rating, ok := fields["rating"].(float64); ok && rating == math.Trunc(rating) {
+			inputs[i].Rating = gofsrs.Rating(rating)
+		}
+	}
+
+	response, err := s.SubmitReviews(inputs)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error submitting reviews: %v"}`, err)), nil
+	}
+	for i := range response.Results {
+		if message, ok := invalid[response.Results[i].Index]; ok {
+			response.Results[i].Error = message
+		}
+	}
+
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleResetCard handles the reset_card tool request by clearing a card's
+// scheduling back to New, keeping its review history unless keep_history is false.
+func handleResetCard(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	cardID, ok := request.Params.Arguments["card_id"].(string)
+	if !ok || cardID == "" {
+		return mcp.NewToolResultError("Missing required parameter: card_id"), nil
+	}
+	keepHistory := true
+	if raw, exists := request.Params.Arguments["keep_history"]; exists {
+		if keepHistory, ok = raw.(bool); !ok {
+			return mcp.NewToolResultError("Invalid keep_history: must be a boolean"), nil
+		}
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	card, err := s.ResetCard(cardID, keepHistory)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error resetting card: %v"}`, err)), nil
+	}
+	message := "Card reset to New and due now; review history kept"
+	if !keepHistory {
+		message = "Card reset to New and due now; review history deleted"
+	}
+	response := ReviewResponse{
+		Success: true,
+		Message: message,
+		Card:    card,
+	}
+
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
 // handleCreateCard handles the create_card tool request by creating a new flashcard
 // with the provided front and back content and optional tags.
-// It also supports setting an optional hour_offset for the due date (for testing purposes).
 func handleCreateCard(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Accept question/answer as synonyms for front/back
+	applyCardFieldAliases(&request)
+
 	// Extract required parameters
 	front, ok := request.Params.Arguments["front"].(string)
 	if !ok {
@@ -206,39 +528,29 @@ func handleCreateCard(ctx context.Context, request mcp.CallToolRequest) (*mcp.Ca
 	}
 
 	// Get the storage from server context
-	s, ok := ctx.Value("service").(*FlashcardService)
-	if !ok || s == nil {
-		return mcp.NewToolResultText("Error: Service not available"), nil
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
 	}
 
-	// Create the card in storage
-	newCard, err := s.Storage.CreateCard(front, back, tags)
+	warnings, err := s.ValidateReservedTags(tags)
 	if err != nil {
-		return mcp.NewToolResultText(fmt.Sprintf("Error creating card: %v", err)), nil
-	}
-
-	// Check for optional hour_offset parameter (for testing only)
-	if hourOffsetFloat, ok := request.Params.Arguments["hour_offset"].(float64); ok {
-		// Set due date based on hour offset (relative to now)
-		hourOffsetDuration := time.Duration(hourOffsetFloat * float64(time.Hour))
-		newCard.FSRS.Due = time.Now().Add(hourOffsetDuration)
-
-		// Update the card in storage
-		if err := s.Storage.UpdateCard(newCard); err != nil {
-			log.Printf("Warning: Failed to update card due date: %v", err)
-		}
+		return mcp.NewToolResultError(fmt.Sprintf("Error creating card: %v", err)), nil
 	}
 
-	// Save changes to disk
-	if err := s.Storage.Save(); err != nil {
-		log.Printf("Warning: Failed to save storage after creating card: %v", err)
+	// Create the card, with the optional hint, in a single write
+	hint, _ := request.Params.Arguments["hint"].(string)
+	newCard, err := s.createCard(storage.CardInput{Front: front, Back: back, Tags: tags, Hint: hint})
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf("Error creating card: %v", err)), nil
 	}
 
 	response := CreateCardResponse{
-		Card: newCard,
+		Card:     newCard,
+		Warnings: warnings,
 	}
 
-	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	jsonBytes, err := marshalCardResponse(request, response)
 	if err != nil {
 		return nil, err
 	}
@@ -255,6 +567,9 @@ func handleUpdateCard(ctx context.Context, request mcp.CallToolRequest) (*mcp.Ca
 		return mcp.NewToolResultError("Missing or empty required parameter: card_id"), nil
 	}
 
+	// Accept question/answer as synonyms for front/back
+	applyCardFieldAliases(&request)
+
 	// Extract optional parameters and store them as pointers
 	var frontPtr *string
 	if frontVal, exists := request.Params.Arguments["front"]; exists {
@@ -295,19 +610,37 @@ func handleUpdateCard(ctx context.Context, request mcp.CallToolRequest) (*mcp.Ca
 		}
 	}
 
+	var hintPtr *string
+	if hintVal, exists := request.Params.Arguments["hint"]; exists {
+		if hintStr, ok := hintVal.(string); ok {
+			hintPtr = &hintStr
+		} else {
+			return mcp.NewToolResultError("Invalid type for parameter: hint (must be string)"), nil
+		}
+	}
+
 	// Ensure at least one field was provided for update
-	if frontPtr == nil && backPtr == nil && tagsPtr == nil {
-		return mcp.NewToolResultError("No update fields provided. Please provide at least one of 'front', 'back', or 'tags'."), nil
+	if frontPtr == nil && backPtr == nil && tagsPtr == nil && hintPtr == nil {
+		return mcp.NewToolResultError("No update fields provided. Please provide at least one of 'front', 'back', 'tags', or 'hint'."), nil
 	}
 
 	// Get the service from context
-	s, ok := ctx.Value("service").(*FlashcardService)
-	if !ok || s == nil {
-		return mcp.NewToolResultError("Internal Server Error: Service not available"), nil
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	var warnings []string
+	if tagsPtr != nil {
+		var err error
+		warnings, err = s.ValidateReservedTags(*tagsPtr)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error updating card: %v", err)), nil
+		}
 	}
 
 	// Update the card using the service with pointers
-	_, err := s.UpdateCard(cardID, frontPtr, backPtr, tagsPtr)
+	_, err := s.UpdateCard(cardID, frontPtr, backPtr, tagsPtr, hintPtr)
 	if err != nil {
 		// Return error in a structured JSON format
 		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error updating card: %v"}`, err)), nil
@@ -315,13 +648,14 @@ func handleUpdateCard(ctx context.Context, request mcp.CallToolRequest) (*mcp.Ca
 
 	// Create success response
 	response := UpdateCardResponse{
-		Success: true,
-		Message: fmt.Sprintf("Card %s updated successfully.", cardID),
+		Success:  true,
+		Message:  fmt.Sprintf("Card %s updated successfully.", cardID),
+		Warnings: warnings,
 		// Optionally include the updated card data in the response?
 		// Card: updatedCard, // If Card field exists in UpdateCardResponse
 	}
 
-	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	jsonBytes, err := marshalCardResponse(request, response)
 	if err != nil {
 		// Log internal error, return generic error to client
 		fmt.Printf("Error marshaling update response: %v\n", err)
@@ -341,9 +675,9 @@ func handleDeleteCard(ctx context.Context, request mcp.CallToolRequest) (*mcp.Ca
 	}
 
 	// Get the service from context
-	s, ok := ctx.Value("service").(*FlashcardService)
-	if !ok || s == nil {
-		return mcp.NewToolResultText("Error: Service not available"), nil
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
 	}
 
 	// First check if the card exists
@@ -390,27 +724,59 @@ func handleListCards(ctx context.Context, request mcp.CallToolRequest) (*mcp.Cal
 		includeStats = includeStatsVal
 	}
 
+	// An empty tags array means "no filter", so "cards with no tags" needs its own flag
+	untaggedOnly, _ := request.Params.Arguments["untagged_only"].(bool)
+	if untaggedOnly && len(filterTags) > 0 {
+		return mcp.NewToolResultError("untagged_only cannot be combined with tags"), nil
+	}
+
+	tagMatch, errResult := tagMatchArg(request)
+	if errResult != nil {
+		return errResult, nil
+	}
+	excludeSuspended, _ := request.Params.Arguments["exclude_suspended"].(bool)
+
+	limit := DefaultListCardsLimit
+	if v, ok := request.Params.Arguments["limit"].(float64); ok {
+		if v < 0 || v != math.Trunc(v) {
+			return mcp.NewToolResultError("limit must be a whole number, at least 0"), nil
+		}
+		limit = int(v)
+	}
+	offset := 0
+	if v, ok := request.Params.Arguments["offset"].(float64); ok {
+		if v < 0 || v != math.Trunc(v) {
+			return mcp.NewToolResultError("offset must be a whole number, at least 0"), nil
+		}
+		offset = int(v)
+	}
+
 	// Get the service from context
-	s, ok := ctx.Value("service").(*FlashcardService)
-	if !ok || s == nil {
-		return mcp.NewToolResultText("Error: Service not available"), nil
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
 	}
 
 	// Get cards from service
-	cards, stats, err := s.ListCards(filterTags, includeStats)
+	cards, stats, err := s.ListCardsMatching(filterTags, tagMatch, includeStats)
 	if err != nil {
 		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error listing cards: %v"}`, err)), nil
 	}
-
-	// Prepare the cards for the response
-	var responseCards []Card
-	for _, card := range cards {
-		responseCards = append(responseCards, card)
+	if untaggedOnly {
+		cards = untaggedCards(cards)
 	}
+	if excludeSuspended {
+		cards = unsuspendedCards(cards)
+	}
+
+	// Page only after filtering, so total_count and has_more describe the filtered list
+	page, hasMore := PageCards(cards, limit, offset)
 
 	// Create response
 	response := ListCardsResponse{
-		Cards: responseCards,
+		Cards:      page,
+		TotalCount: len(cards),
+		HasMore:    hasMore,
 	}
 
 	// Include stats if requested
@@ -426,14 +792,36 @@ func handleListCards(ctx context.Context, request mcp.CallToolRequest) (*mcp.Cal
 	return mcp.NewToolResultText(string(jsonBytes)), nil
 }
 
+// untaggedCards returns the cards that carry no tags.
+func untaggedCards(cards []Card) []Card {
+	var result []Card
+	for _, card := range cards {
+		if len(card.Tags) == 0 {
+			result = append(result, card)
+		}
+	}
+	return result
+}
+
+// unsuspendedCards returns the cards that aren't suspended.
+func unsuspendedCards(cards []Card) []Card {
+	var result []Card
+	for _, card := range cards {
+		if !card.Suspended {
+			result = append(result, card)
+		}
+	}
+	return result
+}
+
 // handleHelpAnalyzeLearning analyzes the student's learning progress by identifying
 // low-scoring cards, finding patterns in difficult content, and providing data
 // that assists the LLM in making personalized learning recommendations.
 func handleHelpAnalyzeLearning(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// Get the service from context
-	s, ok := ctx.Value("service").(*FlashcardService)
-	if !ok || s == nil {
-		return mcp.NewToolResultText("Error: Service not available"), nil
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
 	}
 
 	// Get all cards from storage to analyze
@@ -449,10 +837,11 @@ func handleHelpAnalyzeLearning(ctx context.Context, request mcp.CallToolRequest)
 	if len(allCards) == 0 {
 		response := AnalyzeLearningResponse{
 			LowScoringCards: []struct {
-				Card        Card         `json:"card"`
-				Reviews     []CardReview `json:"reviews"`
-				AvgRating   float64      `json:"avg_rating"`
-				ReviewCount int          `json:"review_count"`
+				Card            Card         `json:"card"`
+				Reviews         []CardReview `json:"reviews"`
+				AvgRating       float64      `json:"avg_rating"`
+				ReviewCount     int          `json:"review_count"`
+				AvgAnswerTimeMS int64        `json:"avg_answer_time_ms,omitempty"`
 			}{},
 			CommonTags:   []string{},
 			TotalReviews: 0,
@@ -472,6 +861,7 @@ func handleHelpAnalyzeLearning(ctx context.Context, request mcp.CallToolRequest)
 		Reviews     []CardReview
 		AvgRating   float64
 		ReviewCount int
+		AvgAnswerMS int64
 	}
 
 	var analyzedCards []cardAnalysis
@@ -480,25 +870,19 @@ func handleHelpAnalyzeLearning(ctx context.Context, request mcp.CallToolRequest)
 
 	for _, storageCard := range allCards {
 		// Convert storage.Card to our Card type
-		card := Card{
-			ID:        storageCard.ID,
-			Front:     storageCard.Front,
-			Back:      storageCard.Back,
-			CreatedAt: storageCard.CreatedAt,
-			Tags:      storageCard.Tags,
-			FSRS:      storageCard.FSRS,
-		}
+		card := cardFromStorage(storageCard)
 
 		// Count tags for finding common patterns
 		for _, tag := range card.Tags {
 			tagFrequency[tag]++
 		}
 
-		// Get reviews for this card
+		// Get reviews for this card; practice reviews don't count toward the analysis
 		cardReviews, err := s.Storage.GetCardReviews(card.ID)
 		if err != nil {
 			continue // Skip this card if reviews can't be retrieved
 		}
+		cardReviews = scheduledReviews(cardReviews)
 
 		// If there are no reviews, skip this card
 		if len(cardReviews) == 0 {
@@ -524,11 +908,13 @@ func handleHelpAnalyzeLearning(ctx context.Context, request mcp.CallToolRequest)
 		avgRating := float64(ratingSum) / float64(len(cardReviews))
 
 		// Store the analysis for this card
+		avgAnswerMS, _ := averageAnswerMS(cardReviews)
 		analyzedCards = append(analyzedCards, cardAnalysis{
 			Card:        card,
 			Reviews:     simplifiedReviews,
 			AvgRating:   avgRating,
 			ReviewCount: len(cardReviews),
+			AvgAnswerMS: avgAnswerMS,
 		})
 	}
 
@@ -582,10 +968,11 @@ func handleHelpAnalyzeLearning(ctx context.Context, request mcp.CallToolRequest)
 	// Prepare response data structure
 	responseData := AnalyzeLearningResponse{
 		LowScoringCards: make([]struct {
-			Card        Card         `json:"card"`
-			Reviews     []CardReview `json:"reviews"`
-			AvgRating   float64      `json:"avg_rating"`
-			ReviewCount int          `json:"review_count"`
+			Card            Card         `json:"card"`
+			Reviews         []CardReview `json:"reviews"`
+			AvgRating       float64      `json:"avg_rating"`
+			ReviewCount     int          `json:"review_count"`
+			AvgAnswerTimeMS int64        `json:"avg_answer_time_ms,omitempty"`
 		}, len(lowScoringCards)),
 		CommonTags:   commonTagNames,
 		TotalReviews: totalReviews,
@@ -595,15 +982,17 @@ func handleHelpAnalyzeLearning(ctx context.Context, request mcp.CallToolRequest)
 	// Fill in the low-scoring cards data
 	for i, analysis := range lowScoringCards {
 		responseData.LowScoringCards[i] = struct {
-			Card        Card         `json:"card"`
-			Reviews     []CardReview `json:"reviews"`
-			AvgRating   float64      `json:"avg_rating"`
-			ReviewCount int          `json:"review_count"`
+			Card            Card         `json:"card"`
+			Reviews         []CardReview `json:"reviews"`
+			AvgRating       float64      `json:"avg_rating"`
+			ReviewCount     int          `json:"review_count"`
+			AvgAnswerTimeMS int64        `json:"avg_answer_time_ms,omitempty"`
 		}{
-			Card:        analysis.Card,
-			Reviews:     analysis.Reviews,
-			AvgRating:   analysis.AvgRating,
-			ReviewCount: analysis.ReviewCount,
+			Card:            analysis.Card,
+			Reviews:         analysis.Reviews,
+			AvgRating:       analysis.AvgRating,
+			ReviewCount:     analysis.ReviewCount,
+			AvgAnswerTimeMS: analysis.AvgAnswerMS,
 		}
 	}
 
@@ -621,9 +1010,9 @@ func handleHelpAnalyzeLearning(ctx context.Context, request mcp.CallToolRequest)
 // are available for filtering cards.
 func handleTagsResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
 	// Get the service from context
-	s, ok := ctx.Value("service").(*FlashcardService)
-	if !ok || s == nil {
-		return nil, fmt.Errorf("service not available")
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return nil, ErrServiceUnavailable
 	}
 
 	// Get all cards from storage
@@ -651,10 +1040,11 @@ func handleTagsResource(ctx context.Context, request mcp.ReadResourceRequest) ([
 
 	// Calculate overall stats once
 	now := time.Now()
+	vacations := s.vacationsOrNil()
 	totalCards := len(allCards)
 	dueCards := 0
 	for _, card := range allCards {
-		if !card.FSRS.Due.After(now) {
+		if !adjustDueForVacations(card.FSRS.Due, now, vacations).After(now) {
 			dueCards++
 		}
 	}
@@ -662,7 +1052,7 @@ func handleTagsResource(ctx context.Context, request mcp.ReadResourceRequest) ([
 	// Calculate due counts per tag
 	tagDueCounts := make(map[string]int)
 	for _, card := range allCards {
-		if !card.FSRS.Due.After(now) {
+		if !adjustDueForVacations(card.FSRS.Due, now, vacations).After(now) {
 			for _, tag := range card.Tags {
 				tagDueCounts[tag]++
 			}
@@ -709,9 +1099,9 @@ func handleTagsResource(ctx context.Context, request mcp.ReadResourceRequest) ([
 // handleManageDueDates handles CRUD operations for due date entries.
 func handleManageDueDates(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// Get the service from context
-	s, ok := ctx.Value("service").(*FlashcardService)
-	if !ok || s == nil {
-		return mcp.NewToolResultError("Service not available"), nil
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
 	}
 
 	// Extract action parameter
@@ -776,19 +1166,11 @@ func handleManageDueDates(ctx context.Context, request mcp.CallToolRequest) (*mc
 			return mcp.NewToolResultError("Missing required parameter for update: due_date_id"), nil
 		}
 		// Fetch existing due date to update
-		existingDueDates, err := s.ListDueDates() // Inefficient, need GetDueDateByID in service/storage
-		if err != nil {
-			return mcp.NewToolResultError(fmt.Sprintf("Error fetching existing due dates: %v", err)), nil
-		}
-		var existingDueDate *storage.DueDate
-		for i := range existingDueDates {
-			if existingDueDates[i].ID == dueDateID {
-				existingDueDate = &existingDueDates[i]
-				break
-			}
-		}
-		if existingDueDate == nil {
+		existingDueDate, err := s.GetDueDate(dueDateID)
+		if errors.Is(err, storage.ErrDueDateNotFound) {
 			return mcp.NewToolResultError(fmt.Sprintf("Due date with ID %s not found", dueDateID)), nil
+		} else if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error fetching existing due date: %v", err)), nil
 		}
 
 		// Update fields if provided
@@ -806,16 +1188,23 @@ func handleManageDueDates(ctx context.Context, request mcp.CallToolRequest) (*mc
 			existingDueDate.Tag = tag
 		}
 
-		if err := s.UpdateDueDate(*existingDueDate); err != nil {
+		if err := s.UpdateDueDate(existingDueDate); err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error updating due date: %v", err)), nil
 		}
-		jsonBytes, _ := json.MarshalIndent(*existingDueDate, "", "  ")
+		jsonBytes, _ := json.MarshalIndent(existingDueDate, "", "  ")
 		return mcp.NewToolResultText(string(jsonBytes)), nil
 
 	case "delete":
 		if dueDateID == "" {
 			return mcp.NewToolResultError("Missing required parameter for delete: due_date_id"), nil
 		}
+		if withCards, _ := request.Params.Arguments["with_cards"].(bool); withCards {
+			deleted, err := s.DeleteDueDateWithCards(dueDateID)
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("Error deleting due date: %v", err)), nil
+			}
+			return mcp.NewToolResultText(fmt.Sprintf(`{"message": "Due date %s and %d cards deleted successfully"}`, dueDateID, deleted)), nil
+		}
 		if err := s.DeleteDueDate(dueDateID); err != nil {
 			return mcp.NewToolResultError(fmt.Sprintf("Error deleting due date: %v", err)), nil
 		}
@@ -826,42 +1215,317 @@ func handleManageDueDates(ctx context.Context, request mcp.CallToolRequest) (*mc
 	}
 }
 
-// DueDateProgressInfo holds detailed progress for a single due date.
-type DueDateProgressInfo struct {
-	ID              string  `json:"id"`
-	Topic           string  `json:"topic"`
-	DueDate         string  `json:"due_date"` // YYYY-MM-DD format
-	Tag             string  `json:"tag"`
-	TotalCards      int     `json:"total_cards"`
-	MasteredCards   int     `json:"mastered_cards"`
-	ProgressPercent float64 `json:"progress_percent"`
-	DaysRemaining   float64 `json:"days_remaining"` // Days until day *before* due date
-	CardsLeft       int     `json:"cards_left"`
-	RequiredPace    float64 `json:"required_pace"` // Cards per day needed
+// handleStateHistoryResource generates a resource with per-day counts of cards in
+// each FSRS state, suitable for drawing a stacked-area chart of learning progress.
+func handleStateHistoryResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return nil, ErrServiceUnavailable
+	}
+
+	history, err := s.StateHistory()
+	if err != nil {
+		return nil, fmt.Errorf("error computing state history: %w", err)
+	}
+
+	jsonBytes, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling state history: %w", err)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      "state-history",
+			MIMEType: "application/json",
+			Text:     string(jsonBytes),
+		},
+	}, nil
 }
 
-// handleDueDateProgressResource generates a resource showing progress towards upcoming due dates.
-func handleDueDateProgressResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+// handleReviewHeatmapResource handles requests for the review-heatmap resource,
+// returning per-day review counts for the last year.
+func handleReviewHeatmapResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
 	// Get the service from context
-	s, ok := ctx.Value("service").(*FlashcardService)
-	if !ok || s == nil {
-		return nil, fmt.Errorf("service not available")
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return nil, ErrServiceUnavailable
 	}
 
-	// Get all defined due dates
-	dueDates, err := s.ListDueDates()
+	heatmap, err := s.ReviewHeatmap()
 	if err != nil {
-		return nil, fmt.Errorf("error listing due dates: %w", err)
+		return nil, fmt.Errorf("error computing review heatmap: %w", err)
 	}
 
-	// Log the number of due dates found
-	fmt.Printf("Found %d due dates in handleDueDateProgressResource\n", len(dueDates))
-	for i, dd := range dueDates {
-		fmt.Printf("Due date %d: ID=%s, Topic=%s, Date=%s, Tag=%s\n",
-			i+1, dd.ID, dd.Topic, dd.DueDate.Format("2006-01-02"), dd.Tag)
+	jsonBytes, err := json.MarshalIndent(heatmap, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling review heatmap: %w", err)
 	}
 
-	now := time.Now()
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      "review-heatmap",
+			MIMEType: "application/json",
+			Text:     string(jsonBytes),
+		},
+	}, nil
+}
+
+// handleReviewForecastResource handles the review-forecast resource by returning
+// the due counts for each of the next ForecastDays days.
+func handleReviewForecastResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return nil, ErrServiceUnavailable
+	}
+
+	days := s.ForecastDays
+	if days <= 0 {
+		days = DefaultForecastDays
+	}
+	forecast, err := s.ForecastReviews(days)
+	if err != nil {
+		return nil, fmt.Errorf("error computing review forecast: %w", err)
+	}
+
+	jsonBytes, err := json.MarshalIndent(forecast, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling review forecast: %w", err)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      "review-forecast",
+			MIMEType: "application/json",
+			Text:     string(jsonBytes),
+		},
+	}, nil
+}
+
+// handleStudyStreakResource handles the study-streak resource by returning the
+// current and longest study streaks.
+func handleStudyStreakResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return nil, ErrServiceUnavailable
+	}
+
+	streak, err := s.StudyStreak()
+	if err != nil {
+		return nil, fmt.Errorf("error computing study streak: %w", err)
+	}
+
+	jsonBytes, err := json.MarshalIndent(streak, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling study streak: %w", err)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      "study-streak",
+			MIMEType: "application/json",
+			Text:     string(jsonBytes),
+		},
+	}, nil
+}
+
+// handleLeechesResource handles the leeches resource by listing the cards that
+// keep being forgotten.
+func handleLeechesResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return nil, ErrServiceUnavailable
+	}
+
+	leeches, err := s.Leeches()
+	if err != nil {
+		return nil, fmt.Errorf("error listing leeches: %w", err)
+	}
+
+	jsonBytes, err := json.MarshalIndent(leeches, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling leeches: %w", err)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      "leeches",
+			MIMEType: "application/json",
+			Text:     string(jsonBytes),
+		},
+	}, nil
+}
+
+// handleSetForecastHorizon handles the set_forecast_horizon tool request by setting
+// how many days the review-forecast resource covers.
+func handleSetForecastHorizon(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	days, ok := request.Params.Arguments["days"].(float64)
+	if !ok {
+		return mcp.NewToolResultError("Missing required parameter: days"), nil
+	}
+	if days < 1 || days > MaxForecastDays || days != math.Trunc(days) {
+		return mcp.NewToolResultError(fmt.Sprintf("days must be a whole number from 1 to %d", MaxForecastDays)), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	if err := s.SetForecastHorizon(int(days)); err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error setting forecast horizon: %v"}`, err)), nil
+	}
+
+	message := fmt.Sprintf("The review forecast now covers the next %d days.", int(days))
+	jsonBytes, err := json.MarshalIndent(UpdateCardResponse{Success: true, Message: message}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleSetNewCardsPerDay handles the set_new_cards_per_day tool request by
+// persisting how many New cards get_due_card introduces per day.
+func handleSetNewCardsPerDay(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	limit, ok := request.Params.Arguments["limit"].(float64)
+	if !ok {
+		return mcp.NewToolResultError("Missing required parameter: limit"), nil
+	}
+	if limit < 0 || limit != math.Trunc(limit) {
+		return mcp.NewToolResultError("limit must be a whole number, at least 0"), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	if err := s.SetNewCardsPerDay(int(limit)); err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error setting new cards per day: %v"}`, err)), nil
+	}
+
+	message := fmt.Sprintf("Up to %d new cards will be introduced per day.", int(limit))
+	if limit == 0 {
+		message = "New cards are no longer limited per day."
+	}
+	jsonBytes, err := json.MarshalIndent(UpdateCardResponse{Success: true, Message: message}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleCardHistoryResource handles requests for the card-history resource, the
+// whole audit log newest first.
+func handleCardHistoryResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return nil, ErrServiceUnavailable
+	}
+
+	entries, err := s.CardHistory("", time.Time{}, time.Time{})
+	if err != nil {
+		return nil, fmt.Errorf("error listing card history: %w", err)
+	}
+
+	jsonBytes, err := json.MarshalIndent(CardHistoryResponse{Entries: entries, Count: len(entries), MaxEntries: s.auditLogSize()}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling card history: %w", err)
+	}
+
+	return []mcp.ResourceContents{
+		mcp.TextResourceContents{
+			URI:      "card-history",
+			MIMEType: "application/json",
+			Text:     string(jsonBytes),
+		},
+	}, nil
+}
+
+// handleGetCardHistory handles the get_card_history tool request by listing the
+// audit log, optionally for one card and a date range with an inclusive end date.
+func handleGetCardHistory(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var since, until time.Time
+	if startStr, _ := request.Params.Arguments["start_date"].(string); startStr != "" {
+		start, err := time.ParseInLocation("2006-01-02", startStr, time.Local)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid start_date format: %s. Use YYYY-MM-DD.", startStr)), nil
+		}
+		since = start
+	}
+	if endStr, _ := request.Params.Arguments["end_date"].(string); endStr != "" {
+		end, err := time.ParseInLocation("2006-01-02", endStr, time.Local)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid end_date format: %s. Use YYYY-MM-DD.", endStr)), nil
+		}
+		// The end date is inclusive: the range runs to the start of the following day
+		until = end.AddDate(0, 0, 1)
+	}
+	if !since.IsZero() && !until.IsZero() && !since.Before(until) {
+		return mcp.NewToolResultError("start_date must not be after end_date"), nil
+	}
+	cardID, _ := request.Params.Arguments["card_id"].(string)
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	entries, err := s.CardHistory(cardID, since, until)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error listing card history: %v"}`, err)), nil
+	}
+	jsonBytes, err := json.MarshalIndent(CardHistoryResponse{Entries: entries, Count: len(entries), MaxEntries: s.auditLogSize()}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Error marshaling card history: %v", err)), nil
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// DueDateProgressInfo holds detailed progress for a single due date.
+type DueDateProgressInfo struct {
+	ID              string  `json:"id"`
+	Topic           string  `json:"topic"`
+	DueDate         string  `json:"due_date"` // YYYY-MM-DD format
+	Tag             string  `json:"tag"`
+	TotalCards      int     `json:"total_cards"`
+	MasteredCards   int     `json:"mastered_cards"`
+	MatureCards     int     `json:"mature_cards"` // Interval of at least MatureIntervalDays, a maturity-based readiness signal
+	ProgressPercent float64 `json:"progress_percent"`
+	DaysRemaining   float64 `json:"days_remaining"` // Days until day *before* due date
+	CardsLeft       int     `json:"cards_left"`
+	RequiredPace    float64 `json:"required_pace"` // Cards per day needed
+}
+
+// handleDueDateProgressResource generates a resource showing progress towards upcoming due dates.
+func handleDueDateProgressResource(ctx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return nil, ErrServiceUnavailable
+	}
+
+	// Get all defined due dates
+	dueDates, err := s.ListDueDates()
+	if err != nil {
+		return nil, fmt.Errorf("error listing due dates: %w", err)
+	}
+
+	// Log the number of due dates found
+	fmt.Printf("Found %d due dates in handleDueDateProgressResource\n", len(dueDates))
+	for i, dd := range dueDates {
+		fmt.Printf("Due date %d: ID=%s, Topic=%s, Date=%s, Tag=%s\n",
+			i+1, dd.ID, dd.Topic, dd.DueDate.Format("2006-01-02"), dd.Tag)
+	}
+
+	now := time.Now()
 	// Truncate now to the beginning of the day for consistent day calculation
 	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 
@@ -906,6 +1570,7 @@ func handleDueDateProgressResource(ctx context.Context, request mcp.ReadResource
 			Tag:             dd.Tag,
 			TotalCards:      stats.TotalCards,
 			MasteredCards:   stats.MasteredCards,
+			MatureCards:     stats.MatureCards,
 			ProgressPercent: stats.ProgressPercent,
 			DaysRemaining:   daysRemaining,
 			CardsLeft:       cardsLeft,
@@ -949,3 +1614,2345 @@ func handleDueDateProgressResource(ctx context.Context, request mcp.ReadResource
 
 	return contents, nil
 }
+
+// ErrServiceUnavailable means a handler ran without a FlashcardService in its
+// context. That is a wiring bug in the server, not a problem with the caller's input.
+var ErrServiceUnavailable = errors.New("service not available")
+
+// ServiceUnavailableCode is the error code tool results carry when the service is missing.
+const ServiceUnavailableCode = "service_unavailable"
+
+// serviceFromContext returns the FlashcardService stored under the "service" key.
+func serviceFromContext(ctx context.Context) (*FlashcardService, bool) {
+	s, ok := ctx.Value("service").(*FlashcardService)
+	return s, ok && s != nil
+}
+
+// serviceUnavailableResult is the IsError tool result returned when serviceFromContext
+// fails. The body is JSON with a code so clients can tell it apart from tool output.
+func serviceUnavailableResult() *mcp.CallToolResult {
+	return mcp.NewToolResultError(fmt.Sprintf(`{"error": {"code": %q, "message": "Service not available"}}`, ServiceUnavailableCode))
+}
+
+// stringSliceArg extracts an optional array-of-strings argument from a tool request.
+// Non-string elements are ignored; a missing argument yields nil.
+func stringSliceArg(request mcp.CallToolRequest, name string) []string {
+	var result []string
+	if values, ok := request.Params.Arguments[name].([]interface{}); ok {
+		for _, value := range values {
+			if str, ok := value.(string); ok {
+				result = append(result, str)
+			}
+		}
+	}
+	return result
+}
+
+// tagMatchArg extracts the optional tag_match argument, defaulting to TagMatchAll.
+// An unknown mode yields a tool error result to return to the client.
+func tagMatchArg(request mcp.CallToolRequest) (string, *mcp.CallToolResult) {
+	tagMatch, _ := request.Params.Arguments["tag_match"].(string)
+	if tagMatch == "" {
+		return TagMatchAll, nil
+	}
+	if tagMatch != TagMatchAll && tagMatch != TagMatchAny {
+		return "", mcp.NewToolResultError(fmt.Sprintf("Invalid tag_match: %s. Must be '%s' or '%s'", tagMatch, TagMatchAll, TagMatchAny))
+	}
+	return tagMatch, nil
+}
+
+// DueStateAny is the state argument of get_due_card that serves cards in every FSRS state
+const DueStateAny = "any"
+
+// dueStateArg reads the optional state argument of get_due_card; nil means any state.
+func dueStateArg(request mcp.CallToolRequest) (*gofsrs.State, *mcp.CallToolResult) {
+	value, _ := request.Params.Arguments["state"].(string)
+	if value == "" || value == DueStateAny {
+		return nil, nil
+	}
+	state, err := parseState(value)
+	if err != nil {
+		return nil, mcp.NewToolResultError(fmt.Sprintf("Invalid state: %s. Must be 'new', 'learning', 'review', 'relearning' or '%s'", value, DueStateAny))
+	}
+	return &state, nil
+}
+
+// cardFieldAliases maps the alternative field names some clients expect onto the
+// canonical card fields.
+var cardFieldAliases = map[string]string{
+	"question": "front",
+	"answer":   "back",
+}
+
+// applyCardFieldAliases rewrites aliased card arguments (question/answer) to their
+// canonical names. When both an alias and its canonical field are given, the
+// canonical field wins.
+func applyCardFieldAliases(request *mcp.CallToolRequest) {
+	// Copy the arguments so the caller's map is left untouched
+	args := make(map[string]interface{}, len(request.Params.Arguments))
+	for key, value := range request.Params.Arguments {
+		args[key] = value
+	}
+	request.Params.Arguments = args
+
+	for alias, field := range cardFieldAliases {
+		value, exists := request.Params.Arguments[alias]
+		if !exists {
+			continue
+		}
+		if _, hasField := request.Params.Arguments[field]; !hasField {
+			request.Params.Arguments[field] = value
+		}
+		delete(request.Params.Arguments, alias)
+	}
+}
+
+// marshalCardResponse marshals a tool response, renaming front/back to
+// question/answer when the request asked for field_names "question_answer".
+func marshalCardResponse(request mcp.CallToolRequest, response interface{}) ([]byte, error) {
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if mode, _ := request.Params.Arguments["field_names"].(string); mode != "question_answer" {
+		return jsonBytes, nil
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(jsonBytes, &generic); err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(renameCardFields(generic), "", "  ")
+}
+
+// renameCardFields recursively renames front/back keys to their question/answer aliases.
+func renameCardFields(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		renamed := make(map[string]interface{}, len(v))
+		for key, inner := range v {
+			for alias, field := range cardFieldAliases {
+				if key == field {
+					key = alias
+					break
+				}
+			}
+			renamed[key] = renameCardFields(inner)
+		}
+		return renamed
+	case []interface{}:
+		for i := range v {
+			v[i] = renameCardFields(v[i])
+		}
+		return v
+	default:
+		return value
+	}
+}
+
+// handleManageTemplates handles create, list, and delete operations for card templates.
+func handleManageTemplates(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	action, _ := request.Params.Arguments["action"].(string)
+	if action == "" {
+		return mcp.NewToolResultError("Missing required parameter: action"), nil
+	}
+	name, _ := request.Params.Arguments["name"].(string)
+
+	switch action {
+	case "create":
+		front, _ := request.Params.Arguments["front"].(string)
+		back, _ := request.Params.Arguments["back"].(string)
+		if name == "" || front == "" || back == "" {
+			return mcp.NewToolResultError("Missing required parameters for create: name, front, back"), nil
+		}
+		template := storage.CardTemplate{
+			Name:  name,
+			Front: front,
+			Back:  back,
+			Tags:  stringSliceArg(request, "tags"),
+		}
+		if err := s.SaveTemplate(template); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error creating template: %v", err)), nil
+		}
+		jsonBytes, _ := json.MarshalIndent(template, "", "  ")
+		return mcp.NewToolResultText(string(jsonBytes)), nil
+
+	case "list":
+		templates, err := s.ListTemplates()
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error listing templates: %v", err)), nil
+		}
+		jsonBytes, err := json.MarshalIndent(templates, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error marshaling templates: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(jsonBytes)), nil
+
+	case "delete":
+		if name == "" {
+			return mcp.NewToolResultError("Missing required parameter for delete: name"), nil
+		}
+		if err := s.DeleteTemplate(name); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error deleting template: %v", err)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf(`{"message": "Template %s deleted successfully"}`, name)), nil
+
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid action: %s. Must be one of 'create', 'delete', 'list'", action)), nil
+	}
+}
+
+// handleCreateCardFromTemplate handles the create_card_from_template tool request by
+// filling a named template's placeholders and creating the resulting card.
+func handleCreateCardFromTemplate(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name, ok := request.Params.Arguments["template"].(string)
+	if !ok || name == "" {
+		return mcp.NewToolResultError("Missing required parameter: template"), nil
+	}
+
+	values := map[string]string{}
+	if rawValues, ok := request.Params.Arguments["values"].(map[string]interface{}); ok {
+		for key, value := range rawValues {
+			switch v := value.(type) {
+			case string:
+				values[key] = v
+			default:
+				values[key] = fmt.Sprint(v)
+			}
+		}
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	card, err := s.CreateCardFromTemplate(name, values, stringSliceArg(request, "tags"))
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error creating card from template: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(map[string]Card{"card": card}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleRepairDueDates handles the repair_due_dates maintenance tool, fixing cards
+// whose FSRS due date is zero or otherwise invalid.
+func handleRepairDueDates(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	dryRun, _ := request.Params.Arguments["dry_run"].(bool)
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	repairs, err := s.RepairDueDates(dryRun)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error repairing due dates: %v"}`, err)), nil
+	}
+
+	response := RepairDueDatesResponse{
+		DryRun:  dryRun,
+		Repairs: repairs,
+	}
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleCleanupCollection handles the cleanup_collection maintenance tool. Without
+// confirm it only reports what would change.
+func handleCleanupCollection(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	confirm, _ := request.Params.Arguments["confirm"].(bool)
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	report, err := s.CleanupCollection(confirm)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error cleaning up collection: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleAutosuspendStale handles the autosuspend_stale tool request by suspending
+// cards that haven't been reviewed in a long time.
+func handleAutosuspendStale(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	days := float64(DefaultStaleDays)
+	if v, ok := request.Params.Arguments["days"].(float64); ok {
+		if v <= 0 {
+			return mcp.NewToolResultError("days must be positive"), nil
+		}
+		days = v
+	}
+	filterTags := stringSliceArg(request, "tags")
+	dryRun, _ := request.Params.Arguments["dry_run"].(bool)
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	response, err := s.AutosuspendStale(days, filterTags, dryRun)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error suspending stale cards: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleClockAnomalyCheck handles the clock_anomaly_check maintenance tool, reporting
+// cards whose due date looks like the result of a clock jump.
+func handleClockAnomalyCheck(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	maxFutureDays := float64(DefaultClockAnomalyFutureDays)
+	if v, ok := request.Params.Arguments["max_future_days"].(float64); ok {
+		if v <= 0 {
+			return mcp.NewToolResultError("max_future_days must be positive"), nil
+		}
+		maxFutureDays = v
+	}
+	toleranceDays := float64(DefaultClockAnomalyToleranceDays)
+	if v, ok := request.Params.Arguments["tolerance_days"].(float64); ok {
+		if v < 0 {
+			return mcp.NewToolResultError("tolerance_days must not be negative"), nil
+		}
+		toleranceDays = v
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	anomalies, err := s.ClockAnomalyCheck(maxFutureDays, toleranceDays)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error checking for clock anomalies: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(anomalies, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleGetHint handles the get_hint tool request by returning a card's hint
+// without revealing the answer. It is meant to be used between get_due_card and submit_review.
+func handleGetHint(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	cardID, ok := request.Params.Arguments["card_id"].(string)
+	if !ok || cardID == "" {
+		return mcp.NewToolResultError("Missing required parameter: card_id"), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	hint, err := s.GetHint(cardID)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error getting hint: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(hint, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleCheckAnswer handles the check_answer tool request by comparing a student's
+// answer with the back of the card.
+func handleCheckAnswer(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	cardID, ok := request.Params.Arguments["card_id"].(string)
+	if !ok || cardID == "" {
+		return mcp.NewToolResultError("Missing required parameter: card_id"), nil
+	}
+	answer, ok := request.Params.Arguments["answer"].(string)
+	if !ok {
+		return mcp.NewToolResultError("Missing required parameter: answer"), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	check, err := s.CheckAnswer(cardID, answer)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error checking answer: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(check, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleListCardsByDue handles the list_cards_by_due tool request by returning cards
+// ordered by their next due date, optionally filtered by tags.
+func handleListCardsByDue(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	filterTags := stringSliceArg(request, "tags")
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	cards, err := s.ListCardsByDue(filterTags)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error listing cards by due date: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(ListCardsByDueResponse{Cards: cards}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleListLongIntervalCards handles the list_long_interval_cards tool request by
+// returning cards whose next review is further away than a threshold.
+func handleListLongIntervalCards(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	thresholdDays := DefaultLongIntervalDays
+	if v, ok := request.Params.Arguments["min_days"].(float64); ok {
+		if v < 0 {
+			return mcp.NewToolResultError("min_days must not be negative"), nil
+		}
+		thresholdDays = v
+	}
+	filterTags := stringSliceArg(request, "tags")
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	cards, err := s.ListLongIntervalCards(thresholdDays, filterTags)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error listing long-interval cards: %v"}`, err)), nil
+	}
+
+	response := ListLongIntervalCardsResponse{ThresholdDays: thresholdDays, Cards: cards}
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleListCardsByReviewCount handles the list_cards_by_review_count tool request by
+// returning cards ordered by how often they have been reviewed.
+func handleListCardsByReviewCount(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	filterTags := stringSliceArg(request, "tags")
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	cards, err := s.ListCardsByReviewCount(filterTags)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error listing cards by review count: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(ListCardsByReviewCountResponse{Cards: cards}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleSearchByAnswer handles the search_by_answer tool request by finding cards
+// whose back contains the query text.
+func handleSearchByAnswer(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query, ok := request.Params.Arguments["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return mcp.NewToolResultError("Missing required parameter: query"), nil
+	}
+	filterTags := stringSliceArg(request, "tags")
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	cards, err := s.SearchByAnswer(query, filterTags)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error searching cards: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(SearchByAnswerResponse{Query: query, Cards: cards}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleSearchCards handles the search_cards tool request by finding cards
+// whose front or back contains the query text.
+func handleSearchCards(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	query, ok := request.Params.Arguments["query"].(string)
+	if !ok || strings.TrimSpace(query) == "" {
+		return mcp.NewToolResultError("Missing required parameter: query"), nil
+	}
+	filterTags := stringSliceArg(request, "tags")
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	cards, err := s.SearchCards(query, filterTags)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error searching cards: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(SearchCardsResponse{Query: query, Cards: cards}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleVacationMode handles the vacation_mode tool, which pauses scheduling over a date range.
+func handleVacationMode(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	action, _ := request.Params.Arguments["action"].(string)
+	if action == "" {
+		return mcp.NewToolResultError("Missing required parameter: action"), nil
+	}
+
+	switch action {
+	case "create":
+		startStr, _ := request.Params.Arguments["start_date"].(string)
+		endStr, _ := request.Params.Arguments["end_date"].(string)
+		if startStr == "" || endStr == "" {
+			return mcp.NewToolResultError("Missing required parameters for create: start_date, end_date (YYYY-MM-DD)"), nil
+		}
+		start, err := time.ParseInLocation("2006-01-02", startStr, time.Local)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid start_date format: %s. Use YYYY-MM-DD.", startStr)), nil
+		}
+		end, err := time.ParseInLocation("2006-01-02", endStr, time.Local)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid end_date format: %s. Use YYYY-MM-DD.", endStr)), nil
+		}
+		// The end date is inclusive: scheduling resumes at the start of the following day
+		vacation, err := s.AddVacation(start, end.AddDate(0, 0, 1))
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error creating vacation: %v", err)), nil
+		}
+		jsonBytes, _ := json.MarshalIndent(vacation, "", "  ")
+		return mcp.NewToolResultText(string(jsonBytes)), nil
+
+	case "list":
+		vacations, err := s.ListVacations()
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error listing vacations: %v", err)), nil
+		}
+		jsonBytes, err := json.MarshalIndent(vacations, "", "  ")
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error marshaling vacations: %v", err)), nil
+		}
+		return mcp.NewToolResultText(string(jsonBytes)), nil
+
+	case "delete":
+		vacationID, _ := request.Params.Arguments["vacation_id"].(string)
+		if vacationID == "" {
+			return mcp.NewToolResultError("Missing required parameter for delete: vacation_id"), nil
+		}
+		if err := s.DeleteVacation(vacationID); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Error deleting vacation: %v", err)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf(`{"message": "Vacation %s deleted successfully"}`, vacationID)), nil
+
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid action: %s. Must be one of 'create', 'delete', 'list'", action)), nil
+	}
+}
+
+// handleRatingDistribution handles the rating_distribution tool request by counting
+// the ratings given across all reviews of cards with a tag.
+func handleRatingDistribution(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	tag, ok := request.Params.Arguments["tag"].(string)
+	if !ok || tag == "" {
+		return mcp.NewToolResultError("Missing required parameter: tag"), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	dist, err := s.GetRatingDistribution(tag)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error getting rating distribution: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(dist, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleTagStateSummary handles the tag_state_summary tool request by reporting the
+// share of a tag's cards in each FSRS state.
+func handleTagStateSummary(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	tag, ok := request.Params.Arguments["tag"].(string)
+	if !ok || tag == "" {
+		return mcp.NewToolResultError("Missing required parameter: tag"), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	summary, err := s.TagStateSummary(tag)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error summarizing card states: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleForgettingCurve handles the forgetting_curve tool request by sampling a
+// card's predicted recall over its current interval.
+func handleForgettingCurve(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	cardID, ok := request.Params.Arguments["card_id"].(string)
+	if !ok || cardID == "" {
+		return mcp.NewToolResultError("Missing required parameter: card_id"), nil
+	}
+	samples := DefaultForgettingCurveSamples
+	if v, ok := request.Params.Arguments["samples"].(float64); ok {
+		if v < 2 || v > 100 {
+			return mcp.NewToolResultError("samples must be between 2 and 100"), nil
+		}
+		samples = int(v)
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	curve, err := s.ForgettingCurve(cardID, samples)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error computing forgetting curve: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(curve, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleReassignCardDueDate handles the reassign_card_due_date tool request by moving
+// a card from one due date to another (swapping the due date tags on the card).
+func handleReassignCardDueDate(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	cardID, _ := request.Params.Arguments["card_id"].(string)
+	oldDueDateID, _ := request.Params.Arguments["old_due_date_id"].(string)
+	newDueDateID, _ := request.Params.Arguments["new_due_date_id"].(string)
+	if cardID == "" || oldDueDateID == "" || newDueDateID == "" {
+		return mcp.NewToolResultError("Missing required parameters: card_id, old_due_date_id, new_due_date_id"), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	card, err := s.ReassignCardDueDate(cardID, oldDueDateID, newDueDateID)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error reassigning card: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(map[string]Card{"card": card}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handlePrioritizeCard handles the prioritize_card tool request by boosting a card
+// so it is returned by the next get_due_card call.
+func handlePrioritizeCard(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	cardID, ok := request.Params.Arguments["card_id"].(string)
+	if !ok || cardID == "" {
+		return mcp.NewToolResultError("Missing required parameter: card_id"), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	if _, err := s.PrioritizeCard(cardID); err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error prioritizing card: %v"}`, err)), nil
+	}
+
+	response := UpdateCardResponse{
+		Success: true,
+		Message: fmt.Sprintf("Card %s will be served by the next get_due_card call.", cardID),
+	}
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleRescheduleCard handles the reschedule_card tool request by moving a card's
+// due date to an absolute time or a number of days from now.
+func handleRescheduleCard(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	cardID, ok := request.Params.Arguments["card_id"].(string)
+	if !ok || cardID == "" {
+		return mcp.NewToolResultError("Missing required parameter: card_id"), nil
+	}
+
+	dueStr, hasDue := request.Params.Arguments["due"].(string)
+	days, hasDays := request.Params.Arguments["due_in_days"].(float64)
+	if hasDue == hasDays {
+		return mcp.NewToolResultError("Provide exactly one of 'due' (RFC3339) or 'due_in_days'"), nil
+	}
+	var due time.Time
+	if hasDue {
+		parsed, err := time.Parse(time.RFC3339, dueStr)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid due format: %s. Use RFC3339, e.g. 2025-06-01T09:00:00Z.", dueStr)), nil
+		}
+		due = parsed
+	} else {
+		if days < 0 {
+			return mcp.NewToolResultError("due_in_days must not be negative"), nil
+		}
+		due = timeNow().Add(time.Duration(days * 24 * float64(time.Hour)))
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	response, err := s.RescheduleCard(cardID, due)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error rescheduling card: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleBulkReschedule handles the bulk_reschedule tool request by moving the due
+// date of every card matching a tag filter to an absolute date or a number of days from now.
+func handleBulkReschedule(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	filterTags := stringSliceArg(request, "tags")
+	if len(filterTags) == 0 {
+		return mcp.NewToolResultError("Missing required parameter: tags"), nil
+	}
+
+	dateStr, hasDate := request.Params.Arguments["date"].(string)
+	days, hasDays := request.Params.Arguments["days"].(float64)
+	if hasDate == hasDays {
+		return mcp.NewToolResultError("Provide exactly one of 'date' (YYYY-MM-DD) or 'days'"), nil
+	}
+	var due time.Time
+	if hasDate {
+		parsedDate, err := time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid date format: %s. Use YYYY-MM-DD.", dateStr)), nil
+		}
+		due = parsedDate
+	} else {
+		if days < 0 {
+			return mcp.NewToolResultError("days must not be negative"), nil
+		}
+		due = timeNow().AddDate(0, 0, int(days))
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	response, err := s.BulkReschedule(filterTags, due)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error rescheduling cards: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleFlagCard handles the flag_card tool request by marking a card for teacher review.
+func handleFlagCard(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	cardID, ok := request.Params.Arguments["card_id"].(string)
+	if !ok || cardID == "" {
+		return mcp.NewToolResultError("Missing required parameter: card_id"), nil
+	}
+	reason, _ := request.Params.Arguments["reason"].(string)
+	flagged := true
+	if v, ok := request.Params.Arguments["flagged"].(bool); ok {
+		flagged = v
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	if _, err := s.FlagCard(cardID, flagged, reason); err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error flagging card: %v"}`, err)), nil
+	}
+
+	message := fmt.Sprintf("Card %s flagged for teacher review.", cardID)
+	if !flagged {
+		message = fmt.Sprintf("Flag cleared on card %s.", cardID)
+	}
+	jsonBytes, err := json.MarshalIndent(UpdateCardResponse{Success: true, Message: message}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleSuspendCard handles the suspend_card tool request by taking a card out of review.
+func handleSuspendCard(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return setCardSuspended(ctx, request, true)
+}
+
+// handleUnsuspendCard handles the unsuspend_card tool request by returning a suspended
+// card to review.
+func handleUnsuspendCard(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return setCardSuspended(ctx, request, false)
+}
+
+// setCardSuspended implements suspend_card and unsuspend_card.
+func setCardSuspended(ctx context.Context, request mcp.CallToolRequest, suspended bool) (*mcp.CallToolResult, error) {
+	cardID, ok := request.Params.Arguments["card_id"].(string)
+	if !ok || cardID == "" {
+		return mcp.NewToolResultError("Missing required parameter: card_id"), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	if _, err := s.SuspendCard(cardID, suspended); err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error updating card: %v"}`, err)), nil
+	}
+
+	message := fmt.Sprintf("Card %s suspended. It won't be served for review until it is unsuspended.", cardID)
+	if !suspended {
+		message = fmt.Sprintf("Card %s unsuspended and back in review.", cardID)
+	}
+	jsonBytes, err := json.MarshalIndent(UpdateCardResponse{Success: true, Message: message}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleListFlaggedCards handles the list_flagged_cards tool request by returning
+// every flagged card with its reason.
+func handleListFlaggedCards(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	cards, err := s.ListFlaggedCards()
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error listing flagged cards: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(ListFlaggedCardsResponse{Cards: cards}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleApplyTagsToCards handles the apply_tags_to_cards tool request by adding
+// tags to an explicit list of cards in one save.
+func handleApplyTagsToCards(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	cardIDs := stringSliceArg(request, "card_ids")
+	if len(cardIDs) == 0 {
+		return mcp.NewToolResultError("Missing required parameter: card_ids"), nil
+	}
+	addTags := stringSliceArg(request, "add_tags")
+	if len(addTags) == 0 {
+		return mcp.NewToolResultError("Missing required parameter: add_tags"), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	response, err := s.ApplyTagsToCards(cardIDs, addTags)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error applying tags: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleSimulateDue handles the simulate_due tool request by listing the cards that
+// would be due at a given future time, without changing anything.
+func handleSimulateDue(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	atStr, _ := request.Params.Arguments["at"].(string)
+	if atStr == "" {
+		return mcp.NewToolResultError("Missing required parameter: at"), nil
+	}
+	at, err := time.Parse(time.RFC3339, atStr)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid at timestamp (use RFC 3339, e.g. 2024-03-02T09:00:00Z): %v", err)), nil
+	}
+	filterTags := stringSliceArg(request, "tags")
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	response, err := s.SimulateDue(at, filterTags)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error simulating due cards: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleSlowCards handles the slow_cards tool request by listing cards whose
+// average answer time exceeds a threshold.
+func handleSlowCards(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	thresholdMS := int64(DefaultSlowAnswerMS)
+	if v, ok := request.Params.Arguments["threshold_ms"].(float64); ok {
+		if v <= 0 {
+			return mcp.NewToolResultError("threshold_ms must be positive"), nil
+		}
+		thresholdMS = int64(v)
+	}
+	filterTags := stringSliceArg(request, "tags")
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	cards, err := s.SlowCards(thresholdMS, filterTags)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error finding slow cards: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(cards, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleAssignToDueDate handles the assign_to_due_date tool request by tagging
+// every card matching a search query or tag filter with a due date's tag.
+func handleAssignToDueDate(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	dueDateID, _ := request.Params.Arguments["due_date_id"].(string)
+	if dueDateID == "" {
+		return mcp.NewToolResultError("Missing required parameter: due_date_id"), nil
+	}
+	query, _ := request.Params.Arguments["query"].(string)
+	filterTags := stringSliceArg(request, "tags")
+	if strings.TrimSpace(query) == "" && len(filterTags) == 0 {
+		return mcp.NewToolResultError("Provide a search query, tags, or both to select cards"), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	response, err := s.AssignToDueDate(dueDateID, query, filterTags)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error assigning cards to due date: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleDecayForecast handles the decay_forecast tool request by projecting how many
+// mastered cards would be forgotten if the student stopped reviewing.
+func handleDecayForecast(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	days := DefaultDecayForecastDays
+	if v, ok := request.Params.Arguments["days"].(float64); ok {
+		if v < 1 || v > 365 {
+			return mcp.NewToolResultError("days must be between 1 and 365"), nil
+		}
+		days = int(v)
+	}
+	threshold := DefaultMasteryThreshold
+	if v, ok := request.Params.Arguments["threshold"].(float64); ok {
+		if v <= 0 || v >= 1 {
+			return mcp.NewToolResultError("threshold must be between 0 and 1"), nil
+		}
+		threshold = v
+	}
+	filterTags := stringSliceArg(request, "tags")
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	forecast, err := s.DecayForecast(days, threshold, filterTags)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error forecasting decay: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(forecast, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleProjectedReviews handles the projected_reviews tool request by forecasting
+// the reviews needed per day over the next week.
+func handleProjectedReviews(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	filterTags := stringSliceArg(request, "tags")
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	projection, err := s.ProjectedReviews(filterTags)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error projecting reviews: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(projection, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleLevelWorkload handles the level_workload tool request by spreading overdue
+// cards over the coming days so none exceeds the daily cap.
+func handleLevelWorkload(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	dailyCap, ok := request.Params.Arguments["daily_cap"].(float64)
+	if !ok {
+		return mcp.NewToolResultError("Missing required parameter: daily_cap"), nil
+	}
+	if dailyCap < 1 {
+		return mcp.NewToolResultError("daily_cap must be at least 1"), nil
+	}
+	filterTags := stringSliceArg(request, "tags")
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	response, err := s.LevelWorkload(int(dailyCap), filterTags)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error leveling workload: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleCreateSnapshot handles the create_snapshot tool request by storing a
+// timestamped copy of the collection for later comparison.
+func handleCreateSnapshot(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	label, _ := request.Params.Arguments["label"].(string)
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	info, err := s.CreateSnapshot(label)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error creating snapshot: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleDiffSnapshot handles the diff_snapshot tool request by comparing the
+// current collection to a snapshot (the most recent one by default).
+func handleDiffSnapshot(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	snapshotID, _ := request.Params.Arguments["snapshot_id"].(string)
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	diff, err := s.DiffSnapshot(snapshotID)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error diffing snapshot: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(diff, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleDeleteSnapshot handles the delete_snapshot tool request by removing a
+// snapshot that is no longer needed.
+func handleDeleteSnapshot(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	snapshotID, ok := request.Params.Arguments["snapshot_id"].(string)
+	if !ok || snapshotID == "" {
+		return mcp.NewToolResultError("Missing required parameter: snapshot_id"), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	if err := s.DeleteSnapshot(snapshotID); err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error deleting snapshot: %v"}`, err)), nil
+	}
+	return mcp.NewToolResultText(fmt.Sprintf(`{"message": "Snapshot %s deleted successfully"}`, snapshotID)), nil
+}
+
+// handleUpcomingDueDates handles the upcoming_due_dates tool request by returning the
+// next due dates with countdowns, e.g. for a dashboard header.
+func handleUpcomingDueDates(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	limit := 5
+	if v, ok := request.Params.Arguments["limit"].(float64); ok {
+		if v < 0 {
+			return mcp.NewToolResultError("limit must not be negative"), nil
+		}
+		limit = int(v)
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	upcoming, err := s.UpcomingDueDates(limit)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error listing upcoming due dates: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(upcoming, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleGetMaturityBreakdown handles the get_maturity_breakdown tool request by
+// counting new, young and mature cards.
+func handleGetMaturityBreakdown(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	filterTags := stringSliceArg(request, "tags")
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	breakdown, err := s.GetMaturityBreakdown(filterTags)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error computing maturity breakdown: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(breakdown, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleGetStatistics handles the get_statistics tool request by summarizing the
+// collection, with retention over the requested windows of days.
+func handleGetStatistics(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	windows := DefaultStatisticsWindows
+	if raw, exists := request.Params.Arguments["windows"]; exists {
+		values, ok := raw.([]interface{})
+		if !ok {
+			return mcp.NewToolResultError("Invalid windows: must be an array of whole numbers of days"), nil
+		}
+		windows = make([]int, 0, len(values))
+		for _, value := range values {
+			days, ok := value.(float64)
+			if !ok || days != math.Trunc(days) || days < 1 {
+				return mcp.NewToolResultError(fmt.Sprintf("Invalid window %v: must be a whole number of days, at least 1", value)), nil
+			}
+			windows = append(windows, int(days))
+		}
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	stats, err := s.GetStatistics(windows)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error computing statistics: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleAutotagByDifficulty handles the autotag_by_difficulty tool request by
+// tagging cards into difficulty bands from their FSRS difficulty.
+func handleAutotagByDifficulty(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	filterTags := stringSliceArg(request, "tags")
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	result, err := s.AutotagByDifficulty(filterTags)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error tagging cards by difficulty: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleGetFSRSConfig handles the get_fsrs_config tool request by returning the
+// FSRS parameters used for scheduling.
+func handleGetFSRSConfig(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(s.GetFSRSConfig(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleSetFSRSConfig handles the set_fsrs_config tool request. Provided parameters
+// are merged into the saved overrides; reset restores the defaults.
+func handleSetFSRSConfig(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+
+	reset, _ := args["reset"].(bool)
+	requestRetention, hasRequestRetention := args["request_retention"].(float64)
+	maximumInterval, hasMaximumInterval := args["maximum_interval"].(float64)
+	rawWeights, hasWeights := args["weights"].([]interface{})
+	weights := make([]float64, 0, len(rawWeights))
+	for i, raw := range rawWeights {
+		w, ok := raw.(float64)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid weight at index %d (must be a number)", i)), nil
+		}
+		weights = append(weights, w)
+	}
+	changed := hasRequestRetention || hasMaximumInterval || hasWeights
+	if reset == changed {
+		return mcp.NewToolResultError("Provide request_retention, maximum_interval or weights, or reset: true"), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	var params *storage.FSRSParams
+	if !reset {
+		config, err := s.Storage.GetConfig()
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error getting config: %v"}`, err)), nil
+		}
+		params = &storage.FSRSParams{}
+		if config.FSRS != nil {
+			*params = *config.FSRS
+		}
+		if hasRequestRetention {
+			params.RequestRetention = requestRetention
+		}
+		if hasMaximumInterval {
+			params.MaximumInterval = maximumInterval
+		}
+		if hasWeights {
+			params.Weights = weights
+		}
+	}
+
+	response, err := s.SetFSRSConfig(params)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error updating FSRS config: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleOptimizeFSRS handles the optimize_fsrs tool request by fitting the FSRS
+// weights to the review history.
+func handleOptimizeFSRS(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	response, err := s.OptimizeFSRS()
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error optimizing FSRS weights: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleConfigure handles the configure tool request. Provided settings are
+// persisted and applied immediately; with no arguments the current config is returned.
+func handleConfigure(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args := request.Params.Arguments
+
+	maxReviews, hasMaxReviews := args["max_reviews_per_day"].(float64)
+	if hasMaxReviews && maxReviews < 0 {
+		return mcp.NewToolResultError("max_reviews_per_day must not be negative"), nil
+	}
+	maxOverdueFactor, hasMaxOverdueFactor := args["max_overdue_factor"].(float64)
+	autoTagRecall, hasAutoTagRecall := args["auto_tag_recall"].(bool)
+	secondsPerCard, hasSecondsPerCard := args["seconds_per_card"].(float64)
+	if hasSecondsPerCard && secondsPerCard < 0 {
+		return mcp.NewToolResultError("seconds_per_card must not be negative"), nil
+	}
+	foldDiacritics, hasFoldDiacritics := args["fold_diacritics"].(bool)
+	newCardDelay, hasNewCardDelay := args["new_card_delay"].(float64)
+	if hasNewCardDelay && newCardDelay < 0 {
+		return mcp.NewToolResultError("new_card_delay must not be negative"), nil
+	}
+	rejectReservedTags, hasRejectReservedTags := args["reject_reserved_tags"].(bool)
+	siblingSpacingDays, hasSiblingSpacingDays := args["sibling_spacing_days"].(float64)
+	if hasSiblingSpacingDays && siblingSpacingDays < 0 {
+		return mcp.NewToolResultError("sibling_spacing_days must not be negative"), nil
+	}
+	masteryMode, hasMasteryMode := args["mastery_mode"].(string)
+	if hasMasteryMode && masteryMode != MasteryModeLastRating && masteryMode != MasteryModeRetrievability {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid mastery_mode: %s. Must be '%s' or '%s'", masteryMode, MasteryModeLastRating, MasteryModeRetrievability)), nil
+	}
+	masteryThreshold, hasMasteryThreshold := args["mastery_threshold"].(float64)
+	if hasMasteryThreshold && (masteryThreshold < 0 || masteryThreshold > 1) {
+		return mcp.NewToolResultError("mastery_threshold must be between 0 and 1"), nil
+	}
+	maxTagsPerCard, hasMaxTagsPerCard := args["max_tags_per_card"].(float64)
+	if hasMaxTagsPerCard && maxTagsPerCard < 0 {
+		return mcp.NewToolResultError("max_tags_per_card must not be negative"), nil
+	}
+	leechThreshold, hasLeechThreshold := args["leech_threshold"].(float64)
+	if hasLeechThreshold && leechThreshold < 0 {
+		return mcp.NewToolResultError("leech_threshold must not be negative"), nil
+	}
+	autoSuspendLeeches, hasAutoSuspendLeeches := args["auto_suspend_leeches"].(bool)
+	auditLogSize, hasAuditLogSize := args["audit_log_size"].(float64)
+	if hasAuditLogSize && (auditLogSize < 0 || auditLogSize != math.Trunc(auditLogSize)) {
+		return mcp.NewToolResultError("audit_log_size must be a whole number of at least 0"), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	if !hasMaxReviews && !hasMaxOverdueFactor && !hasAutoTagRecall && !hasSecondsPerCard && !hasFoldDiacritics && !hasNewCardDelay && !hasRejectReservedTags && !hasSiblingSpacingDays &&
+		!hasMasteryMode && !hasMasteryThreshold && !hasMaxTagsPerCard && !hasLeechThreshold && !hasAutoSuspendLeeches &&
+		!hasAuditLogSize {
+		config, err := s.Storage.GetConfig()
+		if err != nil {
+			return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error getting config: %v"}`, err)), nil
+		}
+		jsonBytes, err := json.MarshalIndent(config, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(string(jsonBytes)), nil
+	}
+
+	config, err := s.UpdateConfig(func(config *storage.Config) {
+		if hasMaxReviews {
+			config.MaxReviewsPerDay = int(maxReviews)
+		}
+		if hasMaxOverdueFactor {
+			config.MaxOverdueFactor = &maxOverdueFactor
+		}
+		if hasAutoTagRecall {
+			config.AutoTagRecall = autoTagRecall
+		}
+		if hasSecondsPerCard {
+			config.SecondsPerCard = secondsPerCard
+		}
+		if hasFoldDiacritics {
+			config.FoldDiacritics = foldDiacritics
+		}
+		if hasNewCardDelay {
+			config.NewCardDelay = newCardDelay
+		}
+		if hasRejectReservedTags {
+			config.RejectReservedTags = rejectReservedTags
+		}
+		if hasSiblingSpacingDays {
+			config.SiblingSpacingDays = &siblingSpacingDays
+		}
+		if hasMasteryMode {
+			config.MasteryMode = masteryMode
+		}
+		if hasMasteryThreshold {
+			config.MasteryThreshold = masteryThreshold
+		}
+		if hasMaxTagsPerCard {
+			config.MaxTagsPerCard = int(maxTagsPerCard)
+		}
+		if hasLeechThreshold {
+			config.LeechThreshold = int(leechThreshold)
+		}
+		if hasAutoSuspendLeeches {
+			config.AutoSuspendLeeches = autoSuspendLeeches
+		}
+		if hasAuditLogSize {
+			config.AuditLogSize = int(auditLogSize)
+		}
+	})
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error updating config: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleImportAnki handles the import_anki tool request by creating cards from an
+// Anki .apkg file on the server's filesystem.
+func handleImportAnki(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	path, ok := request.Params.Arguments["path"].(string)
+	if !ok || strings.TrimSpace(path) == "" {
+		return mcp.NewToolResultError("Missing required parameter: path"), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	response, err := s.ImportAnki(path)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error importing Anki package: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleImportCards handles the import_cards tool request by creating many cards at once,
+// from either a 'cards' array or 'csv' text. Invalid CSV rows are reported in the
+// response rather than failing the import.
+// If the request carries a progress token, a notifications/progress message is sent
+// to the client after each chunk. The context must be the per-request context so the
+// server and client session are available.
+func handleImportCards(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	rawCards, hasCards := request.Params.Arguments["cards"].([]interface{})
+	csvText, _ := request.Params.Arguments["csv"].(string)
+	if hasCards == (csvText != "") {
+		return mcp.NewToolResultError("Provide exactly one of 'cards' (an array of card objects) or 'csv'"), nil
+	}
+
+	var cards []ImportCard
+	var rowErrors []ImportRowError
+	if csvText != "" {
+		var err error
+		cards, rowErrors, err = ParseCardsCSV(strings.NewReader(csvText))
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid CSV: %v", err)), nil
+		}
+	}
+	for i, rawCard := range rawCards {
+		fields, ok := rawCard.(map[string]interface{})
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid card at index %d (must be an object)", i)), nil
+		}
+		card := ImportCard{}
+		card.Front, _ = fields["front"].(string)
+		card.Back, _ = fields["back"].(string)
+		card.Hint, _ = fields["hint"].(string)
+		if card.Front == "" || card.Back == "" {
+			return mcp.NewToolResultError(fmt.Sprintf("Card at index %d needs both front and back", i)), nil
+		}
+		if tags, ok := fields["tags"].([]interface{}); ok {
+			for _, tag := range tags {
+				if tagStr, ok := tag.(string); ok {
+					card.Tags = append(card.Tags, tagStr)
+				}
+			}
+		}
+		cards = append(cards, card)
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	var progress func(done, total int)
+	if request.Params.Meta != nil && request.Params.Meta.ProgressToken != nil {
+		if srv := server.ServerFromContext(ctx); srv != nil {
+			token := request.Params.Meta.ProgressToken
+			progress = func(done, total int) {
+				err := srv.SendNotificationToClient(ctx, "notifications/progress", map[string]any{
+					"progressToken": token,
+					"progress":      done,
+					"total":         total,
+				})
+				if err != nil {
+					log.Printf("Warning: Failed to send import progress: %v", err)
+				}
+			}
+		}
+	}
+
+	response, err := s.ImportCards(cards, progress)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error importing cards: %v"}`, err)), nil
+	}
+	if len(rowErrors) > 0 {
+		response.Errors = rowErrors
+	}
+
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleEffortReport handles the effort_report tool request by summarizing the
+// effort put into the reviews between two timestamps.
+func handleEffortReport(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	startStr, _ := request.Params.Arguments["start"].(string)
+	endStr, _ := request.Params.Arguments["end"].(string)
+	if startStr == "" || endStr == "" {
+		return mcp.NewToolResultError("Missing required parameters: start and end"), nil
+	}
+	start, err := time.Parse(time.RFC3339, startStr)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid start timestamp (use RFC 3339, e.g. 2024-03-01T15:00:00Z): %v", err)), nil
+	}
+	end, err := time.Parse(time.RFC3339, endStr)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid end timestamp (use RFC 3339, e.g. 2024-03-01T16:00:00Z): %v", err)), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	report, err := s.EffortReport(start, end)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error building effort report: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleSessionCards handles the session_cards tool request by listing the cards
+// reviewed between two timestamps.
+func handleSessionCards(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	startStr, _ := request.Params.Arguments["start"].(string)
+	endStr, _ := request.Params.Arguments["end"].(string)
+	if startStr == "" || endStr == "" {
+		return mcp.NewToolResultError("Missing required parameters: start and end"), nil
+	}
+	start, err := time.Parse(time.RFC3339, startStr)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid start timestamp (use RFC 3339, e.g. 2024-03-01T15:00:00Z): %v", err)), nil
+	}
+	end, err := time.Parse(time.RFC3339, endStr)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid end timestamp (use RFC 3339, e.g. 2024-03-01T16:00:00Z): %v", err)), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	cards, err := s.SessionCards(start, end)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error listing session cards: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(cards, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleRareTags handles the rare_tags tool request by listing tags used by only a few cards.
+func handleRareTags(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	maxCards := 1
+	if v, ok := request.Params.Arguments["max_cards"].(float64); ok {
+		if v < 1 {
+			return mcp.NewToolResultError("max_cards must be at least 1"), nil
+		}
+		maxCards = int(v)
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	tags, err := s.RareTags(maxCards)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error finding rare tags: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(tags, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleSuggestTagMerges handles the suggest_tag_merges tool request by finding
+// near-duplicate tags and optionally merging them.
+func handleSuggestTagMerges(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	maxDistance := DefaultTagMergeDistance
+	if v, ok := request.Params.Arguments["max_distance"].(float64); ok {
+		if v < 1 {
+			return mcp.NewToolResultError("max_distance must be at least 1"), nil
+		}
+		maxDistance = int(v)
+	}
+	apply, _ := request.Params.Arguments["apply"].(bool)
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	response, err := s.SuggestTagMerges(maxDistance, apply)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error suggesting tag merges: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleEstimateSessionTime handles the estimate_session_time tool request by estimating
+// how many minutes it takes to clear the due cards.
+func handleEstimateSessionTime(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	filterTags := stringSliceArg(request, "tags")
+	secondsPerCard := 0.0
+	if v, ok := request.Params.Arguments["seconds_per_card"].(float64); ok {
+		if v <= 0 {
+			return mcp.NewToolResultError("seconds_per_card must be positive"), nil
+		}
+		secondsPerCard = v
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	estimate, err := s.EstimateSessionTime(filterTags, secondsPerCard)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error estimating session time: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(estimate, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleSetProfileInfo handles the set_profile_info tool request by updating the
+// student's display name and grade level.
+func handleSetProfileInfo(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var namePtr, gradeLevelPtr *string
+	if name, ok := request.Params.Arguments["name"].(string); ok {
+		namePtr = &name
+	}
+	if gradeLevel, ok := request.Params.Arguments["grade_level"].(string); ok {
+		gradeLevelPtr = &gradeLevel
+	}
+	if namePtr == nil && gradeLevelPtr == nil {
+		return mcp.NewToolResultError("No profile fields provided. Please provide at least one of 'name' or 'grade_level'."), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	profile, err := s.SetProfileInfo(namePtr, gradeLevelPtr)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error setting profile info: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleGetProfileInfo handles the get_profile_info tool request.
+func handleGetProfileInfo(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	profile, err := s.GetProfileInfo()
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error getting profile info: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(profile, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleExportCards handles the export_cards tool request by returning the cards as
+// JSON, or as CSV when 'format' is "csv".
+func handleExportCards(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	filterTags := stringSliceArg(request, "tags")
+	includeSuspended, _ := request.Params.Arguments["include_suspended"].(bool)
+	includeArchived, _ := request.Params.Arguments["include_archived"].(bool)
+	includeScheduling, _ := request.Params.Arguments["include_scheduling"].(bool)
+	format, _ := request.Params.Arguments["format"].(string)
+	if format != "" && format != "json" && format != "csv" {
+		return mcp.NewToolResultError("Invalid format: must be 'json' or 'csv'"), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	export, err := s.ExportCards(filterTags, includeSuspended, includeArchived)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error exporting cards: %v"}`, err)), nil
+	}
+
+	if format == "csv" {
+		var csvText strings.Builder
+		if err := WriteCardsCSV(&csvText, export.Cards, includeScheduling); err != nil {
+			return nil, err
+		}
+		return mcp.NewToolResultText(csvText.String()), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleGenerateReverseCards handles the generate_reverse_cards tool request by
+// creating back-to-front copies of the selected cards.
+func handleGenerateReverseCards(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	cardIDs := stringSliceArg(request, "card_ids")
+	filterTags := stringSliceArg(request, "tags")
+	if len(cardIDs) == 0 && len(filterTags) == 0 {
+		return mcp.NewToolResultError("Provide 'card_ids' or 'tags' to select the cards to reverse"), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	response, err := s.GenerateReverseCards(cardIDs, filterTags)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error generating reverse cards: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleExportFull handles the export_full tool request by serializing the entire store.
+func handleExportFull(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	export, err := s.ExportFull()
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error exporting store: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleImportFull handles the import_full tool request by replacing the entire
+// store with the output of a previous export_full.
+func handleImportFull(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	data, ok := request.Params.Arguments["data"].(string)
+	if !ok || data == "" {
+		return mcp.NewToolResultError("Missing required parameter: data"), nil
+	}
+	var export FullExport
+	if err := json.Unmarshal([]byte(data), &export); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid export data: %v", err)), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	if err := s.ImportFull(export); err != nil {
+		if errors.Is(err, ErrSchemaVersionMismatch) {
+			return mcp.NewToolResultError(fmt.Sprintf("Refusing import: %v", err)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error importing store: %v"}`, err)), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf(`{"message": "Imported %d cards and %d reviews"}`,
+		len(export.Store.Cards), len(export.Store.Reviews))), nil
+}
+
+// handleRestoreBackup handles the restore_backup tool request. Without a backup
+// name it lists the available backups; with one it restores that backup.
+func handleRestoreBackup(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	name, _ := request.Params.Arguments["backup"].(string)
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	response := RestoreBackupResponse{}
+	if name != "" {
+		if err := s.RestoreBackup(name); err != nil {
+			if errors.Is(err, ErrBackupsUnsupported) || errors.Is(err, storage.ErrBackupNotFound) {
+				return mcp.NewToolResultError(fmt.Sprintf("Cannot restore backup: %v", err)), nil
+			}
+			return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error restoring backup: %v"}`, err)), nil
+		}
+		response.Restored = name
+	}
+
+	backups, err := s.ListBackups()
+	if err != nil {
+		if errors.Is(err, ErrBackupsUnsupported) {
+			return mcp.NewToolResultError(fmt.Sprintf("Cannot list backups: %v", err)), nil
+		}
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error listing backups: %v"}`, err)), nil
+	}
+	response.Backups = backups
+	if name != "" {
+		response.Message = fmt.Sprintf("Restored %s; the data it replaced was backed up first", name)
+	} else {
+		response.Message = fmt.Sprintf("%d backups available; pass one as 'backup' to restore it", len(backups))
+	}
+
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleDueDateReadiness handles the due_date_readiness tool request by scoring how
+// ready the student is for a due date.
+func handleDueDateReadiness(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	dueDateID, ok := request.Params.Arguments["due_date_id"].(string)
+	if !ok || dueDateID == "" {
+		return mcp.NewToolResultError("Missing required parameter: due_date_id"), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	readiness, err := s.DueDateReadiness(dueDateID)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error computing readiness: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(readiness, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleBurstSchedule handles the burst_schedule tool request by bringing forward a
+// due date's unmastered cards.
+func handleBurstSchedule(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	dueDateID, ok := request.Params.Arguments["due_date_id"].(string)
+	if !ok || dueDateID == "" {
+		return mcp.NewToolResultError("Missing required parameter: due_date_id"), nil
+	}
+	spread, _ := request.Params.Arguments["spread"].(bool)
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	response, err := s.BurstSchedule(dueDateID, spread)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error scheduling review burst: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleReloadStorage handles the reload_storage tool request by re-reading the
+// data file into memory.
+func handleReloadStorage(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	response, err := s.ReloadStorage()
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error reloading storage: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleClearSession handles the clear_session tool request by dropping the queued
+// re-shows of cards that lapsed this session.
+func handleClearSession(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(ClearSessionResponse{Cleared: s.ClearSession()}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleSuggestDueDate handles the suggest_due_date tool request by recommending a
+// test date for a tag from the study pace.
+func handleSuggestDueDate(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	tag, ok := request.Params.Arguments["tag"].(string)
+	if !ok || tag == "" {
+		return mcp.NewToolResultError("Missing required parameter: tag"), nil
+	}
+	cardsPerDay, ok := request.Params.Arguments["cards_per_day"].(float64)
+	if !ok {
+		return mcp.NewToolResultError("Missing required parameter: cards_per_day"), nil
+	}
+	if cardsPerDay <= 0 {
+		return mcp.NewToolResultError("cards_per_day must be positive"), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	suggestion, err := s.SuggestDueDate(tag, cardsPerDay)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error suggesting due date: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(suggestion, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleDueDateCoverage handles the due_date_coverage tool request by listing the
+// cards of a reference tag that a due date's tag is missing.
+func handleDueDateCoverage(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	dueDateID, ok := request.Params.Arguments["due_date_id"].(string)
+	if !ok || dueDateID == "" {
+		return mcp.NewToolResultError("Missing required parameter: due_date_id"), nil
+	}
+	referenceTag, ok := request.Params.Arguments["reference_tag"].(string)
+	if !ok || referenceTag == "" {
+		return mcp.NewToolResultError("Missing required parameter: reference_tag"), nil
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	coverage, err := s.DueDateCoverage(dueDateID, referenceTag)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error computing due date coverage: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(coverage, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handlePractice handles the practice tool request by returning a tag's cards in
+// random order for a practice round.
+func handlePractice(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	tag, ok := request.Params.Arguments["tag"].(string)
+	if !ok || tag == "" {
+		return mcp.NewToolResultError("Missing required parameter: tag"), nil
+	}
+	limit := 0
+	if v, ok := request.Params.Arguments["limit"].(float64); ok {
+		if v < 1 {
+			return mcp.NewToolResultError("limit must be at least 1"), nil
+		}
+		limit = int(v)
+	}
+	seed := time.Now().UnixNano()
+	if v, ok := request.Params.Arguments["seed"].(float64); ok {
+		seed = int64(v)
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	cards, err := s.PracticeCards(tag, limit, seed)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error getting practice cards: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(PracticeResponse{Tag: tag, Cards: cards}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleTagStreaks handles the tag_streaks tool request by reporting the longest
+// run of consecutive study days for each tag.
+func handleTagStreaks(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	streaks, err := s.TagStreaks()
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error computing tag streaks: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(TagStreaksResponse{Tags: streaks}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleTagCloud handles the tag_cloud tool request by weighting tags by card count
+// and due urgency.
+func handleTagCloud(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	entries, err := s.TagCloud()
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error building tag cloud: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(TagCloudResponse{Tags: entries}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleRegressionAlerts handles the regression_alerts tool request by listing cards
+// that lapsed after a long interval.
+func handleRegressionAlerts(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	minIntervalDays := float64(DefaultRegressionIntervalDays)
+	if value, ok := request.Params.Arguments["min_interval_days"].(float64); ok {
+		if value < 0 {
+			return mcp.NewToolResultError("min_interval_days must not be negative"), nil
+		}
+		minIntervalDays = value
+	}
+	filterTags := stringSliceArg(request, "tags")
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	alerts, err := s.RegressionAlerts(minIntervalDays, filterTags)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error finding regressions: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(RegressionAlertsResponse{Cards: alerts}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleIntervalAdherence handles the interval_adherence tool request by comparing
+// scheduled intervals with the actual time between reviews.
+func handleIntervalAdherence(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	filterTags := stringSliceArg(request, "tags")
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	adherence, err := s.IntervalAdherence(filterTags)
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error computing interval adherence: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(adherence, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleReservedTagConflicts handles the reserved_tag_conflicts tool request by listing
+// cards whose "test-" tags don't belong to any due date.
+func handleReservedTagConflicts(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	conflicts, err := s.ReservedTagConflicts()
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error auditing reserved tags: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(ReservedTagConflictsResponse{Conflicts: conflicts}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
+
+// handleSessionAnalytics handles the session_analytics tool request by grouping
+// reviews into sessions and reporting their average length and size.
+func handleSessionAnalytics(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	idleMinutes := DefaultSessionIdleMinutes
+	if v, ok := request.Params.Arguments["idle_minutes"].(float64); ok {
+		if v <= 0 {
+			return mcp.NewToolResultError("idle_minutes must be positive"), nil
+		}
+		idleMinutes = v
+	}
+
+	// Get the service from context
+	s, ok := serviceFromContext(ctx)
+	if !ok {
+		return serviceUnavailableResult(), nil
+	}
+
+	analytics, err := s.SessionAnalytics(time.Duration(idleMinutes * float64(time.Minute)))
+	if err != nil {
+		return mcp.NewToolResultText(fmt.Sprintf(`{"error": "Error computing session analytics: %v"}`, err)), nil
+	}
+
+	jsonBytes, err := json.MarshalIndent(analytics, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return mcp.NewToolResultText(string(jsonBytes)), nil
+}
@@ -0,0 +1,775 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/danieldreier/mcp-flashcards/internal/storage"
+	"github.com/mark3labs/mcp-go/mcp"
+	gofsrs "github.com/open-spaced-repetition/go-fsrs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toolHandler is the signature shared by all tool handlers in handlers.go
+type toolHandler func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error)
+
+// callHandlerDirectly invokes a tool handler in-process and returns its text output.
+func callHandlerDirectly(t *testing.T, ctx context.Context, handler toolHandler, args map[string]interface{}) (string, *mcp.CallToolResult) {
+	t.Helper()
+	request := mcp.CallToolRequest{}
+	request.Params.Arguments = args
+	result, err := handler(ctx, request)
+	require.NoError(t, err, "Handler should not return a Go error")
+	require.NotNil(t, result, "Handler should return a result")
+	require.NotEmpty(t, result.Content, "Handler result should have content")
+	text, ok := result.Content[0].(mcp.TextContent)
+	require.True(t, ok, "Expected TextContent, got %T", result.Content[0])
+	return text.Text, result
+}
+
+// TestGetHint tests that get_hint reveals the hint but not the answer,
+// and that get_due_card does not leak the hint
+func TestGetHint(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+
+	text, _ := callHandlerDirectly(t, ctx, handleCreateCard, map[string]interface{}{
+		"front": "What is the powerhouse of the cell?",
+		"back":  "Mitochondria",
+		"hint":  "It starts with 'M'",
+	})
+	var created CreateCardResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &created))
+	assert.Equal(t, "It starts with 'M'", created.Card.Hint)
+
+	// get_due_card presents the card without the hint
+	text, _ = callHandlerDirectly(t, ctx, handleGetDueCard, map[string]interface{}{})
+	var due CardResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &due))
+	assert.Equal(t, created.Card.ID, due.Card.ID)
+	assert.Empty(t, due.Card.Hint, "get_due_card should not reveal the hint")
+	assert.NotContains(t, text, "It starts with")
+
+	// get_hint returns the hint without the answer
+	text, _ = callHandlerDirectly(t, ctx, handleGetHint, map[string]interface{}{"card_id": created.Card.ID})
+	var hint HintResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &hint))
+	assert.Equal(t, "It starts with 'M'", hint.Hint)
+	assert.Equal(t, created.Card.Front, hint.Front)
+	assert.NotContains(t, text, "Mitochondria", "get_hint must not reveal the answer")
+
+	// Cards without a hint report an error
+	plain := createCardDirectly(t, service, "Plain Q", "Plain A", nil)
+	text, _ = callHandlerDirectly(t, ctx, handleGetHint, map[string]interface{}{"card_id": plain.ID})
+	assert.Contains(t, text, "has no hint")
+}
+
+// TestCardFieldAliases tests that create_card and update_card accept question/answer
+// as synonyms for front/back and can emit those field names
+func TestCardFieldAliases(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+
+	text, _ := callHandlerDirectly(t, ctx, handleCreateCard, map[string]interface{}{
+		"question": "What is 2+2?",
+		"answer":   "4",
+	})
+	var created CreateCardResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &created))
+
+	stored, err := service.Storage.GetCard(created.Card.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "What is 2+2?", stored.Front)
+	assert.Equal(t, "4", stored.Back)
+
+	// update_card maps answer onto back
+	callHandlerDirectly(t, ctx, handleUpdateCard, map[string]interface{}{
+		"card_id": created.Card.ID,
+		"answer":  "Four",
+	})
+	stored, err = service.Storage.GetCard(created.Card.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "What is 2+2?", stored.Front)
+	assert.Equal(t, "Four", stored.Back)
+
+	// The question_answer output mode renames the card fields
+	text, _ = callHandlerDirectly(t, ctx, handleCreateCard, map[string]interface{}{
+		"front":       "Capital of France?",
+		"back":        "Paris",
+		"field_names": "question_answer",
+	})
+	var generic map[string]map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(text), &generic))
+	assert.Equal(t, "Capital of France?", generic["card"]["question"])
+	assert.Equal(t, "Paris", generic["card"]["answer"])
+	assert.NotContains(t, generic["card"], "front")
+	assert.NotContains(t, generic["card"], "back")
+}
+
+// TestRescheduleCard tests that reschedule_card moves only the due date, and that
+// exactly one of due and due_in_days is accepted
+func TestRescheduleCard(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+	now := time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	card := createCardDirectly(t, service, "Q", "A", nil)
+	card.FSRS.State = gofsrs.Review
+	card.FSRS.Stability = 12.5
+	card.FSRS.Difficulty = 4.2
+	card.FSRS.Due = now.AddDate(0, 0, 10)
+	updateCardDirectly(t, service, card)
+
+	reschedule := func(args map[string]interface{}) (RescheduleCardResponse, *mcp.CallToolResult) {
+		t.Helper()
+		args["card_id"] = card.ID
+		text, result := callHandlerDirectly(t, ctx, handleRescheduleCard, args)
+		var response RescheduleCardResponse
+		if !result.IsError {
+			require.NoError(t, json.Unmarshal([]byte(text), &response), text)
+		}
+		return response, result
+	}
+
+	response, result := reschedule(map[string]interface{}{"due_in_days": 1.5})
+	require.False(t, result.IsError)
+	assert.True(t, response.PreviousDue.Equal(now.AddDate(0, 0, 10)))
+	assert.True(t, response.Due.Equal(now.Add(36*time.Hour)), "due %v", response.Due)
+	stored, err := service.Storage.GetCard(card.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.FSRS.Due.Equal(now.Add(36*time.Hour)))
+	assert.Equal(t, gofsrs.Review, stored.FSRS.State)
+	assert.Equal(t, 12.5, stored.FSRS.Stability, "Stability is kept")
+	assert.Equal(t, 4.2, stored.FSRS.Difficulty, "Difficulty is kept")
+
+	// An absolute time may be in the past, which makes the card overdue
+	response, result = reschedule(map[string]interface{}{"due": "2026-05-03T09:00:00Z"})
+	require.False(t, result.IsError)
+	assert.True(t, response.Due.Equal(now.Add(-24*time.Hour)))
+	_, _, err = service.GetDueCard(nil)
+	require.NoError(t, err, "The rescheduled card is due")
+
+	for name, args := range map[string]map[string]interface{}{
+		"neither":  {},
+		"both":     {"due": "2026-05-05T09:00:00Z", "due_in_days": float64(1)},
+		"format":   {"due": "2026-05-05"},
+		"negative": {"due_in_days": float64(-1)},
+	} {
+		_, result := reschedule(args)
+		assert.True(t, result.IsError, name)
+	}
+
+	text, result := callHandlerDirectly(t, ctx, handleRescheduleCard, map[string]interface{}{"card_id": "missing", "due_in_days": float64(1)})
+	assert.False(t, result.IsError)
+	assert.Contains(t, text, "Error rescheduling card")
+}
+
+// TestMixedDirection tests that mixed_direction assigns directions deterministically for a
+// fixed seed and that submit_review records the direction a card was answered in
+func TestMixedDirection(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+
+	var cardIDs []string
+	for i := 0; i < 20; i++ {
+		cardIDs = append(cardIDs, createCardDirectly(t, service, fmt.Sprintf("Front %d", i), fmt.Sprintf("Back %d", i), nil).ID)
+	}
+
+	// The same seed always yields the same assignment, and it mixes both directions
+	counts := map[string]int{}
+	for _, id := range cardIDs {
+		first := chooseDirection(42, id, 0)
+		assert.Equal(t, first, chooseDirection(42, id, 0), "Direction must be deterministic for a fixed seed")
+		counts[first]++
+	}
+	assert.Positive(t, counts[DirectionForward])
+	assert.Positive(t, counts[DirectionReverse])
+
+	text, _ := callHandlerDirectly(t, ctx, handleGetDueCard, map[string]interface{}{
+		"mixed_direction": true,
+		"seed":            float64(42),
+	})
+	var due CardResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &due))
+	expected := chooseDirection(42, due.Card.ID, 0)
+	assert.Equal(t, expected, due.Direction)
+
+	stored, err := service.Storage.GetCard(due.Card.ID)
+	require.NoError(t, err)
+	if expected == DirectionReverse {
+		assert.Equal(t, stored.Back, due.Card.Front, "Reverse cards are asked back-to-front")
+	} else {
+		assert.Equal(t, stored.Front, due.Card.Front)
+	}
+
+	// The review records the direction, and the pending direction is cleared
+	_, err = service.SubmitReview(due.Card.ID, gofsrs.Good, "")
+	require.NoError(t, err)
+	reviews, err := service.Storage.GetCardReviews(due.Card.ID)
+	require.NoError(t, err)
+	require.Len(t, reviews, 1)
+	assert.Equal(t, expected, reviews[0].Direction)
+	stored, err = service.Storage.GetCard(due.Card.ID)
+	require.NoError(t, err)
+	assert.Empty(t, stored.Direction)
+}
+
+// TestGetDueCardMarkdown tests that markdown rendering shows the question but hides the answer
+func TestGetDueCardMarkdown(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+
+	card := createCardDirectly(t, service, "What is the capital of France?", "Paris", []string{"geography"})
+	hint := "Think of the Eiffel Tower"
+	_, err := service.UpdateCard(card.ID, nil, nil, nil, &hint)
+	require.NoError(t, err)
+
+	text, result := callHandlerDirectly(t, ctx, handleGetDueCard, map[string]interface{}{
+		"render": "markdown",
+	})
+	require.False(t, result.IsError, text)
+	assert.Contains(t, text, "## Question")
+	assert.Contains(t, text, "What is the capital of France?")
+	assert.Contains(t, text, "Answer hidden")
+	assert.Contains(t, text, card.ID, "The card ID is needed for submit_review")
+	assert.NotContains(t, text, "Paris", "Markdown mode must not reveal the back")
+	assert.NotContains(t, text, "Eiffel", "Markdown mode must not reveal the hint")
+
+	// The default is still JSON
+	text, _ = callHandlerDirectly(t, ctx, handleGetDueCard, map[string]interface{}{})
+	var due CardResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &due))
+	assert.Equal(t, card.ID, due.Card.ID)
+
+	_, result = callHandlerDirectly(t, ctx, handleGetDueCard, map[string]interface{}{"render": "html"})
+	assert.True(t, result.IsError)
+}
+
+// TestGetDueCardIncludeSiblings tests that sibling fronts are returned without their backs
+func TestGetDueCardIncludeSiblings(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+
+	due := createCardDirectly(t, service, "What is H2O?", "Water", []string{"chemistry", "molecules"})
+	sibling := createCardDirectly(t, service, "What is CO2?", "Carbon dioxide", []string{"chemistry", "molecules", "gases"})
+	createCardDirectly(t, service, "What is Fe?", "Iron", []string{"chemistry"}) // Missing the "molecules" tag
+	setDueDateDirectly(t, service, sibling.ID, time.Now().AddDate(0, 0, 3))
+	setDueDateDirectly(t, service, due.ID, time.Now().Add(-time.Hour))
+
+	text, result := callHandlerDirectly(t, ctx, handleGetDueCard, map[string]interface{}{
+		"tags":             []interface{}{"molecules"},
+		"include_siblings": true,
+	})
+	require.False(t, result.IsError, text)
+	var response CardResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &response))
+	assert.Equal(t, due.ID, response.Card.ID)
+	assert.Equal(t, []string{"What is CO2?"}, response.Siblings)
+	assert.NotContains(t, text, "Carbon dioxide", "Sibling backs must not be revealed")
+	assert.NotContains(t, text, "What is Fe?", "Cards missing one of the tags are not siblings")
+
+	// Siblings are omitted unless requested
+	text, _ = callHandlerDirectly(t, ctx, handleGetDueCard, map[string]interface{}{"tags": []interface{}{"molecules"}})
+	assert.NotContains(t, text, "What is CO2?")
+}
+
+// TestReservedTagValidation tests warnings, rejection and auditing of unbacked "test-" tags
+func TestReservedTagValidation(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+
+	require.NoError(t, service.AddDueDate(storage.DueDate{
+		ID:      "bio",
+		Topic:   "Biology",
+		DueDate: time.Now().AddDate(0, 0, 7),
+		Tag:     "test-biology-20240715",
+	}))
+
+	// A spurious "test-" tag is accepted with a warning
+	text, result := callHandlerDirectly(t, ctx, handleCreateCard, map[string]interface{}{
+		"front": "Q1",
+		"back":  "A1",
+		"tags":  []interface{}{"test-bogus", "biology"},
+	})
+	require.False(t, result.IsError, text)
+	var created CreateCardResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &created))
+	require.Len(t, created.Warnings, 1)
+	assert.Contains(t, created.Warnings[0], "test-bogus")
+
+	// A tag backed by a due date produces no warning
+	text, _ = callHandlerDirectly(t, ctx, handleCreateCard, map[string]interface{}{
+		"front": "Q2",
+		"back":  "A2",
+		"tags":  []interface{}{"test-biology-20240715"},
+	})
+	var backed CreateCardResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &backed))
+	assert.Empty(t, backed.Warnings)
+
+	conflicts, err := service.ReservedTagConflicts()
+	require.NoError(t, err)
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, created.Card.ID, conflicts[0].CardID)
+	assert.Equal(t, []string{"test-bogus"}, conflicts[0].Tags)
+
+	// In reject mode the update is refused and the card keeps its tags
+	service.RejectReservedTags = true
+	_, result = callHandlerDirectly(t, ctx, handleUpdateCard, map[string]interface{}{
+		"card_id": backed.Card.ID,
+		"tags":    []interface{}{"test-typo"},
+	})
+	assert.True(t, result.IsError)
+	stored, err := service.Storage.GetCard(backed.Card.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test-biology-20240715"}, stored.Tags)
+}
+
+// TestListCardsUntaggedOnly tests that no filter, a tag filter and untagged_only are distinct
+func TestListCardsUntaggedOnly(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+
+	tagged := createCardDirectly(t, service, "Tagged", "A", []string{"math"})
+	untagged := createCardDirectly(t, service, "Untagged", "A", nil)
+	emptyTags := createCardDirectly(t, service, "Empty tags", "A", []string{})
+
+	listIDs := func(args map[string]interface{}) []string {
+		t.Helper()
+		text, result := callHandlerDirectly(t, ctx, handleListCards, args)
+		require.False(t, result.IsError, text)
+		var response ListCardsResponse
+		require.NoError(t, json.Unmarshal([]byte(text), &response))
+		var ids []string
+		for _, card := range response.Cards {
+			ids = append(ids, card.ID)
+		}
+		return ids
+	}
+
+	// An empty tags array is no filter at all
+	assert.ElementsMatch(t, []string{tagged.ID, untagged.ID, emptyTags.ID}, listIDs(map[string]interface{}{}))
+	assert.ElementsMatch(t, []string{tagged.ID, untagged.ID, emptyTags.ID}, listIDs(map[string]interface{}{"tags": []interface{}{}}))
+	assert.ElementsMatch(t, []string{tagged.ID}, listIDs(map[string]interface{}{"tags": []interface{}{"math"}}))
+	assert.ElementsMatch(t, []string{untagged.ID, emptyTags.ID}, listIDs(map[string]interface{}{"untagged_only": true}))
+
+	_, result := callHandlerDirectly(t, ctx, handleListCards, map[string]interface{}{
+		"tags":          []interface{}{"math"},
+		"untagged_only": true,
+	})
+	assert.True(t, result.IsError, "untagged_only with tags is contradictory")
+}
+
+// TestTagMatch tests tag_match "all" and "any" on list_cards and get_due_card
+func TestTagMatch(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+
+	both := createCardDirectly(t, service, "Both", "A", []string{"math", "physics"})
+	mathOnly := createCardDirectly(t, service, "Math", "A", []string{"math"})
+	createCardDirectly(t, service, "Other", "A", []string{"history"})
+
+	listCards := func(args map[string]interface{}) ListCardsResponse {
+		t.Helper()
+		text, result := callHandlerDirectly(t, ctx, handleListCards, args)
+		require.False(t, result.IsError, text)
+		var response ListCardsResponse
+		require.NoError(t, json.Unmarshal([]byte(text), &response))
+		return response
+	}
+	ids := func(cards []Card) []string {
+		var result []string
+		for _, card := range cards {
+			result = append(result, card.ID)
+		}
+		return result
+	}
+
+	tags := []interface{}{"math", "physics"}
+	assert.ElementsMatch(t, []string{both.ID}, ids(listCards(map[string]interface{}{"tags": tags}).Cards))
+	assert.ElementsMatch(t, []string{both.ID}, ids(listCards(map[string]interface{}{"tags": tags, "tag_match": "all"}).Cards))
+	anyResponse := listCards(map[string]interface{}{"tags": tags, "tag_match": "any", "include_stats": true})
+	assert.ElementsMatch(t, []string{both.ID, mathOnly.ID}, ids(anyResponse.Cards))
+	assert.Equal(t, 3, anyResponse.Stats.TotalCards, "stats cover every card regardless of the filter")
+
+	// No card carries both history and geography, so only "any" finds one
+	text, _ := callHandlerDirectly(t, ctx, handleGetDueCard, map[string]interface{}{
+		"tags": []interface{}{"history", "geography"},
+	})
+	assert.Contains(t, text, "No cards found with the specified tags")
+	text, result := callHandlerDirectly(t, ctx, handleGetDueCard, map[string]interface{}{
+		"tags":      []interface{}{"history", "geography"},
+		"tag_match": "any",
+	})
+	require.False(t, result.IsError, text)
+	var due CardResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &due))
+	assert.Equal(t, "Other", due.Card.Front)
+	assert.Equal(t, 3, due.Stats.TotalCards)
+
+	_, result = callHandlerDirectly(t, ctx, handleListCards, map[string]interface{}{"tag_match": "some"})
+	assert.True(t, result.IsError, "unknown tag_match should be rejected")
+}
+
+// TestServiceUnavailable tests that a context without the service yields a coded error result
+func TestServiceUnavailable(t *testing.T) {
+	ctx := context.Background()
+	for name, handler := range map[string]toolHandler{
+		"get_due_card": handleGetDueCard,
+		"create_card":  handleCreateCard,
+		"list_cards":   handleListCards,
+	} {
+		text, result := callHandlerDirectly(t, ctx, handler, map[string]interface{}{
+			"front": "Q",
+			"back":  "A",
+		})
+		assert.True(t, result.IsError, "%s should flag a missing service as an error", name)
+		var body struct {
+			Error struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(text), &body), text)
+		assert.Equal(t, ServiceUnavailableCode, body.Error.Code, name)
+	}
+
+	_, err := handleTagsResource(ctx, mcp.ReadResourceRequest{})
+	assert.ErrorIs(t, err, ErrServiceUnavailable)
+}
+
+// TestGetCard tests fetching one card by ID, with and without its review history
+func TestGetCard(t *testing.T) {
+	service, _ := setupTestService(t)
+	card := createCardDirectly(t, service, "Q", "A", []string{"math"})
+	createCardDirectly(t, service, "Other", "B", nil)
+	_, err := service.SubmitReviewWithTime(card.ID, gofsrs.Good, "A", time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	_, err = service.SubmitReviewWithTime(card.ID, gofsrs.Easy, "A", time.Now())
+	require.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), "service", service)
+	text, result := callHandlerDirectly(t, ctx, handleGetCard, map[string]interface{}{"card_id": card.ID})
+	require.False(t, result.IsError, text)
+	var response CardResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &response), text)
+	assert.Equal(t, card.ID, response.Card.ID)
+	assert.Equal(t, "Q", response.Card.Front)
+	assert.Equal(t, 2, response.Stats.TotalCards, "Stats cover the whole collection")
+	assert.Empty(t, response.Reviews)
+
+	text, _ = callHandlerDirectly(t, ctx, handleGetCard, map[string]interface{}{"card_id": card.ID, "include_reviews": true})
+	response = CardResponse{}
+	require.NoError(t, json.Unmarshal([]byte(text), &response), text)
+	require.Len(t, response.Reviews, 2)
+	assert.Equal(t, gofsrs.Good, response.Reviews[0].Rating, "Reviews are oldest first")
+	assert.Equal(t, gofsrs.Easy, response.Reviews[1].Rating)
+
+	text, result = callHandlerDirectly(t, ctx, handleGetCard, map[string]interface{}{"card_id": "missing"})
+	assert.False(t, result.IsError)
+	var errResponse map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(text), &errResponse), text)
+	assert.Contains(t, errResponse["error"], "card not found")
+
+	_, result = callHandlerDirectly(t, ctx, handleGetCard, map[string]interface{}{})
+	assert.True(t, result.IsError)
+}
+
+// TestUndoReview tests that undo_review restores the schedule from before the last review
+func TestUndoReview(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+
+	created := createCardDirectly(t, service, "Q", "A", nil)
+	start := time.Now().Add(-48 * time.Hour)
+	afterFirst, err := service.SubmitReviewWithTime(created.ID, gofsrs.Good, "", start)
+	require.NoError(t, err)
+	_, err = service.SubmitReviewWithTime(created.ID, gofsrs.Again, "", start.Add(24*time.Hour))
+	require.NoError(t, err)
+
+	undo := func() ReviewResponse {
+		t.Helper()
+		text, result := callHandlerDirectly(t, ctx, handleUndoReview, map[string]interface{}{"card_id": created.ID})
+		require.False(t, result.IsError, text)
+		var response ReviewResponse
+		require.NoError(t, json.Unmarshal([]byte(text), &response), text)
+		require.True(t, response.Success, text)
+		return response
+	}
+
+	// Undoing the Again review restores the schedule set by the Good review
+	restored := undo().Card
+	assert.True(t, afterFirst.FSRS.Due.Equal(restored.FSRS.Due), "due %v, want %v", restored.FSRS.Due, afterFirst.FSRS.Due)
+	assert.Equal(t, afterFirst.FSRS.State, restored.FSRS.State)
+	assert.Equal(t, afterFirst.FSRS.Reps, restored.FSRS.Reps)
+	reviews, err := service.Storage.GetCardReviews(created.ID)
+	require.NoError(t, err)
+	require.Len(t, reviews, 1)
+	assert.Equal(t, gofsrs.Good, reviews[0].Rating)
+
+	// Undoing the only review returns the card to New
+	restored = undo().Card
+	assert.Equal(t, gofsrs.New, restored.FSRS.State)
+	assert.Zero(t, restored.FSRS.Reps)
+
+	// Nothing left to undo
+	text, result := callHandlerDirectly(t, ctx, handleUndoReview, map[string]interface{}{"card_id": created.ID})
+	assert.False(t, result.IsError)
+	assert.Contains(t, text, "card has no reviews")
+
+	_, result = callHandlerDirectly(t, ctx, handleUndoReview, map[string]interface{}{})
+	assert.True(t, result.IsError)
+}
+
+// TestResetCard tests that reset_card returns a card to New, keeping or deleting its reviews
+func TestResetCard(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+	now := time.Now().Truncate(time.Second)
+	defer mockTimeNow(now)()
+
+	reviewed := func(front string) storage.Card {
+		t.Helper()
+		card := createCardDirectly(t, service, front, "A", nil)
+		_, err := service.SubmitReviewWithTime(card.ID, gofsrs.Good, "", now.Add(-48*time.Hour))
+		require.NoError(t, err)
+		_, err = service.SubmitReviewWithTime(card.ID, gofsrs.Good, "", now.Add(-24*time.Hour))
+		require.NoError(t, err)
+		return card
+	}
+	reset := func(args map[string]interface{}) Card {
+		t.Helper()
+		text, result := callHandlerDirectly(t, ctx, handleResetCard, args)
+		require.False(t, result.IsError, text)
+		var response ReviewResponse
+		require.NoError(t, json.Unmarshal([]byte(text), &response), text)
+		require.True(t, response.Success, text)
+		return response.Card
+	}
+
+	// By default the history is kept
+	kept := reviewed("Kept")
+	card := reset(map[string]interface{}{"card_id": kept.ID})
+	assert.Equal(t, gofsrs.New, card.FSRS.State)
+	assert.True(t, card.FSRS.Due.Equal(now), "due %v, want now", card.FSRS.Due)
+	assert.Zero(t, card.FSRS.Reps)
+	assert.Zero(t, card.FSRS.Stability)
+	reviews, err := service.Storage.GetCardReviews(kept.ID)
+	require.NoError(t, err)
+	assert.Len(t, reviews, 2)
+	stored, err := service.Storage.GetCard(kept.ID)
+	require.NoError(t, err)
+	assert.Equal(t, gofsrs.New, stored.FSRS.State, "The reset is persisted")
+	assert.True(t, stored.LastReviewedAt.IsZero())
+
+	// keep_history=false deletes only this card's reviews
+	deleted := reviewed("Deleted")
+	card = reset(map[string]interface{}{"card_id": deleted.ID, "keep_history": false})
+	assert.Equal(t, gofsrs.New, card.FSRS.State)
+	reviews, err = service.Storage.GetCardReviews(deleted.ID)
+	require.NoError(t, err)
+	assert.Empty(t, reviews)
+	reviews, err = service.Storage.GetCardReviews(kept.ID)
+	require.NoError(t, err)
+	assert.Len(t, reviews, 2)
+
+	// Reviews from before the reset no longer shape the schedule: review, undo, and the
+	// card is back in its reset state; repairing it replays only the later review
+	_, err = service.SubmitReviewWithTime(kept.ID, gofsrs.Again, "", now.Add(time.Hour))
+	require.NoError(t, err)
+	undone, err := service.UndoLastReview(kept.ID)
+	require.NoError(t, err)
+	assert.Equal(t, gofsrs.New, undone.FSRS.State)
+	assert.True(t, undone.FSRS.Due.Equal(now), "due %v, want the reset time", undone.FSRS.Due)
+	assert.Zero(t, undone.FSRS.Reps)
+
+	afterReset, err := service.SubmitReviewWithTime(kept.ID, gofsrs.Good, "", now.Add(time.Hour))
+	require.NoError(t, err)
+	stored, err = service.Storage.GetCard(kept.ID)
+	require.NoError(t, err)
+	stored.FSRS.Due = time.Time{}
+	updateCardDirectly(t, service, stored)
+	_, err = service.RepairDueDates(false)
+	require.NoError(t, err)
+	stored, err = service.Storage.GetCard(kept.ID)
+	require.NoError(t, err)
+	assert.True(t, afterReset.FSRS.Due.Equal(stored.FSRS.Due), "Repair replays only the review since the reset")
+	assert.Equal(t, uint64(1), stored.FSRS.Reps)
+
+	text, result := callHandlerDirectly(t, ctx, handleResetCard, map[string]interface{}{"card_id": "missing"})
+	assert.False(t, result.IsError)
+	assert.Contains(t, text, "card not found")
+
+	_, result = callHandlerDirectly(t, ctx, handleResetCard, map[string]interface{}{})
+	assert.True(t, result.IsError)
+	_, result = callHandlerDirectly(t, ctx, handleResetCard, map[string]interface{}{"card_id": kept.ID, "keep_history": "no"})
+	assert.True(t, result.IsError)
+}
+
+// writeCountingStorage counts the storage calls that write, to check batching
+type writeCountingStorage struct {
+	storage.Storage
+	writes int
+}
+
+func (w *writeCountingStorage) CreateCard(front, back string, tags []string) (storage.Card, error) {
+	w.writes++
+	return w.Storage.CreateCard(front, back, tags)
+}
+
+func (w *writeCountingStorage) CreateCards(inputs []storage.CardInput) ([]storage.Card, error) {
+	w.writes++
+	return w.Storage.CreateCards(inputs)
+}
+
+func (w *writeCountingStorage) UpdateCards(cards []storage.Card) error {
+	w.writes++
+	return w.Storage.UpdateCards(cards)
+}
+
+func (w *writeCountingStorage) DeleteCards(ids []string) error {
+	w.writes++
+	return w.Storage.DeleteCards(ids)
+}
+
+func (w *writeCountingStorage) UpdateCard(card storage.Card) error {
+	w.writes++
+	return w.Storage.UpdateCard(card)
+}
+
+func (w *writeCountingStorage) AddReviewDirect(review storage.Review) error {
+	w.writes++
+	return w.Storage.AddReviewDirect(review)
+}
+
+func (w *writeCountingStorage) RecordReviews(cards []storage.Card, reviews []storage.Review) error {
+	w.writes++
+	return w.Storage.RecordReviews(cards, reviews)
+}
+
+func (w *writeCountingStorage) Save() error {
+	w.writes++
+	return w.Storage.Save()
+}
+
+// TestBulkReview tests that bulk_review matches one-by-one reviews with a single write
+func TestBulkReview(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+	now := time.Now().Truncate(time.Second)
+	defer mockTimeNow(now)()
+
+	first := createCardDirectly(t, service, "First", "A", nil)
+	second := createCardDirectly(t, service, "Second", "A", nil)
+	twin := createCardDirectly(t, service, "Twin", "A", nil)
+
+	// The same reviews submitted one by one, for comparison
+	start := now.Add(-48 * time.Hour)
+	_, err := service.SubmitReviewWithTime(twin.ID, gofsrs.Good, "", start)
+	require.NoError(t, err)
+	want, err := service.SubmitReviewWithTime(twin.ID, gofsrs.Again, "", start.Add(24*time.Hour))
+	require.NoError(t, err)
+
+	counting := &writeCountingStorage{Storage: service.Storage}
+	service.Storage = counting
+	response, err := service.SubmitReviews([]ReviewInput{
+		{CardID: first.ID, Rating: gofsrs.Good, Timestamp: start},
+		{CardID: "missing", Rating: gofsrs.Good},
+		{CardID: first.ID, Rating: gofsrs.Again, Timestamp: start.Add(24 * time.Hour)},
+		{CardID: second.ID, Rating: gofsrs.Easy, Answer: "A"},
+		{CardID: second.ID, Rating: 5},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, counting.writes, "The batch is stored with one write")
+	assert.Equal(t, 3, response.Reviewed)
+	assert.Equal(t, 2, response.Failed)
+	require.Len(t, response.Results, 5)
+	assert.Contains(t, response.Results[1].Error, "card not found")
+	assert.Contains(t, response.Results[4].Error, "rating must be between 1 and 4")
+
+	// Reviews of the same card build on each other within the batch
+	stored, err := service.Storage.GetCard(first.ID)
+	require.NoError(t, err)
+	assert.True(t, want.FSRS.Due.Equal(stored.FSRS.Due), "due %v, want %v", stored.FSRS.Due, want.FSRS.Due)
+	assert.Equal(t, want.FSRS.Reps, stored.FSRS.Reps)
+	assert.Equal(t, want.FSRS.Lapses, stored.FSRS.Lapses)
+	require.NotNil(t, response.Results[2].Due)
+	assert.True(t, stored.FSRS.Due.Equal(*response.Results[2].Due))
+	assert.Equal(t, stateNames[want.FSRS.State], response.Results[2].State)
+	reviews, err := service.Storage.GetCardReviews(first.ID)
+	require.NoError(t, err)
+	assert.Len(t, reviews, 2)
+
+	// A review without a timestamp happens now
+	reviews, err = service.Storage.GetCardReviews(second.ID)
+	require.NoError(t, err)
+	require.Len(t, reviews, 1)
+	assert.True(t, reviews[0].Timestamp.Equal(now))
+	assert.Equal(t, "A", reviews[0].Answer)
+
+	// Through the handler, malformed entries are reported by index
+	text, result := callHandlerDirectly(t, ctx, handleBulkReview, map[string]interface{}{
+		"reviews": []interface{}{
+			map[string]interface{}{"card_id": second.ID, "rating": 3.0, "timestamp": now.Add(time.Hour).Format(time.RFC3339)},
+			"not a review",
+			map[string]interface{}{"card_id": second.ID, "rating": 3.0, "timestamp": "yesterday"},
+		},
+	})
+	require.False(t, result.IsError, text)
+	var handled BulkReviewResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &handled), text)
+	assert.Equal(t, 1, handled.Reviewed)
+	assert.True(t, handled.Results[0].Success)
+	assert.Equal(t, "review must be an object", handled.Results[1].Error)
+	assert.Contains(t, handled.Results[2].Error, "Invalid timestamp format")
+
+	_, result = callHandlerDirectly(t, ctx, handleBulkReview, map[string]interface{}{"reviews": []interface{}{}})
+	assert.True(t, result.IsError)
+}
+
+// TestBulkOperationsWriteOnce tests that bulk card changes go through a single batch
+// write (plus the service's save) however many cards they touch
+func TestBulkOperationsWriteOnce(t *testing.T) {
+	service, _ := setupTestService(t)
+	require.NoError(t, service.AddDueDate(storage.DueDate{ID: "unit", Topic: "Unit", DueDate: time.Now().AddDate(0, 0, 7), Tag: "test-unit"}))
+	for i := 0; i < 4; i++ {
+		card := createCardDirectly(t, service, fmt.Sprintf("Q%d", i), "A", []string{"test-unit"})
+		card.FSRS.State = gofsrs.Review
+		card.FSRS.Difficulty = 8.5
+		card.FSRS.Due = time.Time{}
+		updateCardDirectly(t, service, card)
+	}
+
+	counting := &writeCountingStorage{Storage: service.Storage}
+	service.Storage = counting
+	counted := func(name string, writes int, operation func() error) {
+		t.Helper()
+		counting.writes = 0
+		require.NoError(t, operation(), name)
+		assert.Equal(t, writes, counting.writes, "%s should write the batch once, plus any save it needs", name)
+	}
+	counted("autotag", 1, func() error {
+		result, err := service.AutotagByDifficulty(nil)
+		assert.Equal(t, 4, result.UpdatedCards)
+		return err
+	})
+	counted("repair", 1, func() error {
+		repairs, err := service.RepairDueDates(false)
+		assert.Len(t, repairs, 4)
+		return err
+	})
+	counted("reverse", 2, func() error {
+		response, err := service.GenerateReverseCards(nil, []string{"test-unit"})
+		assert.Equal(t, 4, response.Created)
+		return err
+	})
+	counted("delete", 2, func() error {
+		deleted, err := service.DeleteDueDateWithCards("unit")
+		assert.Equal(t, 8, deleted)
+		return err
+	})
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danieldreier/mcp-flashcards/internal/importer"
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	gofsrs "github.com/open-spaced-repetition/go-fsrs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingSession is a client session that buffers the notifications sent to it.
+// The in-process transport does not deliver server notifications itself, so the
+// test attaches this session to the request context.
+type recordingSession struct {
+	notifications chan mcp.JSONRPCNotification
+}
+
+func (r *recordingSession) Initialize()                                         {}
+func (r *recordingSession) Initialized() bool                                   { return true }
+func (r *recordingSession) NotificationChannel() chan<- mcp.JSONRPCNotification { return r.notifications }
+func (r *recordingSession) SessionID() string                                   { return "import-test" }
+
+// TestImportCardsProgress tests that a large import reports progress per chunk when given a progress token
+func TestImportCardsProgress(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	srv := server.NewMCPServer("flashcards-test", "1.0.0", server.WithToolCapabilities(true))
+	srv.AddTool(mcp.NewTool("import_cards"), func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleImportCards(context.WithValue(reqCtx, "service", service), request)
+	})
+
+	session := &recordingSession{notifications: make(chan mcp.JSONRPCNotification, 100)}
+	ctx := srv.WithContext(context.Background(), session)
+
+	c, err := client.NewInProcessClient(srv)
+	require.NoError(t, err)
+	defer c.Close()
+	initRequest := mcp.InitializeRequest{}
+	initRequest.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	initRequest.Params.ClientInfo = mcp.Implementation{Name: "import-test-client", Version: "1.0.0"}
+	_, err = c.Initialize(ctx, initRequest)
+	require.NoError(t, err)
+
+	total := 3*importChunkSize + 5
+	cards := make([]interface{}, total)
+	for i := range cards {
+		cards[i] = map[string]interface{}{
+			"front": fmt.Sprintf("Question %d", i),
+			"back":  fmt.Sprintf("Answer %d", i),
+			"tags":  []interface{}{"imported"},
+		}
+	}
+
+	request := mcp.CallToolRequest{}
+	request.Params.Name = "import_cards"
+	request.Params.Arguments = map[string]interface{}{"cards": cards}
+	request.Params.Meta = &struct {
+		ProgressToken mcp.ProgressToken `json:"progressToken,omitempty"`
+	}{ProgressToken: "import-1"}
+
+	result, err := c.CallTool(ctx, request)
+	require.NoError(t, err)
+	require.False(t, result.IsError)
+	var response ImportCardsResponse
+	require.NoError(t, json.Unmarshal([]byte(result.Content[0].(mcp.TextContent).Text), &response))
+	assert.Equal(t, total, response.Imported)
+
+	stored, err := service.Storage.ListCards([]string{"imported"})
+	require.NoError(t, err)
+	assert.Len(t, stored, total)
+
+	// One notification per chunk, ending at the total
+	close(session.notifications)
+	var progress []int
+	for notification := range session.notifications {
+		assert.Equal(t, "notifications/progress", notification.Method)
+		fields := notification.Params.AdditionalFields
+		assert.Equal(t, "import-1", fields["progressToken"])
+		assert.EqualValues(t, total, fields["total"])
+		progress = append(progress, fields["progress"].(int))
+	}
+	assert.Equal(t, []int{importChunkSize, 2 * importChunkSize, 3 * importChunkSize, total}, progress)
+}
+
+// TestImportAnki tests that Anki notes become New cards tagged with their deck
+func TestImportAnki(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+
+	response, err := service.importAnkiPackage(importer.AnkiPackage{
+		Notes: []importer.AnkiNote{
+			{Front: "Powerhouse of the cell?", Back: "Mitochondria", Deck: "Biology::Cells"},
+			{Front: "Capital of France?", Back: "Paris", Deck: "World Capitals"},
+			{Front: "Largest organelle?", Back: "Nucleus", Deck: "Biology::Cells"},
+		},
+		Unsupported: 2,
+		Blank:       1,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, response.Imported)
+	assert.Equal(t, 2, response.Skipped)
+	assert.Equal(t, 1, response.SkippedBlank)
+	assert.Equal(t, []string{"biology::cells", "world-capitals"}, response.Decks)
+
+	cells, err := service.Storage.ListCards([]string{"biology::cells"})
+	require.NoError(t, err)
+	require.Len(t, cells, 2)
+	for _, card := range cells {
+		assert.Equal(t, gofsrs.New, card.FSRS.State)
+	}
+
+	_, result := callHandlerDirectly(t, ctx, handleImportAnki, map[string]interface{}{})
+	assert.True(t, result.IsError)
+	text, _ := callHandlerDirectly(t, ctx, handleImportAnki, map[string]interface{}{"path": filepath.Join(t.TempDir(), "missing.apkg")})
+	assert.Contains(t, text, "Error importing Anki package")
+}
+
+// TestCreateCards tests that create_cards creates the valid cards in order and reports the rest by index
+func TestCreateCards(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+	service.MaxTagsPerCard = 2
+	service.NewCardDelay = 30 * time.Minute
+
+	text, result := callHandlerDirectly(t, ctx, handleCreateCards, map[string]interface{}{
+		"cards": []interface{}{
+			map[string]interface{}{"front": "Q1", "back": "A1", "tags": []interface{}{"quiz"}},
+			map[string]interface{}{"front": "Q2", "back": " "},
+			"not a card",
+			map[string]interface{}{"front": "Q4", "back": "A4", "tags": []interface{}{"a", "b", "c"}},
+			map[string]interface{}{"front": "Q5", "back": "A5"},
+		},
+	})
+	require.False(t, result.IsError, text)
+	var response CreateCardsResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &response), text)
+
+	assert.Equal(t, 2, response.Created)
+	require.Len(t, response.CardIDs, 5)
+	for _, i := range []int{1, 2, 3} {
+		assert.Empty(t, response.CardIDs[i], "Card %d failed validation", i)
+	}
+	require.Len(t, response.Errors, 3)
+	assert.Equal(t, CreateCardError{Index: 1, Error: "front and back are required"}, response.Errors[0])
+	assert.Equal(t, CreateCardError{Index: 2, Error: "card must be an object"}, response.Errors[1])
+	assert.Equal(t, 3, response.Errors[2].Index)
+	assert.Contains(t, response.Errors[2].Error, "too many tags")
+
+	first, err := service.Storage.GetCard(response.CardIDs[0])
+	require.NoError(t, err)
+	assert.Equal(t, "Q1", first.Front)
+	assert.Equal(t, []string{"quiz"}, first.Tags)
+	assert.Equal(t, first.CreatedAt.Add(30*time.Minute), first.FSRS.Due, "The new card delay applies")
+	last, err := service.Storage.GetCard(response.CardIDs[4])
+	require.NoError(t, err)
+	assert.Equal(t, "Q5", last.Front)
+
+	cards, err := service.Storage.ListCards(nil)
+	require.NoError(t, err)
+	assert.Len(t, cards, 2)
+
+	_, result = callHandlerDirectly(t, ctx, handleCreateCards, map[string]interface{}{"cards": []interface{}{}})
+	assert.True(t, result.IsError)
+	_, result = callHandlerDirectly(t, ctx, handleCreateCards, map[string]interface{}{})
+	assert.True(t, result.IsError)
+}
+
+// TestImportCardsNewCardDelay tests that an imported New card is created already
+// delayed, in a single write, while a card with a schedule keeps its due time
+func TestImportCardsNewCardDelay(t *testing.T) {
+	service, _ := setupTestService(t)
+	service.NewCardDelay = 30 * time.Minute
+	scheduledDue := time.Date(2024, 3, 5, 9, 0, 0, 0, time.UTC)
+
+	counting := &writeCountingStorage{Storage: service.Storage}
+	service.Storage = counting
+	response, err := service.ImportCards([]ImportCard{
+		{Front: "Fresh", Back: "A", Hint: "F"},
+		{Front: "Kept", Back: "A", Schedule: &ImportSchedule{State: gofsrs.Review, Due: scheduledDue, Stability: 10, Difficulty: 5}},
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, 2, response.Imported)
+	assert.Equal(t, 2, counting.writes, "One batch create and the final save")
+
+	fresh, err := service.Storage.GetCard(response.CardIDs[0])
+	require.NoError(t, err)
+	assert.Equal(t, "F", fresh.Hint)
+	assert.Equal(t, fresh.CreatedAt.Add(30*time.Minute), fresh.FSRS.Due)
+	kept, err := service.Storage.GetCard(response.CardIDs[1])
+	require.NoError(t, err)
+	assert.True(t, scheduledDue.Equal(kept.FSRS.Due), "The delay doesn't apply to a restored schedule")
+}
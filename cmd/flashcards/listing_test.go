@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	gofsrs "github.com/open-spaced-repetition/go-fsrs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestListCardsByDue tests that cards are returned in ascending due order
+func TestListCardsByDue(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	later := createCardDirectly(t, service, "Later", "A", []string{"math"})
+	setDueDateDirectly(t, service, later.ID, now.AddDate(0, 0, 5))
+	overdue := createCardDirectly(t, service, "Overdue", "A", []string{"math"})
+	setDueDateDirectly(t, service, overdue.ID, now.AddDate(0, 0, -2))
+	soon := createCardDirectly(t, service, "Soon", "A", []string{"science"})
+	setDueDateDirectly(t, service, soon.ID, now.AddDate(0, 0, 1))
+
+	entries, err := service.ListCardsByDue(nil)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, overdue.ID, entries[0].Card.ID)
+	assert.Equal(t, soon.ID, entries[1].Card.ID)
+	assert.Equal(t, later.ID, entries[2].Card.ID)
+	for i := 1; i < len(entries); i++ {
+		assert.False(t, entries[i].Due.Before(entries[i-1].Due), "Entries must be in ascending due order")
+	}
+	assert.InDelta(t, -2.0, entries[0].DaysUntilDue, 0.001)
+	assert.InDelta(t, 5.0, entries[2].DaysUntilDue, 0.001)
+
+	// Tag filter
+	entries, err = service.ListCardsByDue([]string{"math"})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, overdue.ID, entries[0].Card.ID)
+	assert.Equal(t, later.ID, entries[1].Card.ID)
+}
+
+// TestListLongIntervalCards tests that only cards due beyond the threshold are listed
+func TestListLongIntervalCards(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	normal := createCardDirectly(t, service, "Normal", "A", nil)
+	setDueDateDirectly(t, service, normal.ID, now.AddDate(0, 0, 10))
+	farOut := createCardDirectly(t, service, "Far out", "A", nil)
+	setDueDateDirectly(t, service, farOut.ID, now.AddDate(2, 0, 0))
+	distant := createCardDirectly(t, service, "Distant", "A", nil)
+	setDueDateDirectly(t, service, distant.ID, now.AddDate(0, 0, 200))
+
+	entries, err := service.ListLongIntervalCards(DefaultLongIntervalDays, nil)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, farOut.ID, entries[0].Card.ID, "Farthest-due card should come first")
+	assert.Equal(t, distant.ID, entries[1].Card.ID)
+
+	entries, err = service.ListLongIntervalCards(365, nil)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, farOut.ID, entries[0].Card.ID)
+}
+
+// TestListCardsByReviewCount tests that cards are ordered by descending review count
+func TestListCardsByReviewCount(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Now()
+
+	once := createCardDirectly(t, service, "Once", "A", []string{"math"})
+	addReviewDirectly(t, service, once.ID, gofsrs.Good, now)
+	never := createCardDirectly(t, service, "Never", "A", []string{"science"})
+	often := createCardDirectly(t, service, "Often", "A", []string{"math"})
+	for i := 0; i < 3; i++ {
+		addReviewDirectly(t, service, often.ID, gofsrs.Again, now.Add(time.Duration(i)*time.Minute))
+	}
+
+	entries, err := service.ListCardsByReviewCount(nil)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, often.ID, entries[0].Card.ID)
+	assert.Equal(t, 3, entries[0].ReviewCount)
+	assert.Equal(t, once.ID, entries[1].Card.ID)
+	assert.Equal(t, 1, entries[1].ReviewCount)
+	assert.Equal(t, never.ID, entries[2].Card.ID)
+	assert.Equal(t, 0, entries[2].ReviewCount)
+
+	// Tag filter
+	entries, err = service.ListCardsByReviewCount([]string{"math"})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, often.ID, entries[0].Card.ID)
+}
+
+// TestFlagCard tests that flagged cards are listed with their reason and still schedule normally
+func TestFlagCard(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	confusing := createCardDirectly(t, service, "Confusing", "A", nil)
+	createCardDirectly(t, service, "Fine", "A", nil)
+
+	_, err := service.FlagCard(confusing.ID, true, "answer seems wrong")
+	require.NoError(t, err)
+
+	flagged, err := service.ListFlaggedCards()
+	require.NoError(t, err)
+	require.Len(t, flagged, 1)
+	assert.Equal(t, confusing.ID, flagged[0].Card.ID)
+	assert.Equal(t, "answer seems wrong", flagged[0].Reason)
+	assert.False(t, flagged[0].FlaggedAt.IsZero())
+
+	// Flagging doesn't change the schedule
+	stored, err := service.Storage.GetCard(confusing.ID)
+	require.NoError(t, err)
+	assert.True(t, confusing.FSRS.Due.Equal(stored.FSRS.Due))
+
+	_, err = service.FlagCard(confusing.ID, false, "")
+	require.NoError(t, err)
+	flagged, err = service.ListFlaggedCards()
+	require.NoError(t, err)
+	assert.Empty(t, flagged)
+}
+
+// TestSuspendCard tests that suspended cards keep their history but are left out of review, stats and due-date progress
+func TestSuspendCard(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+
+	later := createCardDirectly(t, service, "Not covered yet", "A", []string{"biology"})
+	_, err := service.SubmitReview(later.ID, gofsrs.Easy, "A")
+	require.NoError(t, err)
+	setDueDateDirectly(t, service, later.ID, time.Now().Add(-time.Hour))
+	current := createCardDirectly(t, service, "Covered", "A", []string{"biology"})
+
+	text, result := callHandlerDirectly(t, ctx, handleSuspendCard, map[string]interface{}{"card_id": later.ID})
+	require.False(t, result.IsError, text)
+	assert.Contains(t, text, "suspended")
+
+	card, stats, err := service.GetDueCard(nil)
+	require.NoError(t, err)
+	assert.Equal(t, current.ID, card.ID, "Suspended cards are never served")
+	assert.Equal(t, 1, stats.DueCards)
+	assert.Equal(t, 1, stats.SuspendedCards)
+
+	reviews, err := service.Storage.GetCardReviews(later.ID)
+	require.NoError(t, err)
+	assert.Len(t, reviews, 1, "Suspending keeps the review history")
+
+	// Only the unsuspended card counts toward due-date progress, and it isn't mastered
+	progress, err := service.GetDueDateProgressStats("biology")
+	require.NoError(t, err)
+	assert.Equal(t, 1, progress.TotalCards)
+	assert.Equal(t, 0, progress.MasteredCards)
+
+	// list_cards marks suspended cards unless asked to leave them out
+	listed := func(args map[string]interface{}) []Card {
+		text, result := callHandlerDirectly(t, ctx, handleListCards, args)
+		require.False(t, result.IsError, text)
+		var response ListCardsResponse
+		require.NoError(t, json.Unmarshal([]byte(text), &response))
+		return response.Cards
+	}
+	cards := listed(map[string]interface{}{})
+	require.Len(t, cards, 2)
+	for _, card := range cards {
+		assert.Equal(t, card.ID == later.ID, card.Suspended)
+	}
+	cards = listed(map[string]interface{}{"exclude_suspended": true})
+	require.Len(t, cards, 1)
+	assert.Equal(t, current.ID, cards[0].ID)
+
+	text, result = callHandlerDirectly(t, ctx, handleUnsuspendCard, map[string]interface{}{"card_id": later.ID})
+	require.False(t, result.IsError, text)
+	card, _, err = service.GetDueCard(nil)
+	require.NoError(t, err)
+	assert.Equal(t, later.ID, card.ID, "The unsuspended card is overdue again")
+
+	_, result = callHandlerDirectly(t, ctx, handleSuspendCard, map[string]interface{}{})
+	assert.True(t, result.IsError)
+	text, _ = callHandlerDirectly(t, ctx, handleSuspendCard, map[string]interface{}{"card_id": "missing"})
+	assert.Contains(t, text, "Error updating card")
+}
+
+// TestSearchByAnswer tests that only the back is matched, case-insensitively
+func TestSearchByAnswer(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	capital := createCardDirectly(t, service, "Capital of France?", "Paris", []string{"geography"})
+	city := createCardDirectly(t, service, "City of Light?", "paris, France", []string{"history"})
+	createCardDirectly(t, service, "What is Paris known for?", "The Eiffel Tower", []string{"geography"})
+	createCardDirectly(t, service, "Capital of Italy?", "Rome", []string{"geography"})
+
+	cards, err := service.SearchByAnswer("PARIS", nil)
+	require.NoError(t, err)
+	require.Len(t, cards, 2, "Cards mentioning Paris only on the front must not match")
+	assert.Equal(t, capital.ID, cards[0].ID)
+	assert.Equal(t, city.ID, cards[1].ID)
+
+	cards, err = service.SearchByAnswer("paris", []string{"geography"})
+	require.NoError(t, err)
+	require.Len(t, cards, 1)
+	assert.Equal(t, capital.ID, cards[0].ID)
+
+	_, err = service.SearchByAnswer("  ", nil)
+	assert.Error(t, err)
+}
+
+// TestSearchCards tests that fronts and backs are searched, front matches ranking first
+func TestSearchCards(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+
+	backOnly := createCardDirectly(t, service, "Where do plants make food?", "Photosynthesis happens in chloroplasts; photosynthesis needs light", []string{"biology"})
+	frontOnce := createCardDirectly(t, service, "What is photosynthesis?", "Making sugar from light", []string{"biology"})
+	frontTwice := createCardDirectly(t, service, "Photosynthesis equation for PHOTOSYNTHESIS?", "6CO2 + 6H2O -> C6H12O6 + 6O2", []string{"chemistry"})
+	createCardDirectly(t, service, "What is respiration?", "Releasing energy", []string{"biology"})
+
+	text, result := callHandlerDirectly(t, ctx, handleSearchCards, map[string]interface{}{"query": "photosynthesis"})
+	require.False(t, result.IsError, text)
+	var response SearchCardsResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &response), text)
+	require.Len(t, response.Cards, 3)
+	assert.Equal(t, frontTwice.ID, response.Cards[0].Card.ID)
+	assert.Equal(t, 2, response.Cards[0].MatchCount)
+	assert.Equal(t, frontOnce.ID, response.Cards[1].Card.ID)
+	assert.Equal(t, backOnly.ID, response.Cards[2].Card.ID, "Back matches rank below front matches, even with more of them")
+	assert.Equal(t, 2, response.Cards[2].MatchCount)
+
+	cards, err := service.SearchCards("photosynthesis", []string{"biology"})
+	require.NoError(t, err)
+	assert.Len(t, cards, 2)
+
+	// No match is an empty list, not an error
+	text, result = callHandlerDirectly(t, ctx, handleSearchCards, map[string]interface{}{"query": "mitochondria"})
+	require.False(t, result.IsError, text)
+	response = SearchCardsResponse{}
+	require.NoError(t, json.Unmarshal([]byte(text), &response), text)
+	assert.NotNil(t, response.Cards)
+	assert.Empty(t, response.Cards)
+
+	_, result = callHandlerDirectly(t, ctx, handleSearchCards, map[string]interface{}{})
+	assert.True(t, result.IsError)
+}
+
+// TestListCardsPagination tests limit and offset on list_cards, applied after filtering
+func TestListCardsPagination(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+
+	// Created in reverse so the listing order comes from CreatedAt, not insertion
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	ids := make([]string, 5)
+	for i := len(ids) - 1; i >= 0; i-- {
+		card := createCardDirectly(t, service, fmt.Sprintf("Card %d", i), "A", []string{"math"})
+		card.CreatedAt = start.Add(time.Duration(i) * time.Hour)
+		updateCardDirectly(t, service, card)
+		ids[i] = card.ID
+	}
+	createCardDirectly(t, service, "Other", "A", []string{"history"})
+
+	list := func(args map[string]interface{}) ListCardsResponse {
+		t.Helper()
+		args["tags"] = []interface{}{"math"}
+		text, result := callHandlerDirectly(t, ctx, handleListCards, args)
+		require.False(t, result.IsError, text)
+		var response ListCardsResponse
+		require.NoError(t, json.Unmarshal([]byte(text), &response), text)
+		return response
+	}
+	pageIDs := func(response ListCardsResponse) []string {
+		result := []string{}
+		for _, card := range response.Cards {
+			result = append(result, card.ID)
+		}
+		return result
+	}
+
+	response := list(map[string]interface{}{"limit": 2.0})
+	assert.Equal(t, ids[:2], pageIDs(response))
+	assert.Equal(t, 5, response.TotalCount, "total_count counts the filtered cards, not the collection")
+	assert.True(t, response.HasMore)
+
+	response = list(map[string]interface{}{"limit": 2.0, "offset": 4.0})
+	assert.Equal(t, ids[4:], pageIDs(response))
+	assert.False(t, response.HasMore)
+
+	response = list(map[string]interface{}{"offset": 10.0})
+	assert.NotNil(t, response.Cards)
+	assert.Empty(t, response.Cards)
+	assert.Equal(t, 5, response.TotalCount)
+
+	response = list(map[string]interface{}{"limit": 0.0})
+	assert.Equal(t, ids, pageIDs(response), "limit 0 lists every card")
+	assert.False(t, response.HasMore)
+
+	for _, args := range []map[string]interface{}{{"limit": -1.0}, {"limit": 2.5}, {"offset": -3.0}} {
+		_, result := callHandlerDirectly(t, ctx, handleListCards, args)
+		assert.True(t, result.IsError, "%v should be rejected", args)
+	}
+}
+
+// TestPageCards tests the default page size and tie-breaking on ID
+func TestPageCards(t *testing.T) {
+	created := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	var cards []Card
+	for i := DefaultListCardsLimit + 9; i >= 0; i-- {
+		cards = append(cards, Card{ID: fmt.Sprintf("card-%03d", i), CreatedAt: created})
+	}
+
+	page, hasMore := PageCards(cards, DefaultListCardsLimit, 0)
+	require.Len(t, page, DefaultListCardsLimit)
+	assert.True(t, hasMore)
+	assert.Equal(t, "card-000", page[0].ID, "Cards created together are ordered by ID")
+	assert.Equal(t, "card-059", cards[0].ID, "The input is left unsorted")
+
+	page, hasMore = PageCards(cards, DefaultListCardsLimit, DefaultListCardsLimit)
+	assert.Len(t, page, 10)
+	assert.False(t, hasMore)
+}
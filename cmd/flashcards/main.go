@@ -1,12 +1,17 @@
 package main
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
 
+	"github.com/danieldreier/mcp-flashcards/internal/fsrs"
 	"github.com/danieldreier/mcp-flashcards/internal/storage"
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -51,7 +56,7 @@ When using this server, always follow this precise educational workflow:
    - Keep the energy high with enthusiastic language and emojis 🔥 ✨ 🎉
 
 6. COMPLETION PHASE:
-   - When out of cards, congratulate student on a great study session
+   - When out of cards (or the daily review limit is reached), congratulate student on a great study session
    - Use extra enthusiastic celebration language and emojis 🎊 🎓 🥳
    - Propose brainstorming new cards together
    - When creating new cards, analyze what the student struggled with most
@@ -64,8 +69,9 @@ MANAGING DUE DATES:
 - You can manage test due dates using the 'manage_due_dates' tool.
 - To create: Specify action='create', topic='Your Topic Name', date='YYYY-MM-DD'. A tag will be generated.
 - To update: Specify action='update', due_date_id='...', and optionally new topic, date, or tag.
-- To delete: Specify action='delete', due_date_id='...'.
+- To delete: Specify action='delete', due_date_id='...'. Add with_cards=true to also delete the unit's cards.
 - To list: Specify action='list'.
+- To move a card between tests, use 'reassign_card_due_date' with the card and both due date IDs.
 - Use the 'due-date-progress' resource to see current due dates, tags, and progress.
 
 STUDYING FOR A TEST:
@@ -75,15 +81,82 @@ STUDYING FOR A TEST:
 - After each 'submit_review', check the 'due-date-progress' resource again (or calculate based on the list result and the review) and inform the user of their updated progress towards the goal (e.g., "Great! You've now mastered X out of Y cards for the biology test (Z% complete). Let's keep going! 💪").
 `
 
+// parseFSRSParamsFlag reads the -fsrs-params value, which is either inline JSON or
+// the path of a JSON file.
+func parseFSRSParamsFlag(value string) (*storage.FSRSParams, error) {
+	data := []byte(value)
+	if !strings.HasPrefix(strings.TrimSpace(value), "{") {
+		var err error
+		if data, err = os.ReadFile(value); err != nil {
+			return nil, err
+		}
+	}
+	var params storage.FSRSParams
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields() // Catch misspelled parameter names
+	if err := decoder.Decode(&params); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	return &params, nil
+}
+
 func main() {
 	// Parse command-line flags
 	filePath := flag.String("file", "./flashcards.json", "Path to flashcard data file")
+	storageBackend := flag.String("storage", "", "Storage backend: 'json' or 'sqlite' (default: 'sqlite' for .db/.sqlite files, otherwise 'json')")
+	maxOverdueFactor := flag.Float64("max-overdue-factor", fsrs.DefaultMaxOverdueFactor, "Cap on the overdue priority multiplier (values < 1 disable the cap)")
+	maxReviewsPerDay := flag.Int("max-reviews-per-day", 0, "Maximum number of reviews served per day (0 = unlimited)")
+	newCardsPerDay := flag.Int("new-cards-per-day", DefaultNewCardsPerDay, "Maximum number of new cards introduced per day (0 = unlimited)")
+	autoTagRecall := flag.Bool("auto-tag-recall", false, "Tag cards 'struggling' or 'solid' based on their recent ratings")
+	autoSuspendLeeches := flag.Bool("auto-suspend-leeches", false, "Suspend cards as soon as they become leeches")
+	leechThreshold := flag.Int("leech-threshold", DefaultLeechThreshold, "Number of lapses after which a card is marked a leech")
+	saveAttempts := flag.Int("save-attempts", storage.DefaultSaveAttempts, "Number of times to try writing the data file before reporting a save error")
+	backupDir := flag.String("backup-dir", "", "Directory for daily backups; the first save of each day writes a dated copy of the data file there")
+	backups := flag.Int("backups", storage.DefaultBackups, "Number of <file>.bak.<timestamp> copies to keep of the data file from before deletes and bulk changes (0 = none)")
+	quarantine := flag.Bool("quarantine", false, "If the data file is partially corrupted, load what can be salvaged in read-only mode instead of exiting")
+	fsrsParamsFlag := flag.String("fsrs-params", "", "FSRS parameters as inline JSON or the path of a JSON file, e.g. '{\"request_retention\": 0.85, \"maximum_interval\": 365}'")
+	requestRetention := flag.Float64("request-retention", 0, "Target recall probability at each card's due date, between 0 and 1 (default 0.9)")
 	flag.Parse()
 
-	// Initialize storage
-	fileStorage := storage.NewFileStorage(*filePath)
-	if err := fileStorage.Load(); err != nil {
-		fmt.Printf("Error loading storage: %v\n", err)
+	// Initialize storage; the backend follows -storage, or else the -file extension
+	backend := *storageBackend
+	if backend == "" {
+		backend = storage.BackendForPath(*filePath)
+	}
+	var flashcardStorage storage.Storage
+	switch backend {
+	case storage.BackendJSON:
+		fileStorage := storage.NewFileStorage(*filePath)
+		fileStorage.SetSaveRetry(*saveAttempts, storage.DefaultSaveRetryDelay)
+		fileStorage.SetBackupDir(*backupDir)
+		fileStorage.SetBackups(*backups)
+		loadStorage := fileStorage.Load
+		if *quarantine {
+			loadStorage = fileStorage.LoadQuarantined
+		}
+		if err := loadStorage(); err != nil {
+			fmt.Printf("Error loading storage: %v\n", err)
+			os.Exit(1)
+		}
+		if fileStorage.Quarantined() {
+			log.Printf("Warning: %s is corrupted; serving salvaged data in read-only quarantine mode", *filePath)
+		}
+		flashcardStorage = fileStorage
+	case storage.BackendSQLite:
+		backupsSet := false
+		flag.Visit(func(f *flag.Flag) { backupsSet = backupsSet || f.Name == "backups" })
+		if *backupDir != "" || *quarantine || backupsSet {
+			log.Printf("Warning: -backup-dir, -backups and -quarantine only apply to JSON storage and are ignored for %s", *filePath)
+		}
+		sqliteStorage, err := storage.NewSQLiteStorage(*filePath)
+		if err != nil {
+			fmt.Printf("Error loading storage: %v\n", err)
+			os.Exit(1)
+		}
+		defer sqliteStorage.Close()
+		flashcardStorage = sqliteStorage
+	default:
+		fmt.Printf("Unknown storage backend %q: must be '%s' or '%s'\n", backend, storage.BackendJSON, storage.BackendSQLite)
 		os.Exit(1)
 	}
 
@@ -98,7 +171,60 @@ func main() {
 	)
 
 	// Initialize the flashcard service
-	flashcardService := NewFlashcardService(fileStorage)
+	flashcardService := NewFlashcardService(flashcardStorage)
+	flashcardService.DirectionSeed = time.Now().UnixNano() // New mixed_direction shuffle each session
+
+	// Apply the persisted config; flags given on the command line override it for this
+	// run, including after the config is changed with configure
+	var fsrsOverride *storage.FSRSParams
+	if *fsrsParamsFlag != "" {
+		params, err := parseFSRSParamsFlag(*fsrsParamsFlag)
+		if err != nil {
+			fmt.Printf("Error reading -fsrs-params: %v\n", err)
+			os.Exit(1)
+		}
+		fsrsOverride = params
+	}
+	flashcardService.ConfigOverrides = func(config *storage.Config) {
+		flag.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "max-overdue-factor":
+				config.MaxOverdueFactor = maxOverdueFactor
+			case "max-reviews-per-day":
+				config.MaxReviewsPerDay = *maxReviewsPerDay
+			case "new-cards-per-day":
+				config.NewCardsPerDay = newCardsPerDay
+			case "auto-tag-recall":
+				config.AutoTagRecall = *autoTagRecall
+			case "auto-suspend-leeches":
+				config.AutoSuspendLeeches = *autoSuspendLeeches
+			case "leech-threshold":
+				config.LeechThreshold = *leechThreshold
+			}
+		})
+		if fsrsOverride != nil {
+			params := *fsrsOverride
+			config.FSRS = &params
+		}
+		if *requestRetention != 0 {
+			params := storage.FSRSParams{}
+			if config.FSRS != nil {
+				params = *config.FSRS
+			}
+			params.RequestRetention = *requestRetention
+			config.FSRS = &params
+		}
+	}
+	config, err := flashcardStorage.GetConfig()
+	if err != nil {
+		fmt.Printf("Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := fsrsParameters(flashcardService.effectiveConfig(config).FSRS); err != nil {
+		fmt.Printf("Invalid FSRS parameters: %v\n", err)
+		os.Exit(1)
+	}
+	flashcardService.ApplyConfig(config)
 
 	// Create context with the service for tool handlers
 	ctx := context.WithValue(context.Background(), "service", flashcardService)
@@ -121,6 +247,24 @@ func main() {
 		mcp.WithArray("tags",
 			mcp.Description("Optional list of tags to filter due cards by. Card must have ALL specified tags."),
 		),
+		mcp.WithString("tag_match",
+			mcp.Description("How tags are matched: 'all' (default) requires every tag, 'any' requires at least one"),
+		),
+		mcp.WithString("state",
+			mcp.Description("Only serve cards in this FSRS state: 'new', 'learning', 'review', 'relearning', or 'any' (default). Use 'new' to drill only brand-new cards, 'review' for only reviews."),
+		),
+		mcp.WithBoolean("mixed_direction",
+			mcp.Description("Mixed practice: randomly ask some cards back-to-front. When the response has direction 'reverse', front and back are already swapped, so still show only the front."),
+		),
+		mcp.WithNumber("seed",
+			mcp.Description("Optional seed for the mixed_direction choice (defaults to a per-session seed)"),
+		),
+		mcp.WithString("render",
+			mcp.Description("Response format: 'json' (default) or 'markdown' for clients that render Markdown. Markdown output shows only the question and a card ID for submit_review."),
+		),
+		mcp.WithBoolean("include_siblings",
+			mcp.Description(fmt.Sprintf("If true, also return the fronts (never the backs) of up to %d other cards sharing all of this card's tags, as context about related material", MaxSiblingFronts)),
+		),
 	)
 
 	// Define the submit_review tool
@@ -154,6 +298,12 @@ func main() {
 		mcp.WithString("answer",
 			mcp.Description("The answer provided by the user"),
 		),
+		mcp.WithBoolean("practice",
+			mcp.Description("If true, record the review as practice: it is logged, but the card's schedule and mastery are unaffected. Use for cards from the practice tool."),
+		),
+		mcp.WithNumber("duration_ms",
+			mcp.Description("Optional time the student took to answer, in milliseconds"),
+		),
 	)
 
 	// Define the create_card tool
@@ -178,16 +328,26 @@ func main() {
 		),
 		// Define parameters
 		mcp.WithString("front",
-			mcp.Required(),
-			mcp.Description("The front text of the card"),
+			mcp.Description("The front text of the card (required unless 'question' is given)"),
 		),
 		mcp.WithString("back",
-			mcp.Required(),
-			mcp.Description("The back text of the card"),
+			mcp.Description("The back text of the card (required unless 'answer' is given)"),
+		),
+		mcp.WithString("question",
+			mcp.Description("Synonym for 'front'"),
+		),
+		mcp.WithString("answer",
+			mcp.Description("Synonym for 'back'"),
+		),
+		mcp.WithString("field_names",
+			mcp.Description("Field names used in the response: 'front_back' (default) or 'question_answer'"),
 		),
 		mcp.WithArray("tags",
 			mcp.Description("Tags for categorizing the card"),
 		),
+		mcp.WithString("hint",
+			mcp.Description("Optional hint that nudges the student toward the answer without giving it away"),
+		),
 	)
 
 	// Define the update_card tool
@@ -212,9 +372,21 @@ func main() {
 		mcp.WithString("back",
 			mcp.Description("The new back text of the card"),
 		),
+		mcp.WithString("question",
+			mcp.Description("Synonym for 'front'"),
+		),
+		mcp.WithString("answer",
+			mcp.Description("Synonym for 'back'"),
+		),
+		mcp.WithString("field_names",
+			mcp.Description("Field names used in the response: 'front_back' (default) or 'question_answer'"),
+		),
 		mcp.WithArray("tags",
 			mcp.Description("New tags for the card"),
 		),
+		mcp.WithString("hint",
+			mcp.Description("The new hint for the card (empty string removes it)"),
+		),
 	)
 
 	// Define the delete_card tool
@@ -246,6 +418,21 @@ func main() {
 		mcp.WithBoolean("include_stats",
 			mcp.Description("Include statistics in the response"),
 		),
+		mcp.WithBoolean("untagged_only",
+			mcp.Description("If true, list only cards with no tags. An empty tags array means no filter, so use this to find untagged cards. Cannot be combined with tags."),
+		),
+		mcp.WithString("tag_match",
+			mcp.Description("How tags are matched: 'all' (default) requires every tag, 'any' requires at least one"),
+		),
+		mcp.WithBoolean("exclude_suspended",
+			mcp.Description("If true, leave out suspended cards. Otherwise they are listed with \"suspended\": true."),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of cards to return (default 50, 0 for all). Cards are ordered by creation time; check has_more and total_count in the response."),
+		),
+		mcp.WithNumber("offset",
+			mcp.Description("Number of cards to skip, for fetching later pages (default 0)"),
+		),
 	)
 
 	// Define the help_analyze_learning tool
@@ -291,65 +478,1492 @@ func main() {
 		mcp.WithString("tag",
 			mcp.Description("The specific tag to associate cards with this due date. Optional for 'update'."),
 		),
+		mcp.WithBoolean("with_cards",
+			mcp.Description("For 'delete': also delete every card carrying the due date's tag (default false)"),
+		),
 	)
 
-	// Register all tools with their handlers
-	s.AddTool(getDueCardTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		// Pass the context with service to the handler
-		return handleGetDueCard(ctx, request)
-	})
-	s.AddTool(submitReviewTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		return handleSubmitReview(ctx, request)
-	})
-	s.AddTool(createCardTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		return handleCreateCard(ctx, request)
-	})
-	s.AddTool(updateCardTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		return handleUpdateCard(ctx, request)
-	})
-	s.AddTool(deleteCardTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		return handleDeleteCard(ctx, request)
-	})
-	s.AddTool(listCardsTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		return handleListCards(ctx, request)
-	})
-	s.AddTool(helpAnalyzeLearningTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		// Pass the context with service to the handler
-		return handleHelpAnalyzeLearning(ctx, request)
-	})
-	s.AddTool(manageDueDatesTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-		// Pass the context with service to the handler (to be implemented in handlers.go)
-		return handleManageDueDates(ctx, request)
-	})
+	// Define the manage_templates tool
+	manageTemplatesTool := mcp.NewTool("manage_templates",
+		mcp.WithDescription(
+			"Manage named card templates. Action can be 'create', 'delete', or 'list'. "+
+				"Template front/back text may contain {{placeholder}} markers, e.g. front='Q: {{question}}', "+
+				"back='{{answer}}\nSource: {{source}}'. Creating a template with an existing name replaces it.",
+		),
+		mcp.WithString("action",
+			mcp.Required(),
+			mcp.Description("The action to perform: 'create', 'delete', 'list'"),
+		),
+		mcp.WithString("name",
+			mcp.Description("The template name. Required for 'create' and 'delete'."),
+		),
+		mcp.WithString("front",
+			mcp.Description("Front pattern with {{placeholder}} markers. Required for 'create'."),
+		),
+		mcp.WithString("back",
+			mcp.Description("Back pattern with {{placeholder}} markers. Required for 'create'."),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Tags applied to every card created from this template"),
+		),
+	)
 
-	// Register a resource for available tags and card counts
-	tagsResource := mcp.NewResource(
-		"available-tags",
-		"Available Flashcard Tags",
-		mcp.WithResourceDescription("Shows all available tags in the flashcard system and how many cards exist for each tag."),
-		mcp.WithMIMEType("application/json"),
+	// Define the create_card_from_template tool
+	createCardFromTemplateTool := mcp.NewTool("create_card_from_template",
+		mcp.WithDescription(
+			"Create a flashcard by filling a named template's placeholders. "+
+				"Follow the same confirmation workflow as create_card: propose the rendered card and "+
+				"ONLY call this tool once the user approves. ✅",
+		),
+		mcp.WithString("template",
+			mcp.Required(),
+			mcp.Description("The name of the template to use"),
+		),
+		mcp.WithObject("values",
+			mcp.Description("Placeholder values, e.g. {\"question\": \"...\", \"source\": \"...\"}"),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Additional tags for the card, added to the template's tags"),
+		),
 	)
 
-	// Define a resource for due date progress
-	dueDateProgressResource := mcp.NewResource(
-		"due-date-progress",
-		"Due Date Progress Overview",
-		mcp.WithResourceDescription(
-			"Provides a summary of upcoming test due dates, associated tags, progress, and required study pace. "+
-				"Progress is based on cards last rated as Easy (4). Pace is calculated based on days remaining excluding the due date itself.",
+	// Define the repair_due_dates tool
+	repairDueDatesTool := mcp.NewTool("repair_due_dates",
+		mcp.WithDescription(
+			"Maintenance tool: find cards with a zero or invalid due date (common in legacy data files) and repair them. "+
+				"Unreviewed cards are reset to New and due now; reviewed cards are rescheduled by replaying their review history.",
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("If true, report the repairs that would be made without saving them"),
 		),
-		mcp.WithMIMEType("application/json"),
 	)
 
-	// Add the resource with its handler
-	s.AddResource(tagsResource, func(reqCtx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
-		// Pass the context with service to the handler
-		return handleTagsResource(ctx, request)
-	})
-	// Register the new resource
-	s.AddResource(dueDateProgressResource, func(reqCtx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
-		// Pass the context with service to the handler (to be implemented in handlers.go)
-		return handleDueDateProgressResource(ctx, request)
+	// Define the get_hint tool
+	getHintTool := mcp.NewTool("get_hint",
+		mcp.WithDescription(
+			"Get the hint for a card without revealing the answer. "+
+				"Use this between get_due_card and submit_review when a student is stuck 🤔 "+
+				"Offer the hint encouragingly and let the student try again before showing the answer. "+
+				"A student who needed the hint should usually be rated 2 or 3.",
+		),
+		mcp.WithString("card_id",
+			mcp.Required(),
+			mcp.Description("The ID of the card the student is working on"),
+		),
+	)
+
+	// Define the list_cards_by_due tool
+	listCardsByDueTool := mcp.NewTool("list_cards_by_due",
+		mcp.WithDescription(
+			"List flashcards ordered by their next due date (soonest first) for a \"what's coming up\" view. "+
+				"Each entry includes the due date, the current interval in days, and days until due (negative if overdue). "+
+				"When showing upcoming cards to the student, prefer to show only the question side 📝",
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Optional list of tags to filter cards by. Card must have ALL specified tags."),
+		),
+	)
+
+	// Define the vacation_mode tool
+	vacationModeTool := mcp.NewTool("vacation_mode",
+		mcp.WithDescription(
+			"Pause scheduling over a break so the student doesn't come back to a crushing backlog 🏖️ "+
+				"Action can be 'create', 'delete', or 'list'. Days inside a vacation don't count toward how overdue a card is. "+
+				"Dates must be in YYYY-MM-DD format; the end date is inclusive.",
+		),
+		mcp.WithString("action",
+			mcp.Required(),
+			mcp.Description("The action to perform: 'create', 'delete', 'list'"),
+		),
+		mcp.WithString("start_date",
+			mcp.Description("First day of the vacation (YYYY-MM-DD). Required for 'create'."),
+		),
+		mcp.WithString("end_date",
+			mcp.Description("Last day of the vacation (YYYY-MM-DD). Required for 'create'."),
+		),
+		mcp.WithString("vacation_id",
+			mcp.Description("The ID of the vacation. Required for 'delete'."),
+		),
+	)
+
+	// Define the rating_distribution tool
+	ratingDistributionTool := mcp.NewTool("rating_distribution",
+		mcp.WithDescription(
+			"Get how many times cards with a tag were rated Again (1), Hard (2), Good (3), and Easy (4) across all reviews. "+
+				"Use this to gauge how difficult a unit is for the student 📊 and frame the results encouragingly.",
+		),
+		mcp.WithString("tag",
+			mcp.Required(),
+			mcp.Description("The tag to analyze"),
+		),
+	)
+
+	// Define the reassign_card_due_date tool
+	reassignCardDueDateTool := mcp.NewTool("reassign_card_due_date",
+		mcp.WithDescription(
+			"Move a card from one test/due date to another by swapping its due date tags. "+
+				"Use the 'due-date-progress' resource or manage_due_dates 'list' to find due date IDs.",
+		),
+		mcp.WithString("card_id",
+			mcp.Required(),
+			mcp.Description("The ID of the card to move"),
+		),
+		mcp.WithString("old_due_date_id",
+			mcp.Required(),
+			mcp.Description("The ID of the due date the card currently belongs to"),
+		),
+		mcp.WithString("new_due_date_id",
+			mcp.Required(),
+			mcp.Description("The ID of the due date to move the card to"),
+		),
+	)
+
+	// Define the prioritize_card tool
+	prioritizeCardTool := mcp.NewTool("prioritize_card",
+		mcp.WithDescription(
+			"Make sure a specific card comes up next, e.g. right before a quiz 🎯 "+
+				"The card is returned by the next get_due_card call (if it matches that call's filters) even if it isn't due yet. "+
+				"The boost is one-time: normal ordering resumes afterwards.",
+		),
+		mcp.WithString("card_id",
+			mcp.Required(),
+			mcp.Description("The ID of the card to serve next"),
+		),
+	)
+
+	// Define the list_long_interval_cards tool
+	listLongIntervalCardsTool := mcp.NewTool("list_long_interval_cards",
+		mcp.WithDescription(
+			"List cards whose next review is so far away they're effectively out of rotation, e.g. after a streak of Easy ratings 💤 "+
+				"Results are ordered farthest-due first so the teacher can decide whether to reintroduce them (for example with reassign_card_due_date or prioritize_card).",
+		),
+		mcp.WithNumber("min_days",
+			mcp.Description("Only list cards due more than this many days from now (default 180)"),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Optional list of tags to filter cards by. Card must have ALL specified tags."),
+		),
+	)
+
+	// Define the create_snapshot tool
+	createSnapshotTool := mcp.NewTool("create_snapshot",
+		mcp.WithDescription(
+			"Save a timestamped copy of the flashcard collection so you can later report what changed 📸 "+
+				"Useful at the start of a week for a 'what did we accomplish' progress report with diff_snapshot.",
+		),
+		mcp.WithString("label",
+			mcp.Description("Optional label for the snapshot, e.g. 'Week 12 start'"),
+		),
+	)
+
+	// Define the diff_snapshot tool
+	diffSnapshotTool := mcp.NewTool("diff_snapshot",
+		mcp.WithDescription(
+			"Compare the current collection to a snapshot and report added, removed and modified cards plus reviews done since 📈 "+
+				"Celebrate the progress with the student! 🎉",
+		),
+		mcp.WithString("snapshot_id",
+			mcp.Description("ID of the snapshot to compare against (defaults to the most recent snapshot)"),
+		),
+	)
+
+	// Define the delete_snapshot tool
+	deleteSnapshotTool := mcp.NewTool("delete_snapshot",
+		mcp.WithDescription(
+			"Delete a snapshot that is no longer needed 🗑️ Each snapshot is a full copy of the collection, "+
+				"so remove old ones once their progress report is done.",
+		),
+		mcp.WithString("snapshot_id",
+			mcp.Required(),
+			mcp.Description("ID of the snapshot to delete"),
+		),
+	)
+
+	// Define the upcoming_due_dates tool
+	upcomingDueDatesTool := mcp.NewTool("upcoming_due_dates",
+		mcp.WithDescription(
+			"Get the next upcoming due dates (tests, deadlines) with countdowns and progress, soonest first ⏳ "+
+				"Great for a quick header like \"Biology test in 3 days, History in 10\".",
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of due dates to return (default 5, 0 for all)"),
+		),
+	)
+
+	// Define the get_maturity_breakdown tool
+	getMaturityBreakdownTool := mcp.NewTool("get_maturity_breakdown",
+		mcp.WithDescription(
+			"Count cards by maturity: new (never reviewed), young (interval under 21 days) and mature (21 days or more) 🌱🌳 "+
+				"A growing mature count means knowledge is sticking long-term.",
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Optional list of tags to filter cards by. Card must have ALL specified tags."),
+		),
+	)
+
+	// Define the autotag_by_difficulty tool
+	autotagByDifficultyTool := mcp.NewTool("autotag_by_difficulty",
+		mcp.WithDescription(
+			"Tag reviewed cards into difficulty bands ('difficulty-low', 'difficulty-medium', 'difficulty-high') from their FSRS difficulty 🏷️ "+
+				"Run again at any time to refresh the bands. Afterwards, filter get_due_card by 'difficulty-high' to focus on the hardest material 💪",
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Optional list of tags to limit which cards are re-tagged. Card must have ALL specified tags."),
+		),
+	)
+
+	// Define the configure tool
+	configureTool := mcp.NewTool("configure",
+		mcp.WithDescription(
+			"View or change server settings ⚙️ Settings are saved with the flashcards and survive restarts "+
+				"(command-line flags override them for a single run). Call with no arguments to see the current settings.",
+		),
+		mcp.WithNumber("max_reviews_per_day",
+			mcp.Description("Maximum number of reviews served per day (0 = unlimited)"),
+		),
+		mcp.WithNumber("max_overdue_factor",
+			mcp.Description("Cap on the overdue priority multiplier (values < 1 disable the cap)"),
+		),
+		mcp.WithBoolean("auto_tag_recall",
+			mcp.Description("Tag cards 'struggling' or 'solid' based on their recent ratings"),
+		),
+		mcp.WithNumber("seconds_per_card",
+			mcp.Description("Average seconds per review used by estimate_session_time (0 = default of 20)"),
+		),
+		mcp.WithBoolean("fold_diacritics",
+			mcp.Description("Ignore accents when check_answer compares answers, so 'cafe' matches 'café'"),
+		),
+		mcp.WithNumber("new_card_delay",
+			mcp.Description("Minutes before a newly created card first becomes due (0 = due immediately)"),
+		),
+		mcp.WithBoolean("reject_reserved_tags",
+			mcp.Description("Reject create_card/update_card calls that apply a 'test-' tag with no matching due date (default: warn only)"),
+		),
+		mcp.WithNumber("sibling_spacing_days",
+			mcp.Description("Days between a card's due date and that of its reverse from generate_reverse_cards (default 3, 0 = same time)"),
+		),
+		mcp.WithString("mastery_mode",
+			mcp.Description("How due date progress decides a card is mastered: 'last_rating' (default, last review was Easy) or 'retrievability' (current recall probability at least mastery_threshold)"),
+		),
+		mcp.WithNumber("mastery_threshold",
+			mcp.Description("Recall probability (0-1) needed for mastery in 'retrievability' mode (default 0.9)"),
+		),
+		mcp.WithNumber("max_tags_per_card",
+			mcp.Description("Reject cards with more tags than this when creating or updating them (0 = no limit, the default)"),
+		),
+		mcp.WithNumber("leech_threshold",
+			mcp.Description("Lapses (forgetting a card after learning it) after which a card is marked a leech (0 = default of 8)"),
+		),
+		mcp.WithBoolean("auto_suspend_leeches",
+			mcp.Description("Suspend cards as soon as they become leeches"),
+		),
+		mcp.WithNumber("audit_log_size",
+			mcp.Description(fmt.Sprintf("Most change history entries kept before the oldest are dropped (0 = default of %d)", DefaultAuditLogSize)),
+		),
+	)
+
+	// Define the import_cards tool
+	importCardsTool := mcp.NewTool("import_cards",
+		mcp.WithDescription(
+			"Create many flashcards at once 📦 Each entry needs 'front' and 'back', and may have 'tags' and 'hint'. "+
+				"Cards can also be given as CSV (e.g. pasted from a spreadsheet); rows with an empty front or back are "+
+				"reported by row number and the rest are still imported. "+
+				"Ask the user to approve the full list before importing. "+
+				"If the request includes a progress token, progress notifications are sent while the import runs.",
+		),
+		mcp.WithArray("cards",
+			mcp.Description("Cards to create: objects with front, back, and optional tags and hint. Provide this or 'csv'."),
+		),
+		mcp.WithString("csv",
+			mcp.Description("Cards to create as CSV with a header row of front,back,tags (tags separated by semicolons). "+
+				"Optional state,due,stability,difficulty(,last_review) columns, as written by export_cards with include_scheduling, restore scheduling. "+
+				"Provide this or 'cards'."),
+		),
+	)
+
+	// Define the session_cards tool
+	sessionCardsTool := mcp.NewTool("session_cards",
+		mcp.WithDescription(
+			"List the distinct cards reviewed between two timestamps, with the ratings given in that window 🗂️ "+
+				"Useful for an after-the-fact look at a study session.",
+		),
+		mcp.WithString("start",
+			mcp.Required(),
+			mcp.Description("Start of the window in RFC 3339 format, e.g. 2024-03-01T15:00:00Z"),
+		),
+		mcp.WithString("end",
+			mcp.Required(),
+			mcp.Description("End of the window in RFC 3339 format, e.g. 2024-03-01T16:00:00Z"),
+		),
+	)
+
+	// Define the rare_tags tool
+	rareTagsTool := mcp.NewTool("rare_tags",
+		mcp.WithDescription(
+			"Find tags used by only a few cards (by default, just one) so they can be merged into more common tags 🧹 "+
+				"Suggest consolidations to the user and use update_card to apply the ones they approve.",
+		),
+		mcp.WithNumber("max_cards",
+			mcp.Description("Report tags used by at most this many cards (default 1)"),
+		),
+	)
+
+	// Define the estimate_session_time tool
+	estimateSessionTimeTool := mcp.NewTool("estimate_session_time",
+		mcp.WithDescription(
+			"Estimate how many minutes it takes to clear all currently due cards ⏱️ "+
+				"Helps plan a study session (\"about 15 minutes today!\").",
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Optional list of tags to filter cards by. Card must have ALL specified tags."),
+		),
+		mcp.WithNumber("seconds_per_card",
+			mcp.Description("Average seconds per card (defaults to the configured value, or 20)"),
+		),
+	)
+
+	// Define the set_profile_info tool
+	setProfileInfoTool := mcp.NewTool("set_profile_info",
+		mcp.WithDescription(
+			"Set the student's display name and grade level 👤 These label reports and are saved with the flashcards. "+
+				"Only the provided fields are changed.",
+		),
+		mcp.WithString("name",
+			mcp.Description("The student's display name"),
+		),
+		mcp.WithString("grade_level",
+			mcp.Description("The student's grade level, e.g. '7th grade'"),
+		),
+	)
+
+	// Define the get_profile_info tool
+	getProfileInfoTool := mcp.NewTool("get_profile_info",
+		mcp.WithDescription("Get the student's display name and grade level 👤 Use the name to greet the student!"),
+	)
+
+	// Define the export_cards tool
+	exportCardsTool := mcp.NewTool("export_cards",
+		mcp.WithDescription(
+			"Export flashcards as JSON or CSV for backup or sharing 📤 "+
+				"CSV has the columns front,back,tags and can be edited in a spreadsheet and re-imported with import_cards. "+
+				"Suspended and archived cards are left out unless requested.",
+		),
+		mcp.WithString("format",
+			mcp.Description("Export format: 'json' (default) or 'csv'"),
+		),
+		mcp.WithBoolean("include_scheduling",
+			mcp.Description("For CSV, add the FSRS columns state,due,stability,difficulty,last_review so import_cards can restore scheduling (default false)"),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Optional list of tags to filter cards by. Card must have ALL specified tags."),
+		),
+		mcp.WithBoolean("include_suspended",
+			mcp.Description("Include suspended cards (default false)"),
+		),
+		mcp.WithBoolean("include_archived",
+			mcp.Description("Include archived cards (default false)"),
+		),
+	)
+
+	// Define the due_date_readiness tool
+	dueDateReadinessTool := mcp.NewTool("due_date_readiness",
+		mcp.WithDescription(
+			"Get a single 0-100 readiness score for an upcoming due date (e.g. \"how ready am I for the Biology test?\") 🎯 "+
+				"The score combines the share of mastered cards (50%), the average recall probability of the remaining cards (30%), "+
+				"and whether the recent study pace keeps up with the pace still required (20%). A breakdown of each part is included.",
+		),
+		mcp.WithString("due_date_id",
+			mcp.Required(),
+			mcp.Description("The ID of the due date to score"),
+		),
+	)
+
+	// Define the list_cards_by_review_count tool
+	listCardsByReviewCountTool := mcp.NewTool("list_cards_by_review_count",
+		mcp.WithDescription(
+			"List cards with their total number of reviews, most reviewed first, to see what has been drilled the most 🔁",
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Optional list of tags to filter cards by. Card must have ALL specified tags."),
+		),
+	)
+
+	// Define the check_answer tool
+	checkAnswerTool := mcp.NewTool("check_answer",
+		mcp.WithDescription(
+			"Check a student's typed answer against the back of the card ✅ "+
+				"The comparison ignores case and extra whitespace, and ignores accents when the fold_diacritics setting is on. "+
+				"This does not record a review; still call submit_review with a rating afterwards.",
+		),
+		mcp.WithString("card_id",
+			mcp.Required(),
+			mcp.Description("The ID of the card the student is answering"),
+		),
+		mcp.WithString("answer",
+			mcp.Required(),
+			mcp.Description("The student's answer"),
+		),
+	)
+
+	// Define the bulk_reschedule tool
+	bulkRescheduleTool := mcp.NewTool("bulk_reschedule",
+		mcp.WithDescription(
+			"Set the next due date of every card with the given tags, e.g. \"re-study everything tagged biology starting tomorrow\" 📅 "+
+				"Give either an absolute 'date' or a number of 'days' from now. Confirm with the user before rescheduling many cards.",
+		),
+		mcp.WithArray("tags",
+			mcp.Required(),
+			mcp.Description("Cards must have ALL of these tags to be rescheduled"),
+		),
+		mcp.WithString("date",
+			mcp.Description("New due date in YYYY-MM-DD format"),
+		),
+		mcp.WithNumber("days",
+			mcp.Description("Number of days from now until the cards are due (e.g. 1 for tomorrow)"),
+		),
+	)
+
+	// Define the tag_streaks tool
+	tagStreaksTool := mcp.NewTool("tag_streaks",
+		mcp.WithDescription(
+			"Report, for each tag, the longest run of consecutive days with at least one review 🔥 "+
+				"Use it to celebrate subject-specific consistency, e.g. \"You studied Spanish 9 days in a row!\"",
+		),
+	)
+
+	// Define the interval_adherence tool
+	intervalAdherenceTool := mcp.NewTool("interval_adherence",
+		mcp.WithDescription(
+			"Measure how closely reviews followed the schedule, for algorithm tuning 📏 "+
+				"For each review after a card's first, compares the days actually elapsed with the interval that was scheduled, "+
+				"and reports the average delay (positive = late) plus counts of early, on-time and late reviews.",
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Optional list of tags to filter cards by. Card must have ALL specified tags."),
+		),
+	)
+
+	// Define the reserved_tag_conflicts tool
+	reservedTagConflictsTool := mcp.NewTool("reserved_tag_conflicts",
+		mcp.WithDescription(
+			"Audit cards for 'test-' tags that no due date uses 🔍 The 'test-' prefix is reserved for due date tags, "+
+				"so a manually applied one usually means a typo or a deleted due date. Suggest fixing the tag or creating the due date.",
+		),
+	)
+
+	// Define the generate_reverse_cards tool
+	generateReverseCardsTool := mcp.NewTool("generate_reverse_cards",
+		mcp.WithDescription(
+			"Create a reverse (back-to-front) card for each selected card, e.g. 'Hello' → 'Hola' for a 'Hola' → 'Hello' card 🔄 "+
+				"Each reverse is first due a few days after its forward card (the sibling_spacing_days setting) so the pair isn't reviewed together. "+
+				"Cards that are already a reverse, or already have one, are skipped.",
+		),
+		mcp.WithArray("card_ids",
+			mcp.Description("IDs of the cards to reverse"),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Reverse every card with ALL of these tags (used when card_ids is not given)"),
+		),
+	)
+
+	// Define the export_full tool
+	exportFullTool := mcp.NewTool("export_full",
+		mcp.WithDescription(
+			"Export the ENTIRE flashcard store — cards with their FSRS scheduling state, reviews, due dates, templates, snapshots and settings — "+
+				"as JSON for moving to another machine without losing anything 🚚 Pass the output unchanged to import_full.",
+		),
+	)
+
+	// Define the import_full tool
+	importFullTool := mcp.NewTool("import_full",
+		mcp.WithDescription(
+			"Replace the ENTIRE flashcard store with the output of export_full ⚠️ Everything currently stored is overwritten, "+
+				"so confirm with the user first. Exports from an incompatible schema version are refused.",
+		),
+		mcp.WithString("data",
+			mcp.Required(),
+			mcp.Description("The complete JSON text returned by export_full"),
+		),
+	)
+
+	// Define the flag_card tool
+	flagCardTool := mcp.NewTool("flag_card",
+		mcp.WithDescription(
+			"Flag a card the student finds confusing or thinks is wrong, so the teacher can review it 🚩 "+
+				"Ask the student what's confusing and pass it as the reason. Flagged cards are still scheduled normally.",
+		),
+		mcp.WithString("card_id",
+			mcp.Required(),
+			mcp.Description("The ID of the card to flag"),
+		),
+		mcp.WithString("reason",
+			mcp.Description("Why the card was flagged, e.g. 'answer seems wrong'"),
+		),
+		mcp.WithBoolean("flagged",
+			mcp.Description("Set to false to clear the flag once the card has been reviewed (default true)"),
+		),
+	)
+
+	// Define the list_flagged_cards tool
+	listFlaggedCardsTool := mcp.NewTool("list_flagged_cards",
+		mcp.WithDescription("List cards flagged for teacher review, with the student's reasons, most recently flagged first 🚩"),
+	)
+
+	// Define the search_by_answer tool
+	searchByAnswerTool := mcp.NewTool("search_by_answer",
+		mcp.WithDescription(
+			"Find cards whose answer (back) contains the given text, ignoring case, e.g. every card whose answer is 'Paris' 🔎 "+
+				"The question (front) is not searched. This is a teacher tool: don't show results to a student mid-session, since they reveal answers.",
+		),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("Text to look for in card answers"),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Optional list of tags to filter cards by. Card must have ALL specified tags."),
+		),
+	)
+
+	// Define the session_analytics tool
+	sessionAnalyticsTool := mcp.NewTool("session_analytics",
+		mcp.WithDescription(
+			"Group all reviews into study sessions and report the average session length and cards per session ⏱️ "+
+				"A pause longer than idle_minutes between two reviews starts a new session.",
+		),
+		mcp.WithNumber("idle_minutes",
+			mcp.Description("Gap between reviews, in minutes, that starts a new session (default 30)"),
+		),
+	)
+
+	// Define the level_workload tool
+	levelWorkloadTool := mcp.NewTool("level_workload",
+		mcp.WithDescription(
+			"Spread a backlog of overdue cards over the coming days so no day has more than daily_cap cards due 📊 "+
+				"The highest-priority overdue cards stay due today; the rest move to later days in priority order. "+
+				"Confirm with the user before leveling, since it changes many due dates.",
+		),
+		mcp.WithNumber("daily_cap",
+			mcp.Required(),
+			mcp.Description("Maximum number of cards due on any day, e.g. 20"),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Optional list of tags to filter cards by. Card must have ALL specified tags."),
+		),
+	)
+
+	// Define the tag_cloud tool
+	tagCloudTool := mcp.NewTool("tag_cloud",
+		mcp.WithDescription(
+			"List every tag with a weight for drawing a tag cloud ☁️ "+
+				"The weight is the tag's card count plus the total days its cards are overdue, so urgent subjects appear larger.",
+		),
+	)
+
+	// Define the regression_alerts tool
+	regressionAlertsTool := mcp.NewTool("regression_alerts",
+		mcp.WithDescription(
+			"Find cards the student had remembered for a long time but just forgot 🚨 "+
+				"Returns cards whose latest review was Again right after a Good or Easy review at least min_interval_days earlier. "+
+				"Consider reintroducing these with extra context or a fresh mnemonic.",
+		),
+		mcp.WithNumber("min_interval_days",
+			mcp.Description("Minimum days between the last successful review and the lapse (default 21)"),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Optional list of tags to filter cards by. Card must have ALL specified tags."),
+		),
+	)
+
+	// Define the suggest_tag_merges tool
+	suggestTagMergesTool := mcp.NewTool("suggest_tag_merges",
+		mcp.WithDescription(
+			"Find tags that look like misspellings of each other (e.g. \"biolgy\" and \"biology\") and suggest merging the rarer into the more common 🏷️ "+
+				"Show the suggestions to the user first; call again with apply=true to merge them on every card.",
+		),
+		mcp.WithNumber("max_distance",
+			mcp.Description("Maximum number of character edits between two tags (default 2)"),
+		),
+		mcp.WithBoolean("apply",
+			mcp.Description("If true, perform the suggested merges instead of only listing them"),
+		),
+	)
+
+	// Define the burst_schedule tool
+	burstScheduleTool := mcp.NewTool("burst_schedule",
+		mcp.WithDescription(
+			"Prepare for an upcoming test by making the due date's unmastered cards due now, so the student can drill them 💥 "+
+				"With spread=true the cards are instead spread over the days left before the due date, weakest first. "+
+				"Mastered cards are left alone and no card is pushed later than it already was.",
+		),
+		mcp.WithString("due_date_id",
+			mcp.Required(),
+			mcp.Description("The ID of the due date to prepare for"),
+		),
+		mcp.WithBoolean("spread",
+			mcp.Description("If true, spread the cards over the remaining days instead of making them all due now"),
+		),
+	)
+
+	// Define the tag_state_summary tool
+	tagStateSummaryTool := mcp.NewTool("tag_state_summary",
+		mcp.WithDescription(
+			"Get the fraction of a tag's cards in each FSRS state (New, Learning, Review, Relearning) for a compact progress widget 📈",
+		),
+		mcp.WithString("tag",
+			mcp.Required(),
+			mcp.Description("The tag to summarize"),
+		),
+	)
+
+	// Define the forgetting_curve tool
+	forgettingCurveTool := mcp.NewTool("forgetting_curve",
+		mcp.WithDescription(
+			"Get a card's predicted recall probability at evenly spaced times from now through the length of its current interval, for plotting its forgetting curve 📉 "+
+				"Great for showing students why spaced review works.",
+		),
+		mcp.WithString("card_id",
+			mcp.Required(),
+			mcp.Description("The ID of the card"),
+		),
+		mcp.WithNumber("samples",
+			mcp.Description("Number of points to return, 2-100 (default 10)"),
+		),
+	)
+
+	// Define the clear_session tool
+	clearSessionTool := mcp.NewTool("clear_session",
+		mcp.WithDescription(
+			"End the current study session 🏁 Cards rated Again during a session are re-offered by get_due_card once nothing else is due; "+
+				"this drops those queued re-shows so the next session starts clean. The cards still come back on their normal schedule.",
+		),
+	)
+
+	// Define the suggest_due_date tool
+	suggestDueDateTool := mcp.NewTool("suggest_due_date",
+		mcp.WithDescription(
+			"Recommend a test date for a tag, given how many cards the student can master per day 🗓️ "+
+				"Counts the tag's unmastered cards and returns the day after the last study day needed at that pace. "+
+				"Use it when a teacher is choosing an exam date, then create the due date with manage_due_dates.",
+		),
+		mcp.WithString("tag",
+			mcp.Required(),
+			mcp.Description("The tag whose cards the test covers"),
+		),
+		mcp.WithNumber("cards_per_day",
+			mcp.Required(),
+			mcp.Description("How many cards the student can master per day"),
+		),
+	)
+
+	// Define the due_date_coverage tool
+	dueDateCoverageTool := mcp.NewTool("due_date_coverage",
+		mcp.WithDescription(
+			"Find coverage gaps in a test deck 🔎 Compares a due date's tag with a reference tag (e.g. the tag for the whole unit) "+
+				"and lists the unit's cards that don't carry the due date's tag yet. Add the tag to the ones the test should cover.",
+		),
+		mcp.WithString("due_date_id",
+			mcp.Required(),
+			mcp.Description("The ID of the due date whose tag defines the test deck"),
+		),
+		mcp.WithString("reference_tag",
+			mcp.Required(),
+			mcp.Description("The tag of the full set of cards the test should cover"),
+		),
+	)
+
+	// Define the practice tool
+	practiceTool := mcp.NewTool("practice",
+		mcp.WithDescription(
+			"Start a stress-free practice round 🎲 Returns a tag's cards in random order, whether or not they are due. "+
+				"Quiz the student one card at a time, showing only the front, and submit each answer with submit_review and practice=true "+
+				"so the round doesn't change the spaced repetition schedule.",
+		),
+		mcp.WithString("tag",
+			mcp.Required(),
+			mcp.Description("The tag to practice"),
+		),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of cards to return (default: all)"),
+		),
+		mcp.WithNumber("seed",
+			mcp.Description("Optional seed for the random order"),
+		),
+	)
+
+	// Define the autosuspend_stale tool
+	autosuspendStaleTool := mcp.NewTool("autosuspend_stale",
+		mcp.WithDescription(
+			"Maintenance tool: suspend cards that haven't been reviewed in more than the given number of days, to declutter the review queue 🧹 "+
+				"Cards never reviewed count from when they were created. Confirm with the user first, or use dry_run to preview.",
+		),
+		mcp.WithNumber("days",
+			mcp.Description("Suspend cards whose last review is more than this many days ago (default 180)"),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Optional list of tags to filter cards by. Card must have ALL specified tags."),
+		),
+		mcp.WithBoolean("dry_run",
+			mcp.Description("If true, report the stale cards without suspending them"),
+		),
+	)
+
+	// Define the projected_reviews tool
+	projectedReviewsTool := mcp.NewTool("projected_reviews",
+		mcp.WithDescription(
+			"Forecast how many reviews are needed on each of the next 7 days, for capacity planning 🔮 "+
+				"Assumes every card is reviewed when due and rated Good, so cards in short learning steps may be counted more than once.",
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Optional list of tags to filter cards by. Card must have ALL specified tags."),
+		),
+	)
+
+	// Define the apply_tags_to_cards tool
+	applyTagsToCardsTool := mcp.NewTool("apply_tags_to_cards",
+		mcp.WithDescription(
+			"Add tags to a specific set of cards in one call 🏷️ "+
+				"Use it after identifying cards that belong together instead of calling update_card for each one. "+
+				"If any card ID is unknown, no cards are changed.",
+		),
+		mcp.WithArray("card_ids",
+			mcp.Required(),
+			mcp.Description("IDs of the cards to tag"),
+		),
+		mcp.WithArray("add_tags",
+			mcp.Required(),
+			mcp.Description("Tags to add; tags a card already has are left as they are"),
+		),
+	)
+
+	// Define the simulate_due tool
+	simulateDueTool := mcp.NewTool("simulate_due",
+		mcp.WithDescription(
+			"Preview which cards will be due at a future time, e.g. \"what will I have tomorrow morning?\" ⏩ "+
+				"Read-only: nothing is reviewed or rescheduled.",
+		),
+		mcp.WithString("at",
+			mcp.Required(),
+			mcp.Description("Time to simulate, in RFC 3339 format (e.g. 2024-03-02T09:00:00Z)"),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Optional list of tags to filter cards by. Card must have ALL specified tags."),
+		),
+	)
+
+	// Define the slow_cards tool
+	slowCardsTool := mcp.NewTool("slow_cards",
+		mcp.WithDescription(
+			"Find cards the student answers slowly, using the duration_ms recorded with submit_review 🐢 "+
+				"Slow answers often mean shaky recall even when the rating was good; consider extra practice or a hint for these cards.",
+		),
+		mcp.WithNumber("threshold_ms",
+			mcp.Description("Average answer time above which a card is flagged, in milliseconds (default 60000)"),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Optional list of tags to filter cards by. Card must have ALL specified tags."),
+		),
+	)
+
+	// Define the reload_storage tool
+	reloadStorageTool := mcp.NewTool("reload_storage",
+		mcp.WithDescription(
+			"Reload all cards and reviews from the data file, discarding what the server holds in memory 🔄 "+
+				"Use after the file was edited or restored outside the server. Unsaved in-memory changes are lost.",
+		),
+	)
+
+	// Define the effort_report tool
+	effortReportTool := mcp.NewTool("effort_report",
+		mcp.WithDescription(
+			"Summarize the effort in a study session, e.g. for a teacher grading participation 📝 "+
+				"Reports cards and reviews, how many reviews had a typed answer, time spent (from duration_ms), and an effort score from 0 to 100.",
+		),
+		mcp.WithString("start",
+			mcp.Required(),
+			mcp.Description("Start of the session in RFC 3339 format, e.g. 2024-03-01T15:00:00Z"),
+		),
+		mcp.WithString("end",
+			mcp.Required(),
+			mcp.Description("End of the session in RFC 3339 format, e.g. 2024-03-01T16:00:00Z"),
+		),
+	)
+
+	// Define the clock_anomaly_check maintenance tool
+	clockAnomalyCheckTool := mcp.NewTool("clock_anomaly_check",
+		mcp.WithDescription(
+			"Maintenance: find cards whose due date is implausible given when they were created and reviewed, "+
+				"as happens after the system clock jumped ⏰ Read-only; use bulk_reschedule or repair_due_dates to fix what it finds.",
+		),
+		mcp.WithNumber("max_future_days",
+			mcp.Description("Flag cards due more than this many days after their last review or creation (default 3650)"),
+		),
+		mcp.WithNumber("tolerance_days",
+			mcp.Description("How many days before creation or the last review a due date may fall before it is flagged (default 1)"),
+		),
+	)
+
+	// Define the assign_to_due_date tool
+	assignToDueDateTool := mcp.NewTool("assign_to_due_date",
+		mcp.WithDescription(
+			"Add every card matching a search and/or tags to a due date in one step, e.g. all \"chapter 3\" cards for Friday's test 📌 "+
+				"Returns the due date's updated progress. Confirm with the user before assigning many cards.",
+		),
+		mcp.WithString("due_date_id",
+			mcp.Required(),
+			mcp.Description("ID of the due date to assign cards to"),
+		),
+		mcp.WithString("query",
+			mcp.Description("Text to look for in each card's front or back, ignoring case"),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Cards must have ALL of these tags. At least one of query and tags is required."),
+		),
+	)
+
+	// Define the decay_forecast tool
+	decayForecastTool := mcp.NewTool("decay_forecast",
+		mcp.WithDescription(
+			"Show what happens if the student stops studying: for each coming day, how many of today's mastered cards would have dropped below a recall threshold 📉 "+
+				"Use it for a gentle \"use it or lose it\" nudge.",
+		),
+		mcp.WithNumber("days",
+			mcp.Description("Number of days to forecast, 1-365 (default 30)"),
+		),
+		mcp.WithNumber("threshold",
+			mcp.Description("Recall probability (0-1) below which a card counts as at risk (default 0.9)"),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Optional list of tags to filter cards by. Card must have ALL specified tags."),
+		),
+	)
+
+	// Define the cleanup_collection maintenance tool
+	cleanupCollectionTool := mcp.NewTool("cleanup_collection",
+		mcp.WithDescription(
+			"Maintenance tool: tidy the collection after messy imports 🧹 Removes cards with a blank front or back, "+
+				"normalizes tags (lowercase, words joined by hyphens), and merges cards with the same front into the oldest one, keeping all their reviews. "+
+				"By default this is a dry run that only reports the changes; show the report to the user and call again with confirm=true to apply it.",
+		),
+		mcp.WithBoolean("confirm",
+			mcp.Description("Apply the changes. Without it, nothing is saved."),
+		),
+	)
+
+	// Define the get_card tool
+	getCardTool := mcp.NewTool("get_card",
+		mcp.WithDescription(
+			"Get a single flashcard by its ID, with current statistics. "+
+				"Unlike get_due_card this includes the hint, so don't show it to the student while quizzing.",
+		),
+		mcp.WithString("card_id",
+			mcp.Required(),
+			mcp.Description("The ID of the card to get"),
+		),
+		mcp.WithBoolean("include_reviews",
+			mcp.Description("If true, include the card's full review history, oldest first"),
+		),
+	)
+
+	// Define the undo_review tool
+	undoReviewTool := mcp.NewTool("undo_review",
+		mcp.WithDescription(
+			"Undo the most recent review of a card, e.g. when the student tapped the wrong rating. "+
+				"The review is deleted and the card's schedule is recomputed from its remaining reviews.",
+		),
+		mcp.WithString("card_id",
+			mcp.Required(),
+			mcp.Description("The ID of the card whose last review should be undone"),
+		),
+	)
+
+	// Define the search_cards tool
+	searchCardsTool := mcp.NewTool("search_cards",
+		mcp.WithDescription(
+			"Find cards whose question (front) or answer (back) contains the given text, ignoring case, e.g. every card about 'photosynthesis' 🔎 "+
+				"Front matches are listed first, and each result has a match_count. "+
+				"Results reveal answers, so don't show them to a student mid-session.",
+		),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("Text to look for in card fronts and backs"),
+		),
+		mcp.WithArray("tags",
+			mcp.Description("Optional list of tags to filter cards by. Card must have ALL specified tags."),
+		),
+	)
+
+	// Define the import_anki tool
+	importAnkiTool := mcp.NewTool("import_anki",
+		mcp.WithDescription(
+			"Import an Anki deck (.apkg file) 📥 Each basic note becomes a card from its first two fields, tagged with its deck name. "+
+				"Cloze and image occlusion notes are skipped and counted, and imported cards start as new cards rather than keeping their Anki schedule.",
+		),
+		mcp.WithString("path",
+			mcp.Required(),
+			mcp.Description("Path to the .apkg file on the server's filesystem"),
+		),
+	)
+
+	// Define the suspend_card tool
+	suspendCardTool := mcp.NewTool("suspend_card",
+		mcp.WithDescription(
+			"Suspend a card that is temporarily irrelevant (e.g. a topic not covered yet) so get_due_card skips it ⏸️ "+
+				"Unlike deleting, its schedule and review history are kept. Use unsuspend_card to bring it back.",
+		),
+		mcp.WithString("card_id",
+			mcp.Required(),
+			mcp.Description("The ID of the card to suspend"),
+		),
+	)
+
+	// Define the unsuspend_card tool
+	unsuspendCardTool := mcp.NewTool("unsuspend_card",
+		mcp.WithDescription("Return a suspended card to review ▶️ It picks up its schedule where it left off."),
+		mcp.WithString("card_id",
+			mcp.Required(),
+			mcp.Description("The ID of the card to unsuspend"),
+		),
+	)
+
+	// Define the get_fsrs_config tool
+	getFSRSConfigTool := mcp.NewTool("get_fsrs_config",
+		mcp.WithDescription(
+			"Show the FSRS parameters used for scheduling 🧮 request_retention is the target chance of remembering a card "+
+				"on its due date; maximum_interval caps intervals in days; weights are the 17 FSRS model weights.",
+		),
+	)
+
+	// Define the set_fsrs_config tool
+	setFSRSConfigTool := mcp.NewTool("set_fsrs_config",
+		mcp.WithDescription(
+			"Tune the FSRS scheduling parameters (for power users) 🎛️ Changes are saved with the flashcards and apply to "+
+				"reviews from now on; cards keep their current due dates until reviewed. A higher request_retention means "+
+				"shorter intervals and more reviews. Only the provided parameters are changed; use reset to restore the defaults.",
+		),
+		mcp.WithNumber("request_retention",
+			mcp.Description("Target recall probability at the due date, between 0 and 1 (default 0.9)"),
+		),
+		mcp.WithNumber("maximum_interval",
+			mcp.Description("Longest interval between reviews, in days (default 36500)"),
+		),
+		mcp.WithArray("weights",
+			mcp.Description("All 17 FSRS model weights, e.g. from an FSRS optimizer"),
+		),
+		mcp.WithBoolean("reset",
+			mcp.Description("If true, discard all saved parameters and use the defaults. Cannot be combined with other parameters."),
+		),
+	)
+
+	// Define the optimize_fsrs tool
+	optimizeFSRSTool := mcp.NewTool("optimize_fsrs",
+		mcp.WithDescription(
+			"Fit the 17 FSRS weights to the student's own review history, so scheduling matches how they actually remember 🧠 "+
+				fmt.Sprintf("Needs at least %d reviews and can take a few seconds. ", fsrs.MinOptimizeReviews)+
+				"Reports the log-loss (lower is better) of the old and new weights; the new weights are saved, like set_fsrs_config, "+
+				"only if they improve on the old ones.",
+		),
+	)
+
+	// Define the set_forecast_horizon tool
+	setForecastHorizonTool := mcp.NewTool("set_forecast_horizon",
+		mcp.WithDescription("Set how many days ahead the review-forecast resource looks 📅 The setting is saved with the flashcards."),
+		mcp.WithNumber("days",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf("Days to forecast, starting today: 1-%d (default %d)", MaxForecastDays, DefaultForecastDays)),
+		),
+	)
+
+	// Define the reset_card tool
+	resetCardTool := mcp.NewTool("reset_card",
+		mcp.WithDescription(
+			"Reset a card's scheduling back to New and due now, e.g. after substantially rewriting it "+
+				"so its old stability is misleading. Returns the reset card.",
+		),
+		mcp.WithString("card_id",
+			mcp.Required(),
+			mcp.Description("The ID of the card to reset"),
+		),
+		mcp.WithBoolean("keep_history",
+			mcp.Description("Keep the card's past reviews in its history and stats (default true); false deletes them"),
+		),
+	)
+
+	// Define the create_cards tool
+	createCardsTool := mcp.NewTool("create_cards",
+		mcp.WithDescription(
+			"Create several flashcards in one call, e.g. all the cards of a quiz. Each entry needs 'front' and 'back' "+
+				"and may have 'tags'. Returns the created card IDs in input order; entries that fail validation are "+
+				"reported by index and the rest are still created.",
+		),
+		mcp.WithArray("cards",
+			mcp.Required(),
+			mcp.Description("Cards to create: objects with front, back, and optional tags"),
+		),
+	)
+
+	// Define the restore_backup tool
+	restoreBackupTool := mcp.NewTool("restore_backup",
+		mcp.WithDescription(
+			"List or restore the automatic backups of the flashcard file 🛟 A backup is taken before deletes and bulk changes. "+
+				"Without 'backup', lists the available backups, newest first. With it, replaces ALL current cards, reviews "+
+				"and settings with that backup; confirm with the user first.",
+		),
+		mcp.WithString("backup",
+			mcp.Description("Name of the backup to restore, as listed by this tool. Omit to only list backups."),
+		),
+	)
+
+	// Define the get_statistics tool
+	getStatisticsTool := mcp.NewTool("get_statistics",
+		mcp.WithDescription(
+			"Get a full progress report 📊 Card counts (total, due, new, learning, review, suspended, archived), "+
+				"cards per FSRS state, average review interval, and retention (share of Good or Easy reviews) "+
+				"today, over the last 7 and 30 days, and all time. Use it to tell the student how they're doing.",
+		),
+		mcp.WithArray("windows",
+			mcp.Description("Optional retention windows in days, replacing the default [1, 7, 30]; 1 means today. All-time retention is always included."),
+		),
+	)
+
+	// Define the bulk_review tool
+	bulkReviewTool := mcp.NewTool("bulk_review",
+		mcp.WithDescription(
+			"Submit several reviews in one call, e.g. at the end of a study session, saved together instead of one by one. "+
+				"Each entry needs 'card_id' and 'rating' (1=Again, 2=Hard, 3=Good, 4=Easy) and may have 'answer' and an "+
+				"RFC3339 'timestamp'. Reviews are applied in order; each result gives the card's new due date, and a "+
+				"failed entry (e.g. unknown card) is reported without stopping the rest.",
+		),
+		mcp.WithArray("reviews",
+			mcp.Required(),
+			mcp.Description("Reviews to submit: objects with card_id, rating, and optional answer and timestamp"),
+		),
+	)
+
+	// Define the set_new_cards_per_day tool
+	setNewCardsPerDayTool := mcp.NewTool("set_new_cards_per_day",
+		mcp.WithDescription(
+			"Set how many brand-new cards get_due_card introduces per day 🌱 Once the limit is reached, only cards already "+
+				"being learned or reviewed are served until tomorrow, so a big batch of new cards doesn't overwhelm the student. "+
+				"The setting is saved with the flashcards.",
+		),
+		mcp.WithNumber("limit",
+			mcp.Required(),
+			mcp.Description(fmt.Sprintf("New cards per day (default %d, 0 for unlimited)", DefaultNewCardsPerDay)),
+		),
+	)
+
+	// Define the get_card_history tool
+	getCardHistoryTool := mcp.NewTool("get_card_history",
+		mcp.WithDescription(
+			"List the audit log of changes to the flashcards, newest first 📜 Each entry records when a card was created, "+
+				"updated, deleted or reviewed, with a short summary. Useful for answering \"what happened to this card?\" "+
+				"The log keeps only the most recent entries (see the audit_log_size setting).",
+		),
+		mcp.WithString("card_id",
+			mcp.Description("Only list entries for this card (default: all cards)"),
+		),
+		mcp.WithString("start_date",
+			mcp.Description("Only list entries on or after this date (YYYY-MM-DD)"),
+		),
+		mcp.WithString("end_date",
+			mcp.Description("Only list entries on or before this date (YYYY-MM-DD)"),
+		),
+	)
+
+	// Define the reschedule_card tool
+	rescheduleCardTool := mcp.NewTool("reschedule_card",
+		mcp.WithDescription(
+			"Move one card's next due date, e.g. to pull it forward before a quiz or push it back 📆 "+
+				"Give either an absolute 'due' time or 'due_in_days' from now (0 makes it due now). "+
+				"Only the due date changes: the card's memory stability and difficulty are kept.",
+		),
+		mcp.WithString("card_id",
+			mcp.Required(),
+			mcp.Description("The ID of the card to reschedule"),
+		),
+		mcp.WithString("due",
+			mcp.Description("New due time in RFC3339 format, e.g. 2025-06-01T09:00:00Z"),
+		),
+		mcp.WithNumber("due_in_days",
+			mcp.Description("Days from now until the card is due; fractions are allowed (e.g. 0.5 for twelve hours)"),
+		),
+	)
+
+	// Register all tools with their handlers
+	s.AddTool(getDueCardTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Pass the context with service to the handler
+		return handleGetDueCard(ctx, request)
+	})
+	s.AddTool(submitReviewTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleSubmitReview(ctx, request)
+	})
+	s.AddTool(createCardTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleCreateCard(ctx, request)
+	})
+	s.AddTool(updateCardTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleUpdateCard(ctx, request)
+	})
+	s.AddTool(deleteCardTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleDeleteCard(ctx, request)
+	})
+	s.AddTool(listCardsTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleListCards(ctx, request)
+	})
+	s.AddTool(helpAnalyzeLearningTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Pass the context with service to the handler
+		return handleHelpAnalyzeLearning(ctx, request)
+	})
+	s.AddTool(manageDueDatesTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Pass the context with service to the handler (to be implemented in handlers.go)
+		return handleManageDueDates(ctx, request)
+	})
+	s.AddTool(manageTemplatesTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleManageTemplates(ctx, request)
+	})
+	s.AddTool(createCardFromTemplateTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleCreateCardFromTemplate(ctx, request)
+	})
+	s.AddTool(repairDueDatesTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleRepairDueDates(ctx, request)
+	})
+	s.AddTool(getHintTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetHint(ctx, request)
+	})
+	s.AddTool(listCardsByDueTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleListCardsByDue(ctx, request)
+	})
+	s.AddTool(vacationModeTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleVacationMode(ctx, request)
+	})
+	s.AddTool(ratingDistributionTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleRatingDistribution(ctx, request)
+	})
+	s.AddTool(reassignCardDueDateTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleReassignCardDueDate(ctx, request)
+	})
+	s.AddTool(prioritizeCardTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handlePrioritizeCard(ctx, request)
+	})
+	s.AddTool(listLongIntervalCardsTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleListLongIntervalCards(ctx, request)
+	})
+	s.AddTool(createSnapshotTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleCreateSnapshot(ctx, request)
+	})
+	s.AddTool(diffSnapshotTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleDiffSnapshot(ctx, request)
+	})
+	s.AddTool(deleteSnapshotTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleDeleteSnapshot(ctx, request)
+	})
+	s.AddTool(upcomingDueDatesTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleUpcomingDueDates(ctx, request)
+	})
+	s.AddTool(getMaturityBreakdownTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetMaturityBreakdown(ctx, request)
+	})
+	s.AddTool(autotagByDifficultyTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleAutotagByDifficulty(ctx, request)
+	})
+	s.AddTool(configureTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleConfigure(ctx, request)
+	})
+	s.AddTool(importCardsTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		// Progress notifications need the per-request context (server and client session)
+		return handleImportCards(context.WithValue(reqCtx, "service", flashcardService), request)
+	})
+	s.AddTool(sessionCardsTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleSessionCards(ctx, request)
+	})
+	s.AddTool(rareTagsTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleRareTags(ctx, request)
+	})
+	s.AddTool(estimateSessionTimeTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleEstimateSessionTime(ctx, request)
+	})
+	s.AddTool(setProfileInfoTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleSetProfileInfo(ctx, request)
+	})
+	s.AddTool(getProfileInfoTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetProfileInfo(ctx, request)
+	})
+	s.AddTool(exportCardsTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleExportCards(ctx, request)
+	})
+	s.AddTool(dueDateReadinessTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleDueDateReadiness(ctx, request)
+	})
+	s.AddTool(listCardsByReviewCountTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleListCardsByReviewCount(ctx, request)
+	})
+	s.AddTool(checkAnswerTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleCheckAnswer(ctx, request)
+	})
+	s.AddTool(bulkRescheduleTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleBulkReschedule(ctx, request)
+	})
+	s.AddTool(tagStreaksTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleTagStreaks(ctx, request)
+	})
+	s.AddTool(intervalAdherenceTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleIntervalAdherence(ctx, request)
+	})
+	s.AddTool(reservedTagConflictsTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleReservedTagConflicts(ctx, request)
+	})
+	s.AddTool(exportFullTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleExportFull(ctx, request)
+	})
+	s.AddTool(importFullTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleImportFull(ctx, request)
+	})
+	s.AddTool(generateReverseCardsTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGenerateReverseCards(ctx, request)
+	})
+	s.AddTool(flagCardTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleFlagCard(ctx, request)
+	})
+	s.AddTool(listFlaggedCardsTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleListFlaggedCards(ctx, request)
+	})
+	s.AddTool(searchByAnswerTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleSearchByAnswer(ctx, request)
+	})
+	s.AddTool(sessionAnalyticsTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleSessionAnalytics(ctx, request)
+	})
+	s.AddTool(levelWorkloadTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleLevelWorkload(ctx, request)
+	})
+	s.AddTool(tagCloudTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleTagCloud(ctx, request)
+	})
+	s.AddTool(regressionAlertsTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleRegressionAlerts(ctx, request)
+	})
+	s.AddTool(suggestTagMergesTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleSuggestTagMerges(ctx, request)
+	})
+	s.AddTool(burstScheduleTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleBurstSchedule(ctx, request)
+	})
+	s.AddTool(tagStateSummaryTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleTagStateSummary(ctx, request)
+	})
+	s.AddTool(forgettingCurveTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleForgettingCurve(ctx, request)
+	})
+	s.AddTool(clearSessionTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleClearSession(ctx, request)
+	})
+	s.AddTool(suggestDueDateTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleSuggestDueDate(ctx, request)
+	})
+	s.AddTool(dueDateCoverageTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleDueDateCoverage(ctx, request)
+	})
+	s.AddTool(practiceTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handlePractice(ctx, request)
+	})
+	s.AddTool(autosuspendStaleTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleAutosuspendStale(ctx, request)
+	})
+	s.AddTool(projectedReviewsTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleProjectedReviews(ctx, request)
+	})
+	s.AddTool(applyTagsToCardsTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleApplyTagsToCards(ctx, request)
+	})
+	s.AddTool(simulateDueTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleSimulateDue(ctx, request)
+	})
+	s.AddTool(slowCardsTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleSlowCards(ctx, request)
+	})
+	s.AddTool(reloadStorageTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleReloadStorage(ctx, request)
+	})
+	s.AddTool(effortReportTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleEffortReport(ctx, request)
+	})
+	s.AddTool(clockAnomalyCheckTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleClockAnomalyCheck(ctx, request)
+	})
+	s.AddTool(assignToDueDateTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleAssignToDueDate(ctx, request)
+	})
+	s.AddTool(decayForecastTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleDecayForecast(ctx, request)
+	})
+	s.AddTool(cleanupCollectionTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleCleanupCollection(ctx, request)
+	})
+	s.AddTool(getCardTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetCard(ctx, request)
+	})
+	s.AddTool(undoReviewTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleUndoReview(ctx, request)
+	})
+	s.AddTool(searchCardsTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleSearchCards(ctx, request)
+	})
+	s.AddTool(importAnkiTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleImportAnki(ctx, request)
+	})
+	s.AddTool(suspendCardTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleSuspendCard(ctx, request)
+	})
+	s.AddTool(unsuspendCardTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleUnsuspendCard(ctx, request)
+	})
+	s.AddTool(getFSRSConfigTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetFSRSConfig(ctx, request)
+	})
+	s.AddTool(setFSRSConfigTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleSetFSRSConfig(ctx, request)
+	})
+	s.AddTool(optimizeFSRSTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleOptimizeFSRS(ctx, request)
+	})
+	s.AddTool(setForecastHorizonTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleSetForecastHorizon(ctx, request)
+	})
+	s.AddTool(resetCardTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleResetCard(ctx, request)
+	})
+	s.AddTool(createCardsTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleCreateCards(ctx, request)
+	})
+	s.AddTool(restoreBackupTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleRestoreBackup(ctx, request)
+	})
+	s.AddTool(getStatisticsTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetStatistics(ctx, request)
+	})
+	s.AddTool(bulkReviewTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleBulkReview(ctx, request)
+	})
+	s.AddTool(setNewCardsPerDayTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleSetNewCardsPerDay(ctx, request)
+	})
+	s.AddTool(getCardHistoryTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleGetCardHistory(ctx, request)
+	})
+	s.AddTool(rescheduleCardTool, func(reqCtx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return handleRescheduleCard(ctx, request)
+	})
+
+	// Register a resource for available tags and card counts
+	tagsResource := mcp.NewResource(
+		"available-tags",
+		"Available Flashcard Tags",
+		mcp.WithResourceDescription("Shows all available tags in the flashcard system and how many cards exist for each tag."),
+		mcp.WithMIMEType("application/json"),
+	)
+
+	// Define a resource for due date progress
+	dueDateProgressResource := mcp.NewResource(
+		"due-date-progress",
+		"Due Date Progress Overview",
+		mcp.WithResourceDescription(
+			"Provides a summary of upcoming test due dates, associated tags, progress, and required study pace. "+
+				"Progress is based on cards last rated as Easy (4), or on current recall probability when the mastery_mode setting is 'retrievability'. "+
+				"mature_cards counts cards with an interval of at least 21 days. Pace is calculated based on days remaining excluding the due date itself.",
+		),
+		mcp.WithMIMEType("application/json"),
+	)
+
+	// Define a resource for the daily history of card states
+	stateHistoryResource := mcp.NewResource(
+		"state-history",
+		"Card State History",
+		mcp.WithResourceDescription(
+			"For each day since the first card was created, how many cards were New, Learning, Review or Relearning at the end of the day. "+
+				"Computed by replaying reviews; suitable for a stacked-area chart of learning progress.",
+		),
+		mcp.WithMIMEType("application/json"),
+	)
+
+	// Define a resource for the daily review activity heatmap
+	reviewHeatmapResource := mcp.NewResource(
+		"review-heatmap",
+		"Review Activity Heatmap",
+		mcp.WithResourceDescription(
+			"The number of reviews on each of the last 365 days, oldest first, including days without reviews. "+
+				"Suitable for a GitHub-style calendar heatmap; max_day is the busiest day's count.",
+		),
+		mcp.WithMIMEType("application/json"),
+	)
+
+	// Define a resource for the upcoming review workload
+	reviewForecastResource := mcp.NewResource(
+		"review-forecast",
+		"Review Forecast",
+		mcp.WithResourceDescription(
+			"The number of cards falling due on each of the coming days (14 by default; see set_forecast_horizon), starting today. "+
+				"due_count counts cards already studied, with everything overdue in today's count; new_count counts new cards.",
+		),
+		mcp.WithMIMEType("application/json"),
+	)
+
+	// Define a resource for the daily study streak
+	studyStreakResource := mcp.NewResource(
+		"study-streak",
+		"Study Streak",
+		mcp.WithResourceDescription(
+			"The current and longest runs of consecutive days with at least one review 🔥 "+
+				"A missed calendar day ends a streak; the current streak still counts if the last study day was yesterday. Celebrate milestones!",
+		),
+		mcp.WithMIMEType("application/json"),
+	)
+
+	leechesResource := mcp.NewResource(
+		"leeches",
+		"Leeches",
+		mcp.WithResourceDescription(
+			"Cards the student keeps forgetting: marked leeches after repeated lapses, most lapses first 🩹 "+
+				"Help rewrite or split them, or suggest suspending them so they stop wasting study time.",
+		),
+		mcp.WithMIMEType("application/json"),
+	)
+
+	cardHistoryResource := mcp.NewResource(
+		"card-history",
+		"Card History",
+		mcp.WithResourceDescription(
+			"The audit log of card creations, edits, deletions and reviews, newest first. "+
+				"Only the most recent entries are kept; use get_card_history to filter by card or date.",
+		),
+		mcp.WithMIMEType("application/json"),
+	)
+
+	// Add the resource with its handler
+	s.AddResource(tagsResource, func(reqCtx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		// Pass the context with service to the handler
+		return handleTagsResource(ctx, request)
+	})
+	// Register the new resource
+	s.AddResource(dueDateProgressResource, func(reqCtx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		// Pass the context with service to the handler (to be implemented in handlers.go)
+		return handleDueDateProgressResource(ctx, request)
+	})
+	s.AddResource(stateHistoryResource, func(reqCtx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return handleStateHistoryResource(ctx, request)
+	})
+	s.AddResource(reviewHeatmapResource, func(reqCtx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return handleReviewHeatmapResource(ctx, request)
+	})
+	s.AddResource(reviewForecastResource, func(reqCtx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return handleReviewForecastResource(ctx, request)
+	})
+	s.AddResource(studyStreakResource, func(reqCtx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return handleStudyStreakResource(ctx, request)
+	})
+	s.AddResource(leechesResource, func(reqCtx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return handleLeechesResource(ctx, request)
+	})
+	s.AddResource(cardHistoryResource, func(reqCtx context.Context, request mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return handleCardHistoryResource(ctx, request)
 	})
 
 	// Start the server
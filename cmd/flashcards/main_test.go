@@ -329,12 +329,10 @@ func createTestCard(c *client.Client, ctx context.Context, front, back string, t
 		tagInterfaces[i] = tag
 	}
 
-	// Add hourOffset as a custom parameter for testing
 	createCardRequest.Params.Arguments = map[string]interface{}{
-		"front":       front,
-		"back":        back,
-		"tags":        tagInterfaces,
-		"hour_offset": hourOffset, // This will be used to set the due date
+		"front": front,
+		"back":  back,
+		"tags":  tagInterfaces,
 	}
 
 	result, err := c.CallTool(ctx, createCardRequest)
@@ -359,14 +357,20 @@ func createTestCard(c *client.Client, ctx context.Context, front, back string, t
 		return fmt.Errorf("failed to parse create_card response: %w", err)
 	}
 
-	// We now have the card ID, but we need to update its due date
-	// For now, since we don't have a direct way to set the due date,
-	// we'll use this placeholder
-	// In a real implementation, you would add a proper tool to update the due date
-
-	// TODO: Implement a way to directly set due dates for testing
-	// For now, this test relies on the implementation of CreateCard setting
-	// the due date to now, and the prioritization logic working correctly
+	// Move the due date by hourOffset hours from now
+	rescheduleRequest := mcp.CallToolRequest{}
+	rescheduleRequest.Params.Name = "reschedule_card"
+	rescheduleRequest.Params.Arguments = map[string]interface{}{
+		"card_id": response.Card.ID,
+		"due":     time.Now().Add(time.Duration(hourOffset * float64(time.Hour))).Format(time.RFC3339),
+	}
+	result, err = c.CallTool(ctx, rescheduleRequest)
+	if err != nil {
+		return fmt.Errorf("failed to call reschedule_card: %w", err)
+	}
+	if result.IsError {
+		return fmt.Errorf("reschedule_card failed: %v", result.Content)
+	}
 
 	return nil
 }
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/danieldreier/mcp-flashcards/internal/storage"
+	gofsrs "github.com/open-spaced-repetition/go-fsrs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRepairDueDates tests that zero-value due dates are detected and repaired
+func TestRepairDueDates(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	// A legacy card that was never reviewed but has a zero due date
+	unreviewed := createCardDirectly(t, service, "Legacy Q", "Legacy A", nil)
+	unreviewed.FSRS.Due = time.Time{}
+	updateCardDirectly(t, service, unreviewed)
+
+	// A reviewed card whose due date was lost
+	reviewed := createCardDirectly(t, service, "Reviewed Q", "Reviewed A", nil)
+	_, err := service.SubmitReviewWithTime(reviewed.ID, gofsrs.Good, "A", now.AddDate(0, 0, -3))
+	require.NoError(t, err)
+	reviewedCard, err := service.Storage.GetCard(reviewed.ID)
+	require.NoError(t, err)
+	expectedDue := reviewedCard.FSRS.Due
+	reviewedCard.FSRS.Due = time.Time{}
+	updateCardDirectly(t, service, reviewedCard)
+
+	// A healthy card that must be left alone
+	healthy := createCardDirectly(t, service, "Healthy Q", "Healthy A", nil)
+
+	// Dry run reports but does not modify
+	repairs, err := service.RepairDueDates(true)
+	require.NoError(t, err)
+	assert.Len(t, repairs, 2, "Both broken cards should be reported")
+	stored, _ := service.Storage.GetCard(unreviewed.ID)
+	assert.True(t, stored.FSRS.Due.IsZero(), "Dry run must not modify cards")
+
+	repairs, err = service.RepairDueDates(false)
+	require.NoError(t, err)
+	require.Len(t, repairs, 2)
+
+	methods := map[string]string{}
+	for _, r := range repairs {
+		methods[r.CardID] = r.Method
+	}
+	assert.Equal(t, "reset", methods[unreviewed.ID])
+	assert.Equal(t, "replayed", methods[reviewed.ID])
+
+	stored, _ = service.Storage.GetCard(unreviewed.ID)
+	assert.True(t, stored.FSRS.Due.Equal(now), "Unreviewed card should be due now")
+	assert.Equal(t, gofsrs.New, stored.FSRS.State)
+
+	stored, _ = service.Storage.GetCard(reviewed.ID)
+	assert.True(t, stored.FSRS.Due.Equal(expectedDue), "Reviewed card should be rescheduled from its history")
+
+	stored, _ = service.Storage.GetCard(healthy.ID)
+	assert.Equal(t, healthy.FSRS.Due.Unix(), stored.FSRS.Due.Unix(), "Healthy card should be untouched")
+
+	// Running again finds nothing to repair
+	repairs, err = service.RepairDueDates(false)
+	require.NoError(t, err)
+	assert.Empty(t, repairs)
+}
+
+// TestPracticeReviewsNotReplayed tests that rebuilding a schedule from history, and
+// the review stats, ignore practice reviews
+func TestPracticeReviewsNotReplayed(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Date(2024, 5, 10, 12, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	card := createCardDirectly(t, service, "Q", "A", nil)
+	_, err := service.SubmitReviewWithTime(card.ID, gofsrs.Good, "A", now.AddDate(0, 0, -3))
+	require.NoError(t, err)
+	_, err = service.SubmitPracticeReview(card.ID, gofsrs.Again, "?", now, 0)
+	require.NoError(t, err)
+	scheduled, err := service.Storage.GetCard(card.ID)
+	require.NoError(t, err)
+
+	// Practice, then repair: the schedule comes out as it was
+	broken := scheduled
+	broken.FSRS.Due = time.Time{}
+	updateCardDirectly(t, service, broken)
+	repairs, err := service.RepairDueDates(false)
+	require.NoError(t, err)
+	require.Len(t, repairs, 1)
+	assert.Equal(t, "replayed", repairs[0].Method)
+	repaired, err := service.Storage.GetCard(card.ID)
+	require.NoError(t, err)
+	assert.True(t, scheduled.FSRS.Due.Equal(repaired.FSRS.Due), "Repair must not apply the practice rating")
+	assert.Equal(t, scheduled.FSRS.State, repaired.FSRS.State)
+	assert.Equal(t, scheduled.FSRS.Lapses, repaired.FSRS.Lapses)
+
+	// The practice review doesn't count toward today's reviews or retention
+	stats := service.calculateStats([]storage.Card{repaired})
+	assert.Equal(t, 0, stats.ReviewsToday)
+	statistics, err := service.GetStatistics([]int{1})
+	require.NoError(t, err)
+	assert.Equal(t, 1, statistics.TotalReviews)
+	assert.Equal(t, 0, statistics.Retention[0].Reviews)
+
+	history, err := service.StateHistory()
+	require.NoError(t, err)
+	require.NotEmpty(t, history)
+	last := history[len(history)-1]
+	assert.Equal(t, StateHistoryDay{Date: last.Date, Learning: 1}, last, "The card ends today as scheduled, not relearning")
+}
+
+// TestAutosuspendStale tests that only cards without recent reviews are suspended
+func TestAutosuspendStale(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Now()
+	defer mockTimeNow(now)()
+
+	createdLongAgo := func(front string) storage.Card {
+		card := createCardDirectly(t, service, front, "A", nil)
+		card.CreatedAt = now.AddDate(0, 0, -200)
+		updateCardDirectly(t, service, card)
+		return card
+	}
+	stale := createdLongAgo("Stale")
+	addReviewDirectly(t, service, stale.ID, gofsrs.Good, now.AddDate(0, 0, -100))
+	fresh := createdLongAgo("Fresh")
+	addReviewDirectly(t, service, fresh.ID, gofsrs.Good, now.AddDate(0, 0, -100))
+	addReviewDirectly(t, service, fresh.ID, gofsrs.Good, now.AddDate(0, 0, -5))
+	unreviewed := createCardDirectly(t, service, "New", "A", nil) // Created just now
+
+	preview, err := service.AutosuspendStale(90, nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{stale.ID}, preview.CardIDs)
+	card, err := service.Storage.GetCard(stale.ID)
+	require.NoError(t, err)
+	assert.False(t, card.Suspended, "A dry run must not suspend anything")
+
+	response, err := service.AutosuspendStale(90, nil, false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, response.Suspended)
+	for id, wantSuspended := range map[string]bool{stale.ID: true, fresh.ID: false, unreviewed.ID: false} {
+		card, err := service.Storage.GetCard(id)
+		require.NoError(t, err)
+		assert.Equal(t, wantSuspended, card.Suspended, "Card %s", id)
+	}
+}
+
+// TestReloadStorage tests that changes written to the file by another process show up after reload_storage
+func TestReloadStorage(t *testing.T) {
+	service, filePath := setupTestServiceWithBackend(t, storage.BackendJSON) // Only a JSON file can be edited by another process
+	original := createCardDirectly(t, service, "Original", "A", nil)
+
+	// Another process edits the file
+	external := storage.NewFileStorage(filePath)
+	require.NoError(t, external.Load())
+	added, err := external.CreateCard("Added elsewhere", "B", []string{"external"})
+	require.NoError(t, err)
+	require.NoError(t, external.DeleteCard(original.ID))
+
+	_, err = service.Storage.GetCard(added.ID)
+	require.Error(t, err, "The new card shouldn't be visible before reloading")
+
+	ctx := context.WithValue(context.Background(), "service", service)
+	text, result := callHandlerDirectly(t, ctx, handleReloadStorage, nil)
+	require.False(t, result.IsError, text)
+	var response ReloadStorageResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &response), text)
+	assert.Equal(t, 1, response.Cards)
+
+	stored, err := service.Storage.GetCard(added.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "Added elsewhere", stored.Front)
+	_, err = service.Storage.GetCard(original.ID)
+	assert.ErrorIs(t, err, storage.ErrCardNotFound)
+
+	// A corrupt file is reported and the loaded cards are kept
+	require.NoError(t, os.WriteFile(filePath, []byte("{not json"), 0644))
+	_, err = service.ReloadStorage()
+	assert.Error(t, err)
+	_, err = service.Storage.GetCard(added.ID)
+	assert.NoError(t, err)
+
+	// So are a missing and an empty file, rather than wiping the store
+	require.NoError(t, os.Remove(filePath))
+	_, err = service.ReloadStorage()
+	assert.Error(t, err)
+	_, err = service.Storage.GetCard(added.ID)
+	assert.NoError(t, err, "A missing file must not wipe the loaded cards")
+	_, statErr := os.Stat(filePath)
+	assert.True(t, os.IsNotExist(statErr), "Reload must not write a new empty file")
+
+	require.NoError(t, os.WriteFile(filePath, nil, 0644))
+	_, err = service.ReloadStorage()
+	assert.ErrorIs(t, err, storage.ErrEmptyStorageFile)
+	_, err = service.Storage.GetCard(added.ID)
+	assert.NoError(t, err, "An empty file must not wipe the loaded cards")
+}
+
+// TestClockAnomalyCheck tests that wildly future or backdated due dates are detected
+func TestClockAnomalyCheck(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Now()
+
+	normal := createCardDirectly(t, service, "Normal", "A", nil)
+	setDueDateDirectly(t, service, normal.ID, now.AddDate(0, 0, 30))
+
+	farFuture := createCardDirectly(t, service, "Far future", "A", nil)
+	setDueDateDirectly(t, service, farFuture.ID, now.AddDate(40, 0, 0))
+
+	backdated := createCardDirectly(t, service, "Backdated", "A", nil)
+	setDueDateDirectly(t, service, backdated.ID, backdated.CreatedAt.AddDate(-2, 0, 0))
+
+	reviewed := createCardDirectly(t, service, "Reviewed", "A", nil)
+	reviewed.CreatedAt = now.AddDate(0, 0, -60)
+	reviewed.FSRS.Due = now.AddDate(0, 0, -20)
+	updateCardDirectly(t, service, reviewed)
+	addReviewDirectly(t, service, reviewed.ID, gofsrs.Good, now.AddDate(0, 0, -10))
+
+	ctx := context.WithValue(context.Background(), "service", service)
+	text, result := callHandlerDirectly(t, ctx, handleClockAnomalyCheck, map[string]interface{}{})
+	require.False(t, result.IsError, text)
+	var anomalies []ClockAnomaly
+	require.NoError(t, json.Unmarshal([]byte(text), &anomalies), text)
+
+	reasons := map[string]string{}
+	for _, anomaly := range anomalies {
+		reasons[anomaly.CardID] = anomaly.Reason
+	}
+	assert.Equal(t, map[string]string{
+		farFuture.ID: ClockAnomalyTooFar,
+		backdated.ID: ClockAnomalyBeforeCreation,
+		reviewed.ID:  ClockAnomalyBeforeReview,
+	}, reasons)
+
+	// A tighter bound catches the normal card too
+	anomalies, err := service.ClockAnomalyCheck(7, DefaultClockAnomalyToleranceDays)
+	require.NoError(t, err)
+	assert.Len(t, anomalies, 4)
+}
+
+// TestCleanupCollection tests that the dry-run report matches what a confirmed cleanup applies
+func TestCleanupCollection(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Now()
+	require.NoError(t, service.AddDueDate(storage.DueDate{ID: "quiz", Topic: "Quiz", DueDate: now.AddDate(0, 0, 7), Tag: "test-Quiz"}))
+
+	seed := func(front, back string, tags []string, age int) storage.Card {
+		card := createCardDirectly(t, service, front, back, tags)
+		card.CreatedAt = now.AddDate(0, 0, -age)
+		updateCardDirectly(t, service, card)
+		return card
+	}
+	original := seed("Capital of France?", "Paris", []string{"Geography"}, 10)
+	addReviewDirectly(t, service, original.ID, gofsrs.Good, now.AddDate(0, 0, -9))
+	duplicate := seed("Capital of France? ", "Paris", []string{"europe", "test-Quiz"}, 5)
+	addReviewDirectly(t, service, duplicate.ID, gofsrs.Easy, now.AddDate(0, 0, -4))
+	messy := seed("Capital of Spain?", "Madrid", []string{" Chapter 3", "chapter-3", "geography"}, 8)
+	blank := seed("Empty answer", "  ", []string{"geography"}, 7)
+	addReviewDirectly(t, service, blank.ID, gofsrs.Again, now.AddDate(0, 0, -6))
+	clean := seed("Capital of Italy?", "Rome", []string{"geography"}, 6)
+
+	ctx := context.WithValue(context.Background(), "service", service)
+	text, result := callHandlerDirectly(t, ctx, handleCleanupCollection, map[string]interface{}{})
+	require.False(t, result.IsError, text)
+	var dryRun CleanupReport
+	require.NoError(t, json.Unmarshal([]byte(text), &dryRun), text)
+	assert.True(t, dryRun.DryRun)
+	assert.Equal(t, 1, dryRun.Removed)
+	assert.Equal(t, 2, dryRun.Retagged)
+	assert.Equal(t, 1, dryRun.Merged)
+	assert.Equal(t, 1, dryRun.ReviewsMoved)
+
+	// The dry run changed nothing
+	_, err := service.Storage.GetCard(blank.ID)
+	require.NoError(t, err)
+
+	text, result = callHandlerDirectly(t, ctx, handleCleanupCollection, map[string]interface{}{"confirm": true})
+	require.False(t, result.IsError, text)
+	var applied CleanupReport
+	require.NoError(t, json.Unmarshal([]byte(text), &applied), text)
+	assert.False(t, applied.DryRun)
+	applied.DryRun = true
+	assert.Equal(t, dryRun, applied, "The applied changes should be exactly the ones previewed")
+
+	_, err = service.Storage.GetCard(blank.ID)
+	assert.ErrorIs(t, err, storage.ErrCardNotFound)
+	_, err = service.Storage.GetCard(duplicate.ID)
+	assert.ErrorIs(t, err, storage.ErrCardNotFound)
+
+	kept, err := service.Storage.GetCard(original.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"geography", "europe", "test-Quiz"}, kept.Tags, "Due date tags are kept as they are")
+	reviews, err := service.Storage.GetCardReviews(original.ID)
+	require.NoError(t, err)
+	assert.Len(t, reviews, 2, "The duplicate's review moves to the kept card")
+	assert.Equal(t, uint64(2), kept.FSRS.Reps, "Rescheduled from the combined history")
+
+	tidied, err := service.Storage.GetCard(messy.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"chapter-3", "geography"}, tidied.Tags)
+	untouched, err := service.Storage.GetCard(clean.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"geography"}, untouched.Tags)
+
+	// Nothing is left to clean
+	again, err := service.CleanupCollection(true)
+	require.NoError(t, err)
+	assert.Empty(t, again.Changes)
+}
@@ -10,28 +10,84 @@ import (
 
 // Card represents a flashcard with content and FSRS algorithm data
 type Card struct {
-	ID        string    `json:"id"`
-	Front     string    `json:"front"`
-	Back      string    `json:"back"`
-	CreatedAt time.Time `json:"created_at"`
-	Tags      []string  `json:"tags,omitempty"`
+	ID         string    `json:"id"`
+	Front      string    `json:"front"`
+	Back       string    `json:"back"`
+	CreatedAt  time.Time `json:"created_at"`
+	Tags       []string  `json:"tags,omitempty"`
+	Hint       string    `json:"hint,omitempty"`
+	Maturity   string    `json:"maturity,omitempty"` // "new", "young" or "mature"; computed from the FSRS interval
+	Suspended  bool      `json:"suspended,omitempty"`
+	Archived   bool      `json:"archived,omitempty"`
+	Flagged    bool      `json:"flagged,omitempty"`
+	FlagReason string    `json:"flag_reason,omitempty"`
+	Leech      bool      `json:"leech,omitempty"`
 	// Algorithm data - from go-fsrs package which contains:
 	// Due, Stability, Difficulty, ElapsedDays, ScheduledDays, Reps, Lapses, State, LastReview
 	FSRS gofsrs.Card `json:"fsrs"`
 }
 
+// cardFromStorage converts a storage.Card to our main Card type
+func cardFromStorage(storageCard storage.Card) Card {
+	return Card{
+		ID:         storageCard.ID,
+		Front:      storageCard.Front,
+		Back:       storageCard.Back,
+		CreatedAt:  storageCard.CreatedAt,
+		Tags:       storageCard.Tags,
+		Hint:       storageCard.Hint,
+		Maturity:   cardMaturity(storageCard.FSRS),
+		Suspended:  storageCard.Suspended,
+		Archived:   storageCard.Archived,
+		Flagged:    storageCard.Flagged,
+		FlagReason: storageCard.FlagReason,
+		Leech:      storageCard.Leech,
+		FSRS:       storageCard.FSRS,
+	}
+}
+
+// Anki-style maturity labels
+const (
+	MaturityNew    = "new"
+	MaturityYoung  = "young"
+	MaturityMature = "mature"
+)
+
+// MatureIntervalDays is the interval at which a card stops being young.
+const MatureIntervalDays = 21
+
+// cardMaturity labels a card new (never reviewed), young (interval under
+// MatureIntervalDays) or mature.
+func cardMaturity(fsrsCard gofsrs.Card) string {
+	switch {
+	case fsrsCard.State == gofsrs.New:
+		return MaturityNew
+	case fsrsCard.ScheduledDays < MatureIntervalDays:
+		return MaturityYoung
+	default:
+		return MaturityMature
+	}
+}
+
 // CardStats represents statistics for flashcard review
 type CardStats struct {
-	TotalCards    int     `json:"total_cards"`
-	DueCards      int     `json:"due_cards"`
-	ReviewsToday  int     `json:"reviews_today"`
-	RetentionRate float64 `json:"retention_rate"`
+	TotalCards              int     `json:"total_cards"`
+	DueCards                int     `json:"due_cards"`
+	SuspendedCards          int     `json:"suspended_cards"`
+	ReviewsToday            int     `json:"reviews_today"`
+	RetentionRate           float64 `json:"retention_rate"`
+	AvgAnswerTimeMS         int64   `json:"avg_answer_time_ms"`         // Mean duration_ms over all timed reviews; 0 when none were timed
+	StudyStreakDays         int     `json:"study_streak_days"`          // Consecutive days with reviews, through today or yesterday
+	NewCardsIntroducedToday int     `json:"new_cards_introduced_today"` // Cards whose first review was today, counted toward NewCardsPerDay
 }
 
 // CardResponse represents the response structure for get_due_card
 type CardResponse struct {
-	Card  Card      `json:"card"`
-	Stats CardStats `json:"stats"`
+	Card      Card             `json:"card"`
+	Stats     CardStats        `json:"stats"`
+	Direction string           `json:"direction,omitempty"`      // Set in mixed_direction mode; "reverse" means front and back are swapped
+	Siblings  []string         `json:"sibling_fronts,omitempty"` // Set by include_siblings; fronts of cards sharing all of this card's tags
+	Reviews   []storage.Review `json:"reviews,omitempty"`        // Set by get_card's include_reviews, oldest first
 }
 
 // ReviewResponse represents the response structure for submit_review
@@ -43,13 +99,54 @@ type ReviewResponse struct {
 
 // CreateCardResponse represents the response structure for create_card
 type CreateCardResponse struct {
-	Card storage.Card `json:"card"`
+	Card     storage.Card `json:"card"`
+	Warnings []string     `json:"warnings,omitempty"`
+}
+
+// CreateCardsResponse represents the response structure for create_cards
+type CreateCardsResponse struct {
+	Created  int               `json:"created"`
+	CardIDs  []string          `json:"card_ids"` // One per input card, in order; empty for cards listed in Errors
+	Errors   []CreateCardError `json:"errors,omitempty"`
+	Warnings []string          `json:"warnings,omitempty"`
+}
+
+// CreateCardError explains why one input card of create_cards wasn't created
+type CreateCardError struct {
+	Index int    `json:"index"` // Zero-based position in the cards array
+	Error string `json:"error"`
+}
+
+// ReviewInput is one review of a bulk_review batch
+type ReviewInput struct {
+	CardID    string
+	Rating    gofsrs.Rating
+	Answer    string
+	Timestamp time.Time // Zero means the time of the batch
+}
+
+// BulkReviewResponse represents the response structure for bulk_review
+type BulkReviewResponse struct {
+	Reviewed int                `json:"reviewed"`
+	Failed   int                `json:"failed"`
+	Results  []BulkReviewResult `json:"results"` // One per input review, in order
+}
+
+// BulkReviewResult is the outcome of one review of a bulk_review batch
+type BulkReviewResult struct {
+	Index   int        `json:"index"` // Zero-based position in the reviews array
+	CardID  string     `json:"card_id"`
+	Success bool       `json:"success"`
+	Due     *time.Time `json:"due,omitempty"`   // The card's next due time after this review
+	State   string     `json:"state,omitempty"` // The card's FSRS state after this review
+	Error   string     `json:"error,omitempty"`
 }
 
 // UpdateCardResponse represents the response structure for update_card
 type UpdateCardResponse struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success  bool     `json:"success"`
+	Message  string   `json:"message"`
+	Warnings []string `json:"warnings,omitempty"`
 }
 
 // DeleteCardResponse represents the response structure for delete_card
@@ -60,17 +157,20 @@ type DeleteCardResponse struct {
 
 // ListCardsResponse represents the response structure for list_cards
 type ListCardsResponse struct {
-	Cards []Card    `json:"cards"`
-	Stats CardStats `json:"stats,omitempty"`
+	Cards      []Card    `json:"cards"`
+	TotalCount int       `json:"total_count"` // Cards matching the filters, across all pages
+	HasMore    bool      `json:"has_more"`    // True when cards remain past this page
+	Stats      CardStats `json:"stats,omitempty"`
 }
 
 // AnalyzeLearningResponse represents the response structure for help_analyze_learning
 type AnalyzeLearningResponse struct {
 	LowScoringCards []struct {
-		Card        Card         `json:"card"`
-		Reviews     []CardReview `json:"reviews"`
-		AvgRating   float64      `json:"avg_rating"`
-		ReviewCount int          `json:"review_count"`
+		Card            Card         `json:"card"`
+		Reviews         []CardReview `json:"reviews"`
+		AvgRating       float64      `json:"avg_rating"`
+		ReviewCount     int          `json:"review_count"`
+		AvgAnswerTimeMS int64        `json:"avg_answer_time_ms,omitempty"` // Mean duration_ms over timed reviews; 0 when none were timed
 	} `json:"low_scoring_cards"`
 	CommonTags   []string  `json:"common_tags"`
 	TotalReviews int       `json:"total_reviews"`
@@ -83,3 +183,676 @@ type CardReview struct {
 	Timestamp time.Time `json:"timestamp"`
 	Answer    string    `json:"answer,omitempty"`
 }
+
+// CleanupChange is one change made, or proposed, by cleanup_collection
+type CleanupChange struct {
+	Action     string   `json:"action"` // One of the Cleanup* actions
+	CardID     string   `json:"card_id"`
+	Front      string   `json:"front"`
+	OldTags    []string `json:"old_tags,omitempty"`     // For "retagged"
+	NewTags    []string `json:"new_tags,omitempty"`     // For "retagged"
+	IntoCardID string   `json:"into_card_id,omitempty"` // For "merged": the card that absorbed this one
+}
+
+// CleanupReport represents the response structure for cleanup_collection
+type CleanupReport struct {
+	DryRun       bool            `json:"dry_run"`
+	Removed      int             `json:"removed"`
+	Retagged     int             `json:"retagged"`
+	Merged       int             `json:"merged"`
+	ReviewsMoved int             `json:"reviews_moved"` // Reviews moved from merged cards to the card they were merged into
+	Changes      []CleanupChange `json:"changes"`
+}
+
+// AutosuspendResponse represents the response structure for autosuspend_stale
+type AutosuspendResponse struct {
+	Days      float64  `json:"days"`
+	DryRun    bool     `json:"dry_run"`
+	Suspended int      `json:"suspended"` // Cards suspended, or that would be in a dry run
+	CardIDs   []string `json:"card_ids"`
+}
+
+// ClockAnomaly describes a card whose due date is implausible given its history
+type ClockAnomaly struct {
+	CardID    string    `json:"card_id"`
+	Front     string    `json:"front"`
+	Due       time.Time `json:"due"`
+	Reason    string    `json:"reason"`    // One of the ClockAnomaly* reasons
+	Reference time.Time `json:"reference"` // The creation, review or activity time the due date was checked against
+}
+
+// DueDateRepair describes a card whose FSRS due date was invalid and how it was fixed
+type DueDateRepair struct {
+	CardID string    `json:"card_id"`
+	Front  string    `json:"front"`
+	OldDue time.Time `json:"old_due"`
+	NewDue time.Time `json:"new_due"`
+	Method string    `json:"method"` // "reset" for unreviewed cards, "replayed" when recomputed from reviews
+}
+
+// RepairDueDatesResponse represents the response structure for repair_due_dates
+type RepairDueDatesResponse struct {
+	DryRun  bool            `json:"dry_run"`
+	Repairs []DueDateRepair `json:"repairs"`
+}
+
+// HintResponse represents the response structure for get_hint.
+// It deliberately omits the back of the card.
+type HintResponse struct {
+	CardID string `json:"card_id"`
+	Front  string `json:"front"`
+	Hint   string `json:"hint"`
+}
+
+// AnswerCheck represents the response structure for check_answer
+type AnswerCheck struct {
+	CardID         string `json:"card_id"`
+	Answer         string `json:"answer"`
+	Expected       string `json:"expected"`
+	Correct        bool   `json:"correct"`
+	FoldDiacritics bool   `json:"fold_diacritics"` // Whether accents were ignored in the comparison
+}
+
+// CardDueInfo pairs a card with its upcoming schedule for list_cards_by_due
+type CardDueInfo struct {
+	Card         Card      `json:"card"`
+	Due          time.Time `json:"due"`
+	IntervalDays uint64    `json:"interval_days"`  // Current FSRS interval (scheduled days)
+	DaysUntilDue float64   `json:"days_until_due"` // Negative when the card is overdue
+}
+
+// FlaggedCard is a card the student flagged for teacher review
+type FlaggedCard struct {
+	Card      Card      `json:"card"`
+	Reason    string    `json:"reason,omitempty"`
+	FlaggedAt time.Time `json:"flagged_at"`
+}
+
+// ListFlaggedCardsResponse represents the response structure for list_flagged_cards
+type ListFlaggedCardsResponse struct {
+	Cards []FlaggedCard `json:"cards"`
+}
+
+// ListCardsByDueResponse represents the response structure for list_cards_by_due
+type ListCardsByDueResponse struct {
+	Cards []CardDueInfo `json:"cards"`
+}
+
+// ListLongIntervalCardsResponse represents the response structure for list_long_interval_cards
+type ListLongIntervalCardsResponse struct {
+	ThresholdDays float64       `json:"threshold_days"`
+	Cards         []CardDueInfo `json:"cards"`
+}
+
+// SimulateDueResponse represents the response structure for simulate_due
+type SimulateDueResponse struct {
+	At    time.Time `json:"at"`
+	Count int       `json:"count"`
+	Cards []Card    `json:"cards"`
+}
+
+// SlowCard is a card whose answers take longer than the slow_cards threshold
+type SlowCard struct {
+	Card         Card  `json:"card"`
+	AvgAnswerMS  int64 `json:"avg_answer_ms"`
+	TimedReviews int   `json:"timed_reviews"` // Reviews that reported a duration
+}
+
+// ReviewDay is the number of reviews done, or expected, on one day
+type ReviewDay struct {
+	Date    string `json:"date"` // YYYY-MM-DD
+	Reviews int    `json:"reviews"`
+}
+
+// ProjectedReviews represents the response structure for projected_reviews
+type ProjectedReviews struct {
+	Total int         `json:"total"`
+	Days  []ReviewDay `json:"days"` // Starting today
+}
+
+// DayLoad is the number of cards due on one day
+type DayLoad struct {
+	Date  string `json:"date"` // YYYY-MM-DD
+	Cards int    `json:"cards"`
+}
+
+// LevelWorkloadResponse represents the response structure for level_workload
+type LevelWorkloadResponse struct {
+	DailyCap    int       `json:"daily_cap"`
+	Overdue     int       `json:"overdue"`     // Overdue cards found before leveling
+	Rescheduled int       `json:"rescheduled"` // Overdue cards moved to a later day
+	Schedule    []DayLoad `json:"schedule"`    // Resulting load from today through the last day used
+}
+
+// SearchByAnswerResponse represents the response structure for search_by_answer
+type SearchByAnswerResponse struct {
+	Query string `json:"query"`
+	Cards []Card `json:"cards"`
+}
+
+// SearchResult is one card found by search_cards
+type SearchResult struct {
+	Card       Card `json:"card"`
+	MatchCount int  `json:"match_count"` // Occurrences of the query across front and back
+}
+
+// SearchCardsResponse represents the response structure for search_cards
+type SearchCardsResponse struct {
+	Query string         `json:"query"`
+	Cards []SearchResult `json:"cards"` // Most relevant first
+}
+
+// PracticeResponse represents the response structure for practice
+type PracticeResponse struct {
+	Tag   string `json:"tag"`
+	Cards []Card `json:"cards"` // In random order
+}
+
+// ReloadStorageResponse represents the response structure for reload_storage
+type ReloadStorageResponse struct {
+	Cards    int `json:"cards"`
+	Reviews  int `json:"reviews"`
+	DueDates int `json:"due_dates"`
+}
+
+// ClearSessionResponse represents the response structure for clear_session
+type ClearSessionResponse struct {
+	Cleared int `json:"cleared"` // Lapsed cards removed from the session queue
+}
+
+// RescheduleCardResponse represents the response structure for reschedule_card
+type RescheduleCardResponse struct {
+	CardID      string    `json:"card_id"`
+	PreviousDue time.Time `json:"previous_due"`
+	Due         time.Time `json:"due"`
+}
+
+// BulkRescheduleResponse represents the response structure for bulk_reschedule
+type BulkRescheduleResponse struct {
+	Due         time.Time `json:"due"`
+	Rescheduled int       `json:"rescheduled"`
+	CardIDs     []string  `json:"card_ids"`
+}
+
+// ApplyTagsResponse represents the response structure for apply_tags_to_cards
+type ApplyTagsResponse struct {
+	Tags    []string `json:"tags"`
+	Updated int      `json:"updated"`  // Cards that gained at least one tag
+	CardIDs []string `json:"card_ids"` // Every card the tags now apply to
+}
+
+// AssignToDueDateResponse represents the response structure for assign_to_due_date
+type AssignToDueDateResponse struct {
+	DueDateID string               `json:"due_date_id"`
+	Tag       string               `json:"tag"`
+	Matched   int                  `json:"matched"`  // Cards matching the query and tags
+	Assigned  int                  `json:"assigned"` // Matching cards that didn't have the tag yet
+	CardIDs   []string             `json:"card_ids"` // The newly assigned cards
+	Progress  DueDateProgressStats `json:"progress"`
+}
+
+// CardReviewCount pairs a card with the number of reviews recorded for it
+type CardReviewCount struct {
+	Card        Card `json:"card"`
+	ReviewCount int  `json:"review_count"`
+}
+
+// ListCardsByReviewCountResponse represents the response structure for list_cards_by_review_count
+type ListCardsByReviewCountResponse struct {
+	Cards []CardReviewCount `json:"cards"`
+}
+
+// SnapshotInfo summarizes a stored snapshot without its card contents
+type SnapshotInfo struct {
+	ID          string    `json:"id"`
+	Label       string    `json:"label,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	CardCount   int       `json:"card_count"`
+	ReviewCount int       `json:"review_count"`
+}
+
+// ModifiedCard describes a card whose content changed since a snapshot
+type ModifiedCard struct {
+	Card          Card     `json:"card"`
+	ChangedFields []string `json:"changed_fields"` // Any of front, back, tags, hint
+}
+
+// SnapshotDiff represents the response structure for diff_snapshot
+type SnapshotDiff struct {
+	Snapshot      SnapshotInfo     `json:"snapshot"`
+	AddedCards    []Card           `json:"added_cards"`
+	RemovedCards  []Card           `json:"removed_cards"`
+	ModifiedCards []ModifiedCard   `json:"modified_cards"`
+	NewReviews    []storage.Review `json:"new_reviews"`
+}
+
+// UpcomingDueDate is a due date countdown entry for upcoming_due_dates
+type UpcomingDueDate struct {
+	ID              string  `json:"id"`
+	Topic           string  `json:"topic"`
+	DueDate         string  `json:"due_date"` // YYYY-MM-DD format
+	Tag             string  `json:"tag"`
+	DaysUntil       int     `json:"days_until"` // Calendar days from today; 0 means today
+	Countdown       string  `json:"countdown"`  // Human-readable, e.g. "in 3 days"
+	ProgressPercent float64 `json:"progress_percent"`
+}
+
+// MaturityBreakdown represents the response structure for get_maturity_breakdown
+type MaturityBreakdown struct {
+	Total  int `json:"total"`
+	New    int `json:"new"`
+	Young  int `json:"young"`
+	Mature int `json:"mature"`
+}
+
+// Statistics represents the response structure for get_statistics
+type Statistics struct {
+	TotalCards          int               `json:"total_cards"`
+	DueCards            int               `json:"due_cards"`      // Active cards due now
+	NewCards            int               `json:"new_cards"`      // Active cards never reviewed
+	LearningCards       int               `json:"learning_cards"` // Active cards in the Learning or Relearning state
+	ReviewCards         int               `json:"review_cards"`   // Active cards in the Review state
+	SuspendedCards      int               `json:"suspended_cards"`
+	ArchivedCards       int               `json:"archived_cards"`
+	CardsByState        map[string]int    `json:"cards_by_state"`        // Active cards per FSRS state: new, learning, review, relearning
+	AverageIntervalDays float64           `json:"average_interval_days"` // Mean scheduled interval of active cards in the Review state
+	TotalReviews        int               `json:"total_reviews"`
+	Retention           []RetentionWindow `json:"retention"` // One per requested window, then all time
+}
+
+// RetentionWindow is the share of correct (Good or Easy) reviews within a window
+type RetentionWindow struct {
+	Window        string  `json:"window"` // "today", e.g. "7d" for the last 7 days including today, or "all_time"
+	Days          int     `json:"days"`   // Days covered; 0 for all time
+	Reviews       int     `json:"reviews"`
+	Correct       int     `json:"correct"`
+	RetentionRate float64 `json:"retention_rate"` // Percent of reviews rated Good or Easy; 0 without reviews
+}
+
+// TagStateSummary represents the response structure for tag_state_summary. Each
+// state field is the fraction (0-1) of the tag's cards in that FSRS state.
+type TagStateSummary struct {
+	Tag        string  `json:"tag"`
+	CardCount  int     `json:"card_count"`
+	New        float64 `json:"new"`
+	Learning   float64 `json:"learning"`
+	Review     float64 `json:"review"`
+	Relearning float64 `json:"relearning"`
+}
+
+// ForgettingCurvePoint is the predicted recall probability at one moment
+type ForgettingCurvePoint struct {
+	Time           time.Time `json:"time"`
+	DaysFromNow    float64   `json:"days_from_now"`
+	Retrievability float64   `json:"retrievability"` // 0-1
+}
+
+// DecayDay is the number of mastered cards below the recall threshold on one day
+type DecayDay struct {
+	Date   string `json:"date"` // YYYY-MM-DD
+	AtRisk int    `json:"at_risk"`
+}
+
+// DecayForecast represents the response structure for decay_forecast
+type DecayForecast struct {
+	Threshold float64    `json:"threshold"`
+	Mastered  int        `json:"mastered"` // Mastered cards today
+	Days      []DecayDay `json:"days"`     // Starting tomorrow
+}
+
+// ForgettingCurve represents the response structure for forgetting_curve
+type ForgettingCurve struct {
+	CardID     string                 `json:"card_id"`
+	Stability  float64                `json:"stability"` // FSRS stability in days
+	LastReview time.Time              `json:"last_review"`
+	Due        time.Time              `json:"due"`
+	Points     []ForgettingCurvePoint `json:"points"`
+}
+
+// AutotagByDifficultyResponse represents the response structure for autotag_by_difficulty
+type AutotagByDifficultyResponse struct {
+	UpdatedCards int            `json:"updated_cards"` // Cards whose tags changed
+	Bands        map[string]int `json:"bands"`         // Band tag -> number of cards now carrying it
+}
+
+// FSRSConfigResponse represents the response structure for get_fsrs_config and set_fsrs_config
+type FSRSConfigResponse struct {
+	RequestRetention float64   `json:"request_retention"`
+	MaximumInterval  float64   `json:"maximum_interval"` // Days
+	Weights          []float64 `json:"weights"`
+	Defaults         bool      `json:"defaults"` // True when the go-fsrs defaults are in use
+}
+
+// OptimizeFSRSResponse represents the response structure for optimize_fsrs
+type OptimizeFSRSResponse struct {
+	Reviews          int       `json:"reviews"`           // Review records the weights were fit to
+	PredictedReviews int       `json:"predicted_reviews"` // Reviews of cards in the Review state, which the log-loss covers
+	OldLogLoss       float64   `json:"old_log_loss"`
+	NewLogLoss       float64   `json:"new_log_loss"`
+	Weights          []float64 `json:"weights"` // The fitted weights
+	Saved            bool      `json:"saved"`   // False when the fit didn't improve on the current weights
+}
+
+// ImportCard is one entry of an import_cards request
+type ImportCard struct {
+	Front    string          `json:"front"`
+	Back     string          `json:"back"`
+	Tags     []string        `json:"tags,omitempty"`
+	Hint     string          `json:"hint,omitempty"`
+	Schedule *ImportSchedule `json:"schedule,omitempty"` // Restored FSRS scheduling; nil imports the card as New
+}
+
+// ImportSchedule is the FSRS scheduling restored for an imported card, as written
+// by export_cards with include_scheduling.
+type ImportSchedule struct {
+	State      gofsrs.State `json:"state"`
+	Due        time.Time    `json:"due"`
+	Stability  float64      `json:"stability"`
+	Difficulty float64      `json:"difficulty"`
+	LastReview time.Time    `json:"last_review,omitempty"` // Zero when the export predates the last_review column
+}
+
+// ImportRowError describes a CSV row that import_cards left out.
+type ImportRowError struct {
+	Row   int    `json:"row"` // Counting the header as row 1, as spreadsheets do
+	Error string `json:"error"`
+}
+
+// ImportCardsResponse represents the response structure for import_cards
+type ImportCardsResponse struct {
+	Imported int              `json:"imported"`
+	CardIDs  []string         `json:"card_ids"`
+	Errors   []ImportRowError `json:"errors,omitempty"` // Rows of a CSV import that weren't imported
+}
+
+// ImportAnkiResponse represents the response structure for import_anki
+type ImportAnkiResponse struct {
+	Imported     int      `json:"imported"`
+	Skipped      int      `json:"skipped"`       // Notes of unsupported types (cloze, image occlusion)
+	SkippedBlank int      `json:"skipped_blank"` // Notes with an empty front or back once HTML and media are removed
+	Decks        []string `json:"decks"`         // Tags given to the imported cards, one per Anki deck
+	CardIDs      []string `json:"card_ids"`
+}
+
+// SessionCard is a card reviewed within a session window, with the ratings given in that window.
+type SessionCard struct {
+	Card          Card            `json:"card"`
+	Ratings       []gofsrs.Rating `json:"ratings"` // In review order
+	FirstReviewed time.Time       `json:"first_reviewed"`
+	LastReviewed  time.Time       `json:"last_reviewed"`
+}
+
+// EffortReport represents the response structure for effort_report
+type EffortReport struct {
+	Start         time.Time `json:"start"`
+	End           time.Time `json:"end"`
+	Cards         int       `json:"cards"` // Distinct cards reviewed
+	Reviews       int       `json:"reviews"`
+	Answered      int       `json:"answered"` // Reviews with a typed answer
+	AnsweredRatio float64   `json:"answered_ratio"`
+	TimeSpentMS   int64     `json:"time_spent_ms"` // Sum of reported answer durations
+	TimedReviews  int       `json:"timed_reviews"` // Reviews that reported a duration
+	EffortScore   int       `json:"effort_score"`  // 0-100
+}
+
+// TagUsage is a tag with the number of cards carrying it
+type TagUsage struct {
+	Tag       string   `json:"tag"`
+	CardCount int      `json:"card_count"`
+	CardIDs   []string `json:"card_ids"`
+}
+
+// TagMergeSuggestion proposes folding a probably misspelled tag into a similar one
+type TagMergeSuggestion struct {
+	From      string `json:"from"`
+	Into      string `json:"into"`
+	Distance  int    `json:"distance"` // Edit distance between the two tags
+	FromCards int    `json:"from_cards"`
+	IntoCards int    `json:"into_cards"`
+}
+
+// TagMergesResponse represents the response structure for suggest_tag_merges
+type TagMergesResponse struct {
+	Suggestions  []TagMergeSuggestion `json:"suggestions"`
+	Applied      bool                 `json:"applied"`
+	CardsUpdated int                  `json:"cards_updated"`
+}
+
+// SessionTimeEstimate represents the response structure for estimate_session_time
+type SessionTimeEstimate struct {
+	DueCards         int     `json:"due_cards"`
+	SecondsPerCard   float64 `json:"seconds_per_card"`
+	EstimatedMinutes float64 `json:"estimated_minutes"`
+}
+
+// ExportCardsResponse represents the response structure for export_cards
+type ExportCardsResponse struct {
+	ExportedAt time.Time `json:"exported_at"`
+	Cards      []Card    `json:"cards"`
+}
+
+// GenerateReverseCardsResponse represents the response structure for generate_reverse_cards
+type GenerateReverseCardsResponse struct {
+	Created int      `json:"created"`
+	Skipped int      `json:"skipped"` // Cards that are already a reverse or already have one
+	CardIDs []string `json:"card_ids"`
+}
+
+// FullExport represents the payload of export_full and import_full
+type FullExport struct {
+	SchemaVersion int                    `json:"schema_version"`
+	ExportedAt    time.Time              `json:"exported_at"`
+	Store         storage.FlashcardStore `json:"store"`
+}
+
+// LeechesResponse represents the content of the leeches resource
+type LeechesResponse struct {
+	Threshold   int         `json:"threshold"`    // Lapses at which a card becomes a leech
+	AutoSuspend bool        `json:"auto_suspend"` // Whether new leeches are suspended automatically
+	Leeches     []LeechCard `json:"leeches"`      // Most lapses first
+	Suggestion  string      `json:"suggestion,omitempty"`
+}
+
+// LeechCard is a card that keeps being forgotten
+type LeechCard struct {
+	ID          string    `json:"id"`
+	Front       string    `json:"front"`
+	Lapses      uint64    `json:"lapses"`
+	Suspended   bool      `json:"suspended"`
+	LastLapseAt time.Time `json:"last_lapse_at,omitempty"` // Latest review rated Again in the Review state
+}
+
+// RestoreBackupResponse represents the response structure for restore_backup
+type RestoreBackupResponse struct {
+	Restored string           `json:"restored,omitempty"` // Name of the backup restored, if one was chosen
+	Message  string           `json:"message"`
+	Backups  []storage.Backup `json:"backups"` // Available backups, newest first
+}
+
+// ReadinessBreakdown shows the components that make up a readiness score. Each
+// component is in the range 0-1.
+type ReadinessBreakdown struct {
+	MasteredFraction float64 `json:"mastered_fraction"` // Share of cards counted as mastered (see the mastery_mode setting)
+	AverageRecall    float64 `json:"average_recall"`    // Mean FSRS recall probability of the cards not yet mastered
+	PaceScore        float64 `json:"pace_score"`        // Recent reviews per day relative to the pace still required
+	DaysRemaining    float64 `json:"days_remaining"`
+	CardsLeft        int     `json:"cards_left"`
+	RequiredPace     float64 `json:"required_pace"` // Cards per day needed
+	RecentPace       float64 `json:"recent_pace"`   // Reviews per day of these cards over the last week
+}
+
+// BurstScheduleResponse represents the response structure for burst_schedule
+type BurstScheduleResponse struct {
+	DueDateID   string   `json:"due_date_id"`
+	Tag         string   `json:"tag"`
+	Days        int      `json:"days"`        // Days the cards were spread over; 1 means all due now
+	Rescheduled int      `json:"rescheduled"` // Unmastered cards brought forward
+	AlreadyDue  int      `json:"already_due"` // Unmastered cards already due by their slot
+	Mastered    int      `json:"mastered"`    // Cards left alone because they are mastered
+	CardIDs     []string `json:"card_ids"`    // The rescheduled cards
+}
+
+// DueDateSuggestion represents the response structure for suggest_due_date
+type DueDateSuggestion struct {
+	Tag           string  `json:"tag"`
+	Cards         int     `json:"cards"`
+	Mastered      int     `json:"mastered"`
+	Remaining     int     `json:"remaining"`
+	CardsPerDay   float64 `json:"cards_per_day"`
+	StudyDays     int     `json:"study_days"`     // Days needed at this pace, starting today
+	SuggestedDate string  `json:"suggested_date"` // YYYY-MM-DD, the day after the last study day
+}
+
+// DueDateCoverage represents the response structure for due_date_coverage
+type DueDateCoverage struct {
+	DueDateID        string  `json:"due_date_id"`
+	Tag              string  `json:"tag"`           // The due date's tag
+	ReferenceTag     string  `json:"reference_tag"` // e.g. the tag for the whole unit
+	ReferenceCards   int     `json:"reference_cards"`
+	Covered          int     `json:"covered"`           // Reference cards that also carry the due date's tag
+	CoverageFraction float64 `json:"coverage_fraction"` // 0-1
+	Missing          []Card  `json:"missing"`           // Reference cards without the due date's tag
+}
+
+// DueDateReadiness represents the response structure for due_date_readiness
+type DueDateReadiness struct {
+	DueDateID string             `json:"due_date_id"`
+	Topic     string             `json:"topic"`
+	Tag       string             `json:"tag"`
+	Score     float64            `json:"score"` // 0-100
+	Breakdown ReadinessBreakdown `json:"breakdown"`
+}
+
+// RatingDistribution counts reviews by rating for the cards carrying a tag
+type RatingDistribution struct {
+	Tag          string `json:"tag"`
+	CardCount    int    `json:"card_count"`
+	TotalReviews int    `json:"total_reviews"`
+	Again        int    `json:"again"`
+	Hard         int    `json:"hard"`
+	Good         int    `json:"good"`
+	Easy         int    `json:"easy"`
+}
+
+// TagStreak reports the longest run of consecutive study days for a tag
+type TagStreak struct {
+	Tag           string `json:"tag"`
+	LongestStreak int    `json:"longest_streak_days"`
+	StreakStart   string `json:"streak_start"` // YYYY-MM-DD
+	StreakEnd     string `json:"streak_end"`   // YYYY-MM-DD
+}
+
+// StudyStreak reports consecutive study days, for the study-streak resource
+type StudyStreak struct {
+	CurrentStreak int    `json:"current_streak"` // Days in the run ending today or yesterday; 0 once a day is missed
+	LongestStreak int    `json:"longest_streak"`
+	LastStudyDate string `json:"last_study_date,omitempty"` // YYYY-MM-DD; omitted before the first review
+}
+
+// TagStreaksResponse represents the response structure for tag_streaks
+type TagStreaksResponse struct {
+	Tags []TagStreak `json:"tags"`
+}
+
+// RegressionAlert describes a card that lapsed after being remembered for a long time
+type RegressionAlert struct {
+	CardID         string        `json:"card_id"`
+	Front          string        `json:"front"`
+	Tags           []string      `json:"tags,omitempty"`
+	PreviousRating gofsrs.Rating `json:"previous_rating"` // Good or Easy
+	PreviousReview time.Time     `json:"previous_review"`
+	LapsedAt       time.Time     `json:"lapsed_at"`
+	IntervalDays   float64       `json:"interval_days"` // Days between the previous review and the lapse
+	TotalReviews   int           `json:"total_reviews"`
+}
+
+// RegressionAlertsResponse represents the response structure for regression_alerts
+type RegressionAlertsResponse struct {
+	Cards []RegressionAlert `json:"cards"`
+}
+
+// TagCloudEntry is one tag in the tag cloud, weighted by size and due urgency
+type TagCloudEntry struct {
+	Tag          string  `json:"tag"`
+	Cards        int     `json:"cards"`
+	OverdueCards int     `json:"overdue_cards"`
+	OverdueDays  float64 `json:"overdue_days"` // Sum of days overdue across the tag's cards
+	Weight       float64 `json:"weight"`       // Cards + OverdueDays
+}
+
+// TagCloudResponse represents the response structure for tag_cloud
+type TagCloudResponse struct {
+	Tags []TagCloudEntry `json:"tags"`
+}
+
+// IntervalAdherence represents the response structure for interval_adherence.
+// A positive delay means a card was reviewed later than scheduled.
+type IntervalAdherence struct {
+	ReviewsMeasured          int     `json:"reviews_measured"`
+	AverageDelayDays         float64 `json:"average_delay_days"`          // Mean of actual minus scheduled days
+	AverageAbsoluteDelayDays float64 `json:"average_absolute_delay_days"` // Mean distance from the schedule, early or late
+	EarlyReviews             int     `json:"early_reviews"`
+	OnTimeReviews            int     `json:"on_time_reviews"`
+	LateReviews              int     `json:"late_reviews"`
+}
+
+// ReservedTagConflict lists a card's reserved "test-" tags that no due date backs
+type ReservedTagConflict struct {
+	CardID string   `json:"card_id"`
+	Front  string   `json:"front"`
+	Tags   []string `json:"tags"`
+}
+
+// ReservedTagConflictsResponse represents the response structure for reserved_tag_conflicts
+type ReservedTagConflictsResponse struct {
+	Conflicts []ReservedTagConflict `json:"conflicts"`
+}
+
+// ReviewHeatmap is the content of the review-heatmap resource
+type ReviewHeatmap struct {
+	Total  int         `json:"total"`
+	MaxDay int         `json:"max_day"` // Most reviews on any single day, for scaling colors
+	Days   []ReviewDay `json:"days"`    // Oldest first, ending today
+}
+
+// ForecastDay counts the cards falling due on one day, for the review-forecast resource
+type ForecastDay struct {
+	Date     string `json:"date"`      // YYYY-MM-DD
+	DueCount int    `json:"due_count"` // Cards already studied; today includes everything overdue
+	NewCount int    `json:"new_count"` // New cards, never reviewed
+}
+
+// StateHistoryDay counts cards by FSRS state at the end of one day, for the state-history resource
+type StateHistoryDay struct {
+	Date       string `json:"date"` // YYYY-MM-DD
+	New        int    `json:"new"`
+	Learning   int    `json:"learning"`
+	Review     int    `json:"review"`
+	Relearning int    `json:"relearning"`
+}
+
+// StudySession is a run of reviews with no idle gap longer than the session threshold
+type StudySession struct {
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationMinutes float64   `json:"duration_minutes"`
+	Cards           int       `json:"cards"` // Distinct cards reviewed
+	Reviews         int       `json:"reviews"`
+}
+
+// SessionAnalytics represents the response structure for session_analytics
+type SessionAnalytics struct {
+	IdleMinutes              float64        `json:"idle_minutes"`
+	SessionCount             int            `json:"session_count"`
+	AverageDurationMinutes   float64        `json:"average_duration_minutes"`
+	AverageCardsPerSession   float64        `json:"average_cards_per_session"`
+	AverageReviewsPerSession float64        `json:"average_reviews_per_session"`
+	Sessions                 []StudySession `json:"sessions"`
+}
+
+// CardHistoryResponse represents the content of the card-history resource and the get_card_history tool
+type CardHistoryResponse struct {
+	Entries    []storage.AuditEntry `json:"entries"`     // Newest first
+	Count      int                  `json:"count"`       // Entries returned
+	MaxEntries int                  `json:"max_entries"` // Entries kept before the oldest are dropped
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -189,3 +190,513 @@ func TestPastDueDatesResource(t *testing.T) {
 	require.Len(t, progressInfos, 1, "Expected 1 progress info (only future due date)")
 	assert.Equal(t, futureDueDate.ID, progressInfos[0].ID, "Progress info should be for the future due date")
 }
+
+// TestDueDateProgressResourceMatureCards tests the mature card count next to mastered cards
+func TestDueDateProgressResourceMatureCards(t *testing.T) {
+	service, _ := setupTestService(t)
+	require.NoError(t, service.AddDueDate(storage.DueDate{
+		ID:      "final",
+		Topic:   "Final exam",
+		DueDate: time.Now().AddDate(0, 0, 30),
+		Tag:     "test-final",
+	}))
+
+	// Intervals of 30 and 21 days are mature; 20 days is still young, and a new card is neither
+	for i, scheduledDays := range []uint64{30, 21, 20} {
+		card := createCardDirectly(t, service, fmt.Sprintf("Card %d", i), "A", []string{"test-final"})
+		stored, err := service.Storage.GetCard(card.ID)
+		require.NoError(t, err)
+		stored.FSRS.State = gofsrs.Review
+		stored.FSRS.ScheduledDays = scheduledDays
+		updateCardDirectly(t, service, stored)
+	}
+	createCardDirectly(t, service, "New card", "A", []string{"test-final"})
+
+	ctx := context.WithValue(context.Background(), "service", service)
+	contents, err := handleDueDateProgressResource(ctx, mcp.ReadResourceRequest{})
+	require.NoError(t, err)
+	require.Len(t, contents, 1)
+	textContent, ok := contents[0].(mcp.TextResourceContents)
+	require.True(t, ok)
+
+	var progressInfos []DueDateProgressInfo
+	require.NoError(t, json.Unmarshal([]byte(textContent.Text), &progressInfos))
+	require.Len(t, progressInfos, 1)
+	assert.Equal(t, 4, progressInfos[0].TotalCards)
+	assert.Equal(t, 2, progressInfos[0].MatureCards)
+	assert.Equal(t, 0, progressInfos[0].MasteredCards, "Maturity is reported separately from mastery")
+}
+
+// TestStateHistoryResource tests that a reviewed card's state transitions land on the right days
+func TestStateHistoryResource(t *testing.T) {
+	service, _ := setupTestService(t)
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 10, 0, 0, 0, time.Local) }
+	defer mockTimeNow(day(7))()
+
+	card := createCardDirectly(t, service, "Q", "A", nil)
+	stored, err := service.Storage.GetCard(card.ID)
+	require.NoError(t, err)
+	stored.CreatedAt = day(1)
+	updateCardDirectly(t, service, stored)
+
+	_, err = service.SubmitReviewWithTime(card.ID, gofsrs.Good, "", day(2)) // New -> Learning
+	require.NoError(t, err)
+	_, err = service.SubmitReviewWithTime(card.ID, gofsrs.Good, "", day(4)) // Learning -> Review
+	require.NoError(t, err)
+	_, err = service.SubmitReviewWithTime(card.ID, gofsrs.Again, "", day(6)) // Review -> Relearning
+	require.NoError(t, err)
+
+	ctx := context.WithValue(context.Background(), "service", service)
+	contents, err := handleStateHistoryResource(ctx, mcp.ReadResourceRequest{})
+	require.NoError(t, err)
+	require.Len(t, contents, 1)
+	text, ok := contents[0].(mcp.TextResourceContents)
+	require.True(t, ok)
+	assert.Equal(t, "state-history", text.URI)
+
+	var history []StateHistoryDay
+	require.NoError(t, json.Unmarshal([]byte(text.Text), &history))
+	require.Len(t, history, 7, "One entry per day from creation through today")
+
+	expected := []StateHistoryDay{
+		{Date: "2024-03-01", New: 1},
+		{Date: "2024-03-02", Learning: 1},
+		{Date: "2024-03-03", Learning: 1},
+		{Date: "2024-03-04", Review: 1},
+		{Date: "2024-03-05", Review: 1},
+		{Date: "2024-03-06", Relearning: 1},
+		{Date: "2024-03-07", Relearning: 1},
+	}
+	assert.Equal(t, expected, history)
+}
+
+// TestReviewHeatmapResource tests that reviews are counted on the days they happened
+func TestReviewHeatmapResource(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Date(2024, 12, 31, 18, 0, 0, 0, time.Local)
+	defer mockTimeNow(now)()
+
+	card := createCardDirectly(t, service, "Q1", "A", nil)
+	other := createCardDirectly(t, service, "Q2", "A", nil)
+	addReviewDirectly(t, service, card.ID, gofsrs.Good, time.Date(2024, 3, 5, 9, 0, 0, 0, time.Local))
+	addReviewDirectly(t, service, other.ID, gofsrs.Again, time.Date(2024, 3, 5, 21, 0, 0, 0, time.Local))
+	addReviewDirectly(t, service, card.ID, gofsrs.Easy, time.Date(2024, 3, 5, 23, 30, 0, 0, time.Local))
+	addReviewDirectly(t, service, card.ID, gofsrs.Good, time.Date(2024, 12, 31, 8, 0, 0, 0, time.Local))
+	addReviewDirectly(t, service, card.ID, gofsrs.Good, time.Date(2023, 6, 1, 8, 0, 0, 0, time.Local)) // Older than a year
+
+	ctx := context.WithValue(context.Background(), "service", service)
+	contents, err := handleReviewHeatmapResource(ctx, mcp.ReadResourceRequest{})
+	require.NoError(t, err)
+	require.Len(t, contents, 1)
+	text, ok := contents[0].(mcp.TextResourceContents)
+	require.True(t, ok)
+	assert.Equal(t, "review-heatmap", text.URI)
+
+	var heatmap ReviewHeatmap
+	require.NoError(t, json.Unmarshal([]byte(text.Text), &heatmap))
+	require.Len(t, heatmap.Days, HeatmapDays)
+	assert.Equal(t, "2024-01-02", heatmap.Days[0].Date)
+	assert.Equal(t, "2024-12-31", heatmap.Days[HeatmapDays-1].Date)
+
+	byDate := map[string]int{}
+	for _, day := range heatmap.Days {
+		byDate[day.Date] = day.Reviews
+	}
+	assert.Equal(t, 3, byDate["2024-03-05"])
+	assert.Equal(t, 1, byDate["2024-12-31"])
+	assert.Equal(t, 0, byDate["2024-03-06"])
+	assert.Equal(t, 4, heatmap.Total)
+	assert.Equal(t, 3, heatmap.MaxDay)
+}
+
+// TestReviewForecastResource tests that cards are counted on their due day, with overdue cards today
+func TestReviewForecastResource(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Date(2024, 3, 1, 18, 0, 0, 0, time.Local)
+	defer mockTimeNow(now)()
+
+	overdue := createCardDirectly(t, service, "Overdue", "A", nil)
+	_, err := service.SubmitReviewWithTime(overdue.ID, gofsrs.Good, "A", now.AddDate(0, 0, -10))
+	require.NoError(t, err)
+	setDueDateDirectly(t, service, overdue.ID, now.AddDate(0, 0, -3))
+	tomorrow := createCardDirectly(t, service, "Tomorrow", "A", nil)
+	_, err = service.SubmitReviewWithTime(tomorrow.ID, gofsrs.Good, "A", now.AddDate(0, 0, -1))
+	require.NoError(t, err)
+	setDueDateDirectly(t, service, tomorrow.ID, time.Date(2024, 3, 2, 0, 30, 0, 0, time.Local))
+	newCard := createCardDirectly(t, service, "New", "A", nil)
+	setDueDateDirectly(t, service, newCard.ID, now.AddDate(0, 0, -1))
+	suspended := createCardDirectly(t, service, "Suspended", "A", nil)
+	_, err = service.SuspendCard(suspended.ID, true)
+	require.NoError(t, err)
+	later := createCardDirectly(t, service, "Later", "A", nil)
+	_, err = service.SubmitReviewWithTime(later.ID, gofsrs.Good, "A", now)
+	require.NoError(t, err)
+	setDueDateDirectly(t, service, later.ID, now.AddDate(0, 0, 30)) // Beyond the horizon
+
+	ctx := context.WithValue(context.Background(), "service", service)
+	read := func() []ForecastDay {
+		contents, err := handleReviewForecastResource(ctx, mcp.ReadResourceRequest{})
+		require.NoError(t, err)
+		require.Len(t, contents, 1)
+		text, ok := contents[0].(mcp.TextResourceContents)
+		require.True(t, ok)
+		assert.Equal(t, "review-forecast", text.URI)
+		var forecast []ForecastDay
+		require.NoError(t, json.Unmarshal([]byte(text.Text), &forecast))
+		return forecast
+	}
+
+	forecast := read()
+	require.Len(t, forecast, DefaultForecastDays)
+	assert.Equal(t, ForecastDay{Date: "2024-03-01", DueCount: 1, NewCount: 1}, forecast[0])
+	assert.Equal(t, ForecastDay{Date: "2024-03-02", DueCount: 1}, forecast[1])
+	assert.Equal(t, "2024-03-14", forecast[DefaultForecastDays-1].Date)
+	total := 0
+	for _, day := range forecast {
+		total += day.DueCount + day.NewCount
+	}
+	assert.Equal(t, 3, total, "Suspended cards and cards due after the horizon are left out")
+
+	text, result := callHandlerDirectly(t, ctx, handleSetForecastHorizon, map[string]interface{}{"days": 45.0})
+	require.False(t, result.IsError, text)
+	forecast = read()
+	require.Len(t, forecast, 45)
+	assert.Equal(t, 1, forecast[30].DueCount)
+
+	_, result = callHandlerDirectly(t, ctx, handleSetForecastHorizon, map[string]interface{}{"days": 0.0})
+	assert.True(t, result.IsError)
+	_, result = callHandlerDirectly(t, ctx, handleSetForecastHorizon, map[string]interface{}{"days": 2.5})
+	assert.True(t, result.IsError)
+}
+
+// TestStudyStreakResource tests that streaks count local calendar days and break on a missed day
+func TestStudyStreakResource(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Date(2024, 3, 10, 8, 0, 0, 0, time.Local)
+	defer mockTimeNow(now)()
+
+	ctx := context.WithValue(context.Background(), "service", service)
+	read := func() StudyStreak {
+		contents, err := handleStudyStreakResource(ctx, mcp.ReadResourceRequest{})
+		require.NoError(t, err)
+		require.Len(t, contents, 1)
+		text, ok := contents[0].(mcp.TextResourceContents)
+		require.True(t, ok)
+		assert.Equal(t, "study-streak", text.URI)
+		var streak StudyStreak
+		require.NoError(t, json.Unmarshal([]byte(text.Text), &streak))
+		return streak
+	}
+	assert.Equal(t, StudyStreak{}, read(), "No reviews, no streak")
+
+	card := createCardDirectly(t, service, "Q1", "A", nil)
+	other := createCardDirectly(t, service, "Q2", "A", nil)
+	// A four-day streak from Feb 28 through Mar 2, late at night and just after midnight
+	addReviewDirectly(t, service, card.ID, gofsrs.Good, time.Date(2024, 2, 27, 23, 30, 0, 0, time.Local))
+	addReviewDirectly(t, service, other.ID, gofsrs.Good, time.Date(2024, 2, 28, 0, 30, 0, 0, time.Local))
+	addReviewDirectly(t, service, card.ID, gofsrs.Good, time.Date(2024, 2, 29, 12, 0, 0, 0, time.Local))
+	addReviewDirectly(t, service, card.ID, gofsrs.Again, time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local))
+	addReviewDirectly(t, service, other.ID, gofsrs.Good, time.Date(2024, 3, 1, 21, 0, 0, 0, time.Local))
+	// Then a gap on Mar 2, and two days in a row ending yesterday
+	addReviewDirectly(t, service, card.ID, gofsrs.Good, time.Date(2024, 3, 3, 10, 0, 0, 0, time.Local))
+	addReviewDirectly(t, service, card.ID, gofsrs.Good, time.Date(2024, 3, 8, 10, 0, 0, 0, time.Local))
+	addReviewDirectly(t, service, card.ID, gofsrs.Good, time.Date(2024, 3, 9, 22, 0, 0, 0, time.Local))
+
+	assert.Equal(t, StudyStreak{CurrentStreak: 2, LongestStreak: 4, LastStudyDate: "2024-03-09"}, read(),
+		"Yesterday's streak is still current before today's study")
+
+	addReviewDirectly(t, service, other.ID, gofsrs.Good, now)
+	assert.Equal(t, 3, read().CurrentStreak)
+
+	defer mockTimeNow(now.AddDate(0, 0, 2))()
+	assert.Equal(t, StudyStreak{CurrentStreak: 0, LongestStreak: 4, LastStudyDate: "2024-03-10"}, read(), "A missed day ends the streak")
+}
+
+// TestStudyStreakStats tests that CardStats reports the current study streak
+func TestStudyStreakStats(t *testing.T) {
+	service, _ := setupTestService(t)
+	card := createCardDirectly(t, service, "Q1", "A", nil)
+	now := time.Now()
+	addReviewDirectly(t, service, card.ID, gofsrs.Good, now.AddDate(0, 0, -2))
+	addReviewDirectly(t, service, card.ID, gofsrs.Good, now.AddDate(0, 0, -1))
+	addReviewDirectly(t, service, card.ID, gofsrs.Good, now)
+
+	_, stats, err := service.ListCards(nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.StudyStreakDays)
+}
+
+// TestLeechesResource tests that cards are marked leeches at the lapse threshold and listed with their last lapse
+func TestLeechesResource(t *testing.T) {
+	service, _ := setupTestService(t)
+	service.LeechThreshold = 2
+	ctx := context.WithValue(context.Background(), "service", service)
+	start := time.Now().AddDate(0, 0, -30)
+
+	// lapse reviews a card into the Review state and then forgets it, lapses times
+	lapse := func(cardID string, lapses int) (Card, time.Time) {
+		t.Helper()
+		at := start
+		var card Card
+		var err error
+		var lastLapse time.Time
+		for _, rating := range []gofsrs.Rating{gofsrs.Good, gofsrs.Good} {
+			card, err = service.SubmitReviewWithTime(cardID, rating, "", at)
+			require.NoError(t, err)
+			at = at.Add(24 * time.Hour)
+		}
+		require.Equal(t, gofsrs.Review, card.FSRS.State)
+		for i := 0; i < lapses; i++ {
+			card, err = service.SubmitReviewWithTime(cardID, gofsrs.Again, "", at)
+			require.NoError(t, err)
+			lastLapse = at
+			card, err = service.SubmitReviewWithTime(cardID, gofsrs.Good, "", at.Add(time.Hour))
+			require.NoError(t, err)
+			at = at.Add(24 * time.Hour)
+		}
+		return card, lastLapse
+	}
+	read := func() LeechesResponse {
+		t.Helper()
+		contents, err := handleLeechesResource(ctx, mcp.ReadResourceRequest{})
+		require.NoError(t, err)
+		require.Len(t, contents, 1)
+		text, ok := contents[0].(mcp.TextResourceContents)
+		require.True(t, ok)
+		assert.Equal(t, "leeches", text.URI)
+		var response LeechesResponse
+		require.NoError(t, json.Unmarshal([]byte(text.Text), &response))
+		return response
+	}
+
+	// One lapse is below the threshold; the second makes the card a leech
+	struggling := createCardDirectly(t, service, "Struggling", "A", nil)
+	card, _ := lapse(struggling.ID, 1)
+	assert.False(t, card.Leech)
+	assert.Empty(t, read().Leeches)
+
+	leech := createCardDirectly(t, service, "Leech", "A", nil)
+	card, leechLapse := lapse(leech.ID, 2)
+	assert.True(t, card.Leech)
+	assert.False(t, card.Suspended, "Leeches aren't suspended unless configured")
+
+	// With auto-suspend, a new leech is suspended
+	service.AutoSuspendLeeches = true
+	suspended := createCardDirectly(t, service, "Suspended leech", "A", nil)
+	card, _ = lapse(suspended.ID, 3)
+	assert.True(t, card.Leech)
+	assert.True(t, card.Suspended)
+
+	// Cards past the threshold count even if never marked; archived ones don't
+	legacy := createCardDirectly(t, service, "Legacy", "A", nil)
+	legacy.FSRS.Lapses = 5
+	updateCardDirectly(t, service, legacy)
+	archived := createCardDirectly(t, service, "Archived", "A", nil)
+	archived.FSRS.Lapses = 9
+	archived.Archived = true
+	updateCardDirectly(t, service, archived)
+
+	response := read()
+	assert.Equal(t, 2, response.Threshold)
+	assert.True(t, response.AutoSuspend)
+	require.Len(t, response.Leeches, 3)
+	assert.Equal(t, []string{legacy.ID, suspended.ID, leech.ID},
+		[]string{response.Leeches[0].ID, response.Leeches[1].ID, response.Leeches[2].ID}, "Most lapses first")
+	assert.Equal(t, uint64(2), response.Leeches[2].Lapses)
+	assert.True(t, response.Leeches[2].LastLapseAt.Equal(leechLapse), "last lapse %v, want %v", response.Leeches[2].LastLapseAt, leechLapse)
+	assert.True(t, response.Leeches[0].LastLapseAt.IsZero(), "No lapse in the legacy card's history")
+	assert.Contains(t, response.Suggestion, "2 leeches are still being reviewed")
+
+	// Resetting a leech clears the mark
+	reset, err := service.ResetCard(leech.ID, true)
+	require.NoError(t, err)
+	assert.False(t, reset.Leech)
+	assert.Len(t, read().Leeches, 2)
+}
+
+// TestCardHistory tests that card changes are recorded in the audit log, filtered by
+// get_card_history, and trimmed to the configured size
+func TestCardHistory(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+	day1 := time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local)
+	day2 := day1.AddDate(0, 0, 1)
+
+	restore := mockTimeNow(day1)
+	card, err := service.CreateCard("Front", "Back", []string{"tag1"})
+	require.NoError(t, err)
+	front := "New front"
+	_, err = service.UpdateCard(card.ID, &front, nil, nil, nil)
+	require.NoError(t, err)
+	_, err = service.UpdateCard(card.ID, &front, nil, nil, nil)
+	require.NoError(t, err) // Unchanged, so not recorded
+	_, err = service.SubmitReviewWithTime(card.ID, gofsrs.Good, "", day1)
+	require.NoError(t, err)
+	restore()
+
+	restore = mockTimeNow(day2)
+	other, err := service.CreateCard("Other", "Back", nil)
+	require.NoError(t, err)
+	require.NoError(t, service.DeleteCard(other.ID))
+	restore()
+
+	contents, err := handleCardHistoryResource(ctx, mcp.ReadResourceRequest{})
+	require.NoError(t, err)
+	require.Len(t, contents, 1)
+	text, ok := contents[0].(mcp.TextResourceContents)
+	require.True(t, ok)
+	assert.Equal(t, "card-history", text.URI)
+	var response CardHistoryResponse
+	require.NoError(t, json.Unmarshal([]byte(text.Text), &response))
+	assert.Equal(t, DefaultAuditLogSize, response.MaxEntries)
+	require.Equal(t, 5, response.Count)
+	var actions []string
+	for _, entry := range response.Entries {
+		actions = append(actions, entry.Action)
+	}
+	assert.Equal(t, []string{AuditDelete, AuditCreate, AuditReview, AuditUpdate, AuditCreate}, actions, "Newest first")
+	assert.Equal(t, "Updated front", response.Entries[3].Summary)
+	assert.Contains(t, response.Entries[2].Summary, "Rated Good")
+
+	history := func(args map[string]interface{}) CardHistoryResponse {
+		t.Helper()
+		text, result := callHandlerDirectly(t, ctx, handleGetCardHistory, args)
+		require.False(t, result.IsError, text)
+		var response CardHistoryResponse
+		require.NoError(t, json.Unmarshal([]byte(text), &response))
+		return response
+	}
+	for _, entry := range history(map[string]interface{}{"card_id": card.ID}).Entries {
+		assert.Equal(t, card.ID, entry.CardID)
+	}
+	assert.Equal(t, 3, history(map[string]interface{}{"card_id": card.ID}).Count)
+	assert.Equal(t, 2, history(map[string]interface{}{"card_id": other.ID}).Count, "A deleted card's history is kept")
+	assert.Equal(t, 3, history(map[string]interface{}{"end_date": "2026-03-02"}).Count, "The end date is inclusive")
+	assert.Equal(t, 2, history(map[string]interface{}{"start_date": "2026-03-03", "end_date": "2026-03-03"}).Count)
+	assert.Equal(t, 0, history(map[string]interface{}{"start_date": "2026-03-04"}).Count)
+
+	// Cards created with the create_card tool are recorded too
+	createdText, _ := callHandlerDirectly(t, ctx, handleCreateCard, map[string]interface{}{"front": "Tool", "back": "Card"})
+	var created CreateCardResponse
+	require.NoError(t, json.Unmarshal([]byte(createdText), &created))
+	entries := history(map[string]interface{}{"card_id": created.Card.ID}).Entries
+	require.Len(t, entries, 1)
+	assert.Equal(t, AuditCreate, entries[0].Action)
+
+	_, result := callHandlerDirectly(t, ctx, handleGetCardHistory, map[string]interface{}{"start_date": "March 2"})
+	assert.True(t, result.IsError)
+	_, result = callHandlerDirectly(t, ctx, handleGetCardHistory, map[string]interface{}{"start_date": "2026-03-03", "end_date": "2026-03-02"})
+	assert.True(t, result.IsError)
+
+	// Lowering the size drops the oldest entries on the next change
+	_, err = service.UpdateConfig(func(config *storage.Config) { config.AuditLogSize = 2 })
+	require.NoError(t, err)
+	_, err = service.SubmitReviewWithTime(card.ID, gofsrs.Easy, "", day2)
+	require.NoError(t, err)
+	response = history(map[string]interface{}{})
+	assert.Equal(t, 2, response.MaxEntries)
+	require.Equal(t, 2, response.Count)
+	assert.Equal(t, AuditReview, response.Entries[0].Action)
+	assert.Equal(t, created.Card.ID, response.Entries[1].CardID)
+}
+
+// TestMutationsAreAudited tests that every kind of change the service makes to a
+// card is recorded in the audit log and saved along with the change
+func TestMutationsAreAudited(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name    string
+		action  string
+		summary string
+		mutate  func(t *testing.T, service *FlashcardService, card storage.Card) string // Returns the audited card's ID
+	}{
+		{"suspend", AuditUpdate, "Suspended", func(t *testing.T, service *FlashcardService, card storage.Card) string {
+			_, err := service.SuspendCard(card.ID, true)
+			require.NoError(t, err)
+			return card.ID
+		}},
+		{"flag", AuditUpdate, "Flagged: typo in the answer", func(t *testing.T, service *FlashcardService, card storage.Card) string {
+			_, err := service.FlagCard(card.ID, true, "typo in the answer")
+			require.NoError(t, err)
+			return card.ID
+		}},
+		{"prioritize", AuditUpdate, "Prioritized", func(t *testing.T, service *FlashcardService, card storage.Card) string {
+			_, err := service.PrioritizeCard(card.ID)
+			require.NoError(t, err)
+			return card.ID
+		}},
+		{"bulk reschedule", AuditUpdate, "Rescheduled to", func(t *testing.T, service *FlashcardService, card storage.Card) string {
+			_, err := service.BulkReschedule([]string{"unit"}, now.AddDate(0, 0, 3))
+			require.NoError(t, err)
+			return card.ID
+		}},
+		{"apply tags", AuditUpdate, "Added tags extra", func(t *testing.T, service *FlashcardService, card storage.Card) string {
+			_, err := service.ApplyTagsToCards([]string{card.ID}, []string{"extra"})
+			require.NoError(t, err)
+			return card.ID
+		}},
+		{"assign to due date", AuditUpdate, "Assigned to due date Quiz", func(t *testing.T, service *FlashcardService, card storage.Card) string {
+			_, err := service.AssignToDueDate("quiz", "", []string{"unit"})
+			require.NoError(t, err)
+			return card.ID
+		}},
+		{"repair", AuditUpdate, "Repaired due date (reset)", func(t *testing.T, service *FlashcardService, card storage.Card) string {
+			card.FSRS.Due = time.Time{}
+			updateCardDirectly(t, service, card)
+			_, err := service.RepairDueDates(false)
+			require.NoError(t, err)
+			return card.ID
+		}},
+		{"autosuspend", AuditUpdate, "Suspended as stale", func(t *testing.T, service *FlashcardService, card storage.Card) string {
+			card.CreatedAt = now.AddDate(0, 0, -200)
+			updateCardDirectly(t, service, card)
+			_, err := service.AutosuspendStale(DefaultStaleDays, nil, false)
+			require.NoError(t, err)
+			return card.ID
+		}},
+		{"import", AuditCreate, "Imported card", func(t *testing.T, service *FlashcardService, card storage.Card) string {
+			response, err := service.ImportCards([]ImportCard{{Front: "Imported", Back: "Card"}}, nil)
+			require.NoError(t, err)
+			return response.CardIDs[0]
+		}},
+		{"reverse", AuditCreate, "Created as the reverse of card", func(t *testing.T, service *FlashcardService, card storage.Card) string {
+			response, err := service.GenerateReverseCards([]string{card.ID}, nil)
+			require.NoError(t, err)
+			return response.CardIDs[0]
+		}},
+		{"practice review", AuditReview, "Practiced, rated Good", func(t *testing.T, service *FlashcardService, card storage.Card) string {
+			_, err := service.SubmitPracticeReview(card.ID, gofsrs.Good, "", now, 0)
+			require.NoError(t, err)
+			return card.ID
+		}},
+		{"delete with due date", AuditDelete, "Deleted with due date Quiz", func(t *testing.T, service *FlashcardService, card storage.Card) string {
+			card.Tags = append(card.Tags, "test-quiz")
+			updateCardDirectly(t, service, card)
+			_, err := service.DeleteDueDateWithCards("quiz")
+			require.NoError(t, err)
+			return card.ID
+		}},
+		{"cleanup", AuditDelete, "Removed by cleanup", func(t *testing.T, service *FlashcardService, card storage.Card) string {
+			card.Back = " "
+			updateCardDirectly(t, service, card)
+			_, err := service.CleanupCollection(true)
+			require.NoError(t, err)
+			return card.ID
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service, filePath := setupTestService(t)
+			require.NoError(t, service.AddDueDate(storage.DueDate{ID: "quiz", Topic: "Quiz", DueDate: now.AddDate(0, 0, 7), Tag: "test-quiz"}))
+			card := createCardDirectly(t, service, "Front", "Back", []string{"unit"})
+			cardID := tt.mutate(t, service, card)
+
+			// Read the log back as a restarted server would, to check it was saved
+			entries, err := NewFlashcardService(reopenTestStorage(t, filePath)).CardHistory(cardID, time.Time{}, time.Time{})
+			require.NoError(t, err)
+			require.NotEmpty(t, entries)
+			assert.Equal(t, tt.action, entries[0].Action)
+			assert.Contains(t, entries[0].Summary, tt.summary)
+		})
+	}
+}
@@ -0,0 +1,892 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danieldreier/mcp-flashcards/internal/storage"
+	gofsrs "github.com/open-spaced-repetition/go-fsrs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAdjustDueForVacations tests that vacation days are excluded from overdue calculations
+func TestAdjustDueForVacations(t *testing.T) {
+	now := time.Date(2024, 7, 20, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	vacations := []storage.Vacation{
+		{ID: "summer", Start: now.Add(-10 * day), End: now.Add(-3 * day)},
+	}
+
+	// Due before the vacation: the 7 vacation days don't count
+	due := now.Add(-12 * day)
+	adjusted := adjustDueForVacations(due, now, vacations)
+	assert.InDelta(t, 5.0, now.Sub(adjusted).Hours()/24.0, 0.001, "Card should be 5 days overdue, not 12")
+
+	// Due during the vacation: only the days after the vacation count
+	adjusted = adjustDueForVacations(now.Add(-5*day), now, vacations)
+	assert.InDelta(t, 3.0, now.Sub(adjusted).Hours()/24.0, 0.001, "Card should be 3 days overdue")
+
+	// Due after the vacation: unaffected
+	due = now.Add(-1 * day)
+	assert.True(t, adjustDueForVacations(due, now, vacations).Equal(due))
+
+	// An active vacation freezes scheduling: cards don't come due while it lasts
+	active := []storage.Vacation{{ID: "active", Start: now.Add(-4 * day), End: now.Add(3 * day)}}
+	assert.True(t, adjustDueForVacations(now.Add(-2*day), now, active).After(now), "Card due during an active vacation should wait")
+	assert.True(t, adjustDueForVacations(now.Add(-6*day), now, active).After(now), "Overdue card should not be due during an active vacation")
+
+	// A vacation that hasn't started yet doesn't affect currently due cards
+	future := []storage.Vacation{{ID: "future", Start: now.Add(2 * day), End: now.Add(9 * day)}}
+	due = now.Add(-1 * day)
+	assert.True(t, adjustDueForVacations(due, now, future).Equal(due))
+}
+
+// TestVacationModeDueCounts tests that cards aren't counted as due during a vacation
+func TestVacationModeDueCounts(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Now()
+	day := 24 * time.Hour
+
+	card := createCardDirectly(t, service, "Vacation Q", "Vacation A", nil)
+	setDueDateDirectly(t, service, card.ID, now.Add(-2*day))
+
+	cards, err := service.Storage.ListCards(nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, service.calculateStats(cards).DueCards, "Card should be due before vacation")
+
+	vacation, err := service.AddVacation(now.Add(-3*day), now.Add(4*day))
+	require.NoError(t, err)
+	assert.Equal(t, 0, service.calculateStats(cards).DueCards, "Card should not be due during vacation")
+	_, _, err = service.GetDueCard(nil)
+	assert.ErrorContains(t, err, "no cards due for review")
+
+	// Vacations persist and can be removed
+	vacations, err := service.ListVacations()
+	require.NoError(t, err)
+	require.Len(t, vacations, 1)
+	require.NoError(t, service.DeleteVacation(vacation.ID))
+	assert.Equal(t, 1, service.calculateStats(cards).DueCards, "Card should be due again once vacation is removed")
+
+	_, err = service.AddVacation(now, now.Add(-day))
+	assert.Error(t, err, "End before start should be rejected")
+}
+
+// TestMaxReviewsPerDay tests that get_due_card stops serving cards once the daily limit is reached
+func TestMaxReviewsPerDay(t *testing.T) {
+	service, _ := setupTestService(t)
+	service.MaxReviewsPerDay = 2
+	ctx := context.WithValue(context.Background(), "service", service)
+
+	for i := 0; i < 3; i++ {
+		createCardDirectly(t, service, fmt.Sprintf("Limit Q%d", i), "A", nil)
+	}
+
+	for i := 0; i < 2; i++ {
+		card, _, err := service.GetDueCard(nil)
+		require.NoError(t, err, "Card %d should be served before the limit", i)
+		_, err = service.SubmitReview(card.ID, gofsrs.Again, "")
+		require.NoError(t, err)
+	}
+
+	_, stats, err := service.GetDueCard(nil)
+	assert.ErrorIs(t, err, ErrDailyReviewLimitReached)
+	assert.Equal(t, 2, stats.ReviewsToday)
+
+	text, _ := callHandlerDirectly(t, ctx, handleGetDueCard, map[string]interface{}{})
+	assert.Contains(t, text, "Daily review limit reached: 2 of 2 reviews completed today")
+
+	// The limit is off by default
+	service.MaxReviewsPerDay = 0
+	_, _, err = service.GetDueCard(nil)
+	assert.NoError(t, err)
+}
+
+// TestNewCardsPerDay tests that New cards stop being served once the daily limit is reached
+func TestNewCardsPerDay(t *testing.T) {
+	service, _ := setupTestService(t)
+	assert.Equal(t, DefaultNewCardsPerDay, service.NewCardsPerDay)
+	service.NewCardsPerDay = 2
+	ctx := context.WithValue(context.Background(), "service", service)
+
+	var introduced []string
+	for i := 0; i < 3; i++ {
+		createCardDirectly(t, service, fmt.Sprintf("New Q%d", i), "A", nil)
+	}
+	for i := 0; i < 2; i++ {
+		card, _, err := service.GetDueCard(nil)
+		require.NoError(t, err, "New card %d should be served before the limit", i)
+		_, err = service.SubmitReview(card.ID, gofsrs.Good, "")
+		require.NoError(t, err)
+		introduced = append(introduced, card.ID)
+	}
+
+	_, stats, err := service.GetDueCard(nil)
+	assert.ErrorIs(t, err, ErrNewCardLimitReached)
+	assert.Equal(t, 2, stats.NewCardsIntroducedToday)
+	assert.Equal(t, 1, stats.DueCards, "The held-back card is still counted as due")
+	text, _ := callHandlerDirectly(t, ctx, handleGetDueCard, map[string]interface{}{})
+	assert.Contains(t, text, "New card limit reached: 2 of 2 new cards introduced today")
+
+	// Cards already being learned are unaffected, and further reviews don't count again
+	setDueDateDirectly(t, service, introduced[0], time.Now().Add(-time.Minute))
+	card, stats, err := service.GetDueCard(nil)
+	require.NoError(t, err)
+	assert.Equal(t, introduced[0], card.ID)
+	_, err = service.SubmitReview(card.ID, gofsrs.Easy, "")
+	require.NoError(t, err)
+	_, stats, _ = service.GetDueCard(nil)
+	assert.Equal(t, 2, stats.NewCardsIntroducedToday)
+
+	// The tool persists the limit; 0 lifts it
+	text, result := callHandlerDirectly(t, ctx, handleSetNewCardsPerDay, map[string]interface{}{"limit": 0.0})
+	require.False(t, result.IsError, text)
+	config, err := service.Storage.GetConfig()
+	require.NoError(t, err)
+	require.NotNil(t, config.NewCardsPerDay)
+	assert.Equal(t, 0, *config.NewCardsPerDay)
+	card, _, err = service.GetDueCard(nil)
+	require.NoError(t, err)
+	assert.NotContains(t, introduced, card.ID)
+	assert.Equal(t, gofsrs.New, card.FSRS.State)
+
+	service.ApplyConfig(storage.Config{})
+	assert.Equal(t, DefaultNewCardsPerDay, service.NewCardsPerDay, "An unset limit uses the default")
+
+	for _, args := range []map[string]interface{}{{}, {"limit": -1.0}, {"limit": 1.5}} {
+		_, result := callHandlerDirectly(t, ctx, handleSetNewCardsPerDay, args)
+		assert.True(t, result.IsError, "%v should be rejected", args)
+	}
+}
+
+// TestGetDueCardState tests that the state filter limits which cards are served but not the stats
+func TestGetDueCardState(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+	now := time.Now()
+
+	fresh := createCardDirectly(t, service, "Brand new", "A", nil)
+	reviewed := createCardDirectly(t, service, "Seen before", "A", nil)
+	_, err := service.SubmitReviewWithTime(reviewed.ID, gofsrs.Easy, "", now.AddDate(0, 0, -30))
+	require.NoError(t, err)
+	setDueDateDirectly(t, service, reviewed.ID, now.Add(-time.Hour))
+
+	inState := func(state gofsrs.State) (Card, CardStats, error) {
+		return service.GetDueCardMatching(nil, TagMatchAll, &state)
+	}
+	card, _, err := inState(gofsrs.New)
+	require.NoError(t, err)
+	assert.Equal(t, fresh.ID, card.ID)
+	card, _, err = inState(gofsrs.Review)
+	require.NoError(t, err)
+	assert.Equal(t, reviewed.ID, card.ID)
+
+	_, stats, err := inState(gofsrs.Learning)
+	assert.ErrorIs(t, err, ErrNoDueCardsInState)
+	assert.Equal(t, 2, stats.TotalCards, "Cards in other states still count in the stats")
+	assert.Equal(t, 2, stats.DueCards)
+
+	text, result := callHandlerDirectly(t, ctx, handleGetDueCard, map[string]interface{}{"state": "learning"})
+	assert.False(t, result.IsError)
+	assert.Contains(t, text, "No learning cards due for review")
+	text, result = callHandlerDirectly(t, ctx, handleGetDueCard, map[string]interface{}{"state": "review"})
+	require.False(t, result.IsError, text)
+	var response CardResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &response), text)
+	assert.Equal(t, reviewed.ID, response.Card.ID)
+	text, result = callHandlerDirectly(t, ctx, handleGetDueCard, map[string]interface{}{"state": "any"})
+	require.False(t, result.IsError, text)
+	assert.NotContains(t, text, "error")
+
+	_, result = callHandlerDirectly(t, ctx, handleGetDueCard, map[string]interface{}{"state": "mastered"})
+	assert.True(t, result.IsError, "unknown states should be rejected")
+}
+
+// TestPrioritizeCard tests that a prioritized card is served next exactly once
+func TestPrioritizeCard(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Now()
+
+	overdue := createCardDirectly(t, service, "Overdue", "A", nil)
+	setDueDateDirectly(t, service, overdue.ID, now.Add(-48*time.Hour))
+	quiz := createCardDirectly(t, service, "Quiz card", "A", nil)
+	setDueDateDirectly(t, service, quiz.ID, now.Add(72*time.Hour)) // Not even due yet
+
+	card, _, err := service.GetDueCard(nil)
+	require.NoError(t, err)
+	assert.Equal(t, overdue.ID, card.ID, "Without a boost the overdue card comes first")
+
+	_, err = service.PrioritizeCard(quiz.ID)
+	require.NoError(t, err)
+
+	card, _, err = service.GetDueCard(nil)
+	require.NoError(t, err)
+	assert.Equal(t, quiz.ID, card.ID, "The prioritized card should be served next")
+
+	card, _, err = service.GetDueCard(nil)
+	require.NoError(t, err)
+	assert.Equal(t, overdue.ID, card.ID, "Normal ordering should resume after the boost is used")
+
+	stored, err := service.Storage.GetCard(quiz.ID)
+	require.NoError(t, err)
+	assert.False(t, stored.Prioritized, "The boost should be cleared after serving")
+
+	_, err = service.PrioritizeCard("missing")
+	assert.ErrorIs(t, err, storage.ErrCardNotFound)
+}
+
+// TestEstimateSessionTime tests that the estimate scales with due count and seconds per card
+func TestEstimateSessionTime(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	for i := 0; i < 6; i++ {
+		card := createCardDirectly(t, service, fmt.Sprintf("Due %d", i), "A", []string{"math"})
+		setDueDateDirectly(t, service, card.ID, now.Add(-time.Hour))
+	}
+	notDue := createCardDirectly(t, service, "Later", "A", []string{"math"})
+	setDueDateDirectly(t, service, notDue.ID, now.Add(24*time.Hour))
+	other := createCardDirectly(t, service, "Other", "A", []string{"history"})
+	setDueDateDirectly(t, service, other.ID, now.Add(-time.Hour))
+
+	estimate, err := service.EstimateSessionTime([]string{"math"}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 6, estimate.DueCards)
+	assert.Equal(t, DefaultSecondsPerCard, estimate.SecondsPerCard)
+	assert.InDelta(t, 2.0, estimate.EstimatedMinutes, 0.001) // 6 cards * 20s
+
+	estimate, err = service.EstimateSessionTime([]string{"math"}, 30)
+	require.NoError(t, err)
+	assert.InDelta(t, 3.0, estimate.EstimatedMinutes, 0.001)
+
+	// The configured value is used when no override is given
+	service.SecondsPerCard = 60
+	estimate, err = service.EstimateSessionTime(nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 7, estimate.DueCards)
+	assert.InDelta(t, 7.0, estimate.EstimatedMinutes, 0.001)
+}
+
+// TestBulkReschedule tests that every card with the tag is moved and others are untouched
+func TestBulkReschedule(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	bio1 := createCardDirectly(t, service, "Bio 1", "A", []string{"biology"})
+	setDueDateDirectly(t, service, bio1.ID, now.AddDate(0, 0, 30))
+	bio2 := createCardDirectly(t, service, "Bio 2", "A", []string{"biology", "cells"})
+	setDueDateDirectly(t, service, bio2.ID, now.Add(-time.Hour))
+	other := createCardDirectly(t, service, "History", "A", []string{"history"})
+	otherDue := now.AddDate(0, 0, 10)
+	setDueDateDirectly(t, service, other.ID, otherDue)
+
+	// Relative days through the handler
+	ctx := context.WithValue(context.Background(), "service", service)
+	text, result := callHandlerDirectly(t, ctx, handleBulkReschedule, map[string]interface{}{
+		"tags": []interface{}{"biology"},
+		"days": float64(1),
+	})
+	require.False(t, result.IsError, text)
+
+	tomorrow := now.AddDate(0, 0, 1)
+	for _, id := range []string{bio1.ID, bio2.ID} {
+		stored, err := service.Storage.GetCard(id)
+		require.NoError(t, err)
+		assert.True(t, stored.FSRS.Due.Equal(tomorrow), "Card %s should be due tomorrow, got %v", id, stored.FSRS.Due)
+	}
+	stored, err := service.Storage.GetCard(other.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.FSRS.Due.Equal(otherDue), "Untagged card should keep its due date")
+
+	// Absolute date through the service
+	target := time.Date(2024, 4, 15, 0, 0, 0, 0, time.UTC)
+	response, err := service.BulkReschedule([]string{"cells"}, target)
+	require.NoError(t, err)
+	assert.Equal(t, 1, response.Rescheduled)
+	assert.Equal(t, []string{bio2.ID}, response.CardIDs)
+	stored, err = service.Storage.GetCard(bio2.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.FSRS.Due.Equal(target))
+
+	_, err = service.BulkReschedule(nil, target)
+	assert.Error(t, err, "A tag filter is required")
+
+	_, result = callHandlerDirectly(t, ctx, handleBulkReschedule, map[string]interface{}{
+		"tags": []interface{}{"biology"},
+		"date": "2024-04-15",
+		"days": float64(2),
+	})
+	assert.True(t, result.IsError, "date and days are mutually exclusive")
+}
+
+// TestNewCardDelay tests that a configured delay keeps a new card from being due immediately
+func TestNewCardDelay(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	_, err := service.UpdateConfig(func(config *storage.Config) { config.NewCardDelay = 30 })
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Minute, service.NewCardDelay)
+
+	card, err := service.CreateCard("Fresh", "A", nil)
+	require.NoError(t, err)
+	stored, err := service.Storage.GetCard(card.ID)
+	require.NoError(t, err)
+	assert.Equal(t, stored.CreatedAt.Add(30*time.Minute), stored.FSRS.Due)
+
+	_, _, err = service.GetDueCard(nil)
+	assert.Error(t, err, "A just-created card should not be due yet")
+
+	// create_card applies the delay too, in the same write as the hint
+	ctx := context.WithValue(context.Background(), "service", service)
+	text, _ := callHandlerDirectly(t, ctx, handleCreateCard, map[string]interface{}{
+		"front": "Handler",
+		"back":  "A",
+		"hint":  "H",
+	})
+	var created CreateCardResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &created))
+	stored, err = service.Storage.GetCard(created.Card.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "H", stored.Hint)
+	assert.Equal(t, stored.CreatedAt.Add(30*time.Minute), stored.FSRS.Due)
+
+	// Without a delay new cards are due right away
+	_, err = service.UpdateConfig(func(config *storage.Config) { config.NewCardDelay = 0 })
+	require.NoError(t, err)
+	immediate, err := service.CreateCard("Immediate", "A", nil)
+	require.NoError(t, err)
+	due, _, err := service.GetDueCard(nil)
+	require.NoError(t, err)
+	assert.Equal(t, immediate.ID, due.ID)
+}
+
+// TestGenerateReverseCardsSiblingSpacing tests that a reverse card is due the configured spacing after its forward card
+func TestGenerateReverseCardsSiblingSpacing(t *testing.T) {
+	service, _ := setupTestService(t)
+	assert.Equal(t, DefaultSiblingSpacingDays*24*time.Hour, service.SiblingSpacing)
+
+	spacing := 5.0
+	_, err := service.UpdateConfig(func(config *storage.Config) { config.SiblingSpacingDays = &spacing })
+	require.NoError(t, err)
+
+	forward := createCardDirectly(t, service, "Hola", "Hello", []string{"spanish"})
+	forwardDue := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	setDueDateDirectly(t, service, forward.ID, forwardDue)
+
+	response, err := service.GenerateReverseCards(nil, []string{"spanish"})
+	require.NoError(t, err)
+	require.Equal(t, 1, response.Created)
+
+	reverse, err := service.Storage.GetCard(response.CardIDs[0])
+	require.NoError(t, err)
+	assert.Equal(t, "Hello", reverse.Front)
+	assert.Equal(t, "Hola", reverse.Back)
+	assert.Equal(t, forward.ID, reverse.ReverseOf)
+	assert.Equal(t, []string{"spanish"}, reverse.Tags)
+	assert.Equal(t, 5*24*time.Hour, reverse.FSRS.Due.Sub(forwardDue), "Siblings should be spaced by the configured days")
+
+	// Running again doesn't create a second reverse for either card
+	response, err = service.GenerateReverseCards(nil, []string{"spanish"})
+	require.NoError(t, err)
+	assert.Equal(t, 0, response.Created)
+	assert.Equal(t, 2, response.Skipped)
+}
+
+// TestLevelWorkload tests that a heavy backlog is spread so no day exceeds the cap
+func TestLevelWorkload(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	defer mockTimeNow(now)()
+
+	// 45 overdue cards; the most overdue should be kept for today
+	var mostOverdue string
+	for i := 0; i < 45; i++ {
+		card := createCardDirectly(t, service, fmt.Sprintf("Backlog %d", i), "A", nil)
+		setDueDateDirectly(t, service, card.ID, now.Add(-time.Duration(i+1)*time.Hour))
+		mostOverdue = card.ID
+	}
+	// Tomorrow already has 15 cards scheduled
+	for i := 0; i < 15; i++ {
+		card := createCardDirectly(t, service, fmt.Sprintf("Tomorrow %d", i), "A", nil)
+		setDueDateDirectly(t, service, card.ID, now.AddDate(0, 0, 1))
+	}
+
+	response, err := service.LevelWorkload(20, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 45, response.Overdue)
+	assert.Equal(t, 25, response.Rescheduled)
+
+	// Count the resulting due cards per day
+	cards, err := service.Storage.ListCards(nil)
+	require.NoError(t, err)
+	perDay := map[string]int{}
+	for _, card := range cards {
+		day := card.FSRS.Due
+		if !day.After(now) {
+			day = now
+		}
+		perDay[localDay(day).Format("2006-01-02")]++
+	}
+	for day, count := range perDay {
+		assert.LessOrEqual(t, count, 20, "Day %s exceeds the cap", day)
+	}
+	assert.Equal(t, map[string]int{"2024-03-01": 20, "2024-03-02": 20, "2024-03-03": 20}, perDay)
+
+	stored, err := service.Storage.GetCard(mostOverdue)
+	require.NoError(t, err)
+	assert.False(t, stored.FSRS.Due.After(now), "The highest-priority card should stay due today")
+}
+
+// TestLevelWorkloadAcrossDST tests that cards due after a DST change count toward
+// their own day's load rather than the day before
+func TestLevelWorkloadAcrossDST(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	originalLocal := time.Local
+	time.Local = location
+	defer func() { time.Local = originalLocal }()
+
+	service, _ := setupTestService(t)
+	// Clocks spring forward on 2024-03-10, so that day is only 23 hours long
+	now := time.Date(2024, 3, 9, 9, 0, 0, 0, location)
+	defer mockTimeNow(now)()
+
+	for i := 0; i < 4; i++ {
+		card := createCardDirectly(t, service, fmt.Sprintf("Backlog %d", i), "A", nil)
+		setDueDateDirectly(t, service, card.ID, now.Add(-time.Duration(i+1)*time.Hour))
+	}
+	for i := 0; i < 2; i++ {
+		card := createCardDirectly(t, service, fmt.Sprintf("Monday %d", i), "A", nil)
+		setDueDateDirectly(t, service, card.ID, time.Date(2024, 3, 11, 9, 0, 0, 0, location))
+	}
+
+	response, err := service.LevelWorkload(2, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []DayLoad{
+		{Date: "2024-03-09", Cards: 2},
+		{Date: "2024-03-10", Cards: 2},
+	}, response.Schedule)
+
+	cards, err := service.Storage.ListCards(nil)
+	require.NoError(t, err)
+	perDay := map[string]int{}
+	for _, card := range cards {
+		day := card.FSRS.Due
+		if !day.After(now) {
+			day = now
+		}
+		perDay[localDay(day).Format("2006-01-02")]++
+	}
+	assert.Equal(t, map[string]int{"2024-03-09": 2, "2024-03-10": 2, "2024-03-11": 2}, perDay)
+}
+
+// TestBurstSchedule tests that a due date's unmastered cards become due while mastered ones stay put
+func TestBurstSchedule(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	defer mockTimeNow(now)()
+
+	require.NoError(t, service.AddDueDate(storage.DueDate{
+		ID:      "bio",
+		Topic:   "Biology test",
+		DueDate: now.AddDate(0, 0, 4),
+		Tag:     "test-biology",
+	}))
+
+	later := now.AddDate(0, 0, 20)
+	var weak []string
+	for i := 0; i < 4; i++ {
+		card := createCardDirectly(t, service, fmt.Sprintf("Weak %d", i), "A", []string{"test-biology"})
+		addReviewDirectly(t, service, card.ID, gofsrs.Hard, now.AddDate(0, 0, -1))
+		setDueDateDirectly(t, service, card.ID, later)
+		weak = append(weak, card.ID)
+	}
+	mastered := createCardDirectly(t, service, "Mastered", "A", []string{"test-biology"})
+	addReviewDirectly(t, service, mastered.ID, gofsrs.Easy, now.AddDate(0, 0, -1))
+	setDueDateDirectly(t, service, mastered.ID, later)
+	other := createCardDirectly(t, service, "Other subject", "A", []string{"history"})
+	setDueDateDirectly(t, service, other.ID, later)
+
+	response, err := service.BurstSchedule("bio", true)
+	require.NoError(t, err)
+	assert.Equal(t, 4, response.Days)
+	assert.Equal(t, 4, response.Rescheduled)
+	assert.Equal(t, 1, response.Mastered)
+	for _, id := range weak {
+		stored, err := service.Storage.GetCard(id)
+		require.NoError(t, err)
+		assert.True(t, stored.FSRS.Due.Before(now.AddDate(0, 0, 4)), "Card %s should be due before the test", id)
+	}
+
+	// Without spreading, every unmastered card is due now
+	response, err = service.BurstSchedule("bio", false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, response.Days)
+	for _, id := range weak {
+		stored, err := service.Storage.GetCard(id)
+		require.NoError(t, err)
+		assert.False(t, stored.FSRS.Due.After(now), "Card %s should be due now", id)
+	}
+
+	for _, id := range []string{mastered.ID, other.ID} {
+		stored, err := service.Storage.GetCard(id)
+		require.NoError(t, err)
+		assert.True(t, stored.FSRS.Due.Equal(later), "Card %s should keep its due date", id)
+	}
+
+	_, err = service.BurstSchedule("missing", false)
+	assert.Error(t, err)
+}
+
+// TestClearSession tests that clearing the session drops the queued re-show of a lapsed card
+func TestClearSession(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+
+	card := createCardDirectly(t, service, "Capital of Peru?", "Lima", nil)
+	setDueDateDirectly(t, service, card.ID, time.Now().Add(-time.Hour))
+
+	due, _, err := service.GetDueCard(nil)
+	require.NoError(t, err)
+	require.Equal(t, card.ID, due.ID)
+
+	// Failing the card schedules a short relearning step and queues it for this session
+	_, err = service.SubmitReview(card.ID, gofsrs.Again, "Quito")
+	require.NoError(t, err)
+	stored, err := service.Storage.GetCard(card.ID)
+	require.NoError(t, err)
+	require.True(t, stored.FSRS.Due.After(time.Now()), "The relearning step should not be due yet")
+
+	due, _, err = service.GetDueCard(nil)
+	require.NoError(t, err, "The lapsed card should be re-offered from the session queue")
+	assert.Equal(t, card.ID, due.ID)
+
+	// Fail it again, then clear the session
+	_, err = service.SubmitReview(card.ID, gofsrs.Again, "Quito")
+	require.NoError(t, err)
+	text, result := callHandlerDirectly(t, ctx, handleClearSession, map[string]interface{}{})
+	require.False(t, result.IsError, text)
+	assert.JSONEq(t, `{"cleared": 1}`, text)
+
+	_, _, err = service.GetDueCard(nil)
+	assert.ErrorContains(t, err, "no cards due for review", "A cleared session must not re-offer the card")
+}
+
+// TestSuggestDueDate tests the suggested date from the unmastered card count and pace
+func TestSuggestDueDate(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	defer mockTimeNow(now)()
+
+	for i := 0; i < 11; i++ {
+		createCardDirectly(t, service, fmt.Sprintf("Card %d", i), "A", []string{"spanish"})
+	}
+	mastered := createCardDirectly(t, service, "Mastered", "A", []string{"spanish"})
+	addReviewDirectly(t, service, mastered.ID, gofsrs.Easy, now.AddDate(0, 0, -1))
+
+	// 11 unmastered cards at 3 per day need 4 study days: Mar 1-4, so the test is on Mar 5
+	suggestion, err := service.SuggestDueDate("spanish", 3)
+	require.NoError(t, err)
+	assert.Equal(t, DueDateSuggestion{
+		Tag:           "spanish",
+		Cards:         12,
+		Mastered:      1,
+		Remaining:     11,
+		CardsPerDay:   3,
+		StudyDays:     4,
+		SuggestedDate: "2024-03-05",
+	}, suggestion)
+
+	_, err = service.SuggestDueDate("spanish", 0)
+	assert.Error(t, err)
+}
+
+// TestPracticeReviews tests that practice reviews are logged without changing the schedule or mastery
+func TestPracticeReviews(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+	due := time.Now().AddDate(0, 0, 10).Truncate(time.Second)
+
+	var ids []string
+	for i := 0; i < 5; i++ {
+		card := createCardDirectly(t, service, fmt.Sprintf("Verb %d", i), "A", []string{"french"})
+		setDueDateDirectly(t, service, card.ID, due)
+		ids = append(ids, card.ID)
+	}
+
+	text, result := callHandlerDirectly(t, ctx, handlePractice, map[string]interface{}{"tag": "french", "seed": float64(42)})
+	require.False(t, result.IsError, text)
+	var practice PracticeResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &practice))
+	var served []string
+	for _, card := range practice.Cards {
+		served = append(served, card.ID)
+	}
+	assert.ElementsMatch(t, ids, served, "Practice returns every card for the tag, due or not")
+
+	// Practice reviews keep the card's schedule and don't count as mastery
+	text, result = callHandlerDirectly(t, ctx, handleSubmitReview, map[string]interface{}{
+		"card_id":  ids[0],
+		"rating":   float64(gofsrs.Easy),
+		"practice": true,
+	})
+	require.False(t, result.IsError, text)
+	stored, err := service.Storage.GetCard(ids[0])
+	require.NoError(t, err)
+	assert.True(t, stored.FSRS.Due.Equal(due), "Practice must not change FSRS.Due, got %v", stored.FSRS.Due)
+	assert.Equal(t, gofsrs.New, stored.FSRS.State)
+
+	reviews, err := service.Storage.GetCardReviews(ids[0])
+	require.NoError(t, err)
+	require.Len(t, reviews, 1)
+	assert.True(t, reviews[0].Practice)
+
+	stats, err := service.GetDueDateProgressStats("french")
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.MasteredCards, "An Easy practice review must not count as mastery")
+}
+
+// TestProjectedReviews tests that the daily forecast counts each due review and sums correctly
+func TestProjectedReviews(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	defer mockTimeNow(now)()
+
+	// Well-learned cards: one Good review pushes them far past the 7-day window
+	seedMature := func(front string, due time.Time) {
+		card := createCardDirectly(t, service, front, "A", nil)
+		card.FSRS = gofsrs.Card{
+			Due:           due,
+			Stability:     200,
+			Difficulty:    5,
+			State:         gofsrs.Review,
+			Reps:          5,
+			ScheduledDays: 100,
+			LastReview:    due.AddDate(0, 0, -100),
+		}
+		updateCardDirectly(t, service, card)
+	}
+	seedMature("Overdue", now.AddDate(0, 0, -2))
+	seedMature("Day 3", now.AddDate(0, 0, 3))
+	seedMature("Day 3 again", now.AddDate(0, 0, 3).Add(2*time.Hour))
+	seedMature("Day 6", now.AddDate(0, 0, 6))
+	seedMature("Next month", now.AddDate(0, 0, 30))
+
+	projection, err := service.ProjectedReviews(nil)
+	require.NoError(t, err)
+	require.Len(t, projection.Days, ProjectionDays)
+
+	var perDay []int
+	sum := 0
+	for _, day := range projection.Days {
+		perDay = append(perDay, day.Reviews)
+		sum += day.Reviews
+	}
+	assert.Equal(t, []int{1, 0, 0, 2, 0, 0, 1}, perDay)
+	assert.Equal(t, "2024-03-01", projection.Days[0].Date)
+	assert.Equal(t, 4, projection.Total)
+	assert.Equal(t, sum, projection.Total)
+}
+
+// TestProjectedReviewsAcrossDST tests that a day shortened by a DST change doesn't
+// shift later reviews onto the previous day
+func TestProjectedReviewsAcrossDST(t *testing.T) {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	originalLocal := time.Local
+	time.Local = location
+	defer func() { time.Local = originalLocal }()
+
+	service, _ := setupTestService(t)
+	// Clocks spring forward on 2024-03-10, so that day is only 23 hours long
+	now := time.Date(2024, 3, 9, 9, 0, 0, 0, location)
+	defer mockTimeNow(now)()
+
+	card := createCardDirectly(t, service, "After the change", "A", nil)
+	due := time.Date(2024, 3, 11, 9, 0, 0, 0, location)
+	card.FSRS = gofsrs.Card{
+		Due:           due,
+		Stability:     200,
+		Difficulty:    5,
+		State:         gofsrs.Review,
+		Reps:          5,
+		ScheduledDays: 100,
+		LastReview:    due.AddDate(0, 0, -100),
+	}
+	updateCardDirectly(t, service, card)
+
+	projection, err := service.ProjectedReviews(nil)
+	require.NoError(t, err)
+	require.Len(t, projection.Days, ProjectionDays)
+	assert.Equal(t, "2024-03-11", projection.Days[2].Date)
+	assert.Equal(t, 1, projection.Days[2].Reviews)
+	assert.Equal(t, 0, projection.Days[1].Reviews)
+}
+
+// TestSimulateDue tests that future-due cards appear once the simulated time passes their due date
+func TestSimulateDue(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	overdue := createCardDirectly(t, service, "Overdue", "A", []string{"spanish"})
+	setDueDateDirectly(t, service, overdue.ID, now.Add(-time.Hour))
+	tomorrow := createCardDirectly(t, service, "Tomorrow", "A", []string{"spanish"})
+	setDueDateDirectly(t, service, tomorrow.ID, now.AddDate(0, 0, 1))
+	nextWeek := createCardDirectly(t, service, "Next week", "A", []string{"spanish"})
+	setDueDateDirectly(t, service, nextWeek.ID, now.AddDate(0, 0, 7))
+	otherTag := createCardDirectly(t, service, "French", "A", []string{"french"})
+	setDueDateDirectly(t, service, otherTag.ID, now.AddDate(0, 0, 1))
+
+	ctx := context.WithValue(context.Background(), "service", service)
+	text, result := callHandlerDirectly(t, ctx, handleSimulateDue, map[string]interface{}{
+		"at":   now.AddDate(0, 0, 2).Format(time.RFC3339),
+		"tags": []interface{}{"spanish"},
+	})
+	require.False(t, result.IsError, text)
+	var response SimulateDueResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &response), text)
+	require.Equal(t, 2, response.Count)
+	assert.Equal(t, overdue.ID, response.Cards[0].ID)
+	assert.Equal(t, tomorrow.ID, response.Cards[1].ID)
+
+	// Simulating leaves the schedule untouched
+	stored, err := service.Storage.GetCard(tomorrow.ID)
+	require.NoError(t, err)
+	assert.True(t, stored.FSRS.Due.Equal(now.AddDate(0, 0, 1)))
+
+	_, result = callHandlerDirectly(t, ctx, handleSimulateDue, map[string]interface{}{"at": "tomorrow"})
+	assert.True(t, result.IsError)
+}
+
+// TestFSRSConfig tests that a higher target retention gives shorter intervals for the
+// same reviews, and that FSRS overrides persist and can be reset
+func TestFSRSConfig(t *testing.T) {
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	ratings := []gofsrs.Rating{gofsrs.Good, gofsrs.Good, gofsrs.Good, gofsrs.Good}
+
+	// interval reviews a new card on each due date and returns its final interval
+	interval := func(retention float64) time.Duration {
+		service, _ := setupTestService(t)
+		ctx := context.WithValue(context.Background(), "service", service)
+		text, result := callHandlerDirectly(t, ctx, handleSetFSRSConfig, map[string]interface{}{"request_retention": retention})
+		require.False(t, result.IsError, text)
+
+		cardID := createCardDirectly(t, service, "Hola", "Hello", nil).ID
+		var card Card
+		now := start
+		for _, rating := range ratings {
+			var err error
+			card, err = service.SubmitReviewWithTime(cardID, rating, "hello", now)
+			require.NoError(t, err)
+			now = card.FSRS.Due
+		}
+		return card.FSRS.Due.Sub(card.FSRS.LastReview)
+	}
+	assert.Less(t, interval(0.95), interval(0.8), "Higher retention should schedule the next review sooner")
+
+	service, filePath := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+	assert.True(t, service.GetFSRSConfig().Defaults, "Stores without FSRS config use the defaults")
+
+	text, result := callHandlerDirectly(t, ctx, handleSetFSRSConfig, map[string]interface{}{"maximum_interval": 180.0})
+	require.False(t, result.IsError, text)
+	var response FSRSConfigResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &response))
+	assert.False(t, response.Defaults)
+	assert.Equal(t, 180.0, response.MaximumInterval)
+	assert.Equal(t, gofsrs.DefaultParam().RequestRetention, response.RequestRetention, "Unset parameters keep their defaults")
+	assert.Len(t, response.Weights, 17)
+
+	// Reload the store from disk as a restart would
+	reloaded := reopenTestStorage(t, filePath)
+	persisted, err := reloaded.GetConfig()
+	require.NoError(t, err)
+	restarted := NewFlashcardService(reloaded)
+	restarted.ApplyConfig(persisted)
+	assert.Equal(t, 180.0, restarted.GetFSRSConfig().MaximumInterval)
+
+	// Invalid parameters are rejected and leave the config alone
+	text, _ = callHandlerDirectly(t, ctx, handleSetFSRSConfig, map[string]interface{}{"request_retention": 1.5})
+	assert.Contains(t, text, "Error updating FSRS config")
+	text, _ = callHandlerDirectly(t, ctx, handleSetFSRSConfig, map[string]interface{}{"weights": []interface{}{1.0, 2.0}})
+	assert.Contains(t, text, "expected 17 FSRS weights")
+	assert.Equal(t, 180.0, service.GetFSRSConfig().MaximumInterval)
+
+	text, result = callHandlerDirectly(t, ctx, handleSetFSRSConfig, map[string]interface{}{"reset": true})
+	require.False(t, result.IsError, text)
+	assert.True(t, service.GetFSRSConfig().Defaults)
+	_, result = callHandlerDirectly(t, ctx, handleSetFSRSConfig, map[string]interface{}{})
+	assert.True(t, result.IsError)
+}
+
+// TestParseFSRSParamsFlag tests that -fsrs-params accepts inline JSON or a file path
+func TestParseFSRSParamsFlag(t *testing.T) {
+	params, err := parseFSRSParamsFlag(`{"request_retention": 0.85}`)
+	require.NoError(t, err)
+	assert.Equal(t, &storage.FSRSParams{RequestRetention: 0.85}, params)
+
+	path := filepath.Join(t.TempDir(), "fsrs.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"maximum_interval": 365}`), 0644))
+	params, err = parseFSRSParamsFlag(path)
+	require.NoError(t, err)
+	assert.Equal(t, &storage.FSRSParams{MaximumInterval: 365}, params)
+
+	_, err = parseFSRSParamsFlag(`{"request_retension": 0.85}`)
+	assert.Error(t, err, "Misspelled parameters are rejected")
+	_, err = parseFSRSParamsFlag(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+// TestOptimizeFSRS tests that optimize_fsrs needs enough history, reports the log-loss
+// before and after, and saves weights that fit the history better
+func TestOptimizeFSRS(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+
+	text, _ := callHandlerDirectly(t, ctx, handleOptimizeFSRS, map[string]interface{}{})
+	assert.Contains(t, text, "not enough reviews")
+
+	// A student who forgets every third review, far more often than the defaults predict
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	reviews := 0
+	for i := 0; i < 30; i++ {
+		cardID := createCardDirectly(t, service, fmt.Sprintf("Word %d", i), "Meaning", nil).ID
+		now := start.Add(time.Duration(i) * time.Hour)
+		for j := 0; j < 6; j++ {
+			rating := gofsrs.Good
+			if (i+j)%3 == 0 {
+				rating = gofsrs.Again
+			}
+			card, err := service.SubmitReviewWithTime(cardID, rating, "meaning", now)
+			require.NoError(t, err)
+			reviews++
+			now = card.FSRS.Due
+		}
+	}
+
+	text, result := callHandlerDirectly(t, ctx, handleOptimizeFSRS, map[string]interface{}{})
+	require.False(t, result.IsError, text)
+	var response OptimizeFSRSResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &response), text)
+	assert.Equal(t, reviews, response.Reviews)
+	assert.Positive(t, response.PredictedReviews)
+	assert.Less(t, response.NewLogLoss, response.OldLogLoss)
+	require.True(t, response.Saved)
+
+	config := service.GetFSRSConfig()
+	assert.False(t, config.Defaults)
+	assert.Equal(t, response.Weights, config.Weights, "The fitted weights are used for scheduling")
+	assert.Equal(t, gofsrs.DefaultParam().RequestRetention, config.RequestRetention)
+}
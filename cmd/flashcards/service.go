@@ -2,38 +2,351 @@
 package main
 
 import (
+	"encoding/csv"
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"math/rand"
+	"regexp"
 	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
+	"unicode"
 
 	"github.com/danieldreier/mcp-flashcards/internal/fsrs"
+	"github.com/danieldreier/mcp-flashcards/internal/importer"
 	"github.com/danieldreier/mcp-flashcards/internal/storage"
 	"github.com/google/uuid"
 	gofsrs "github.com/open-spaced-repetition/go-fsrs"
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
 )
 
 // FlashcardService manages operations for flashcards with storage and FSRS algorithm
 type FlashcardService struct {
 	Storage     storage.Storage // Interface for storage operations
 	FSRSManager fsrs.FSRSManager
+
+	// MaxReviewsPerDay caps how many reviews GetDueCard will serve per day (0 = unlimited)
+	MaxReviewsPerDay int
+
+	// NewCardsPerDay caps how many New cards GetDueCard will introduce per day (0 = unlimited)
+	NewCardsPerDay int
+
+	// AutoTagRecall tags cards "struggling" or "solid" from their recent ratings on each review
+	AutoTagRecall bool
+
+	// DirectionSeed seeds the per-card direction choice in mixed_direction mode
+	DirectionSeed int64
+
+	// ConfigOverrides changes the persisted config each time it's applied, e.g. for the
+	// command-line flags, without the changes being saved
+	ConfigOverrides func(config *storage.Config)
+
+	// SecondsPerCard is the average review time used to estimate session length (0 = DefaultSecondsPerCard)
+	SecondsPerCard float64
+
+	// FoldDiacritics makes CheckAnswer ignore accents, so "cafe" matches "café"
+	FoldDiacritics bool
+
+	// NewCardDelay postpones the first due time of newly created cards (0 = due immediately)
+	NewCardDelay time.Duration
+
+	// RejectReservedTags makes ValidateReservedTags fail instead of warn on "test-" tags without a due date
+	RejectReservedTags bool
+
+	// SiblingSpacing offsets a generated reverse card's due date from its forward card
+	SiblingSpacing time.Duration
+
+	// MasteryMode decides when a card counts as mastered for due date progress (MasteryModeLastRating or MasteryModeRetrievability)
+	MasteryMode string
+
+	// MasteryThreshold is the recall probability needed for mastery in MasteryModeRetrievability (0 = DefaultMasteryThreshold)
+	MasteryThreshold float64
+
+	// MaxTagsPerCard rejects creating or updating a card with more tags than this (0 = unlimited)
+	MaxTagsPerCard int
+
+	// ForecastDays is how many days the review-forecast resource covers (0 = DefaultForecastDays)
+	ForecastDays int
+
+	// LeechThreshold is how many lapses make a card a leech (0 = DefaultLeechThreshold)
+	LeechThreshold int
+
+	// AutoSuspendLeeches suspends a card when it becomes a leech
+	AutoSuspendLeeches bool
+
+	// AuditLogSize is how many audit entries are kept before the oldest are dropped (0 = DefaultAuditLogSize)
+	AuditLogSize int
+
+	// sessionQueue holds cards rated Again this session, in the order they lapsed;
+	// GetDueCard re-offers them once nothing else is due
+	sessionMu    sync.Mutex
+	sessionQueue []string
 }
 
+// ErrDailyReviewLimitReached is returned by GetDueCard once MaxReviewsPerDay reviews were done today
+var ErrDailyReviewLimitReached = errors.New("daily review limit reached")
+
+// DefaultNewCardsPerDay is the daily new card limit when none is configured.
+const DefaultNewCardsPerDay = 20
+
+// ErrNewCardLimitReached is returned by GetDueCard when only New cards are due and
+// NewCardsPerDay of them were already introduced today
+var ErrNewCardLimitReached = errors.New("daily new card limit reached")
+
+// ErrNoDueCardsInState is returned by GetDueCardMatching when cards are due, but none in the requested FSRS state
+var ErrNoDueCardsInState = errors.New("no cards due for review in the requested state")
+
+// ErrSchemaVersionMismatch is returned by ImportFull for exports from an incompatible store layout
+var ErrSchemaVersionMismatch = errors.New("schema version mismatch")
+
+// ErrUnbackedReservedTag is returned by ValidateReservedTags when RejectReservedTags is set
+var ErrUnbackedReservedTag = errors.New("reserved tag is not backed by a due date")
+
+// ErrTooManyTags is returned by CreateCard and UpdateCard when a card would exceed MaxTagsPerCard
+var ErrTooManyTags = errors.New("too many tags")
+
+// ErrNoReviews is returned by UndoLastReview for a card that was never reviewed
+var ErrNoReviews = errors.New("card has no reviews")
+
+// ErrBackupsUnsupported is returned by ListBackups and RestoreBackup for storage
+// that doesn't keep rotating backups of its file
+var ErrBackupsUnsupported = errors.New("backups are only kept for JSON storage")
+
 // NewFlashcardService creates a new FlashcardService
 func NewFlashcardService(storage storage.Storage) *FlashcardService {
 	return &FlashcardService{
-		Storage:     storage,
-		FSRSManager: fsrs.NewFSRSManager(),
+		Storage:        storage,
+		FSRSManager:    fsrs.NewFSRSManager(),
+		SiblingSpacing: DefaultSiblingSpacingDays * 24 * time.Hour,
+		NewCardsPerDay: DefaultNewCardsPerDay,
+	}
+}
+
+// effectiveConfig returns the persisted config with ConfigOverrides applied.
+func (s *FlashcardService) effectiveConfig(config storage.Config) storage.Config {
+	if s.ConfigOverrides != nil {
+		s.ConfigOverrides(&config)
+	}
+	return config
+}
+
+// ApplyConfig copies persisted settings, with ConfigOverrides applied, onto the service.
+func (s *FlashcardService) ApplyConfig(config storage.Config) {
+	config = s.effectiveConfig(config)
+	s.MaxReviewsPerDay = config.MaxReviewsPerDay
+	s.NewCardsPerDay = DefaultNewCardsPerDay
+	if config.NewCardsPerDay != nil {
+		s.NewCardsPerDay = *config.NewCardsPerDay
+	}
+	s.AutoTagRecall = config.AutoTagRecall
+	s.SecondsPerCard = config.SecondsPerCard
+	s.FoldDiacritics = config.FoldDiacritics
+	s.NewCardDelay = time.Duration(config.NewCardDelay * float64(time.Minute))
+	s.RejectReservedTags = config.RejectReservedTags
+	siblingSpacingDays := DefaultSiblingSpacingDays
+	if config.SiblingSpacingDays != nil {
+		siblingSpacingDays = *config.SiblingSpacingDays
+	}
+	s.SiblingSpacing = time.Duration(siblingSpacingDays * 24 * float64(time.Hour))
+	s.MasteryMode = config.MasteryMode
+	s.MasteryThreshold = config.MasteryThreshold
+	s.MaxTagsPerCard = config.MaxTagsPerCard
+	s.ForecastDays = config.ForecastDays
+	s.LeechThreshold = config.LeechThreshold
+	s.AutoSuspendLeeches = config.AutoSuspendLeeches
+	s.AuditLogSize = config.AuditLogSize
+
+	maxOverdueFactor := fsrs.DefaultMaxOverdueFactor
+	if config.MaxOverdueFactor != nil {
+		maxOverdueFactor = *config.MaxOverdueFactor
+	}
+	params, err := fsrsParameters(config.FSRS)
+	if err != nil {
+		fmt.Printf("Warning: ignoring invalid FSRS parameters in config: %v\n", err)
+		params = gofsrs.DefaultParam()
+	}
+	s.FSRSManager = fsrs.NewFSRSManagerWithOptions(params, maxOverdueFactor)
+}
+
+// fsrsParameters returns the go-fsrs default parameters with the non-zero overrides
+// applied, or an error if the result can't be used for scheduling.
+func fsrsParameters(overrides *storage.FSRSParams) (gofsrs.Parameters, error) {
+	params := gofsrs.DefaultParam()
+	if overrides == nil {
+		return params, nil
+	}
+	if overrides.RequestRetention != 0 {
+		params.RequestRetention = overrides.RequestRetention
+	}
+	if overrides.MaximumInterval != 0 {
+		params.MaximumInterval = overrides.MaximumInterval
+	}
+	if len(overrides.Weights) > 0 {
+		if len(overrides.Weights) != len(params.W) {
+			return params, fmt.Errorf("expected %d FSRS weights, got %d", len(params.W), len(overrides.Weights))
+		}
+		copy(params.W[:], overrides.Weights)
+	}
+	return params, fsrs.ValidateParameters(params)
+}
+
+// GetFSRSConfig returns the FSRS parameters currently used for scheduling.
+func (s *FlashcardService) GetFSRSConfig() FSRSConfigResponse {
+	return fsrsConfigResponse(s.FSRSManager.Parameters())
+}
+
+// SetFSRSConfig validates and persists FSRS parameter overrides, and schedules all
+// further reviews with them. A nil params restores the defaults. Cards keep their
+// current due dates until their next review.
+func (s *FlashcardService) SetFSRSConfig(params *storage.FSRSParams) (FSRSConfigResponse, error) {
+	if _, err := fsrsParameters(params); err != nil {
+		return FSRSConfigResponse{}, fmt.Errorf("invalid FSRS parameters: %w", err)
+	}
+	if _, err := s.UpdateConfig(func(config *storage.Config) { config.FSRS = params }); err != nil {
+		return FSRSConfigResponse{}, err
+	}
+	return fsrsConfigResponse(s.FSRSManager.Parameters()), nil
+}
+
+// OptimizeFSRS fits the FSRS weights to the full review history and, when they
+// predict the history better than the current weights, saves them with the other
+// FSRS overrides and schedules all further reviews with them.
+func (s *FlashcardService) OptimizeFSRS() (OptimizeFSRSResponse, error) {
+	cards, err := s.Storage.ListCards(nil)
+	if err != nil {
+		return OptimizeFSRSResponse{}, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+	var reviews []storage.Review
+	for _, card := range cards {
+		cardReviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			return OptimizeFSRSResponse{}, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
+		}
+		reviews = append(reviews, cardReviews...)
+	}
+
+	result, err := fsrs.Optimize(reviews, s.FSRSManager.Parameters())
+	if err != nil {
+		return OptimizeFSRSResponse{}, err
 	}
+	response := OptimizeFSRSResponse{
+		Reviews:          result.Reviews,
+		PredictedReviews: result.Predicted,
+		OldLogLoss:       result.OldLogLoss,
+		NewLogLoss:       result.NewLogLoss,
+		Weights:          result.Params.W[:],
+	}
+	if result.NewLogLoss >= result.OldLogLoss {
+		return response, nil
+	}
+
+	_, err = s.UpdateConfig(func(config *storage.Config) {
+		params := storage.FSRSParams{}
+		if config.FSRS != nil {
+			params = *config.FSRS
+		}
+		params.Weights = result.Params.W[:]
+		config.FSRS = &params
+	})
+	if err != nil {
+		return OptimizeFSRSResponse{}, err
+	}
+	response.Saved = true
+	return response, nil
+}
+
+// fsrsConfigResponse describes the FSRS parameters in use.
+func fsrsConfigResponse(params gofsrs.Parameters) FSRSConfigResponse {
+	return FSRSConfigResponse{
+		RequestRetention: params.RequestRetention,
+		MaximumInterval:  params.MaximumInterval,
+		Weights:          params.W[:],
+		Defaults:         params == gofsrs.DefaultParam(),
+	}
+}
+
+// UpdateConfig applies a change to the persisted config, saves it, and applies
+// the result to the service.
+func (s *FlashcardService) UpdateConfig(update func(config *storage.Config)) (storage.Config, error) {
+	config, err := s.Storage.GetConfig()
+	if err != nil {
+		return storage.Config{}, fmt.Errorf("error getting config from storage: %w", err)
+	}
+	update(&config)
+	if err := s.Storage.SaveConfig(config); err != nil {
+		return storage.Config{}, fmt.Errorf("error saving config to storage: %w", err)
+	}
+	if err := s.Storage.Save(); err != nil {
+		return storage.Config{}, fmt.Errorf("error saving storage after updating config: %w", err)
+	}
+	s.ApplyConfig(config)
+	return config, nil
+}
+
+// GetProfileInfo returns the student profile stored in the config.
+func (s *FlashcardService) GetProfileInfo() (storage.Profile, error) {
+	config, err := s.Storage.GetConfig()
+	if err != nil {
+		return storage.Profile{}, fmt.Errorf("error getting config from storage: %w", err)
+	}
+	if config.Profile == nil {
+		return storage.Profile{}, nil
+	}
+	return *config.Profile, nil
+}
+
+// SetProfileInfo updates the provided (non-nil) profile fields and persists them.
+func (s *FlashcardService) SetProfileInfo(name, gradeLevel *string) (storage.Profile, error) {
+	config, err := s.UpdateConfig(func(config *storage.Config) {
+		// Copy rather than mutate the profile shared with the stored config
+		profile := storage.Profile{}
+		if config.Profile != nil {
+			profile = *config.Profile
+		}
+		if name != nil {
+			profile.Name = *name
+		}
+		if gradeLevel != nil {
+			profile.GradeLevel = *gradeLevel
+		}
+		config.Profile = &profile
+	})
+	if err != nil {
+		return storage.Profile{}, err
+	}
+	return *config.Profile, nil
 }
 
 // CreateCard creates a new flashcard using the Storage layer
 func (s *FlashcardService) CreateCard(front, back string, tags []string) (Card, error) {
+	storageCard, err := s.createCard(storage.CardInput{Front: front, Back: back, Tags: tags})
+	if err != nil {
+		return Card{}, err
+	}
+	return cardFromStorage(storageCard), nil
+}
+
+// createCard creates a flashcard from input, with the new card delay applied as it's
+// created so the card is written once.
+func (s *FlashcardService) createCard(input storage.CardInput) (storage.Card, error) {
+	if err := s.checkTagLimit(input.Tags); err != nil {
+		return storage.Card{}, err
+	}
+	input.DueDelay = s.NewCardDelay
 	// Delegate creation to the storage layer, which handles FSRS initialization
-	storageCard, err := s.Storage.CreateCard(front, back, tags)
+	created, err := s.Storage.CreateCards([]storage.CardInput{input})
 	if err != nil {
-		return Card{}, fmt.Errorf("error creating card in storage: %w", err)
+		return storage.Card{}, fmt.Errorf("error creating card in storage: %w", err)
 	}
+	storageCard := created[0]
+	s.appendAudit(newAuditEntry(AuditCreate, storageCard.ID, "Created card"))
 
 	// Persist changes to disk (Save should ideally be part of the storage method)
 	// Assuming storage methods don't auto-save for now.
@@ -42,56 +355,107 @@ func (s *FlashcardService) CreateCard(front, back string, tags []string) (Card,
 		fmt.Printf("Warning: failed to save storage after creating card %s: %v\n", storageCard.ID, err)
 		// Continue anyway, card exists in memory layer of storage
 	}
+	return storageCard, nil
+}
 
-	// Convert storage.Card to our main Card type for the response
-	createdCard := Card{
-		ID:        storageCard.ID,
-		Front:     storageCard.Front,
-		Back:      storageCard.Back,
-		CreatedAt: storageCard.CreatedAt,
-		Tags:      storageCard.Tags,
-		FSRS:      storageCard.FSRS,
+// CreateCards creates several flashcards with a single batched storage write, so the
+// JSON file is serialized once rather than per card. Inputs that fail validation
+// are reported in Errors and skipped; CardIDs has one entry per input, in order,
+// left empty for the skipped ones.
+func (s *FlashcardService) CreateCards(inputs []storage.CardInput) (CreateCardsResponse, error) {
+	response := CreateCardsResponse{CardIDs: make([]string, len(inputs))}
+	valid := make([]storage.CardInput, 0, len(inputs))
+	positions := make([]int, 0, len(inputs))
+	for i, input := range inputs {
+		if strings.TrimSpace(input.Front) == "" || strings.TrimSpace(input.Back) == "" {
+			response.Errors = append(response.Errors, CreateCardError{Index: i, Error: "front and back are required"})
+			continue
+		}
+		if err := s.checkTagLimit(input.Tags); err != nil {
+			response.Errors = append(response.Errors, CreateCardError{Index: i, Error: err.Error()})
+			continue
+		}
+		warnings, err := s.ValidateReservedTags(input.Tags)
+		if err != nil {
+			response.Errors = append(response.Errors, CreateCardError{Index: i, Error: err.Error()})
+			continue
+		}
+		for _, warning := range warnings {
+			response.Warnings = append(response.Warnings, fmt.Sprintf("Card %d: %s", i, warning))
+		}
+		input.DueDelay = s.NewCardDelay
+		valid = append(valid, input)
+		positions = append(positions, i)
+	}
+	if len(valid) == 0 {
+		return response, nil
 	}
 
-	return createdCard, nil
+	created, err := s.Storage.CreateCards(valid)
+	if err != nil {
+		return response, fmt.Errorf("error creating cards in storage: %w", err)
+	}
+	entries := make([]storage.AuditEntry, len(created))
+	for i, card := range created {
+		response.CardIDs[positions[i]] = card.ID
+		entries[i] = newAuditEntry(AuditCreate, card.ID, "Created card in a batch")
+	}
+	response.Created = len(created)
+	s.appendAudit(entries...)
+	if err := s.Storage.Save(); err != nil {
+		fmt.Printf("Warning: failed to save audit log after creating cards: %v\n", err)
+	}
+	return response, nil
 }
 
 // UpdateCard updates an existing flashcard selectively based on non-nil input pointers.
-func (s *FlashcardService) UpdateCard(cardID string, front *string, back *string, tags *[]string) (Card, error) {
+func (s *FlashcardService) UpdateCard(cardID string, front *string, back *string, tags *[]string, hint *string) (Card, error) {
+	if tags != nil {
+		if err := s.checkTagLimit(*tags); err != nil {
+			return Card{}, err
+		}
+	}
 	// Get the card from storage
 	storageCard, err := s.Storage.GetCard(cardID)
 	if err != nil {
 		return Card{}, fmt.Errorf("error getting card %s: %w", cardID, err)
 	}
 
-	updated := false
+	var changed []string
 	// Update fields only if the corresponding pointer is not nil
 	if front != nil {
 		if storageCard.Front != *front {
 			storageCard.Front = *front
-			updated = true
+			changed = append(changed, "front")
 		}
 	}
 	if back != nil {
 		if storageCard.Back != *back {
 			storageCard.Back = *back
-			updated = true
+			changed = append(changed, "back")
+		}
+	}
+	if hint != nil {
+		if storageCard.Hint != *hint {
+			storageCard.Hint = *hint
+			changed = append(changed, "hint")
 		}
 	}
 	if tags != nil {
 		// Need to compare slices carefully to see if an update is needed
 		if !equalStringSlices(storageCard.Tags, *tags) {
 			storageCard.Tags = *tags
-			updated = true
+			changed = append(changed, "tags")
 		}
 	}
 
 	// Only save if changes were actually made
-	if updated {
+	if len(changed) > 0 {
 		// Save the updated card back to storage
 		if err := s.Storage.UpdateCard(storageCard); err != nil {
 			return Card{}, fmt.Errorf("error updating card %s in storage: %w", cardID, err)
 		}
+		s.appendAudit(newAuditEntry(AuditUpdate, cardID, "Updated "+strings.Join(changed, ", ")))
 
 		// Persist changes to disk
 		if err := s.Storage.Save(); err != nil {
@@ -102,140 +466,706 @@ func (s *FlashcardService) UpdateCard(cardID string, front *string, back *string
 	}
 
 	// Convert storage.Card back to our main Card type for the response
-	responseCard := Card{
-		ID:        storageCard.ID,
-		Front:     storageCard.Front,
-		Back:      storageCard.Back,
-		CreatedAt: storageCard.CreatedAt,
-		Tags:      storageCard.Tags,
-		FSRS:      storageCard.FSRS,
-	}
+	responseCard := cardFromStorage(storageCard)
 
 	return responseCard, nil
 }
 
-// equalStringSlices checks if two string slices are equal (considers order).
-// TODO: Move to a utility package or consider sorting before comparison if order doesn't matter.
-func equalStringSlices(a, b []string) bool {
-	if len(a) != len(b) {
-		return false
-	}
-	for i := range a {
-		if a[i] != b[i] {
-			return false
-		}
+// checkTagLimit reports ErrTooManyTags when tags exceeds MaxTagsPerCard.
+func (s *FlashcardService) checkTagLimit(tags []string) error {
+	if s.MaxTagsPerCard > 0 && len(tags) > s.MaxTagsPerCard {
+		return fmt.Errorf("%w: %d given, at most %d allowed per card", ErrTooManyTags, len(tags), s.MaxTagsPerCard)
 	}
-	return true
+	return nil
 }
 
-// DeleteCard deletes a flashcard
-func (s *FlashcardService) DeleteCard(cardID string) error {
-	fmt.Printf("[DEBUG-SVC-DELETE] Starting DeleteCard for ID %s\n", cardID)
-	// Delete the card from storage
-	if err := s.Storage.DeleteCard(cardID); err != nil {
-		fmt.Printf("[DEBUG-SVC-DELETE] Storage.DeleteCard returned error: %v\n", err)
-		return fmt.Errorf("error deleting card: %w", err)
+// PrioritizeCard marks a card to be returned by the next GetDueCard call,
+// regardless of its schedule. The boost is cleared once the card is served.
+func (s *FlashcardService) PrioritizeCard(cardID string) (Card, error) {
+	storageCard, err := s.Storage.GetCard(cardID)
+	if err != nil {
+		return Card{}, fmt.Errorf("error getting card %s: %w", cardID, err)
 	}
-	fmt.Printf("[DEBUG-SVC-DELETE] Card deleted from storage successfully\n")
-
-	// Persist changes to disk
-	fmt.Printf("[DEBUG-SVC-DELETE] Now calling Storage.Save()\n")
+	storageCard.Prioritized = true
+	if err := s.Storage.UpdateCard(storageCard); err != nil {
+		return Card{}, fmt.Errorf("error updating card %s in storage: %w", cardID, err)
+	}
+	s.appendAudit(newAuditEntry(AuditUpdate, cardID, "Prioritized for the next review"))
 	if err := s.Storage.Save(); err != nil {
-		fmt.Printf("[DEBUG-SVC-DELETE] Storage.Save() returned error: %v\n", err)
-		return fmt.Errorf("error saving storage: %w", err)
+		return Card{}, fmt.Errorf("error saving storage after prioritizing card %s: %w", cardID, err)
 	}
-	fmt.Printf("[DEBUG-SVC-DELETE] Storage.Save() completed successfully\n")
+	return cardFromStorage(storageCard), nil
+}
 
-	return nil
+// RescheduleCard moves a card's next due date, e.g. to pull it forward before a
+// quiz. Only the due date changes; stability and difficulty are kept, so the next
+// review schedules the card as usual.
+func (s *FlashcardService) RescheduleCard(cardID string, due time.Time) (RescheduleCardResponse, error) {
+	if due.IsZero() {
+		return RescheduleCardResponse{}, errors.New("due date is required")
+	}
+	storageCard, err := s.Storage.GetCard(cardID)
+	if err != nil {
+		return RescheduleCardResponse{}, fmt.Errorf("error getting card %s: %w", cardID, err)
+	}
+	response := RescheduleCardResponse{CardID: cardID, PreviousDue: storageCard.FSRS.Due, Due: due}
+	storageCard.FSRS.Due = due
+	if err := s.Storage.UpdateCard(storageCard); err != nil {
+		return RescheduleCardResponse{}, fmt.Errorf("error updating card %s in storage: %w", cardID, err)
+	}
+	s.appendAudit(newAuditEntry(AuditUpdate, cardID, "Rescheduled to "+due.Format(time.RFC3339)))
+	if err := s.Storage.Save(); err != nil {
+		return RescheduleCardResponse{}, fmt.Errorf("error saving storage after rescheduling card %s: %w", cardID, err)
+	}
+	return response, nil
 }
 
-// ListCards lists all flashcards, optionally filtered by tags
-func (s *FlashcardService) ListCards(filterTags []string, includeStats bool) ([]Card, CardStats, error) {
-	// Use storage ListCards with the filter
+// BulkReschedule sets the due date of every card carrying all of filterTags.
+// At least one tag is required so a typo cannot reschedule the whole collection.
+func (s *FlashcardService) BulkReschedule(filterTags []string, due time.Time) (BulkRescheduleResponse, error) {
+	if len(filterTags) == 0 {
+		return BulkRescheduleResponse{}, errors.New("at least one tag is required to select cards")
+	}
+	if due.IsZero() {
+		return BulkRescheduleResponse{}, errors.New("due date is required")
+	}
 	storageCards, err := s.Storage.ListCards(filterTags)
 	if err != nil {
-		return nil, CardStats{}, fmt.Errorf("error listing cards from storage: %w", err)
+		return BulkRescheduleResponse{}, fmt.Errorf("error listing cards from storage: %w", err)
 	}
 
-	// Convert storage.Card array to our main Card type array
-	cards := make([]Card, 0, len(storageCards))
-	for _, storageCard := range storageCards {
-		card := Card{
-			ID:        storageCard.ID,
-			Front:     storageCard.Front,
-			Back:      storageCard.Back,
-			CreatedAt: storageCard.CreatedAt,
-			Tags:      storageCard.Tags,
-			FSRS:      storageCard.FSRS,
-		}
-		cards = append(cards, card)
+	response := BulkRescheduleResponse{Due: due, CardIDs: make([]string, 0, len(storageCards))}
+	for i := range storageCards {
+		storageCards[i].FSRS.Due = due
+		response.CardIDs = append(response.CardIDs, storageCards[i].ID)
 	}
+	// The audit entries go first so UpdateCards' save persists them with the batch
+	s.appendAudit(cardAuditEntries(AuditUpdate, "Rescheduled to "+due.Format(time.RFC3339), storageCards)...)
+	if err := s.Storage.UpdateCards(storageCards); err != nil {
+		return BulkRescheduleResponse{}, fmt.Errorf("error updating cards in storage: %w", err)
+	}
+	sort.Strings(response.CardIDs)
+	response.Rescheduled = len(response.CardIDs)
+	return response, nil
+}
 
-	// Calculate stats if requested
-	var stats CardStats
-	if includeStats {
-		// Fetch all cards for stats calculation, regardless of filter
-		allStorageCards, err := s.Storage.ListCards(nil)
-		if err != nil {
-			// Log error but proceed with potentially empty stats
-			fmt.Printf("Warning: error getting all cards for stats: %v\n", err)
-			stats = CardStats{TotalCards: len(storageCards)} // Use filtered count as fallback?
-		} else {
-			stats = s.calculateStats(allStorageCards)
+// FlagCard marks a card for teacher review with an optional reason, or clears
+// the flag when flagged is false. Flagging does not affect scheduling.
+func (s *FlashcardService) FlagCard(cardID string, flagged bool, reason string) (Card, error) {
+	storageCard, err := s.Storage.GetCard(cardID)
+	if err != nil {
+		return Card{}, fmt.Errorf("error getting card %s: %w", cardID, err)
+	}
+	storageCard.Flagged = flagged
+	if flagged {
+		storageCard.FlagReason = reason
+		storageCard.FlaggedAt = timeNow()
+	} else {
+		storageCard.FlagReason = ""
+		storageCard.FlaggedAt = time.Time{}
+	}
+	summary := "Unflagged"
+	if flagged {
+		summary = "Flagged"
+		if reason != "" {
+			summary += ": " + reason
 		}
 	}
-
-	return cards, stats, nil
+	// The audit entry goes first so UpdateCard's save persists it
+	s.appendAudit(newAuditEntry(AuditUpdate, cardID, summary))
+	if err := s.Storage.UpdateCard(storageCard); err != nil {
+		return Card{}, fmt.Errorf("error updating card %s in storage: %w", cardID, err)
+	}
+	return cardFromStorage(storageCard), nil
 }
 
-// GetDueCard returns the next card due for review with statistics, optionally filtered by tags
-func (s *FlashcardService) GetDueCard(filterTags []string) (Card, CardStats, error) {
-	fmt.Printf("[DEBUG-SVC] GetDueCard called with filterTags: %v\n", filterTags)
-	// Get all cards from storage first to calculate overall statistics
-	allCards, err := s.Storage.ListCards(nil)
+// SuspendCard suspends a card so it isn't served for review, or resumes it when
+// suspended is false. Its schedule and review history are kept as they are.
+func (s *FlashcardService) SuspendCard(cardID string, suspended bool) (Card, error) {
+	storageCard, err := s.Storage.GetCard(cardID)
 	if err != nil {
-		fmt.Printf("[DEBUG-SVC] GetDueCard: error listing all cards: %v\n", err)
-		return Card{}, CardStats{}, fmt.Errorf("error listing all cards: %w", err)
+		return Card{}, fmt.Errorf("error getting card %s: %w", cardID, err)
 	}
-	fmt.Printf("[DEBUG-SVC] GetDueCard: Found %d total cards in storage.\n", len(allCards))
+	storageCard.Suspended = suspended
+	summary := "Resumed"
+	if suspended {
+		summary = "Suspended"
+	}
+	// The audit entry goes first so UpdateCard's save persists it
+	s.appendAudit(newAuditEntry(AuditUpdate, cardID, summary))
+	if err := s.Storage.UpdateCard(storageCard); err != nil {
+		return Card{}, fmt.Errorf("error updating card %s in storage: %w", cardID, err)
+	}
+	return cardFromStorage(storageCard), nil
+}
 
-	// Debug - print all card IDs
-	fmt.Printf("[DEBUG-SVC] All card IDs: [")
-	for _, card := range allCards {
-		fmt.Printf("%s, ", card.ID)
+// ListFlaggedCards returns flagged cards, most recently flagged first.
+func (s *FlashcardService) ListFlaggedCards() ([]FlaggedCard, error) {
+	storageCards, err := s.Storage.ListCards(nil)
+	if err != nil {
+		return nil, fmt.Errorf("error listing cards from storage: %w", err)
 	}
-	fmt.Printf("]\n")
+	result := make([]FlaggedCard, 0)
+	for _, card := range storageCards {
+		if card.Flagged {
+			result = append(result, FlaggedCard{
+				Card:      cardFromStorage(card),
+				Reason:    card.FlagReason,
+				FlaggedAt: card.FlaggedAt,
+			})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].FlaggedAt.Equal(result[j].FlaggedAt) {
+			return result[i].FlaggedAt.After(result[j].FlaggedAt)
+		}
+		return result[i].Card.ID < result[j].Card.ID
+	})
+	return result, nil
+}
 
-	// Calculate overall statistics based on all cards
-	stats := s.calculateStats(allCards)
+// ApplyTagsToCards adds addTags to each of the listed cards and saves them together.
+// An unknown card ID, or a card that would exceed MaxTagsPerCard, fails the whole
+// call, so no card is tagged unless all of them are.
+func (s *FlashcardService) ApplyTagsToCards(cardIDs []string, addTags []string) (ApplyTagsResponse, error) {
+	if len(cardIDs) == 0 {
+		return ApplyTagsResponse{}, errors.New("at least one card ID is required")
+	}
+	if len(addTags) == 0 {
+		return ApplyTagsResponse{}, errors.New("at least one tag is required")
+	}
 
-	// If no filter tags were provided, get all cards
-	var cardsToConsider []storage.Card
-	if len(filterTags) == 0 {
-		fmt.Printf("[DEBUG-SVC] GetDueCard: No filter tags provided, considering all %d cards.\n", len(allCards))
-		cardsToConsider = allCards
-	} else {
-		fmt.Printf("[DEBUG-SVC] GetDueCard: Filtering %d cards by tags: %v\n", len(allCards), filterTags)
-		// When filter tags are provided, we need to find cards with ALL the specified tags
-		for i, card := range allCards {
-			matches := hasAllRequiredTags(&card, filterTags)
-			fmt.Printf("[DEBUG-SVC] GetDueCard: Checking card %d (ID: %s, Tags: %v) against filter %v -> Matches: %t\n", i, card.ID, card.Tags, filterTags, matches)
-			if matches {
-				cardsToConsider = append(cardsToConsider, card)
+	response := ApplyTagsResponse{Tags: addTags, CardIDs: make([]string, 0, len(cardIDs))}
+	var updated []storage.Card
+	seen := make(map[string]bool, len(cardIDs))
+	for _, id := range cardIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		card, err := s.Storage.GetCard(id)
+		if err != nil {
+			return ApplyTagsResponse{}, fmt.Errorf("error getting card %s: %w", id, err)
+		}
+		changed := false
+		for _, tag := range addTags {
+			if !containsString(card.Tags, tag) {
+				card.Tags = append(card.Tags, tag)
+				changed = true
 			}
 		}
-		fmt.Printf("[DEBUG-SVC] GetDueCard: Filtering complete. %d cards matched the tags.\n", len(cardsToConsider))
-
-		// If no cards match the tag filter, return an error
-		if len(cardsToConsider) == 0 {
-			fmt.Printf("[DEBUG-SVC] GetDueCard: No cards matched tags, returning error.\n")
-			return Card{}, stats, fmt.Errorf("no cards found with the specified tags: %v", filterTags)
+		if err := s.checkTagLimit(card.Tags); err != nil {
+			return ApplyTagsResponse{}, fmt.Errorf("card %s: %w", id, err)
+		}
+		response.CardIDs = append(response.CardIDs, id)
+		if changed {
+			updated = append(updated, card)
 		}
 	}
+	// The audit entries go first so UpdateCards' save persists them with the batch
+	s.appendAudit(cardAuditEntries(AuditUpdate, "Added tags "+strings.Join(addTags, ", "), updated)...)
+	if err := s.Storage.UpdateCards(updated); err != nil {
+		return ApplyTagsResponse{}, fmt.Errorf("error updating cards in storage: %w", err)
+	}
+	response.Updated = len(updated)
+	return response, nil
+}
 
-	// Current time for priority calculation
-	now := time.Now()
-	fmt.Printf("[DEBUG-SVC] GetDueCard: Finding due cards among %d considered cards.\n", len(cardsToConsider))
+// SimulateDue lists the active cards that will be due at the given time, most overdue
+// first, so a session can be previewed ahead of time. Nothing is written to storage.
+func (s *FlashcardService) SimulateDue(at time.Time, filterTags []string) (SimulateDueResponse, error) {
+	storageCards, err := s.Storage.ListCards(filterTags)
+	if err != nil {
+		return SimulateDueResponse{}, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+
+	var due []storage.Card
+	for _, card := range storageCards {
+		if card.Suspended || card.Archived || card.FSRS.Due.After(at) {
+			continue
+		}
+		due = append(due, card)
+	}
+	sort.SliceStable(due, func(i, j int) bool {
+		return due[i].FSRS.Due.Before(due[j].FSRS.Due)
+	})
+
+	response := SimulateDueResponse{At: at, Cards: make([]Card, 0, len(due))}
+	for _, card := range due {
+		response.Cards = append(response.Cards, cardFromStorage(card))
+	}
+	response.Count = len(response.Cards)
+	return response, nil
+}
+
+// DefaultSlowAnswerMS is the average answer time above which slow_cards flags a card.
+const DefaultSlowAnswerMS = 60000
+
+// SlowCards returns the cards whose average answer time, over reviews that reported
+// a duration, exceeds thresholdMS, slowest first. A slow answer often means shaky
+// recall even when the card was rated correct.
+func (s *FlashcardService) SlowCards(thresholdMS int64, filterTags []string) ([]SlowCard, error) {
+	storageCards, err := s.Storage.ListCards(filterTags)
+	if err != nil {
+		return nil, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+
+	slow := []SlowCard{}
+	for _, card := range storageCards {
+		reviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
+		}
+		avg, timed := averageAnswerMS(reviews)
+		if timed == 0 {
+			continue
+		}
+		if avg > thresholdMS {
+			slow = append(slow, SlowCard{Card: cardFromStorage(card), AvgAnswerMS: avg, TimedReviews: timed})
+		}
+	}
+	sort.SliceStable(slow, func(i, j int) bool {
+		return slow[i].AvgAnswerMS > slow[j].AvgAnswerMS
+	})
+	return slow, nil
+}
+
+// averageAnswerMS returns the mean DurationMS of the reviews that recorded one,
+// and how many did. Reviews without a duration are ignored.
+func averageAnswerMS(reviews []storage.Review) (int64, int) {
+	var total int64
+	timed := 0
+	for _, review := range reviews {
+		if review.DurationMS > 0 {
+			total += review.DurationMS
+			timed++
+		}
+	}
+	if timed == 0 {
+		return 0, 0
+	}
+	return total / int64(timed), timed
+}
+
+// ProjectionDays is how far ahead projected_reviews forecasts.
+const ProjectionDays = 7
+
+// maxProjectedReviewsPerCard bounds the simulation for cards in short learning steps.
+const maxProjectedReviewsPerCard = 50
+
+// ProjectedReviews forecasts how many reviews each of the next ProjectionDays days
+// will need. Every active card is reviewed, in simulation, when it falls due (now,
+// if it is overdue) and rated Good; the FSRS schedule then decides whether and when
+// it comes back within the window.
+func (s *FlashcardService) ProjectedReviews(filterTags []string) (ProjectedReviews, error) {
+	storageCards, err := s.Storage.ListCards(filterTags)
+	if err != nil {
+		return ProjectedReviews{}, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+
+	now := timeNow()
+	today := localDay(now)
+	windowEnd := today.AddDate(0, 0, ProjectionDays)
+	counts := make([]int, ProjectionDays)
+	for _, card := range storageCards {
+		if card.Suspended || card.Archived {
+			continue
+		}
+		fsrsCard := card.FSRS
+		at := fsrsCard.Due
+		if at.Before(now) {
+			at = now
+		}
+		for i := 0; i < maxProjectedReviewsPerCard && at.Before(windowEnd); i++ {
+			counts[calendarDaysBetween(today, at)]++
+			fsrsCard = s.FSRSManager.GetSchedulingInfo(fsrsCard, gofsrs.Good, at)
+			if !fsrsCard.Due.After(at) {
+				break
+			}
+			at = fsrsCard.Due
+		}
+	}
+
+	projection := ProjectedReviews{Days: make([]ReviewDay, 0, ProjectionDays)}
+	for offset, reviews := range counts {
+		projection.Days = append(projection.Days, ReviewDay{
+			Date:    today.AddDate(0, 0, offset).Format("2006-01-02"),
+			Reviews: reviews,
+		})
+		projection.Total += reviews
+	}
+	return projection, nil
+}
+
+// DefaultForecastDays is how many days the review-forecast resource covers when no horizon is set.
+const DefaultForecastDays = 14
+
+// MaxForecastDays bounds the review forecast horizon.
+const MaxForecastDays = 365
+
+// ForecastReviews counts, for each of the next days days starting today, the
+// active cards that fall due that day, split into cards already studied and New
+// cards. Unlike ProjectedReviews it doesn't simulate reviews: each card is counted
+// once, on its current due day, and overdue cards all count toward today. Due
+// dates are shifted for vacations as in the stats.
+func (s *FlashcardService) ForecastReviews(days int) ([]ForecastDay, error) {
+	if days < 1 || days > MaxForecastDays {
+		return nil, fmt.Errorf("forecast must cover 1-%d days", MaxForecastDays)
+	}
+	storageCards, err := s.Storage.ListCards(nil)
+	if err != nil {
+		return nil, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+
+	now := timeNow()
+	today := localDay(now)
+	vacations := s.vacationsOrNil()
+	forecast := make([]ForecastDay, days)
+	for offset := range forecast {
+		forecast[offset].Date = today.AddDate(0, 0, offset).Format("2006-01-02")
+	}
+	for _, card := range storageCards {
+		if card.Suspended || card.Archived {
+			continue
+		}
+		offset := 0
+		if due := adjustDueForVacations(card.FSRS.Due, now, vacations); due.After(now) {
+			// Count calendar days, so DST changes don't shift the buckets
+			dueDay := localDay(due)
+			for offset < days && today.AddDate(0, 0, offset).Before(dueDay) {
+				offset++
+			}
+		}
+		if offset >= days {
+			continue
+		}
+		if card.FSRS.State == gofsrs.New {
+			forecast[offset].NewCount++
+		} else {
+			forecast[offset].DueCount++
+		}
+	}
+	return forecast, nil
+}
+
+// SetForecastHorizon persists how many days the review-forecast resource covers.
+func (s *FlashcardService) SetForecastHorizon(days int) error {
+	if days < 1 || days > MaxForecastDays {
+		return fmt.Errorf("forecast horizon must be 1-%d days", MaxForecastDays)
+	}
+	_, err := s.UpdateConfig(func(config *storage.Config) { config.ForecastDays = days })
+	return err
+}
+
+// SetNewCardsPerDay persists how many New cards GetDueCard introduces per day (0 = unlimited).
+func (s *FlashcardService) SetNewCardsPerDay(limit int) error {
+	if limit < 0 {
+		return errors.New("new cards per day must not be negative")
+	}
+	_, err := s.UpdateConfig(func(config *storage.Config) { config.NewCardsPerDay = &limit })
+	return err
+}
+
+// LevelWorkload spreads a backlog of overdue cards over the coming days so that
+// no day gets more than dailyCap due cards. Cards keep their place in the review
+// priority order: the highest-priority overdue cards stay due today, and the rest
+// move to the start of the first later day with room. Cards already scheduled for
+// a future day count toward that day's load but are not moved, so a day that is
+// over the cap on its own stays that way.
+func (s *FlashcardService) LevelWorkload(dailyCap int, filterTags []string) (LevelWorkloadResponse, error) {
+	if dailyCap <= 0 {
+		return LevelWorkloadResponse{}, errors.New("daily cap must be positive")
+	}
+	storageCards, err := s.Storage.ListCards(filterTags)
+	if err != nil {
+		return LevelWorkloadResponse{}, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+
+	now := timeNow()
+	today := localDay(now)
+	vacations := s.vacationsOrNil()
+	load := make(map[int]int) // Day offset from today -> cards due that day
+	type overdueCard struct {
+		card     storage.Card
+		priority float64
+	}
+	var overdue []overdueCard
+	for _, card := range storageCards {
+		if card.Suspended || card.Archived {
+			continue
+		}
+		effectiveDue := adjustDueForVacations(card.FSRS.Due, now, vacations)
+		if !effectiveDue.After(now) {
+			overdue = append(overdue, overdueCard{card, s.FSRSManager.GetReviewPriority(card.FSRS.State, effectiveDue, now)})
+			continue
+		}
+		load[calendarDaysBetween(today, effectiveDue)]++
+	}
+	sort.Slice(overdue, func(i, j int) bool {
+		if overdue[i].priority != overdue[j].priority {
+			return overdue[i].priority > overdue[j].priority
+		}
+		return overdue[i].card.ID < overdue[j].card.ID
+	})
+
+	response := LevelWorkloadResponse{DailyCap: dailyCap, Overdue: len(overdue)}
+	var moved []storage.Card
+	day := 0
+	for _, entry := range overdue {
+		for load[day] >= dailyCap {
+			day++
+		}
+		load[day]++
+		if day == 0 {
+			continue // Stays due now
+		}
+		entry.card.FSRS.Due = today.AddDate(0, 0, day)
+		moved = append(moved, entry.card)
+	}
+	// The audit entries go first so UpdateCards' save persists them with the batch
+	entries := make([]storage.AuditEntry, len(moved))
+	for i, card := range moved {
+		entries[i] = newAuditEntry(AuditUpdate, card.ID, "Moved to "+card.FSRS.Due.Format("2006-01-02")+" to level the workload")
+	}
+	s.appendAudit(entries...)
+	if err := s.Storage.UpdateCards(moved); err != nil {
+		return LevelWorkloadResponse{}, fmt.Errorf("error updating cards in storage: %w", err)
+	}
+	response.Rescheduled = len(moved)
+
+	for offset := 0; offset <= day; offset++ {
+		response.Schedule = append(response.Schedule, DayLoad{
+			Date:  today.AddDate(0, 0, offset).Format("2006-01-02"),
+			Cards: load[offset],
+		})
+	}
+	return response, nil
+}
+
+// equalStringSlices checks if two string slices are equal (considers order).
+// TODO: Move to a utility package or consider sorting before comparison if order doesn't matter.
+func equalStringSlices(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// DeleteCard deletes a flashcard
+func (s *FlashcardService) DeleteCard(cardID string) error {
+	fmt.Printf("[DEBUG-SVC-DELETE] Starting DeleteCard for ID %s\n", cardID)
+	// Delete the card from storage
+	if err := s.Storage.DeleteCard(cardID); err != nil {
+		fmt.Printf("[DEBUG-SVC-DELETE] Storage.DeleteCard returned error: %v\n", err)
+		return fmt.Errorf("error deleting card: %w", err)
+	}
+	fmt.Printf("[DEBUG-SVC-DELETE] Card deleted from storage successfully\n")
+	s.appendAudit(newAuditEntry(AuditDelete, cardID, "Deleted card"))
+
+	// Persist changes to disk
+	fmt.Printf("[DEBUG-SVC-DELETE] Now calling Storage.Save()\n")
+	if err := s.Storage.Save(); err != nil {
+		fmt.Printf("[DEBUG-SVC-DELETE] Storage.Save() returned error: %v\n", err)
+		return fmt.Errorf("error saving storage: %w", err)
+	}
+	fmt.Printf("[DEBUG-SVC-DELETE] Storage.Save() completed successfully\n")
+
+	return nil
+}
+
+// GetCard returns a single card by ID, with stats computed over the whole collection.
+func (s *FlashcardService) GetCard(cardID string) (Card, CardStats, error) {
+	storageCard, err := s.Storage.GetCard(cardID)
+	if err != nil {
+		return Card{}, CardStats{}, fmt.Errorf("error getting card %s: %w", cardID, err)
+	}
+	allCards, err := s.Storage.ListCards(nil)
+	if err != nil {
+		return Card{}, CardStats{}, fmt.Errorf("error listing all cards: %w", err)
+	}
+	return cardFromStorage(storageCard), s.calculateStats(allCards), nil
+}
+
+// CardReviews returns a card's review history, oldest first.
+func (s *FlashcardService) CardReviews(cardID string) ([]storage.Review, error) {
+	reviews, err := s.Storage.GetCardReviews(cardID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting reviews for card %s: %w", cardID, err)
+	}
+	sort.SliceStable(reviews, func(i, j int) bool {
+		return reviews[i].Timestamp.Before(reviews[j].Timestamp)
+	})
+	return reviews, nil
+}
+
+// Tag match modes accepted by the tag_match argument of list_cards and get_due_card
+const (
+	TagMatchAll = "all" // Card must carry every filter tag (default)
+	TagMatchAny = "any" // Card must carry at least one filter tag
+)
+
+// ListCards lists all flashcards, optionally filtered by tags (card must have ALL of them)
+func (s *FlashcardService) ListCards(filterTags []string, includeStats bool) ([]Card, CardStats, error) {
+	return s.ListCardsMatching(filterTags, TagMatchAll, includeStats)
+}
+
+// ListCardsMatching lists flashcards filtered by tags using tagMatch (TagMatchAll or TagMatchAny).
+// Stats always cover the whole collection, whatever the filter.
+func (s *FlashcardService) ListCardsMatching(filterTags []string, tagMatch string, includeStats bool) ([]Card, CardStats, error) {
+	// Use storage ListCards with the filter
+	storageCards, err := s.Storage.ListCards(filterTags, tagMatch != TagMatchAny)
+	if err != nil {
+		return nil, CardStats{}, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+
+	// Convert storage.Card array to our main Card type array
+	cards := make([]Card, 0, len(storageCards))
+	for _, storageCard := range storageCards {
+		card := cardFromStorage(storageCard)
+		cards = append(cards, card)
+	}
+
+	// Calculate stats if requested
+	var stats CardStats
+	if includeStats {
+		// Fetch all cards for stats calculation, regardless of filter
+		allStorageCards, err := s.Storage.ListCards(nil)
+		if err != nil {
+			// Log error but proceed with potentially empty stats
+			fmt.Printf("Warning: error getting all cards for stats: %v\n", err)
+			stats = CardStats{TotalCards: len(storageCards)} // Use filtered count as fallback?
+		} else {
+			stats = s.calculateStats(allStorageCards)
+		}
+	}
+
+	return cards, stats, nil
+}
+
+// DefaultListCardsLimit is the page size of list_cards when no limit is given.
+const DefaultListCardsLimit = 50
+
+// PageCards sorts cards by creation time, then ID, and returns the page of at most
+// limit cards starting at offset (limit 0 means all), and whether more cards follow it.
+// The sort makes consecutive pages consistent whatever order storage returned.
+func PageCards(cards []Card, limit, offset int) ([]Card, bool) {
+	sorted := append([]Card(nil), cards...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if !sorted[i].CreatedAt.Equal(sorted[j].CreatedAt) {
+			return sorted[i].CreatedAt.Before(sorted[j].CreatedAt)
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	if offset >= len(sorted) {
+		return []Card{}, false
+	}
+	sorted = sorted[offset:]
+	if limit > 0 && limit < len(sorted) {
+		return sorted[:limit], true
+	}
+	return sorted, false
+}
+
+// GetDueCard returns the next card due for review with statistics, optionally filtered by tags
+func (s *FlashcardService) GetDueCard(filterTags []string) (Card, CardStats, error) {
+	return s.GetDueCardMatching(filterTags, TagMatchAll, nil)
+}
+
+// GetDueCardMatching is GetDueCard with the tag filter applied using tagMatch (TagMatchAll or TagMatchAny).
+// When state is set, only cards in that FSRS state are served; the stats still cover every card.
+func (s *FlashcardService) GetDueCardMatching(filterTags []string, tagMatch string, state *gofsrs.State) (Card, CardStats, error) {
+	fmt.Printf("[DEBUG-SVC] GetDueCard called with filterTags: %v\n", filterTags)
+	// Get all cards from storage first to calculate overall statistics
+	allCards, err := s.Storage.ListCards(nil)
+	if err != nil {
+		fmt.Printf("[DEBUG-SVC] GetDueCard: error listing all cards: %v\n", err)
+		return Card{}, CardStats{}, fmt.Errorf("error listing all cards: %w", err)
+	}
+	fmt.Printf("[DEBUG-SVC] GetDueCard: Found %d total cards in storage.\n", len(allCards))
+
+	// Debug - print all card IDs
+	fmt.Printf("[DEBUG-SVC] All card IDs: [")
+	for _, card := range allCards {
+		fmt.Printf("%s, ", card.ID)
+	}
+	fmt.Printf("]\n")
+
+	// Calculate overall statistics based on all cards
+	stats := s.calculateStats(allCards)
+
+	// Stop serving cards once the daily review limit is reached to prevent burnout
+	if s.MaxReviewsPerDay > 0 && stats.ReviewsToday >= s.MaxReviewsPerDay {
+		return Card{}, stats, fmt.Errorf("%w: %d of %d reviews completed today", ErrDailyReviewLimitReached, stats.ReviewsToday, s.MaxReviewsPerDay)
+	}
+
+	// If no filter tags were provided, get all cards
+	var cardsToConsider []storage.Card
+	if len(filterTags) == 0 {
+		fmt.Printf("[DEBUG-SVC] GetDueCard: No filter tags provided, considering all %d cards.\n", len(allCards))
+		cardsToConsider = allCards
+	} else {
+		fmt.Printf("[DEBUG-SVC] GetDueCard: Filtering %d cards by tags: %v\n", len(allCards), filterTags)
+		// When filter tags are provided, we need to find cards with ALL (or, for TagMatchAny, ANY) of the specified tags
+		for i, card := range allCards {
+			matches := hasAllRequiredTags(&card, filterTags)
+			if tagMatch == TagMatchAny {
+				matches = hasAnyRequiredTag(&card, filterTags)
+			}
+			fmt.Printf("[DEBUG-SVC] GetDueCard: Checking card %d (ID: %s, Tags: %v) against filter %v -> Matches: %t\n", i, card.ID, card.Tags, filterTags, matches)
+			if matches {
+				cardsToConsider = append(cardsToConsider, card)
+			}
+		}
+		fmt.Printf("[DEBUG-SVC] GetDueCard: Filtering complete. %d cards matched the tags.\n", len(cardsToConsider))
+
+		// If no cards match the tag filter, return an error
+		if len(cardsToConsider) == 0 {
+			fmt.Printf("[DEBUG-SVC] GetDueCard: No cards matched tags, returning error.\n")
+			return Card{}, stats, fmt.Errorf("no cards found with the specified tags: %v", filterTags)
+		}
+	}
+
+	// Suspended and archived cards are never served for review, nor are cards outside the requested
+	// state, nor New cards once today's new card limit is used up
+	newCardsCapped := s.NewCardsPerDay > 0 && stats.NewCardsIntroducedToday >= s.NewCardsPerDay
+	heldBackNewCards := false
+	activeCards := cardsToConsider[:0:0]
+	for _, storageCard := range cardsToConsider {
+		if storageCard.Suspended || storageCard.Archived {
+			continue
+		}
+		if state != nil && storageCard.FSRS.State != *state {
+			continue
+		}
+		if newCardsCapped && storageCard.FSRS.State == gofsrs.New {
+			heldBackNewCards = true
+			continue
+		}
+		activeCards = append(activeCards, storageCard)
+	}
+	cardsToConsider = activeCards
+
+	// A card boosted with prioritize_card is served next (even if not yet due), exactly once
+	for _, storageCard := range cardsToConsider {
+		if storageCard.Prioritized {
+			storageCard.Prioritized = false
+			if err := s.Storage.UpdateCard(storageCard); err != nil {
+				return Card{}, stats, fmt.Errorf("error clearing priority boost for card %s: %w", storageCard.ID, err)
+			}
+			fmt.Printf("[DEBUG-SVC] GetDueCard: Returning prioritized card ID %s.\n", storageCard.ID)
+			return cardFromStorage(storageCard), stats, nil
+		}
+	}
+
+	// Current time for priority calculation
+	now := time.Now()
+	fmt.Printf("[DEBUG-SVC] GetDueCard: Finding due cards among %d considered cards.\n", len(cardsToConsider))
 
 	// Find due cards from the filtered list and calculate priority
 	var dueCards []struct {
@@ -243,430 +1173,4169 @@ func (s *FlashcardService) GetDueCard(filterTags []string) (Card, CardStats, err
 		priority float64
 	}
 
-	for _, storageCard := range cardsToConsider { // Iterate over the filtered list
-		cardIsDue := !storageCard.FSRS.Due.After(now)
-		fmt.Printf("[DEBUG-SVC] GetDueCard: Checking considered card ID %s (Due: %v, IsDue: %t)\n", storageCard.ID, storageCard.FSRS.Due, cardIsDue)
-		// Consider cards due now or in the past
-		if cardIsDue {
-			priority := s.FSRSManager.GetReviewPriority(storageCard.FSRS.State, storageCard.FSRS.Due, now)
-			// Convert storage.Card to our main Card type here
-			card := Card{
-				ID:        storageCard.ID,
-				Front:     storageCard.Front,
-				Back:      storageCard.Back,
-				CreatedAt: storageCard.CreatedAt,
-				Tags:      storageCard.Tags,
-				FSRS:      storageCard.FSRS,
-			}
-			dueCards = append(dueCards, struct {
-				card     Card
-				priority float64
-			}{card, priority})
-			fmt.Printf("[DEBUG-SVC] GetDueCard: Added due card ID %s to list (Priority: %f)\n", card.ID, priority)
-		}
+	// Time spent on vacation doesn't count toward how overdue a card is
+	vacations := s.vacationsOrNil()
+
+	for _, storageCard := range cardsToConsider { // Iterate over the filtered list
+		effectiveDue := adjustDueForVacations(storageCard.FSRS.Due, now, vacations)
+		cardIsDue := !effectiveDue.After(now)
+		fmt.Printf("[DEBUG-SVC] GetDueCard: Checking considered card ID %s (Due: %v, IsDue: %t)\n", storageCard.ID, storageCard.FSRS.Due, cardIsDue)
+		// Consider cards due now or in the past
+		if cardIsDue {
+			priority := s.FSRSManager.GetReviewPriority(storageCard.FSRS.State, effectiveDue, now)
+			// Convert storage.Card to our main Card type here
+			card := cardFromStorage(storageCard)
+			dueCards = append(dueCards, struct {
+				card     Card
+				priority float64
+			}{card, priority})
+			fmt.Printf("[DEBUG-SVC] GetDueCard: Added due card ID %s to list (Priority: %f)\n", card.ID, priority)
+		}
+	}
+	fmt.Printf("[DEBUG-SVC] GetDueCard: Found %d due cards among considered cards.\n", len(dueCards))
+
+	// Sort the due cards (from the filtered list) by priority (highest first)
+	sort.Slice(dueCards, func(i, j int) bool {
+		return dueCards[i].priority > dueCards[j].priority
+	})
+
+	// Once nothing else is due, give cards that lapsed this session another look
+	if len(dueCards) == 0 {
+		if card, ok := s.popSessionQueue(cardsToConsider); ok {
+			fmt.Printf("[DEBUG-SVC] GetDueCard: Re-offering lapsed card ID %s from the session queue.\n", card.ID)
+			return cardFromStorage(card), stats, nil
+		}
+	}
+
+	// Return highest priority card from the filtered set or error if none due
+	if len(dueCards) == 0 {
+		if heldBackNewCards {
+			return Card{}, stats, fmt.Errorf("%w: %d of %d new cards introduced today", ErrNewCardLimitReached, stats.NewCardsIntroducedToday, s.NewCardsPerDay)
+		}
+		if state != nil {
+			return Card{}, stats, fmt.Errorf("%w: %s", ErrNoDueCardsInState, stateNames[*state])
+		}
+		if len(filterTags) > 0 {
+			fmt.Printf("[DEBUG-SVC] GetDueCard: No DUE cards matched tags, returning error.\n")
+			return Card{}, stats, fmt.Errorf("no cards due for review with the specified tags: %v", filterTags)
+		}
+		// No filter, but no cards due
+		fmt.Printf("[DEBUG-SVC] GetDueCard: No cards are due for review, returning error.\n")
+		return Card{}, stats, fmt.Errorf("no cards due for review")
+	}
+
+	// Return the highest priority card from the filtered due list, along with overall stats
+	fmt.Printf("[DEBUG-SVC] GetDueCard: Returning highest priority card ID %s.\n", dueCards[0].card.ID)
+	return dueCards[0].card, stats, nil
+}
+
+// updateSessionQueue queues a card for a re-show when it was rated Again, and
+// drops it from the queue once it is recalled.
+func (s *FlashcardService) updateSessionQueue(cardID string, rating gofsrs.Rating) {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+	queue := s.sessionQueue[:0]
+	for _, id := range s.sessionQueue {
+		if id != cardID {
+			queue = append(queue, id)
+		}
+	}
+	if rating == gofsrs.Again {
+		queue = append(queue, cardID)
+	}
+	s.sessionQueue = queue
+}
+
+// popSessionQueue removes and returns the first queued card among candidates.
+func (s *FlashcardService) popSessionQueue(candidates []storage.Card) (storage.Card, bool) {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+	for i, id := range s.sessionQueue {
+		for _, card := range candidates {
+			if card.ID == id {
+				s.sessionQueue = append(s.sessionQueue[:i], s.sessionQueue[i+1:]...)
+				return card, true
+			}
+		}
+	}
+	return storage.Card{}, false
+}
+
+// ClearSession drops every queued re-show of lapsed cards so the next session
+// starts clean, and returns how many were dropped. The cards keep their FSRS
+// schedule and come back when they are next due.
+func (s *FlashcardService) ClearSession() int {
+	s.sessionMu.Lock()
+	defer s.sessionMu.Unlock()
+	cleared := len(s.sessionQueue)
+	s.sessionQueue = nil
+	return cleared
+}
+
+// ReloadStorage discards the in-memory store and reads the data file again, for
+// when it was edited or restored outside the server. Storage.Reload holds the write
+// lock while it replaces the store, and keeps the old one if the file is missing,
+// empty or can't be read.
+// The session queue is cleared since its cards may no longer exist; service settings
+// are left as they are, so flag overrides from startup survive.
+func (s *FlashcardService) ReloadStorage() (ReloadStorageResponse, error) {
+	if err := s.Storage.Reload(); err != nil {
+		return ReloadStorageResponse{}, fmt.Errorf("error reloading storage: %w", err)
+	}
+	s.ClearSession()
+
+	store, err := s.Storage.ExportStore()
+	if err != nil {
+		return ReloadStorageResponse{}, fmt.Errorf("error reading reloaded store: %w", err)
+	}
+	return ReloadStorageResponse{
+		Cards:    len(store.Cards),
+		Reviews:  len(store.Reviews),
+		DueDates: len(store.DueDates),
+	}, nil
+}
+
+// Helper function to ensure all required tags are present in a card
+func hasAllRequiredTags(card *storage.Card, requiredTags []string) bool {
+	if len(requiredTags) == 0 {
+		return true // No required tags means all cards match
+	}
+
+	if card == nil {
+		return false // Can't match any tags if card is nil
+	}
+
+	// If the card has no tags but we have required tags, it can't match
+	if len(card.Tags) == 0 {
+		return false
+	}
+
+	// Create a map of the card's tags for efficient lookup
+	cardTagsMap := make(map[string]bool)
+	for _, tag := range card.Tags {
+		cardTagsMap[tag] = true
+	}
+
+	// Check if the card has all required tags
+	for _, reqTag := range requiredTags {
+		if !cardTagsMap[reqTag] {
+			return false // Missing a required tag
+		}
+	}
+
+	return true // All required tags found
+}
+
+// Helper function to check that a card carries at least one of the required tags
+func hasAnyRequiredTag(card *storage.Card, requiredTags []string) bool {
+	if len(requiredTags) == 0 {
+		return true // No required tags means all cards match
+	}
+
+	if card == nil {
+		return false
+	}
+
+	for _, tag := range card.Tags {
+		for _, reqTag := range requiredTags {
+			if tag == reqTag {
+				return true // Found one of the required tags
+			}
+		}
+	}
+
+	return false
+}
+
+// calculateStats calculates statistics from card and review data
+func (s *FlashcardService) calculateStats(cards []storage.Card) CardStats {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	// Count total and due cards; suspended cards are never due
+	totalCards := len(cards)
+	dueCards := 0
+	suspendedCards := 0
+	vacations := s.vacationsOrNil()
+	for _, card := range cards {
+		if card.Suspended {
+			suspendedCards++
+			continue
+		}
+		if !adjustDueForVacations(card.FSRS.Due, now, vacations).After(now) {
+			dueCards++
+		}
+	}
+
+	// Count today's correct answers; answer times and the streak cover all reviews
+	var allReviews []storage.Review
+	correctReviewsToday := 0
+	newCardsToday := 0
+	for _, card := range cards {
+		cardReviews, err := s.Storage.GetCardReviews(card.ID)
+		if err == nil {
+			allReviews = append(allReviews, cardReviews...)
+			if first, ok := firstScheduledReview(card, cardReviews); ok && !first.Timestamp.Before(today) {
+				newCardsToday++
+			}
+			for _, review := range scheduledReviews(cardReviews) {
+				// Rating 3 (Good) or 4 (Easy) is considered correct
+				if !review.Timestamp.Before(today) && review.Rating >= gofsrs.Good {
+					correctReviewsToday++
+				}
+			}
+		}
+	}
+
+	// Calculate retention rate (correct answers / total reviews today)
+	reviewsToday := reviewCountsByDay(scheduledReviews(allReviews))[localDay(now)]
+	retentionRate := 0.0
+	if reviewsToday > 0 {
+		retentionRate = float64(correctReviewsToday) / float64(reviewsToday) * 100.0
+	}
+	avgAnswerMS, _ := averageAnswerMS(allReviews)
+
+	return CardStats{
+		TotalCards:              totalCards,
+		DueCards:                dueCards,
+		SuspendedCards:          suspendedCards,
+		ReviewsToday:            reviewsToday,
+		RetentionRate:           retentionRate,
+		AvgAnswerTimeMS:         avgAnswerMS,
+		StudyStreakDays:         studyStreak(allReviews, now).CurrentStreak,
+		NewCardsIntroducedToday: newCardsToday,
+	}
+}
+
+// scheduledReviews returns the reviews that count toward scheduling and the review
+// stats, leaving out practice reviews, in a new slice.
+func scheduledReviews(reviews []storage.Review) []storage.Review {
+	scheduled := make([]storage.Review, 0, len(reviews))
+	for _, review := range reviews {
+		if !review.Practice {
+			scheduled = append(scheduled, review)
+		}
+	}
+	return scheduled
+}
+
+// reviewsSinceReset returns the reviews of card from after its scheduling was last
+// reset by ResetCard; the earlier ones stay in the history but no longer affect
+// scheduling.
+func reviewsSinceReset(card storage.Card, reviews []storage.Review) []storage.Review {
+	if card.ResetAt.IsZero() {
+		return reviews
+	}
+	kept := make([]storage.Review, 0, len(reviews))
+	for _, review := range reviews {
+		if !review.Timestamp.Before(card.ResetAt) {
+			kept = append(kept, review)
+		}
+	}
+	return kept
+}
+
+// firstScheduledReview returns a card's earliest non-practice review since it was
+// last reset: the one that took it out of the New state.
+func firstScheduledReview(card storage.Card, reviews []storage.Review) (storage.Review, bool) {
+	var first storage.Review
+	found := false
+	for _, review := range reviewsSinceReset(card, reviews) {
+		if !review.Practice && (!found || review.Timestamp.Before(first.Timestamp)) {
+			first, found = review, true
+		}
+	}
+	return first, found
+}
+
+// SubmitReview processes a review for a card and updates its state using the FSRS algorithm
+func (s *FlashcardService) SubmitReview(cardID string, rating gofsrs.Rating, answer string) (Card, error) {
+	return s.SubmitReviewWithTime(cardID, rating, answer, timeNow())
+}
+
+// SubmitPracticeReview records a practice review of a card. The review is logged
+// with Practice set, but the card's FSRS schedule is untouched and the review
+// never counts toward mastery.
+func (s *FlashcardService) SubmitPracticeReview(cardID string, rating gofsrs.Rating, answer string, now time.Time, durationMS int64) (Card, error) {
+	storageCard, err := s.Storage.GetCard(cardID)
+	if err != nil {
+		return Card{}, fmt.Errorf("error getting card %s: %w", cardID, err)
+	}
+	review := storage.Review{
+		ID:         uuid.New().String(),
+		CardID:     cardID,
+		Rating:     rating,
+		Timestamp:  now,
+		Answer:     answer,
+		State:      storageCard.FSRS.State,
+		Practice:   true,
+		DurationMS: durationMS,
+	}
+	// The audit entry goes first so AddReviewDirect's save persists it
+	entry := newAuditEntry(AuditReview, cardID, "Practiced, rated "+ratingNames[rating]+"; schedule unchanged")
+	entry.Timestamp = now
+	s.appendAudit(entry)
+	if err := s.Storage.AddReviewDirect(review); err != nil {
+		return Card{}, fmt.Errorf("error adding practice review: %w", err)
+	}
+	return cardFromStorage(storageCard), nil
+}
+
+// UndoLastReview deletes the most recent review of a card and rebuilds its FSRS
+// state by replaying the remaining scheduled reviews from the card's initial New
+// state. Practice reviews are never replayed since they don't affect scheduling.
+func (s *FlashcardService) UndoLastReview(cardID string) (Card, error) {
+	storageCard, err := s.Storage.GetCard(cardID)
+	if err != nil {
+		return Card{}, fmt.Errorf("error getting card %s: %w", cardID, err)
+	}
+	reviews, err := s.Storage.GetCardReviews(cardID)
+	if err != nil {
+		return Card{}, fmt.Errorf("error getting reviews for card %s: %w", cardID, err)
+	}
+	if len(reviews) == 0 {
+		return Card{}, fmt.Errorf("%w: %s", ErrNoReviews, cardID)
+	}
+
+	sort.SliceStable(reviews, func(i, j int) bool {
+		return reviews[i].Timestamp.Before(reviews[j].Timestamp)
+	})
+	last := reviews[len(reviews)-1]
+	if err := s.Storage.DeleteReview(last.ID); err != nil {
+		return Card{}, fmt.Errorf("error deleting review %s: %w", last.ID, err)
+	}
+
+	scheduled := scheduledReviews(reviewsSinceReset(storageCard, reviews[:len(reviews)-1]))
+	if len(scheduled) == 0 {
+		due := storageCard.CreatedAt
+		if !storageCard.ResetAt.IsZero() {
+			due = storageCard.ResetAt
+		}
+		storageCard.FSRS = gofsrs.Card{Due: due, State: gofsrs.New}
+		storageCard.LastReviewedAt = time.Time{}
+	} else {
+		storageCard.FSRS = s.replayReviews(storageCard, scheduled)
+		storageCard.LastReviewedAt = scheduled[len(scheduled)-1].Timestamp
+	}
+	if storageCard.FSRS.Lapses < uint64(s.leechThreshold()) {
+		storageCard.Leech = false
+	}
+
+	if err := s.Storage.UpdateCard(storageCard); err != nil {
+		return Card{}, fmt.Errorf("error updating card %s: %w", cardID, err)
+	}
+	s.appendAudit(newAuditEntry(AuditUpdate, cardID, "Undid review rated "+ratingNames[last.Rating]))
+	if err := s.Storage.Save(); err != nil {
+		return Card{}, fmt.Errorf("error saving storage after undoing review: %w", err)
+	}
+	return cardFromStorage(storageCard), nil
+}
+
+// ResetCard clears a card's scheduling back to a New card due now, for when the card
+// was rewritten enough that its old stability no longer applies. The card's reviews
+// are deleted unless keepHistory is set; kept reviews stay in the history and stats
+// but, being older than the card's ResetAt, no longer affect scheduling.
+func (s *FlashcardService) ResetCard(cardID string, keepHistory bool) (Card, error) {
+	storageCard, err := s.Storage.GetCard(cardID)
+	if err != nil {
+		return Card{}, fmt.Errorf("error getting card %s: %w", cardID, err)
+	}
+	if !keepHistory {
+		if err := s.Storage.DeleteCardReviews(cardID); err != nil {
+			return Card{}, fmt.Errorf("error deleting reviews for card %s: %w", cardID, err)
+		}
+	}
+
+	now := timeNow()
+	storageCard.FSRS = gofsrs.Card{Due: now, State: gofsrs.New}
+	storageCard.LastReviewedAt = time.Time{}
+	storageCard.ResetAt = now
+	storageCard.Leech = false
+	if err := s.Storage.UpdateCard(storageCard); err != nil {
+		return Card{}, fmt.Errorf("error updating card %s: %w", cardID, err)
+	}
+	summary := "Reset scheduling and deleted review history"
+	if keepHistory {
+		summary = "Reset scheduling, keeping review history"
+	}
+	s.appendAudit(newAuditEntry(AuditUpdate, cardID, summary))
+	if err := s.Storage.Save(); err != nil {
+		return Card{}, fmt.Errorf("error saving storage after resetting card: %w", err)
+	}
+	return cardFromStorage(storageCard), nil
+}
+
+// PracticeCards returns the active cards carrying the tag in a random order chosen
+// by seed, for a practice round that doesn't affect scheduling. At most limit
+// cards are returned when limit is positive. Hints are left out, as in get_due_card.
+func (s *FlashcardService) PracticeCards(tag string, limit int, seed int64) ([]Card, error) {
+	storageCards, err := s.GetCardsByTag(tag)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(storageCards, func(i, j int) bool {
+		return storageCards[i].ID < storageCards[j].ID
+	})
+
+	cards := make([]Card, 0, len(storageCards))
+	for _, storageCard := range storageCards {
+		if storageCard.Suspended || storageCard.Archived {
+			continue
+		}
+		card := cardFromStorage(storageCard)
+		card.Hint = ""
+		cards = append(cards, card)
+	}
+	rand.New(rand.NewSource(seed)).Shuffle(len(cards), func(i, j int) {
+		cards[i], cards[j] = cards[j], cards[i]
+	})
+	if limit > 0 && len(cards) > limit {
+		cards = cards[:limit]
+	}
+	return cards, nil
+}
+
+// SubmitReviewWithTime processes a review for a card and updates its state using the FSRS algorithm
+// with a specific timestamp. This allows tests to provide a simulated "now" timestamp.
+func (s *FlashcardService) SubmitReviewWithTime(cardID string, rating gofsrs.Rating, answer string, now time.Time) (Card, error) {
+	return s.SubmitTimedReview(cardID, rating, answer, now, 0)
+}
+
+// SubmitTimedReview is SubmitReviewWithTime that also records how long, in
+// milliseconds, the student took to answer. A zero duration means it wasn't measured.
+func (s *FlashcardService) SubmitTimedReview(cardID string, rating gofsrs.Rating, answer string, now time.Time, durationMS int64) (Card, error) {
+	startTime := now
+	fmt.Printf("[DEBUG-SVC] SubmitReview starting for cardID=%s, rating=%d at %v\n",
+		cardID, rating, startTime.Format(time.RFC3339Nano))
+
+	// Get the card from storage
+	fmt.Printf("[DEBUG-SVC] Retrieving card from storage\n")
+	storageCard, err := s.Storage.GetCard(cardID)
+	if err != nil {
+		fmt.Printf("[DEBUG-SVC] Error getting card: %v\n", err)
+		return Card{}, fmt.Errorf("error getting card: %w", err)
+	}
+	fmt.Printf("[DEBUG-SVC] Retrieved card with current state=%v, due=%v\n",
+		storageCard.FSRS.State, storageCard.FSRS.Due)
+
+	// Get previous reviews to calculate actual elapsed time
+	fmt.Printf("[DEBUG-SVC] Retrieving previous reviews for cardID=%s\n", cardID)
+	previousReviews, err := s.Storage.GetCardReviews(cardID)
+	if err != nil {
+		fmt.Printf("[DEBUG-SVC] Error getting reviews: %v\n", err)
+		// Don't fail the operation, just continue with default elapsed days
+	}
+	fmt.Printf("[DEBUG-SVC] Found %d previous reviews for card %s\n", len(previousReviews), cardID)
+
+	storageCard, reviewLog := s.scheduleReview(storageCard, previousReviews, rating, answer, now, durationMS)
+
+	// Save the updated card state back to storage
+	fmt.Printf("[DEBUG-SVC] Updating card in storage at %v\n", timeNow().Format(time.RFC3339Nano))
+	if err := s.Storage.UpdateCard(storageCard); err != nil {
+		fmt.Printf("[DEBUG-SVC] Error updating card: %v\n", err)
+		return Card{}, fmt.Errorf("error updating card: %w", err)
+	}
+
+	// Add review to storage
+	fmt.Printf("[DEBUG-SVC] Adding review to storage at %v\n", timeNow().Format(time.RFC3339Nano))
+	if err := s.Storage.AddReviewDirect(reviewLog); err != nil {
+		fmt.Printf("[DEBUG-SVC] Error adding review: %v\n", err)
+		return Card{}, fmt.Errorf("error adding review: %w", err)
+	}
+	fmt.Printf("[DEBUG-SVC] Review added successfully\n")
+	s.appendAudit(reviewAuditEntry(storageCard, reviewLog))
+
+	// Persist changes to disk
+	fmt.Printf("[DEBUG-SVC] Saving storage to disk at %v\n", timeNow().Format(time.RFC3339Nano))
+	if err := s.Storage.Save(); err != nil {
+		fmt.Printf("[DEBUG-SVC] Error saving storage: %v\n", err)
+		return Card{}, fmt.Errorf("error saving storage: %w", err)
+	}
+	fmt.Printf("[DEBUG-SVC] Storage saved successfully\n")
+	s.updateSessionQueue(cardID, rating)
+
+	// Convert updated storage.Card to our main Card type
+	updatedCard := cardFromStorage(storageCard)
+
+	elapsed := time.Since(startTime)
+	fmt.Printf("[DEBUG-SVC] SubmitReview completed in %v at %v\n",
+		elapsed, timeNow().Format(time.RFC3339Nano))
+
+	return updatedCard, nil
+}
+
+// scheduleReview applies a rating to a card with the FSRS scheduler and returns the
+// updated card and the review to record, without storing either. previousReviews
+// are the card's earlier reviews in any order; practice reviews among them, and
+// those from before the card was reset, don't count toward the elapsed time or the
+// recall window.
+func (s *FlashcardService) scheduleReview(storageCard storage.Card, previousReviews []storage.Review, rating gofsrs.Rating, answer string, now time.Time, durationMS int64) (storage.Card, storage.Review) {
+	previousReviews = scheduledReviews(reviewsSinceReset(storageCard, previousReviews))
+	// Calculate elapsed days since last review if we have review history
+	if len(previousReviews) > 0 {
+		// Sort reviews by timestamp (newest first)
+		sort.Slice(previousReviews, func(i, j int) bool {
+			return previousReviews[i].Timestamp.After(previousReviews[j].Timestamp)
+		})
+
+		// Get the most recent review
+		lastReviewTime := previousReviews[0].Timestamp
+
+		// Calculate elapsed days
+		elapsedDuration := now.Sub(lastReviewTime)
+		elapsedDays := uint64(elapsedDuration.Hours() / 24.0)
+
+		// Update the ElapsedDays in the card's FSRS state
+		storageCard.FSRS.ElapsedDays = elapsedDays
+
+		fmt.Printf("[DEBUG-SVC] Last review at %v, now at %v, elapsed days: %d\n",
+			lastReviewTime.Format(time.RFC3339), now.Format(time.RFC3339), elapsedDays)
+	}
+
+	fmt.Printf("[DEBUG-SVC] Calling GetSchedulingInfo with ElapsedDays=%d\n",
+		storageCard.FSRS.ElapsedDays)
+
+	// Get the complete updated FSRS card with all metadata using the new method
+	updatedFSRSCard := s.FSRSManager.GetSchedulingInfo(
+		storageCard.FSRS, // Pass the entire FSRS card with updated ElapsedDays
+		rating,
+		now,
+	)
+	fmt.Printf("[DEBUG-SVC] FSRS scheduling result: newState=%v, newDueDate=%v, stability=%.4f, difficulty=%.4f, reps=%d\n",
+		updatedFSRSCard.State, updatedFSRSCard.Due, updatedFSRSCard.Stability, updatedFSRSCard.Difficulty, updatedFSRSCard.Reps)
+
+	// Update the storage card with the complete FSRS data
+	fmt.Printf("[DEBUG-SVC] Updating card with complete FSRS state\n")
+	storageCard.FSRS = updatedFSRSCard // Replace entire FSRS card with updated version
+	storageCard.LastReviewedAt = now   // Record last reviewed time (field should exist now)
+	direction := storageCard.Direction
+	storageCard.Direction = "" // The recorded direction applies to this review only
+
+	if s.AutoTagRecall {
+		recentRatings := []gofsrs.Rating{rating}
+		for _, review := range previousReviews { // Sorted newest first above
+			if len(recentRatings) == recallWindow {
+				break
+			}
+			recentRatings = append(recentRatings, review.Rating)
+		}
+		storageCard.Tags = applyRecallTag(storageCard.Tags, recentRatings)
+	}
+	if !storageCard.Leech && storageCard.FSRS.Lapses >= uint64(s.leechThreshold()) {
+		storageCard.Leech = true
+		if s.AutoSuspendLeeches {
+			storageCard.Suspended = true
+		}
+	}
+
+	reviewLog := storage.Review{
+		ID:            uuid.New().String(),
+		CardID:        storageCard.ID,
+		Rating:        rating,
+		Timestamp:     now, // Use the provided time for consistency
+		Answer:        answer,
+		Direction:     direction,
+		ScheduledDays: updatedFSRSCard.ScheduledDays,
+		ElapsedDays:   updatedFSRSCard.ElapsedDays,
+		State:         updatedFSRSCard.State,
+		DurationMS:    durationMS,
+	}
+
+	return storageCard, reviewLog
+}
+
+// SubmitReviews applies several reviews in order, as SubmitReviewWithTime would one
+// at a time, but stores them with a single save. An entry that can't be applied, such
+// as one for an unknown card, is reported in its result without failing the rest of
+// the batch. A zero Timestamp means now.
+func (s *FlashcardService) SubmitReviews(inputs []ReviewInput) (BulkReviewResponse, error) {
+	response := BulkReviewResponse{Results: make([]BulkReviewResult, len(inputs))}
+	cards := make(map[string]storage.Card)
+	histories := make(map[string][]storage.Review)
+	var cardOrder []string
+	var reviews []storage.Review
+	var entries []storage.AuditEntry
+	for i, input := range inputs {
+		result := &response.Results[i]
+		result.Index = i
+		result.CardID = input.CardID
+		if input.Rating < gofsrs.Again || input.Rating > gofsrs.Easy {
+			result.Error = "rating must be between 1 and 4"
+			continue
+		}
+
+		// Later entries for the same card build on the earlier ones in the batch
+		storageCard, seen := cards[input.CardID]
+		if !seen {
+			var err error
+			if storageCard, err = s.Storage.GetCard(input.CardID); err != nil {
+				result.Error = fmt.Sprintf("error getting card: %v", err)
+				continue
+			}
+			// As in SubmitTimedReview, missing history only affects the elapsed days
+			histories[input.CardID], _ = s.Storage.GetCardReviews(input.CardID)
+			cardOrder = append(cardOrder, input.CardID)
+		}
+
+		now := input.Timestamp
+		if now.IsZero() {
+			now = timeNow()
+		}
+		storageCard, review := s.scheduleReview(storageCard, histories[input.CardID], input.Rating, input.Answer, now, 0)
+		cards[input.CardID] = storageCard
+		histories[input.CardID] = append(histories[input.CardID], review)
+		reviews = append(reviews, review)
+		entries = append(entries, reviewAuditEntry(storageCard, review))
+
+		due := storageCard.FSRS.Due
+		result.Success = true
+		result.Due = &due
+		result.State = stateNames[storageCard.FSRS.State]
+	}
+	if len(reviews) == 0 {
+		response.Failed = len(inputs)
+		return response, nil
+	}
+
+	updated := make([]storage.Card, 0, len(cardOrder))
+	for _, id := range cardOrder {
+		updated = append(updated, cards[id])
+	}
+	// The audit entries go first so RecordReviews' save persists them with the batch
+	s.appendAudit(entries...)
+	if err := s.Storage.RecordReviews(updated, reviews); err != nil {
+		return BulkReviewResponse{}, fmt.Errorf("error saving reviews: %w", err)
+	}
+	for i, result := range response.Results {
+		if result.Success {
+			response.Reviewed++
+			s.updateSessionQueue(result.CardID, inputs[i].Rating)
+		} else {
+			response.Failed++
+		}
+	}
+	return response, nil
+}
+
+// Audit log actions.
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+	AuditReview = "review"
+)
+
+// DefaultAuditLogSize is how many audit entries are kept when AuditLogSize is unset.
+const DefaultAuditLogSize = 1000
+
+// ratingNames are the names of the FSRS ratings in audit summaries.
+var ratingNames = map[gofsrs.Rating]string{
+	gofsrs.Again: "Again",
+	gofsrs.Hard:  "Hard",
+	gofsrs.Good:  "Good",
+	gofsrs.Easy:  "Easy",
+}
+
+// auditLogSize returns AuditLogSize, or DefaultAuditLogSize when it's unset.
+func (s *FlashcardService) auditLogSize() int {
+	if s.AuditLogSize > 0 {
+		return s.AuditLogSize
+	}
+	return DefaultAuditLogSize
+}
+
+// newAuditEntry returns an audit entry for a change made now.
+func newAuditEntry(action, cardID, summary string) storage.AuditEntry {
+	return storage.AuditEntry{
+		ID:        uuid.New().String(),
+		Timestamp: timeNow(),
+		Action:    action,
+		CardID:    cardID,
+		Summary:   summary,
+	}
+}
+
+// cardAuditEntries returns an audit entry with the same action and summary for
+// each of cards.
+func cardAuditEntries(action, summary string, cards []storage.Card) []storage.AuditEntry {
+	entries := make([]storage.AuditEntry, len(cards))
+	for i, card := range cards {
+		entries[i] = newAuditEntry(action, card.ID, summary)
+	}
+	return entries
+}
+
+// reviewAuditEntry returns the audit entry for a scheduled review, timestamped
+// with the review so backdated reviews land on the day they were made.
+func reviewAuditEntry(card storage.Card, review storage.Review) storage.AuditEntry {
+	entry := newAuditEntry(AuditReview, card.ID, fmt.Sprintf("Rated %s; now %s, due %s",
+		ratingNames[review.Rating], stateNames[card.FSRS.State], card.FSRS.Due.Format("2006-01-02")))
+	entry.Timestamp = review.Timestamp
+	return entry
+}
+
+// appendAudit adds entries to the audit log, to be persisted by the caller's next
+// Save. A failure is logged rather than returned: the change it records has
+// already been made.
+func (s *FlashcardService) appendAudit(entries ...storage.AuditEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	if err := s.Storage.AppendAudit(entries, s.auditLogSize()); err != nil {
+		fmt.Printf("Warning: failed to record %d audit entries: %v\n", len(entries), err)
+	}
+}
+
+// CardHistory returns the audit log newest first, limited to one card when cardID
+// is set and to entries in [since, until) for the non-zero bounds. Entries for a
+// deleted card are kept, so its history can still be looked up.
+func (s *FlashcardService) CardHistory(cardID string, since, until time.Time) ([]storage.AuditEntry, error) {
+	entries, err := s.Storage.ListAudit()
+	if err != nil {
+		return nil, fmt.Errorf("error listing audit log: %w", err)
+	}
+	history := []storage.AuditEntry{}
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		if cardID != "" && entry.CardID != cardID {
+			continue
+		}
+		if !since.IsZero() && entry.Timestamp.Before(since) {
+			continue
+		}
+		if !until.IsZero() && !entry.Timestamp.Before(until) {
+			continue
+		}
+		history = append(history, entry)
+	}
+	return history, nil
+}
+
+// Card directions used in mixed_direction mode.
+const (
+	DirectionForward = "forward" // Front shown as the question
+	DirectionReverse = "reverse" // Back shown as the question
+)
+
+// chooseDirection deterministically picks a direction for a card from the seed, the
+// card ID and its review count, so the choice is stable for a given seed and review.
+func chooseDirection(seed int64, cardID string, reps uint64) string {
+	hash := fnv.New64a()
+	fmt.Fprintf(hash, "%d:%s:%d", seed, cardID, reps)
+	if hash.Sum64()%2 == 0 {
+		return DirectionForward
+	}
+	return DirectionReverse
+}
+
+// AssignDirection chooses the direction a card is shown in and records it on the
+// card so the next SubmitReview can log which way it was answered.
+func (s *FlashcardService) AssignDirection(cardID string, seed int64) (string, error) {
+	storageCard, err := s.Storage.GetCard(cardID)
+	if err != nil {
+		return "", fmt.Errorf("error getting card %s: %w", cardID, err)
+	}
+	storageCard.Direction = chooseDirection(seed, cardID, storageCard.FSRS.Reps)
+	if err := s.Storage.UpdateCard(storageCard); err != nil {
+		return "", fmt.Errorf("error recording direction for card %s: %w", cardID, err)
+	}
+	return storageCard.Direction, nil
+}
+
+// MaxSiblingFronts caps how many sibling fronts get_due_card's include_siblings adds.
+const MaxSiblingFronts = 20
+
+// SiblingFronts returns the fronts of other cards that carry all of the card's tags,
+// so the LLM can see what related material the student is studying. Backs are never
+// included. A card without tags has no siblings.
+func (s *FlashcardService) SiblingFronts(card Card) ([]string, error) {
+	if len(card.Tags) == 0 {
+		return nil, nil
+	}
+	storageCards, err := s.Storage.ListCards(card.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+	sort.Slice(storageCards, func(i, j int) bool {
+		return storageCards[i].CreatedAt.Before(storageCards[j].CreatedAt)
+	})
+
+	var fronts []string
+	for _, sibling := range storageCards {
+		if sibling.ID == card.ID || sibling.Archived {
+			continue
+		}
+		fronts = append(fronts, sibling.Front)
+		if len(fronts) == MaxSiblingFronts {
+			break
+		}
+	}
+	return fronts, nil
+}
+
+// Tags maintained on cards when AutoTagRecall is enabled.
+const (
+	RecallTagStruggling = "struggling"
+	RecallTagSolid      = "solid"
+)
+
+// recallWindow is how many of the most recent ratings feed the rolling average.
+const recallWindow = 5
+
+// applyRecallTag replaces any existing recall tag with one derived from the
+// average of the given ratings: below 2.5 is struggling, 3 or above is solid,
+// and anything in between carries neither tag.
+func applyRecallTag(tags []string, ratings []gofsrs.Rating) []string {
+	result := make([]string, 0, len(tags)+1)
+	for _, tag := range tags {
+		if tag != RecallTagStruggling && tag != RecallTagSolid {
+			result = append(result, tag)
+		}
+	}
+	if len(ratings) == 0 {
+		return result
+	}
+
+	total := 0.0
+	for _, rating := range ratings {
+		total += float64(rating)
+	}
+	average := total / float64(len(ratings))
+	switch {
+	case average < 2.5:
+		result = append(result, RecallTagStruggling)
+	case average >= 3.0:
+		result = append(result, RecallTagSolid)
+	}
+	return result
+}
+
+// Variable to allow mocking time.Now in tests
+var timeNow = time.Now
+
+// AnalyzeLearning provides insights based on review history
+func (s *FlashcardService) AnalyzeLearning() (string, error) {
+	// Fetch all cards and their review histories
+	cards, err := s.Storage.ListCards(nil)
+	if err != nil {
+		return "", fmt.Errorf("error getting all cards for analysis: %w", err)
+	}
+
+	if len(cards) == 0 {
+		return "No cards available to analyze yet. Let's create some!", nil
+	}
+
+	// Simple analysis: Find the card reviewed most recently with the lowest rating (1 or 2)
+	var worstReview *storage.Review = nil
+	var worstCard *storage.Card = nil // Use pointer to allow nil
+	latestTime := time.Time{}
+
+	for i := range cards { // Iterate using index to get addressable card
+		card := cards[i] // Get a copy of the card for this iteration
+		reviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			continue // Skip cards with errors fetching reviews
+		}
+		reviews = scheduledReviews(reviews) // Practice doesn't reflect recall on schedule
+		for j := range reviews {
+			review := reviews[j]              // Get a copy
+			if review.Rating <= gofsrs.Hard { // Again or Hard
+				if review.Timestamp.After(latestTime) {
+					latestTime = review.Timestamp
+					worstReview = &review
+					// Assign the address of the card from the original slice
+					worstCard = &cards[i]
+				}
+			}
+		}
+	}
+
+	if worstCard != nil && worstReview != nil {
+		return fmt.Sprintf("It looks like the card '%s' was challenging (rated %d on %s). Maybe we can break down the concept or create related cards?",
+			worstCard.Front, worstReview.Rating, worstReview.Timestamp.Format(time.RFC822)), nil
+	}
+
+	return "Great job so far! All recent reviews look good. Keep up the excellent work!", nil
+}
+
+// GetTags returns a map of tags to the count of cards with that tag
+func (s *FlashcardService) GetTags() (map[string]int, error) {
+	cards, err := s.Storage.ListCards(nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting cards for tags: %w", err)
+	}
+
+	tagCounts := make(map[string]int)
+	for _, card := range cards {
+		for _, tag := range card.Tags {
+			tagCounts[tag]++
+		}
+	}
+	return tagCounts, nil
+}
+
+// RareTags returns tags carried by at most maxCards cards, rarest first, so they
+// can be consolidated into more common tags.
+func (s *FlashcardService) RareTags(maxCards int) ([]TagUsage, error) {
+	cards, err := s.Storage.ListCards(nil)
+	if err != nil {
+		return nil, fmt.Errorf("error getting cards for tags: %w", err)
+	}
+
+	cardsByTag := make(map[string][]string)
+	for _, card := range cards {
+		for _, tag := range card.Tags {
+			cardsByTag[tag] = append(cardsByTag[tag], card.ID)
+		}
+	}
+
+	result := []TagUsage{}
+	for tag, cardIDs := range cardsByTag {
+		if len(cardIDs) <= maxCards {
+			sort.Strings(cardIDs)
+			result = append(result, TagUsage{Tag: tag, CardCount: len(cardIDs), CardIDs: cardIDs})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CardCount != result[j].CardCount {
+			return result[i].CardCount < result[j].CardCount
+		}
+		return result[i].Tag < result[j].Tag
+	})
+	return result, nil
+}
+
+// DefaultTagMergeDistance is the largest edit distance suggest_tag_merges considers
+// a likely misspelling.
+const DefaultTagMergeDistance = 2
+
+// SuggestTagMerges finds tags that are probably misspellings of each other, such as
+// "biolgy" and "biology", and suggests merging each rarer tag into the more common
+// one. Pairs must be within maxDistance edits, with at most one edit per four
+// characters, so short tags like "cat" and "bat" stay apart. Tags whose numbers
+// differ ("unit-3", "unit-4"), reserved "test-" tags and due date tags are never
+// merged away. With apply set, the suggested merges are also made on every card.
+func (s *FlashcardService) SuggestTagMerges(maxDistance int, apply bool) (TagMergesResponse, error) {
+	cards, err := s.Storage.ListCards(nil)
+	if err != nil {
+		return TagMergesResponse{}, fmt.Errorf("error getting cards for tags: %w", err)
+	}
+	dueDates, err := s.Storage.ListDueDates()
+	if err != nil {
+		return TagMergesResponse{}, fmt.Errorf("error listing due dates: %w", err)
+	}
+	protected := make(map[string]bool, len(dueDates))
+	for _, dueDate := range dueDates {
+		protected[dueDate.Tag] = true
+	}
+
+	tagCounts := make(map[string]int)
+	for _, card := range cards {
+		for _, tag := range card.Tags {
+			tagCounts[tag]++
+		}
+	}
+	// Protected tags come first so they are always merge targets, then the most used
+	tags := make([]string, 0, len(tagCounts))
+	for tag := range tagCounts {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if protected[tags[i]] != protected[tags[j]] {
+			return protected[tags[i]]
+		}
+		if tagCounts[tags[i]] != tagCounts[tags[j]] {
+			return tagCounts[tags[i]] > tagCounts[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+
+	response := TagMergesResponse{Suggestions: []TagMergeSuggestion{}, Applied: apply}
+	mergeInto := make(map[string]string)
+	for i, target := range tags {
+		if _, merged := mergeInto[target]; merged {
+			continue
+		}
+		for _, source := range tags[i+1:] {
+			if _, merged := mergeInto[source]; merged || protected[source] || strings.HasPrefix(source, ReservedTagPrefix) {
+				continue
+			}
+			if digitsOf(source) != digitsOf(target) {
+				continue
+			}
+			distance := editDistance(source, target)
+			shorter := len([]rune(source))
+			if n := len([]rune(target)); n < shorter {
+				shorter = n
+			}
+			if distance > maxDistance || distance*4 > shorter {
+				continue
+			}
+			mergeInto[source] = target
+			response.Suggestions = append(response.Suggestions, TagMergeSuggestion{
+				From:      source,
+				Into:      target,
+				Distance:  distance,
+				FromCards: tagCounts[source],
+				IntoCards: tagCounts[target],
+			})
+		}
+	}
+
+	if !apply || len(mergeInto) == 0 {
+		return response, nil
+	}
+	var updated []storage.Card
+	for _, card := range cards {
+		changed := false
+		seen := make(map[string]bool, len(card.Tags))
+		newTags := make([]string, 0, len(card.Tags))
+		for _, tag := range card.Tags {
+			if target, ok := mergeInto[tag]; ok {
+				tag = target
+				changed = true
+			}
+			if !seen[tag] {
+				seen[tag] = true
+				newTags = append(newTags, tag)
+			}
+		}
+		if !changed {
+			continue
+		}
+		card.Tags = newTags
+		updated = append(updated, card)
+	}
+	// The audit entries go first so UpdateCards' save persists them with the batch
+	s.appendAudit(cardAuditEntries(AuditUpdate, "Merged similar tags", updated)...)
+	if err := s.Storage.UpdateCards(updated); err != nil {
+		return response, fmt.Errorf("error updating cards in storage: %w", err)
+	}
+	response.CardsUpdated = len(updated)
+	return response, nil
+}
+
+// digitsOf returns the digits in s, in order.
+func digitsOf(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// editDistance returns the Levenshtein distance between a and b, counted in runes.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// --- Due Date Management ---
+
+// AddDueDate adds a new due date entry.
+func (s *FlashcardService) AddDueDate(dueDate storage.DueDate) error {
+	if dueDate.Topic == "" || dueDate.Tag == "" || dueDate.DueDate.IsZero() {
+		return errors.New("due date topic, tag, and date are required")
+	}
+	if err := s.Storage.AddDueDate(dueDate); err != nil {
+		return fmt.Errorf("error adding due date to storage: %w", err)
+	}
+	// Check error on Save
+	if err := s.Storage.Save(); err != nil {
+		return fmt.Errorf("error saving storage after adding due date: %w", err)
+	}
+	return nil
+}
+
+// ListDueDates retrieves all due date entries.
+func (s *FlashcardService) ListDueDates() ([]storage.DueDate, error) {
+	return s.Storage.ListDueDates()
+}
+
+// UpdateDueDate updates an existing due date entry.
+func (s *FlashcardService) UpdateDueDate(dueDate storage.DueDate) error {
+	if dueDate.ID == "" {
+		return errors.New("due date ID is required for update")
+	}
+	if err := s.Storage.UpdateDueDate(dueDate); err != nil {
+		return fmt.Errorf("error updating due date in storage: %w", err)
+	}
+	// Check error on Save
+	if err := s.Storage.Save(); err != nil {
+		return fmt.Errorf("error saving storage after updating due date: %w", err)
+	}
+	return nil
+}
+
+// DeleteDueDate deletes a due date entry by its ID.
+func (s *FlashcardService) DeleteDueDate(id string) error {
+	if id == "" {
+		return errors.New("due date ID is required for delete")
+	}
+	if err := s.Storage.DeleteDueDate(id); err != nil {
+		return fmt.Errorf("error deleting due date from storage: %w", err)
+	}
+	// Check error on Save
+	if err := s.Storage.Save(); err != nil {
+		return fmt.Errorf("error saving storage after deleting due date: %w", err)
+	}
+	return nil
+}
+
+// DeleteDueDateWithCards deletes a due date entry together with every card
+// carrying its tag. It returns the number of cards removed.
+func (s *FlashcardService) DeleteDueDateWithCards(id string) (int, error) {
+	if id == "" {
+		return 0, errors.New("due date ID is required for delete")
+	}
+	dueDate, err := s.GetDueDate(id)
+	if err != nil {
+		return 0, err
+	}
+	cards, err := s.Storage.ListCards([]string{dueDate.Tag})
+	if err != nil {
+		return 0, fmt.Errorf("error listing cards for due date %s: %w", id, err)
+	}
+	ids := make([]string, 0, len(cards))
+	for _, card := range cards {
+		ids = append(ids, card.ID)
+	}
+	// The due date goes first so the batch delete's save covers it too, leaving a
+	// single backup of the store from before the whole operation.
+	if err := s.Storage.DeleteDueDate(id); err != nil {
+		return 0, fmt.Errorf("error deleting due date from storage: %w", err)
+	}
+	if err := s.Storage.DeleteCards(ids); err != nil {
+		if restoreErr := s.Storage.AddDueDate(dueDate); restoreErr != nil {
+			fmt.Printf("Warning: failed to restore due date %s: %v\n", id, restoreErr)
+		}
+		return 0, fmt.Errorf("error deleting cards for due date %s: %w", id, err)
+	}
+	s.appendAudit(cardAuditEntries(AuditDelete, "Deleted with due date "+dueDate.Topic, cards)...)
+	if err := s.Storage.Save(); err != nil {
+		return 0, fmt.Errorf("error saving storage after deleting due date: %w", err)
+	}
+	return len(cards), nil
+}
+
+// GetDueDate looks up a due date entry by its ID, returning an error wrapping
+// storage.ErrDueDateNotFound if there is none.
+func (s *FlashcardService) GetDueDate(id string) (storage.DueDate, error) {
+	dueDate, err := s.Storage.GetDueDate(id)
+	if err != nil {
+		return storage.DueDate{}, fmt.Errorf("due date %s: %w", id, err)
+	}
+	return dueDate, nil
+}
+
+// ReservedTagPrefix marks tags generated for due dates by manage_due_dates.
+const ReservedTagPrefix = "test-"
+
+// unbackedReservedTags returns the tags that use ReservedTagPrefix but don't
+// belong to any due date. dueDateTags may be nil, in which case due dates are loaded.
+func (s *FlashcardService) unbackedReservedTags(tags []string, dueDateTags map[string]bool) ([]string, error) {
+	if dueDateTags == nil {
+		dueDates, err := s.Storage.ListDueDates()
+		if err != nil {
+			return nil, fmt.Errorf("error listing due dates: %w", err)
+		}
+		dueDateTags = make(map[string]bool, len(dueDates))
+		for _, dd := range dueDates {
+			dueDateTags[dd.Tag] = true
+		}
+	}
+	var unbacked []string
+	for _, tag := range tags {
+		if strings.HasPrefix(tag, ReservedTagPrefix) && !dueDateTags[tag] {
+			unbacked = append(unbacked, tag)
+		}
+	}
+	return unbacked, nil
+}
+
+// ValidateReservedTags checks tags about to be applied to a card. Reserved "test-"
+// tags not backed by a due date produce one warning each, or ErrUnbackedReservedTag
+// when RejectReservedTags is set.
+func (s *FlashcardService) ValidateReservedTags(tags []string) ([]string, error) {
+	unbacked, err := s.unbackedReservedTags(tags, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(unbacked) == 0 {
+		return nil, nil
+	}
+	if s.RejectReservedTags {
+		return nil, fmt.Errorf("%w: %s (the %q prefix is reserved for due dates; create one with manage_due_dates)",
+			ErrUnbackedReservedTag, strings.Join(unbacked, ", "), ReservedTagPrefix)
+	}
+	warnings := make([]string, 0, len(unbacked))
+	for _, tag := range unbacked {
+		warnings = append(warnings, fmt.Sprintf("Tag %q uses the reserved %q prefix but no due date uses it", tag, ReservedTagPrefix))
+	}
+	return warnings, nil
+}
+
+// ReservedTagConflicts lists existing cards carrying reserved tags that no due date backs.
+func (s *FlashcardService) ReservedTagConflicts() ([]ReservedTagConflict, error) {
+	dueDates, err := s.Storage.ListDueDates()
+	if err != nil {
+		return nil, fmt.Errorf("error listing due dates: %w", err)
+	}
+	dueDateTags := make(map[string]bool, len(dueDates))
+	for _, dd := range dueDates {
+		dueDateTags[dd.Tag] = true
+	}
+
+	storageCards, err := s.Storage.ListCards(nil)
+	if err != nil {
+		return nil, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+	conflicts := make([]ReservedTagConflict, 0)
+	for _, card := range storageCards {
+		unbacked, err := s.unbackedReservedTags(card.Tags, dueDateTags)
+		if err != nil {
+			return nil, err
+		}
+		if len(unbacked) > 0 {
+			conflicts = append(conflicts, ReservedTagConflict{CardID: card.ID, Front: card.Front, Tags: unbacked})
+		}
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].CardID < conflicts[j].CardID })
+	return conflicts, nil
+}
+
+// ReassignCardDueDate moves a card from one due date to another by replacing the
+// old due date's tag with the new one. The card must currently carry the old tag.
+func (s *FlashcardService) ReassignCardDueDate(cardID, oldDueDateID, newDueDateID string) (Card, error) {
+	if cardID == "" || oldDueDateID == "" || newDueDateID == "" {
+		return Card{}, errors.New("card ID, old due date ID, and new due date ID are required")
+	}
+	oldDueDate, err := s.GetDueDate(oldDueDateID)
+	if err != nil {
+		return Card{}, err
+	}
+	newDueDate, err := s.GetDueDate(newDueDateID)
+	if err != nil {
+		return Card{}, err
+	}
+
+	card, err := s.Storage.GetCard(cardID)
+	if err != nil {
+		return Card{}, fmt.Errorf("error getting card %s: %w", cardID, err)
+	}
+	if !containsString(card.Tags, oldDueDate.Tag) {
+		return Card{}, fmt.Errorf("card %s is not assigned to due date %s (missing tag %s)", cardID, oldDueDate.Topic, oldDueDate.Tag)
+	}
+
+	newTags := []string{}
+	for _, tag := range card.Tags {
+		if tag != oldDueDate.Tag {
+			newTags = append(newTags, tag)
+		}
+	}
+	if !containsString(newTags, newDueDate.Tag) {
+		newTags = append(newTags, newDueDate.Tag)
+	}
+	card.Tags = newTags
+
+	if err := s.Storage.UpdateCard(card); err != nil {
+		return Card{}, fmt.Errorf("error updating card %s in storage: %w", cardID, err)
+	}
+	s.appendAudit(newAuditEntry(AuditUpdate, cardID, "Moved from due date "+oldDueDate.Topic+" to "+newDueDate.Topic))
+	if err := s.Storage.Save(); err != nil {
+		return Card{}, fmt.Errorf("error saving storage after reassigning card %s: %w", cardID, err)
+	}
+	return cardFromStorage(card), nil
+}
+
+// AssignToDueDate adds a due date's tag to every card carrying all of filterTags
+// whose front or back contains query (ignoring case), saving them together. At
+// least one of query and filterTags is required so a slip can't assign the whole
+// collection, and no card is assigned if one would exceed MaxTagsPerCard. The
+// response includes the due date's progress after the change.
+func (s *FlashcardService) AssignToDueDate(dueDateID, query string, filterTags []string) (AssignToDueDateResponse, error) {
+	query = strings.TrimSpace(query)
+	if query == "" && len(filterTags) == 0 {
+		return AssignToDueDateResponse{}, errors.New("a search query or at least one tag is required to select cards")
+	}
+	dueDate, err := s.GetDueDate(dueDateID)
+	if err != nil {
+		return AssignToDueDateResponse{}, err
+	}
+	storageCards, err := s.Storage.ListCards(filterTags)
+	if err != nil {
+		return AssignToDueDateResponse{}, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+
+	needle := strings.ToLower(query)
+	response := AssignToDueDateResponse{DueDateID: dueDate.ID, Tag: dueDate.Tag, CardIDs: []string{}}
+	var updated []storage.Card
+	for _, card := range storageCards {
+		if needle != "" && !strings.Contains(strings.ToLower(card.Front), needle) && !strings.Contains(strings.ToLower(card.Back), needle) {
+			continue
+		}
+		response.Matched++
+		if containsString(card.Tags, dueDate.Tag) {
+			continue
+		}
+		card.Tags = append(card.Tags, dueDate.Tag)
+		if err := s.checkTagLimit(card.Tags); err != nil {
+			return AssignToDueDateResponse{}, fmt.Errorf("card %s: %w", card.ID, err)
+		}
+		updated = append(updated, card)
+		response.CardIDs = append(response.CardIDs, card.ID)
+	}
+	// The audit entries go first so UpdateCards' save persists them with the batch
+	s.appendAudit(cardAuditEntries(AuditUpdate, "Assigned to due date "+dueDate.Topic, updated)...)
+	if err := s.Storage.UpdateCards(updated); err != nil {
+		return AssignToDueDateResponse{}, fmt.Errorf("error updating cards in storage: %w", err)
+	}
+	sort.Strings(response.CardIDs)
+	response.Assigned = len(updated)
+
+	response.Progress, err = s.GetDueDateProgressStats(dueDate.Tag)
+	if err != nil {
+		return AssignToDueDateResponse{}, err
+	}
+	return response, nil
+}
+
+// GetCardsByTag retrieves all cards that have a specific tag.
+func (s *FlashcardService) GetCardsByTag(tag string) ([]storage.Card, error) {
+	if tag == "" {
+		return nil, errors.New("tag cannot be empty")
+	}
+	// Use the ListCards method from storage, passing the single tag in a slice
+	matchingCards, err := s.Storage.ListCards([]string{tag})
+	if err != nil {
+		return nil, fmt.Errorf("error getting cards by tag '%s': %w", tag, err)
+	}
+	return matchingCards, nil
+}
+
+// DueDateProgressStats holds statistics for a specific due date.
+type DueDateProgressStats struct {
+	TotalCards      int     `json:"total_cards"`
+	MasteredCards   int     `json:"mastered_cards"`
+	MatureCards     int     `json:"mature_cards"` // Interval of at least MatureIntervalDays
+	ProgressPercent float64 `json:"progress_percent"`
+}
+
+// Mastery modes accepted by the mastery_mode setting
+const (
+	MasteryModeLastRating     = "last_rating"
+	MasteryModeRetrievability = "retrievability"
+)
+
+// DefaultMasteryThreshold is the recall probability a card needs to count as
+// mastered in MasteryModeRetrievability when no threshold is configured.
+const DefaultMasteryThreshold = 0.9
+
+// isMastered reports whether a card counts as mastered for due date progress.
+// By default that means its last review was rated Easy; in MasteryModeRetrievability
+// it means its current FSRS recall probability is at least the mastery threshold.
+// last is the card's most recent review, or nil if it was never reviewed.
+func (s *FlashcardService) isMastered(card storage.Card, last *storage.Review, now time.Time) bool {
+	if s.MasteryMode == MasteryModeRetrievability {
+		threshold := s.MasteryThreshold
+		if threshold <= 0 {
+			threshold = DefaultMasteryThreshold
+		}
+		return s.FSRSManager.Retrievability(card.FSRS, now) >= threshold
+	}
+	return last != nil && last.Rating == gofsrs.Easy
+}
+
+// lastScheduledReview returns the most recent review that counts toward scheduling
+// and mastery, skipping practice reviews and those from before the card was reset,
+// or nil if there is none.
+func lastScheduledReview(card storage.Card, reviews []storage.Review) *storage.Review {
+	reviews = reviewsSinceReset(card, reviews)
+	var last *storage.Review
+	for i := range reviews {
+		if reviews[i].Practice {
+			continue
+		}
+		if last == nil || reviews[i].Timestamp.After(last.Timestamp) {
+			last = &reviews[i]
+		}
+	}
+	return last
+}
+
+// GetDueDateProgressStats calculates progress for cards associated with a due date tag.
+// Mastery is defined as having a last review rating of 4 (Easy). Suspended cards
+// aren't studied, so they are left out entirely.
+func (s *FlashcardService) GetDueDateProgressStats(tag string) (DueDateProgressStats, error) {
+	stats := DueDateProgressStats{}
+
+	// fmt.Printf("GetDueDateProgressStats called for tag: %s\n", tag)
+
+	taggedCards, err := s.GetCardsByTag(tag) // Uses the corrected GetCardsByTag
+	if err != nil {
+		return stats, fmt.Errorf("error getting cards for tag '%s': %w", tag, err)
+	}
+	var cards []storage.Card
+	for _, card := range taggedCards {
+		if !card.Suspended {
+			cards = append(cards, card)
+		}
+	}
+
+	stats.TotalCards = len(cards)
+	// fmt.Printf("Found %d cards with tag %s\n", stats.TotalCards, tag)
+
+	if stats.TotalCards == 0 {
+		return stats, nil // No cards for this tag, progress is 0
+	}
+
+	masteredCount := 0
+	now := timeNow()
+	for _, card := range cards {
+		// fmt.Printf("Checking card %d: %s\n", i+1, card.ID)
+		reviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			// Log or handle error? For now, skip card if reviews can't be fetched.
+			// fmt.Printf("Warning: could not get reviews for card %s: %v\n", card.ID, err)
+			continue
+		}
+		// fmt.Printf("Card %s has %d reviews\n", card.ID, len(reviews))
+		if cardMaturity(card.FSRS) == MaturityMature {
+			stats.MatureCards++
+		}
+		if s.isMastered(card, lastScheduledReview(card, reviews), now) {
+			masteredCount++
+			// fmt.Printf("Card %s counted as mastered\n", card.ID)
+		}
+	}
+
+	stats.MasteredCards = masteredCount
+	stats.ProgressPercent = (float64(masteredCount) / float64(stats.TotalCards)) * 100.0
+
+	// fmt.Printf("GetDueDateProgressStats result: %+v\n", stats)
+
+	return stats, nil
+}
+
+// UpcomingDueDates returns due dates from today onward, soonest first, with a
+// countdown and progress for each. A limit of 0 returns all of them.
+func (s *FlashcardService) UpcomingDueDates(limit int) ([]UpcomingDueDate, error) {
+	dueDates, err := s.Storage.ListDueDates()
+	if err != nil {
+		return nil, fmt.Errorf("error listing due dates: %w", err)
+	}
+
+	now := timeNow()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	result := []UpcomingDueDate{}
+	for _, dd := range dueDates {
+		// Compare calendar days so the countdown doesn't depend on the time of day
+		dueDay := time.Date(dd.DueDate.Year(), dd.DueDate.Month(), dd.DueDate.Day(), 0, 0, 0, 0, time.UTC)
+		daysUntil := int(dueDay.Sub(today).Hours() / 24)
+		if daysUntil < 0 {
+			continue
+		}
+
+		stats, err := s.GetDueDateProgressStats(dd.Tag)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, UpcomingDueDate{
+			ID:              dd.ID,
+			Topic:           dd.Topic,
+			DueDate:         dd.DueDate.Format("2006-01-02"),
+			Tag:             dd.Tag,
+			DaysUntil:       daysUntil,
+			Countdown:       countdownText(daysUntil),
+			ProgressPercent: stats.ProgressPercent,
+		})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].DaysUntil < result[j].DaysUntil
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// countdownText renders a day count as "today", "tomorrow" or "in N days".
+func countdownText(days int) string {
+	switch days {
+	case 0:
+		return "today"
+	case 1:
+		return "tomorrow"
+	default:
+		return fmt.Sprintf("in %d days", days)
+	}
+}
+
+// Weights of the readiness score components; they sum to 1.
+const (
+	readinessMasteredWeight = 0.5
+	readinessRecallWeight   = 0.3
+	readinessPaceWeight     = 0.2
+)
+
+// readinessPaceWindowDays is how far back recent review pace is measured.
+const readinessPaceWindowDays = 7
+
+// DueDateReadiness combines mastery, recall probability and study pace for a due
+// date's cards into a single 0-100 readiness score.
+func (s *FlashcardService) DueDateReadiness(dueDateID string) (DueDateReadiness, error) {
+	dd, err := s.GetDueDate(dueDateID)
+	if err != nil {
+		return DueDateReadiness{}, err
+	}
+	cards, err := s.GetCardsByTag(dd.Tag)
+	if err != nil {
+		return DueDateReadiness{}, fmt.Errorf("error getting cards for tag '%s': %w", dd.Tag, err)
+	}
+
+	now := timeNow()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	dueDay := time.Date(dd.DueDate.Year(), dd.DueDate.Month(), dd.DueDate.Day(), 0, 0, 0, 0, time.UTC)
+	// Days until the day *before* the due date, matching the due date progress resource
+	daysRemaining := math.Max(0, dueDay.Sub(today).Hours()/24.0-1)
+
+	breakdown := ReadinessBreakdown{DaysRemaining: daysRemaining, AverageRecall: 1}
+	result := DueDateReadiness{DueDateID: dd.ID, Topic: dd.Topic, Tag: dd.Tag}
+	if len(cards) == 0 {
+		result.Breakdown = breakdown
+		return result, nil
+	}
+
+	mastered := 0
+	recallTotal := 0.0
+	recentReviews := 0
+	windowStart := now.AddDate(0, 0, -readinessPaceWindowDays)
+	for _, card := range cards {
+		reviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			return DueDateReadiness{}, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
+		}
+		for _, review := range reviews {
+			if review.Timestamp.After(windowStart) && !review.Timestamp.After(now) {
+				recentReviews++
+			}
+		}
+		if s.isMastered(card, lastScheduledReview(card, reviews), now) {
+			mastered++
+		} else {
+			recallTotal += s.FSRSManager.Retrievability(card.FSRS, now)
+		}
+	}
+
+	breakdown.CardsLeft = len(cards) - mastered
+	breakdown.MasteredFraction = float64(mastered) / float64(len(cards))
+	if breakdown.CardsLeft > 0 {
+		breakdown.AverageRecall = recallTotal / float64(breakdown.CardsLeft)
+	}
+	breakdown.RecentPace = float64(recentReviews) / readinessPaceWindowDays
+	switch {
+	case breakdown.CardsLeft == 0:
+		breakdown.PaceScore = 1
+	case daysRemaining > 0:
+		breakdown.RequiredPace = float64(breakdown.CardsLeft) / daysRemaining
+		breakdown.PaceScore = math.Min(1, breakdown.RecentPace/breakdown.RequiredPace)
+	}
+
+	result.Breakdown = breakdown
+	result.Score = 100 * (readinessMasteredWeight*breakdown.MasteredFraction +
+		readinessRecallWeight*breakdown.AverageRecall +
+		readinessPaceWeight*breakdown.PaceScore)
+	return result, nil
+}
+
+// BurstSchedule brings forward a due date's unmastered cards so the student can
+// drill them before the test. By default every such card becomes due now; with
+// spread set they are dealt out over the days that remain before the due date,
+// weakest recall first. A card is only ever moved earlier, never delayed, and
+// mastered, suspended and archived cards are left alone.
+func (s *FlashcardService) BurstSchedule(dueDateID string, spread bool) (BurstScheduleResponse, error) {
+	dd, err := s.GetDueDate(dueDateID)
+	if err != nil {
+		return BurstScheduleResponse{}, err
+	}
+	cards, err := s.GetCardsByTag(dd.Tag)
+	if err != nil {
+		return BurstScheduleResponse{}, fmt.Errorf("error getting cards for tag '%s': %w", dd.Tag, err)
+	}
+
+	now := timeNow()
+	response := BurstScheduleResponse{DueDateID: dd.ID, Tag: dd.Tag, CardIDs: []string{}}
+	type burstCard struct {
+		card   storage.Card
+		recall float64
+	}
+	var unmastered []burstCard
+	for _, card := range cards {
+		if card.Suspended || card.Archived {
+			continue
+		}
+		reviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			return BurstScheduleResponse{}, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
+		}
+		if s.isMastered(card, lastScheduledReview(card, reviews), now) {
+			response.Mastered++
+			continue
+		}
+		unmastered = append(unmastered, burstCard{card, s.FSRSManager.Retrievability(card.FSRS, now)})
+	}
+	sort.Slice(unmastered, func(i, j int) bool {
+		if unmastered[i].recall != unmastered[j].recall {
+			return unmastered[i].recall < unmastered[j].recall
+		}
+		return unmastered[i].card.ID < unmastered[j].card.ID
+	})
+
+	// Study days run from today through the day before the due date, as in due_date_readiness
+	response.Days = 1
+	if spread {
+		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		dueDay := time.Date(dd.DueDate.Year(), dd.DueDate.Month(), dd.DueDate.Day(), 0, 0, 0, 0, time.UTC)
+		if days := int(dueDay.Sub(today).Hours() / 24); days > 1 {
+			response.Days = days
+		}
+	}
+	perDay := (len(unmastered) + response.Days - 1) / response.Days
+	var moved []storage.Card
+	for i, entry := range unmastered {
+		slot := now
+		if day := i / perDay; day > 0 {
+			slot = localDay(now).AddDate(0, 0, day)
+		}
+		if !entry.card.FSRS.Due.After(slot) {
+			response.AlreadyDue++
+			continue
+		}
+		entry.card.FSRS.Due = slot
+		moved = append(moved, entry.card)
+		response.CardIDs = append(response.CardIDs, entry.card.ID)
+	}
+	// The audit entries go first so UpdateCards' save persists them with the batch
+	entries := make([]storage.AuditEntry, len(moved))
+	for i, card := range moved {
+		entries[i] = newAuditEntry(AuditUpdate, card.ID, "Moved to "+card.FSRS.Due.Format(time.RFC3339)+" to study for "+dd.Topic)
+	}
+	s.appendAudit(entries...)
+	if err := s.Storage.UpdateCards(moved); err != nil {
+		return BurstScheduleResponse{}, fmt.Errorf("error updating cards in storage: %w", err)
+	}
+	response.Rescheduled = len(moved)
+	return response, nil
+}
+
+// SuggestDueDate recommends a test date for a tag: studying cardsPerDay of its
+// unmastered cards each day starting today, the student finishes them all the
+// day before the suggested date. Suspended and archived cards are not counted.
+func (s *FlashcardService) SuggestDueDate(tag string, cardsPerDay float64) (DueDateSuggestion, error) {
+	if cardsPerDay <= 0 {
+		return DueDateSuggestion{}, errors.New("cards per day must be positive")
+	}
+	cards, err := s.GetCardsByTag(tag)
+	if err != nil {
+		return DueDateSuggestion{}, err
+	}
+
+	now := timeNow()
+	suggestion := DueDateSuggestion{Tag: tag, CardsPerDay: cardsPerDay}
+	for _, card := range cards {
+		if card.Suspended || card.Archived {
+			continue
+		}
+		suggestion.Cards++
+		reviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			return DueDateSuggestion{}, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
+		}
+		if s.isMastered(card, lastScheduledReview(card, reviews), now) {
+			suggestion.Mastered++
+		}
+	}
+
+	suggestion.Remaining = suggestion.Cards - suggestion.Mastered
+	suggestion.StudyDays = int(math.Ceil(float64(suggestion.Remaining) / cardsPerDay))
+	// The last study day is StudyDays-1 days from today; with nothing left, suggest tomorrow
+	suggestion.SuggestedDate = localDay(now).AddDate(0, 0, max(suggestion.StudyDays, 1)).Format("2006-01-02")
+	return suggestion, nil
+}
+
+// DueDateCoverage compares a due date's tag with a reference tag, such as the tag
+// for the whole unit, and lists the unit's cards that the test deck is missing.
+// Archived cards are ignored.
+func (s *FlashcardService) DueDateCoverage(dueDateID, referenceTag string) (DueDateCoverage, error) {
+	dd, err := s.GetDueDate(dueDateID)
+	if err != nil {
+		return DueDateCoverage{}, err
+	}
+	cards, err := s.GetCardsByTag(referenceTag)
+	if err != nil {
+		return DueDateCoverage{}, fmt.Errorf("error getting cards for tag '%s': %w", referenceTag, err)
+	}
+	sort.Slice(cards, func(i, j int) bool {
+		return cards[i].CreatedAt.Before(cards[j].CreatedAt)
+	})
+
+	coverage := DueDateCoverage{DueDateID: dd.ID, Tag: dd.Tag, ReferenceTag: referenceTag, Missing: []Card{}}
+	for _, card := range cards {
+		if card.Archived {
+			continue
+		}
+		coverage.ReferenceCards++
+		if containsString(card.Tags, dd.Tag) {
+			coverage.Covered++
+		} else {
+			coverage.Missing = append(coverage.Missing, cardFromStorage(card))
+		}
+	}
+	if coverage.ReferenceCards > 0 {
+		coverage.CoverageFraction = float64(coverage.Covered) / float64(coverage.ReferenceCards)
+	}
+	return coverage, nil
+}
+
+// --- Card Templates ---
+
+// templatePlaceholder matches {{name}} markers inside template text.
+var templatePlaceholder = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_]+)\s*\}\}`)
+
+// renderTemplate replaces every {{name}} marker in text with values[name].
+// It returns an error listing any placeholders that have no value.
+func renderTemplate(text string, values map[string]string) (string, error) {
+	var missing []string
+	rendered := templatePlaceholder.ReplaceAllStringFunc(text, func(match string) string {
+		name := templatePlaceholder.FindStringSubmatch(match)[1]
+		value, ok := values[name]
+		if !ok {
+			missing = append(missing, name)
+			return match
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("missing values for placeholders: %s", strings.Join(missing, ", "))
+	}
+	return rendered, nil
+}
+
+// SaveTemplate creates or replaces a named card template.
+func (s *FlashcardService) SaveTemplate(template storage.CardTemplate) error {
+	if template.Name == "" || template.Front == "" || template.Back == "" {
+		return errors.New("template name, front, and back are required")
+	}
+	if err := s.Storage.SaveTemplate(template); err != nil {
+		return fmt.Errorf("error saving template to storage: %w", err)
+	}
+	if err := s.Storage.Save(); err != nil {
+		return fmt.Errorf("error saving storage after saving template: %w", err)
+	}
+	return nil
+}
+
+// ListTemplates retrieves all card templates.
+func (s *FlashcardService) ListTemplates() ([]storage.CardTemplate, error) {
+	return s.Storage.ListTemplates()
+}
+
+// DeleteTemplate deletes a card template by name.
+func (s *FlashcardService) DeleteTemplate(name string) error {
+	if name == "" {
+		return errors.New("template name is required for delete")
+	}
+	if err := s.Storage.DeleteTemplate(name); err != nil {
+		return fmt.Errorf("error deleting template from storage: %w", err)
+	}
+	if err := s.Storage.Save(); err != nil {
+		return fmt.Errorf("error saving storage after deleting template: %w", err)
+	}
+	return nil
+}
+
+// CreateCardFromTemplate fills the named template with values and creates the resulting card.
+// The card receives the template's tags followed by any extra tags not already present.
+func (s *FlashcardService) CreateCardFromTemplate(name string, values map[string]string, extraTags []string) (Card, error) {
+	template, err := s.Storage.GetTemplate(name)
+	if err != nil {
+		return Card{}, fmt.Errorf("error getting template %s: %w", name, err)
+	}
+
+	front, err := renderTemplate(template.Front, values)
+	if err != nil {
+		return Card{}, fmt.Errorf("error rendering template front: %w", err)
+	}
+	back, err := renderTemplate(template.Back, values)
+	if err != nil {
+		return Card{}, fmt.Errorf("error rendering template back: %w", err)
+	}
+
+	tags := append([]string{}, template.Tags...)
+	for _, tag := range extraTags {
+		if !containsString(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	return s.CreateCard(front, back, tags)
+}
+
+// containsString reports whether value is present in list.
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// --- Import ---
+
+// importChunkSize is how many cards ImportCards creates between progress reports.
+const importChunkSize = 25
+
+// ImportCards creates cards in chunks, calling progress (if non-nil) with the
+// number of cards created so far after each chunk. Each chunk is created in a
+// single batch, so a failed import stops at a chunk boundary; a card with more
+// than MaxTagsPerCard tags fails the import before anything is created.
+func (s *FlashcardService) ImportCards(cards []ImportCard, progress func(done, total int)) (ImportCardsResponse, error) {
+	response := ImportCardsResponse{CardIDs: make([]string, 0, len(cards))}
+	for i, card := range cards {
+		if err := s.checkTagLimit(card.Tags); err != nil {
+			return response, fmt.Errorf("card %d: %w", i, err)
+		}
+	}
+	now := timeNow()
+	for start := 0; start < len(cards); start += importChunkSize {
+		end := min(start+importChunkSize, len(cards))
+		inputs := make([]storage.CardInput, 0, end-start)
+		for _, card := range cards[start:end] {
+			input := storage.CardInput{Front: card.Front, Back: card.Back, Tags: card.Tags, Hint: card.Hint}
+			if card.Schedule == nil {
+				input.DueDelay = s.NewCardDelay
+			} else {
+				input.FSRS = importedFSRS(*card.Schedule, now)
+			}
+			inputs = append(inputs, input)
+		}
+		created, err := s.Storage.CreateCards(inputs)
+		if err != nil {
+			return response, fmt.Errorf("error creating cards %d to %d: %w", start, end-1, err)
+		}
+		for _, storageCard := range created {
+			response.Imported++
+			response.CardIDs = append(response.CardIDs, storageCard.ID)
+		}
+		s.appendAudit(cardAuditEntries(AuditCreate, "Imported card", created)...)
+
+		if progress != nil {
+			progress(response.Imported, len(cards))
+		}
+	}
+
+	if err := s.Storage.Save(); err != nil {
+		return response, fmt.Errorf("error saving storage after import: %w", err)
+	}
+	return response, nil
+}
+
+// importedFSRS rebuilds the FSRS card for a restored schedule. The review history
+// isn't imported, so a reviewed card counts as reviewed once. Without a last review
+// time, a Review card is taken to have been reviewed its interval before it's due,
+// the interval being its stability (as FSRS schedules at 90% retention); the last
+// review is never later than now, so the next review has a sane elapsed time.
+func importedFSRS(schedule ImportSchedule, now time.Time) *gofsrs.Card {
+	card := &gofsrs.Card{
+		State:      schedule.State,
+		Due:        schedule.Due,
+		Stability:  schedule.Stability,
+		Difficulty: schedule.Difficulty,
+		LastReview: schedule.LastReview,
+	}
+	if card.State == gofsrs.New {
+		return card
+	}
+	card.Reps = 1
+	if card.State == gofsrs.Review {
+		if card.LastReview.IsZero() {
+			card.ScheduledDays = uint64(math.Max(1, math.Round(card.Stability)))
+			card.LastReview = card.Due.AddDate(0, 0, -int(card.ScheduledDays))
+		} else {
+			card.ScheduledDays = uint64(math.Max(0, math.Round(card.Due.Sub(card.LastReview).Hours()/24)))
+		}
+	} else if card.LastReview.IsZero() {
+		card.LastReview = card.Due
+	}
+	if card.LastReview.After(now) {
+		card.LastReview = now
+	}
+	return card
+}
+
+// ImportAnki creates a card from each supported note of the Anki .apkg package at
+// path, tagged with its deck name. Anki's scheduling isn't translated, so every
+// imported card starts out New.
+func (s *FlashcardService) ImportAnki(path string) (ImportAnkiResponse, error) {
+	pkg, err := importer.ReadAnkiPackage(path)
+	if err != nil {
+		return ImportAnkiResponse{}, err
+	}
+	return s.importAnkiPackage(pkg)
+}
+
+// importAnkiPackage creates the cards for the notes read from an Anki package.
+func (s *FlashcardService) importAnkiPackage(pkg importer.AnkiPackage) (ImportAnkiResponse, error) {
+	response := ImportAnkiResponse{
+		Skipped:      pkg.Unsupported,
+		SkippedBlank: pkg.Blank,
+		Decks:        []string{},
+		CardIDs:      make([]string, 0, len(pkg.Notes)),
+	}
+	seenDecks := make(map[string]bool)
+	for i, note := range pkg.Notes {
+		var tags []string
+		if tag := normalizeTag(note.Deck); tag != "" {
+			tags = []string{tag}
+			if !seenDecks[tag] {
+				seenDecks[tag] = true
+				response.Decks = append(response.Decks, tag)
+			}
+		}
+		card, err := s.CreateCard(note.Front, note.Back, tags)
+		if err != nil {
+			return response, fmt.Errorf("error creating card for note %d: %w", i, err)
+		}
+		response.Imported++
+		response.CardIDs = append(response.CardIDs, card.ID)
+	}
+	sort.Strings(response.Decks)
+	return response, nil
+}
+
+// ExportCards returns the cards matching the tags for export. Suspended and
+// archived cards are left out unless explicitly included.
+func (s *FlashcardService) ExportCards(filterTags []string, includeSuspended, includeArchived bool) (ExportCardsResponse, error) {
+	storageCards, err := s.Storage.ListCards(filterTags)
+	if err != nil {
+		return ExportCardsResponse{}, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+
+	response := ExportCardsResponse{ExportedAt: timeNow(), Cards: []Card{}}
+	for _, card := range storageCards {
+		if card.Suspended && !includeSuspended {
+			continue
+		}
+		if card.Archived && !includeArchived {
+			continue
+		}
+		response.Cards = append(response.Cards, cardFromStorage(card))
+	}
+	sort.Slice(response.Cards, func(i, j int) bool {
+		return response.Cards[i].CreatedAt.Before(response.Cards[j].CreatedAt)
+	})
+	return response, nil
+}
+
+// csvCardColumns are the columns of a card CSV file; csvSchedulingColumns are
+// the optional FSRS columns that follow them. csvLastReviewColumn comes last and
+// may be missing from files exported before it was added.
+var (
+	csvCardColumns       = []string{"front", "back", "tags"}
+	csvSchedulingColumns = []string{"state", "due", "stability", "difficulty"}
+)
+
+const csvLastReviewColumn = "last_review"
+
+// csvTagSeparator separates the tags within the tags column of a card CSV file.
+const csvTagSeparator = ";"
+
+// stateNames are the names of the FSRS states in CSV files.
+var stateNames = map[gofsrs.State]string{
+	gofsrs.New:        "new",
+	gofsrs.Learning:   "learning",
+	gofsrs.Review:     "review",
+	gofsrs.Relearning: "relearning",
+}
+
+// parseState parses an FSRS state by name or number.
+func parseState(value string) (gofsrs.State, error) {
+	for state, name := range stateNames {
+		if strings.EqualFold(value, name) || value == strconv.Itoa(int(state)) {
+			return state, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid state %q (must be new, learning, review or relearning)", value)
+}
+
+// ParseCardsCSV reads cards from CSV with a header row naming the columns front,
+// back and optionally tags (separated by semicolons). When the state, due,
+// stability and difficulty columns are present, rows with a state restore that
+// scheduling, along with the last_review column if there is one. Invalid rows are reported with their row number instead of failing
+// the whole file; an error is returned only if the file can't be used at all.
+func ParseCardsCSV(r io.Reader) ([]ImportCard, []ImportRowError, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1 // Short rows are checked per row below
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil, errors.New("CSV is empty")
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading CSV header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		// Spreadsheet apps often start UTF-8 files with a byte order mark
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	for _, name := range csvCardColumns[:2] {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("CSV header is missing the %q column", name)
+		}
+	}
+	scheduling := 0
+	for _, name := range csvSchedulingColumns {
+		if _, ok := columns[name]; ok {
+			scheduling++
+		}
+	}
+	if scheduling > 0 && scheduling < len(csvSchedulingColumns) {
+		return nil, nil, fmt.Errorf("CSV scheduling columns must include all of %s", strings.Join(csvSchedulingColumns, ", "))
+	}
+
+	cards := []ImportCard{}
+	rowErrors := []ImportRowError{}
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			rowErrors = append(rowErrors, ImportRowError{Row: row, Error: err.Error()})
+			continue
+		}
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		card := ImportCard{Front: field("front"), Back: field("back")}
+		if card.Front == "" || card.Back == "" {
+			rowErrors = append(rowErrors, ImportRowError{Row: row, Error: "front and back must not be empty"})
+			continue
+		}
+		for _, tag := range strings.Split(field("tags"), csvTagSeparator) {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				card.Tags = append(card.Tags, tag)
+			}
+		}
+		if scheduling > 0 && field("state") != "" {
+			schedule, err := parseCSVSchedule(field)
+			if err != nil {
+				rowErrors = append(rowErrors, ImportRowError{Row: row, Error: err.Error()})
+				continue
+			}
+			card.Schedule = &schedule
+		}
+		cards = append(cards, card)
+	}
+	return cards, rowErrors, nil
+}
+
+// parseCSVSchedule parses the scheduling columns of a card CSV row.
+func parseCSVSchedule(field func(name string) string) (ImportSchedule, error) {
+	state, err := parseState(field("state"))
+	if err != nil {
+		return ImportSchedule{}, err
+	}
+	due, err := time.Parse(time.RFC3339, field("due"))
+	if err != nil {
+		return ImportSchedule{}, fmt.Errorf("invalid due %q (must be RFC 3339, e.g. 2024-03-01T15:00:00Z)", field("due"))
+	}
+	schedule := ImportSchedule{State: state, Due: due}
+	if value := field(csvLastReviewColumn); value != "" {
+		schedule.LastReview, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			return ImportSchedule{}, fmt.Errorf("invalid last_review %q (must be RFC 3339, e.g. 2024-03-01T15:00:00Z)", value)
+		}
+	}
+	for _, number := range []struct {
+		name string
+		dest *float64
+	}{{"stability", &schedule.Stability}, {"difficulty", &schedule.Difficulty}} {
+		value := field(number.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil || parsed < 0 {
+			return ImportSchedule{}, fmt.Errorf("invalid %s %q (must be a non-negative number)", number.name, value)
+		}
+		*number.dest = parsed
+	}
+	return schedule, nil
+}
+
+// WriteCardsCSV writes cards as CSV in the format ParseCardsCSV reads, adding the
+// FSRS columns when includeScheduling is true.
+func WriteCardsCSV(w io.Writer, cards []Card, includeScheduling bool) error {
+	writer := csv.NewWriter(w)
+	header := csvCardColumns
+	if includeScheduling {
+		header = append(append([]string{}, csvCardColumns...), csvSchedulingColumns...)
+		header = append(header, csvLastReviewColumn)
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	for _, card := range cards {
+		record := []string{card.Front, card.Back, strings.Join(card.Tags, csvTagSeparator)}
+		if includeScheduling {
+			lastReview := ""
+			if !card.FSRS.LastReview.IsZero() {
+				lastReview = card.FSRS.LastReview.Format(time.RFC3339)
+			}
+			record = append(record,
+				stateNames[card.FSRS.State],
+				card.FSRS.Due.Format(time.RFC3339),
+				strconv.FormatFloat(card.FSRS.Stability, 'f', -1, 64),
+				strconv.FormatFloat(card.FSRS.Difficulty, 'f', -1, 64),
+				lastReview,
+			)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// DefaultSiblingSpacingDays is how many days after its forward card a generated
+// reverse card is first due, so the pair isn't always reviewed together.
+const DefaultSiblingSpacingDays = 3.0
+
+// GenerateReverseCards creates a back-to-front copy of each selected card. Cards
+// are selected by ID, or by tags when no IDs are given. Cards that are already a
+// reverse, or that already have one, are skipped. Each reverse is first due
+// SiblingSpacing after its forward card.
+func (s *FlashcardService) GenerateReverseCards(cardIDs, filterTags []string) (GenerateReverseCardsResponse, error) {
+	if len(cardIDs) == 0 && len(filterTags) == 0 {
+		return GenerateReverseCardsResponse{}, errors.New("card IDs or tags are required to select cards")
+	}
+
+	allCards, err := s.Storage.ListCards(nil)
+	if err != nil {
+		return GenerateReverseCardsResponse{}, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+	reversed := make(map[string]bool)
+	for _, card := range allCards {
+		if card.ReverseOf != "" {
+			reversed[card.ReverseOf] = true
+		}
+	}
+
+	var forwards []storage.Card
+	if len(cardIDs) > 0 {
+		for _, id := range cardIDs {
+			card, err := s.Storage.GetCard(id)
+			if err != nil {
+				return GenerateReverseCardsResponse{}, fmt.Errorf("error getting card %s: %w", id, err)
+			}
+			forwards = append(forwards, card)
+		}
+	} else {
+		forwards, err = s.Storage.ListCards(filterTags)
+		if err != nil {
+			return GenerateReverseCardsResponse{}, fmt.Errorf("error listing cards from storage: %w", err)
+		}
+		sort.Slice(forwards, func(i, j int) bool { return forwards[i].ID < forwards[j].ID })
+	}
+
+	response := GenerateReverseCardsResponse{CardIDs: make([]string, 0, len(forwards))}
+	var inputs []storage.CardInput
+	for _, forward := range forwards {
+		if forward.ReverseOf != "" || reversed[forward.ID] {
+			response.Skipped++
+			continue
+		}
+		if err := s.checkTagLimit(forward.Tags); err != nil {
+			return response, fmt.Errorf("reverse of card %s: %w", forward.ID, err)
+		}
+		inputs = append(inputs, storage.CardInput{
+			Front:     forward.Back,
+			Back:      forward.Front,
+			Tags:      forward.Tags,
+			ReverseOf: forward.ID,
+			FSRS:      &gofsrs.Card{Due: forward.FSRS.Due.Add(s.SiblingSpacing), State: gofsrs.New},
+		})
+		reversed[forward.ID] = true
+	}
+	created, err := s.Storage.CreateCards(inputs)
+	if err != nil {
+		return response, fmt.Errorf("error creating reverse cards: %w", err)
+	}
+	entries := make([]storage.AuditEntry, len(created))
+	for i, reverse := range created {
+		response.Created++
+		response.CardIDs = append(response.CardIDs, reverse.ID)
+		entries[i] = newAuditEntry(AuditCreate, reverse.ID, "Created as the reverse of card "+reverse.ReverseOf)
+	}
+	s.appendAudit(entries...)
+
+	if err := s.Storage.Save(); err != nil {
+		return response, fmt.Errorf("error saving storage after generating reverse cards: %w", err)
+	}
+	return response, nil
+}
+
+// ExportFull serializes the entire store, including FSRS state, reviews, due dates
+// and config, for a lossless move to another machine.
+func (s *FlashcardService) ExportFull() (FullExport, error) {
+	store, err := s.Storage.ExportStore()
+	if err != nil {
+		return FullExport{}, fmt.Errorf("error exporting store: %w", err)
+	}
+	return FullExport{
+		SchemaVersion: storage.StoreSchemaVersion,
+		ExportedAt:    timeNow(),
+		Store:         store,
+	}, nil
+}
+
+// ImportFull replaces the entire store with a previous ExportFull result and
+// applies its config. Exports with a different schema version are refused.
+func (s *FlashcardService) ImportFull(export FullExport) error {
+	if export.SchemaVersion != storage.StoreSchemaVersion {
+		return fmt.Errorf("%w: export has version %d, this server uses version %d",
+			ErrSchemaVersionMismatch, export.SchemaVersion, storage.StoreSchemaVersion)
+	}
+	if err := s.Storage.ReplaceStore(export.Store); err != nil {
+		return fmt.Errorf("error replacing store: %w", err)
+	}
+	if err := s.Storage.Save(); err != nil {
+		return fmt.Errorf("error saving storage after full import: %w", err)
+	}
+	config, err := s.Storage.GetConfig()
+	if err != nil {
+		return fmt.Errorf("error getting config from storage: %w", err)
+	}
+	s.ApplyConfig(config)
+	return nil
+}
+
+// ListBackups returns the rotating backups of the storage file, newest first.
+func (s *FlashcardService) ListBackups() ([]storage.Backup, error) {
+	backupStorage, ok := s.Storage.(storage.BackupStorage)
+	if !ok {
+		return nil, ErrBackupsUnsupported
+	}
+	return backupStorage.ListBackups()
+}
+
+// RestoreBackup replaces the store with the named backup and applies its config.
+// The replaced file is backed up first, so the restore itself can be undone.
+func (s *FlashcardService) RestoreBackup(name string) error {
+	backupStorage, ok := s.Storage.(storage.BackupStorage)
+	if !ok {
+		return ErrBackupsUnsupported
+	}
+	if err := backupStorage.RestoreBackup(name); err != nil {
+		return fmt.Errorf("error restoring backup: %w", err)
+	}
+	config, err := s.Storage.GetConfig()
+	if err != nil {
+		return fmt.Errorf("error getting config from storage: %w", err)
+	}
+	s.ApplyConfig(config)
+	return nil
+}
+
+// --- Maintenance ---
+
+// isInvalidDue reports whether a due date is unusable for scheduling. Legacy stores
+// may contain zero-value dates, which would make a card perpetually due.
+func isInvalidDue(due time.Time) bool {
+	return due.IsZero() || due.Year() < 1970
+}
+
+// replayReviews rebuilds card's FSRS state from scratch by applying each of its
+// reviews, oldest first, to a fresh New card. Practice reviews and those from
+// before the card was last reset are skipped; a card left with none stays New, due
+// at its reset or else the first of its reviews.
+func (s *FlashcardService) replayReviews(card storage.Card, reviews []storage.Review) gofsrs.Card {
+	replayed := gofsrs.Card{State: gofsrs.New, Due: card.ResetAt}
+	reviews = reviewsSinceReset(card, reviews)
+	for _, review := range reviews {
+		if replayed.Due.IsZero() || review.Timestamp.Before(replayed.Due) {
+			replayed.Due = review.Timestamp
+		}
+	}
+
+	sorted := scheduledReviews(reviews)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+	for _, review := range sorted {
+		replayed = s.FSRSManager.GetSchedulingInfo(replayed, review.Rating, review.Timestamp)
+	}
+	return replayed
+}
+
+// RepairDueDates finds cards with zero or otherwise invalid FSRS due dates and fixes them.
+// Cards without reviews are reset to a New card due now; reviewed cards have their
+// scheduling recomputed from their review history. When dryRun is true, nothing is saved.
+func (s *FlashcardService) RepairDueDates(dryRun bool) ([]DueDateRepair, error) {
+	cards, err := s.Storage.ListCards(nil)
+	if err != nil {
+		return nil, fmt.Errorf("error listing cards: %w", err)
+	}
+
+	now := timeNow()
+	repairs := []DueDateRepair{}
+	var repaired []storage.Card
+	for _, card := range cards {
+		if !isInvalidDue(card.FSRS.Due) {
+			continue
+		}
+
+		reviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
+		}
+
+		repair := DueDateRepair{CardID: card.ID, Front: card.Front, OldDue: card.FSRS.Due}
+		if len(scheduledReviews(reviewsSinceReset(card, reviews))) == 0 {
+			card.FSRS = gofsrs.Card{Due: now, State: gofsrs.New}
+			repair.Method = "reset"
+		} else {
+			card.FSRS = s.replayReviews(card, reviews)
+			repair.Method = "replayed"
+		}
+		repair.NewDue = card.FSRS.Due
+		repairs = append(repairs, repair)
+		repaired = append(repaired, card)
+	}
+
+	sort.Slice(repairs, func(i, j int) bool {
+		return repairs[i].CardID < repairs[j].CardID
+	})
+
+	if !dryRun && len(repairs) > 0 {
+		// The audit entries go first so UpdateCards' save persists them with the batch
+		entries := make([]storage.AuditEntry, len(repairs))
+		for i, repair := range repairs {
+			entries[i] = newAuditEntry(AuditUpdate, repair.CardID, "Repaired due date ("+repair.Method+"), now due "+repair.NewDue.Format(time.RFC3339))
+		}
+		s.appendAudit(entries...)
+		if err := s.Storage.UpdateCards(repaired); err != nil {
+			return nil, fmt.Errorf("error updating repaired cards: %w", err)
+		}
+	}
+	return repairs, nil
+}
+
+// DefaultStaleDays is how long a card may go without review before
+// autosuspend_stale suspends it, when no threshold is given.
+const DefaultStaleDays = 180
+
+// Actions reported by CleanupCollection.
+const (
+	CleanupRemoved  = "removed"  // Card with a blank front or back was deleted
+	CleanupRetagged = "retagged" // Card's tags were normalized
+	CleanupMerged   = "merged"   // Card with the same front as an older card was folded into it
+)
+
+// normalizeTag lowercases a tag, trims it and joins its words with hyphens, so
+// " Chapter 3" and "chapter-3" become the same tag.
+func normalizeTag(tag string) string {
+	return strings.Join(strings.Fields(strings.ToLower(tag)), "-")
+}
+
+// errNothingToClean stops UpdateStore from saving a cleanup that changed nothing.
+var errNothingToClean = errors.New("nothing to clean up")
+
+// CleanupCollection tidies a collection after messy imports, in one pass over a copy
+// of the store: cards with a blank front or back are removed along with their
+// reviews, tags are normalized (due date tags are left alone so progress keeps
+// working), and cards whose trimmed front matches an older card's are merged into it.
+// A merge keeps the older card's text, adds the other card's tags, moves its reviews
+// over and reschedules from the combined history. Nothing is saved unless confirm is
+// set; a confirmed cleanup runs under the storage's write lock so no concurrent
+// change is overwritten.
+func (s *FlashcardService) CleanupCollection(confirm bool) (CleanupReport, error) {
+	if !confirm {
+		store, err := s.Storage.ExportStore()
+		if err != nil {
+			return CleanupReport{}, fmt.Errorf("error exporting store: %w", err)
+		}
+		return s.cleanupStore(&store), nil
+	}
+
+	var report CleanupReport
+	err := s.Storage.UpdateStore(func(store *storage.FlashcardStore) error {
+		report = s.cleanupStore(store)
+		report.DryRun = false
+		if len(report.Changes) == 0 {
+			return errNothingToClean
+		}
+		return nil
+	})
+	if errors.Is(err, errNothingToClean) {
+		return report, nil
+	}
+	if err != nil {
+		return CleanupReport{}, fmt.Errorf("error saving storage after cleanup: %w", err)
+	}
+
+	// UpdateStore holds the storage lock while cleaning, so the log is written after
+	var entries []storage.AuditEntry
+	for _, change := range report.Changes {
+		switch change.Action {
+		case CleanupRemoved:
+			entries = append(entries, newAuditEntry(AuditDelete, change.CardID, "Removed by cleanup: blank front or back"))
+		case CleanupRetagged:
+			entries = append(entries, newAuditEntry(AuditUpdate, change.CardID, "Tags normalized to "+strings.Join(change.NewTags, ", ")))
+		case CleanupMerged:
+			entries = append(entries,
+				newAuditEntry(AuditDelete, change.CardID, "Merged into card "+change.IntoCardID+" by cleanup"),
+				newAuditEntry(AuditUpdate, change.IntoCardID, "Merged card "+change.CardID+" into this one by cleanup"))
+		}
+	}
+	s.appendAudit(entries...)
+	if err := s.Storage.Save(); err != nil {
+		fmt.Printf("Warning: failed to save audit log after cleanup: %v\n", err)
+	}
+	return report, nil
+}
+
+// cleanupStore applies CleanupCollection's changes to store and reports them as a
+// dry run.
+func (s *FlashcardService) cleanupStore(store *storage.FlashcardStore) CleanupReport {
+	dueDateTags := make(map[string]bool, len(store.DueDates))
+	for _, dd := range store.DueDates {
+		dueDateTags[dd.Tag] = true
+	}
+
+	cards := make([]storage.Card, 0, len(store.Cards))
+	for _, card := range store.Cards {
+		cards = append(cards, card)
+	}
+	// Oldest first, so merges keep the original card
+	sort.Slice(cards, func(i, j int) bool {
+		if !cards[i].CreatedAt.Equal(cards[j].CreatedAt) {
+			return cards[i].CreatedAt.Before(cards[j].CreatedAt)
+		}
+		return cards[i].ID < cards[j].ID
+	})
+
+	report := CleanupReport{DryRun: true, Changes: []CleanupChange{}}
+	removed := make(map[string]bool)
+	mergedInto := make(map[string]string)
+	keeperByFront := make(map[string]string)
+	for _, card := range cards {
+		if strings.TrimSpace(card.Front) == "" || strings.TrimSpace(card.Back) == "" {
+			removed[card.ID] = true
+			delete(store.Cards, card.ID)
+			report.Removed++
+			report.Changes = append(report.Changes, CleanupChange{Action: CleanupRemoved, CardID: card.ID, Front: card.Front})
+			continue
+		}
+
+		tags := make([]string, 0, len(card.Tags))
+		for _, tag := range card.Tags {
+			if !dueDateTags[tag] {
+				tag = normalizeTag(tag)
+			}
+			if tag != "" && !containsString(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+		if !equalStringSlices(tags, card.Tags) {
+			report.Retagged++
+			report.Changes = append(report.Changes, CleanupChange{Action: CleanupRetagged, CardID: card.ID, Front: card.Front, OldTags: card.Tags, NewTags: tags})
+			card.Tags = tags
+		}
+
+		front := strings.TrimSpace(card.Front)
+		keeperID, duplicate := keeperByFront[front]
+		if !duplicate {
+			keeperByFront[front] = card.ID
+			store.Cards[card.ID] = card
+			continue
+		}
+		keeper := store.Cards[keeperID]
+		for _, tag := range card.Tags {
+			if !containsString(keeper.Tags, tag) {
+				keeper.Tags = append(keeper.Tags, tag)
+			}
+		}
+		store.Cards[keeperID] = keeper
+		delete(store.Cards, card.ID)
+		mergedInto[card.ID] = keeperID
+		report.Merged++
+		report.Changes = append(report.Changes, CleanupChange{Action: CleanupMerged, CardID: card.ID, Front: card.Front, IntoCardID: keeperID})
+	}
+
+	reviews := make([]storage.Review, 0, len(store.Reviews))
+	historyChanged := make(map[string][]storage.Review)
+	for _, review := range store.Reviews {
+		if removed[review.CardID] {
+			continue
+		}
+		if keeperID, ok := mergedInto[review.CardID]; ok {
+			review.CardID = keeperID
+			historyChanged[keeperID] = nil
+			report.ReviewsMoved++
+		}
+		reviews = append(reviews, review)
+	}
+	store.Reviews = reviews
+	for _, review := range reviews {
+		if _, ok := historyChanged[review.CardID]; ok {
+			historyChanged[review.CardID] = append(historyChanged[review.CardID], review)
+		}
+	}
+	for keeperID, history := range historyChanged {
+		keeper := store.Cards[keeperID]
+		keeper.FSRS = s.replayReviews(keeper, history)
+		store.Cards[keeperID] = keeper
+	}
+	return report
+}
+
+// AutosuspendStale suspends active cards whose last review (or creation, for cards
+// never reviewed) is more than days ago, to declutter the review queue. The cards
+// can be reviewed again once they are unsuspended. When dryRun is true, the stale
+// cards are reported but not suspended.
+func (s *FlashcardService) AutosuspendStale(days float64, filterTags []string, dryRun bool) (AutosuspendResponse, error) {
+	if days <= 0 {
+		return AutosuspendResponse{}, errors.New("days must be positive")
+	}
+	storageCards, err := s.Storage.ListCards(filterTags)
+	if err != nil {
+		return AutosuspendResponse{}, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+
+	cutoff := timeNow().Add(-time.Duration(days * 24 * float64(time.Hour)))
+	response := AutosuspendResponse{Days: days, DryRun: dryRun, CardIDs: []string{}}
+	var stale []storage.Card
+	for _, card := range storageCards {
+		if card.Suspended || card.Archived {
+			continue
+		}
+		reviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			return AutosuspendResponse{}, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
+		}
+		lastActivity := card.CreatedAt
+		for _, review := range reviews {
+			if review.Timestamp.After(lastActivity) {
+				lastActivity = review.Timestamp
+			}
+		}
+		if !lastActivity.Before(cutoff) {
+			continue
+		}
+		card.Suspended = true
+		stale = append(stale, card)
+		response.CardIDs = append(response.CardIDs, card.ID)
+	}
+	sort.Strings(response.CardIDs)
+	response.Suspended = len(stale)
+
+	if dryRun {
+		return response, nil
+	}
+	// The audit entries go first so UpdateCards' save persists them with the batch
+	s.appendAudit(cardAuditEntries(AuditUpdate, "Suspended as stale", stale)...)
+	if err := s.Storage.UpdateCards(stale); err != nil {
+		return AutosuspendResponse{}, fmt.Errorf("error suspending cards in storage: %w", err)
+	}
+	return response, nil
+}
+
+// Default bounds for ClockAnomalyCheck.
+const (
+	DefaultClockAnomalyFutureDays    = 3650 // A due date ten years past the card's last activity is implausible
+	DefaultClockAnomalyToleranceDays = 1
+)
+
+// Reasons reported by ClockAnomalyCheck.
+const (
+	ClockAnomalyBeforeCreation = "due_before_creation"
+	ClockAnomalyBeforeReview   = "due_before_last_review"
+	ClockAnomalyTooFar         = "due_too_far_ahead"
+)
+
+// ClockAnomalyCheck reports cards whose due date doesn't fit their history, as
+// happens when the system clock jumped while they were scheduled: due more than
+// toleranceDays before the card was created or last reviewed, or more than
+// maxFutureDays after its last activity. Zero due dates are left to RepairDueDates.
+func (s *FlashcardService) ClockAnomalyCheck(maxFutureDays, toleranceDays float64) ([]ClockAnomaly, error) {
+	cards, err := s.Storage.ListCards(nil)
+	if err != nil {
+		return nil, fmt.Errorf("error listing cards: %w", err)
+	}
+
+	tolerance := time.Duration(toleranceDays * 24 * float64(time.Hour))
+	maxAhead := time.Duration(maxFutureDays * 24 * float64(time.Hour))
+	anomalies := []ClockAnomaly{}
+	for _, card := range cards {
+		due := card.FSRS.Due
+		if isInvalidDue(due) {
+			continue
+		}
+		reviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
+		}
+		var lastReview time.Time
+		for _, review := range scheduledReviews(reviews) {
+			if review.Timestamp.After(lastReview) {
+				lastReview = review.Timestamp
+			}
+		}
+		lastActivity := card.CreatedAt
+		if lastReview.After(lastActivity) {
+			lastActivity = lastReview
+		}
+
+		anomaly := ClockAnomaly{CardID: card.ID, Front: card.Front, Due: due}
+		switch {
+		case !card.CreatedAt.IsZero() && due.Before(card.CreatedAt.Add(-tolerance)):
+			anomaly.Reason, anomaly.Reference = ClockAnomalyBeforeCreation, card.CreatedAt
+		case !lastReview.IsZero() && due.Before(lastReview.Add(-tolerance)):
+			anomaly.Reason, anomaly.Reference = ClockAnomalyBeforeReview, lastReview
+		case !lastActivity.IsZero() && due.After(lastActivity.Add(maxAhead)):
+			anomaly.Reason, anomaly.Reference = ClockAnomalyTooFar, lastActivity
+		default:
+			continue
+		}
+		anomalies = append(anomalies, anomaly)
+	}
+
+	sort.Slice(anomalies, func(i, j int) bool {
+		return anomalies[i].CardID < anomalies[j].CardID
+	})
+	return anomalies, nil
+}
+
+// GetHint returns the card's hint without revealing its answer.
+func (s *FlashcardService) GetHint(cardID string) (HintResponse, error) {
+	card, err := s.Storage.GetCard(cardID)
+	if err != nil {
+		return HintResponse{}, fmt.Errorf("error getting card %s: %w", cardID, err)
+	}
+	if card.Hint == "" {
+		return HintResponse{}, fmt.Errorf("card %s has no hint", cardID)
+	}
+	return HintResponse{
+		CardID: card.ID,
+		Front:  card.Front,
+		Hint:   card.Hint,
+	}, nil
+}
+
+// normalizeAnswer prepares an answer for comparison: surrounding whitespace is
+// trimmed, inner runs of whitespace collapse to one space, and case is folded.
+// With foldDiacritics, combining marks are stripped after NFD decomposition.
+func normalizeAnswer(answer string, foldDiacritics bool) string {
+	answer = strings.ToLower(strings.Join(strings.Fields(answer), " "))
+	if !foldDiacritics {
+		return norm.NFC.String(answer)
+	}
+	folder := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	folded, _, err := transform.String(folder, answer)
+	if err != nil {
+		return answer
+	}
+	return folded
+}
+
+// CheckAnswer compares a student's answer with the back of the card after
+// normalization. It does not record a review.
+func (s *FlashcardService) CheckAnswer(cardID, answer string) (AnswerCheck, error) {
+	card, err := s.Storage.GetCard(cardID)
+	if err != nil {
+		return AnswerCheck{}, fmt.Errorf("error getting card %s: %w", cardID, err)
+	}
+	return AnswerCheck{
+		CardID:         card.ID,
+		Answer:         answer,
+		Expected:       card.Back,
+		Correct:        normalizeAnswer(answer, s.FoldDiacritics) == normalizeAnswer(card.Back, s.FoldDiacritics),
+		FoldDiacritics: s.FoldDiacritics,
+	}, nil
+}
+
+// ListCardsByDue returns cards ordered by FSRS due date (soonest first), optionally filtered by tags.
+func (s *FlashcardService) ListCardsByDue(filterTags []string) ([]CardDueInfo, error) {
+	storageCards, err := s.Storage.ListCards(filterTags)
+	if err != nil {
+		return nil, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+
+	now := timeNow()
+	result := make([]CardDueInfo, 0, len(storageCards))
+	for _, storageCard := range storageCards {
+		result = append(result, CardDueInfo{
+			Card:         cardFromStorage(storageCard),
+			Due:          storageCard.FSRS.Due,
+			IntervalDays: storageCard.FSRS.ScheduledDays,
+			DaysUntilDue: storageCard.FSRS.Due.Sub(now).Hours() / 24.0,
+		})
+	}
+
+	// Sort by due date ascending, using ID as a tie-breaker for stable output
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Due.Equal(result[j].Due) {
+			return result[i].Due.Before(result[j].Due)
+		}
+		return result[i].Card.ID < result[j].Card.ID
+	})
+	return result, nil
+}
+
+// DefaultLongIntervalDays is the default threshold used by ListLongIntervalCards.
+const DefaultLongIntervalDays = 180.0
+
+// ListLongIntervalCards returns cards whose next due date is more than thresholdDays
+// away, farthest first. These are cards an Easy streak has pushed so far out that
+// they are effectively out of rotation.
+func (s *FlashcardService) ListLongIntervalCards(thresholdDays float64, filterTags []string) ([]CardDueInfo, error) {
+	entries, err := s.ListCardsByDue(filterTags)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]CardDueInfo, 0)
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].DaysUntilDue > thresholdDays {
+			result = append(result, entries[i])
+		}
+	}
+	return result, nil
+}
+
+// ListCardsByReviewCount returns cards with their total number of reviews, most
+// reviewed first, optionally filtered by tags.
+func (s *FlashcardService) ListCardsByReviewCount(filterTags []string) ([]CardReviewCount, error) {
+	storageCards, err := s.Storage.ListCards(filterTags)
+	if err != nil {
+		return nil, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+
+	result := make([]CardReviewCount, 0, len(storageCards))
+	for _, storageCard := range storageCards {
+		reviews, err := s.Storage.GetCardReviews(storageCard.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error getting reviews for card %s: %w", storageCard.ID, err)
+		}
+		result = append(result, CardReviewCount{
+			Card:        cardFromStorage(storageCard),
+			ReviewCount: len(reviews),
+		})
+	}
+
+	// Sort by review count descending, using ID as a tie-breaker for stable output
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ReviewCount != result[j].ReviewCount {
+			return result[i].ReviewCount > result[j].ReviewCount
+		}
+		return result[i].Card.ID < result[j].Card.ID
+	})
+	return result, nil
+}
+
+// SearchByAnswer returns cards whose back contains query, ignoring case, optionally
+// filtered by tags. The front is not searched. Results are ordered by front.
+func (s *FlashcardService) SearchByAnswer(query string, filterTags []string) ([]Card, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, errors.New("search query is required")
+	}
+	storageCards, err := s.Storage.ListCards(filterTags)
+	if err != nil {
+		return nil, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+
+	needle := strings.ToLower(query)
+	result := make([]Card, 0)
+	for _, card := range storageCards {
+		if strings.Contains(strings.ToLower(card.Back), needle) {
+			result = append(result, cardFromStorage(card))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Front != result[j].Front {
+			return result[i].Front < result[j].Front
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+// SearchCards returns cards whose front or back contains query, ignoring case,
+// optionally filtered by tags. Cards matching on the front rank above those
+// matching only on the back; within each group more matches rank higher.
+func (s *FlashcardService) SearchCards(query string, filterTags []string) ([]SearchResult, error) {
+	if strings.TrimSpace(query) == "" {
+		return nil, errors.New("search query is required")
+	}
+	storageCards, err := s.Storage.ListCards(filterTags)
+	if err != nil {
+		return nil, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+
+	needle := strings.ToLower(query)
+	result := make([]SearchResult, 0)
+	frontMatches := make(map[string]int)
+	for _, card := range storageCards {
+		front := strings.Count(strings.ToLower(card.Front), needle)
+		back := strings.Count(strings.ToLower(card.Back), needle)
+		if front+back == 0 {
+			continue
+		}
+		frontMatches[card.ID] = front
+		result = append(result, SearchResult{Card: cardFromStorage(card), MatchCount: front + back})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		iFront, jFront := frontMatches[result[i].Card.ID] > 0, frontMatches[result[j].Card.ID] > 0
+		if iFront != jFront {
+			return iFront
+		}
+		if result[i].MatchCount != result[j].MatchCount {
+			return result[i].MatchCount > result[j].MatchCount
+		}
+		return result[i].Card.ID < result[j].Card.ID
+	})
+	return result, nil
+}
+
+// --- Vacation Mode ---
+
+// AddVacation pauses scheduling between start and end. Time inside the vacation
+// does not count toward how overdue a card is.
+func (s *FlashcardService) AddVacation(start, end time.Time) (storage.Vacation, error) {
+	if start.IsZero() || end.IsZero() {
+		return storage.Vacation{}, errors.New("vacation start and end are required")
+	}
+	if !end.After(start) {
+		return storage.Vacation{}, errors.New("vacation end must be after start")
+	}
+	vacation := storage.Vacation{
+		ID:    uuid.NewString(),
+		Start: start,
+		End:   end,
+	}
+	if err := s.Storage.AddVacation(vacation); err != nil {
+		return storage.Vacation{}, fmt.Errorf("error adding vacation to storage: %w", err)
+	}
+	if err := s.Storage.Save(); err != nil {
+		return storage.Vacation{}, fmt.Errorf("error saving storage after adding vacation: %w", err)
+	}
+	return vacation, nil
+}
+
+// ListVacations retrieves all vacation periods.
+func (s *FlashcardService) ListVacations() ([]storage.Vacation, error) {
+	return s.Storage.ListVacations()
+}
+
+// DeleteVacation removes a vacation period by its ID.
+func (s *FlashcardService) DeleteVacation(id string) error {
+	if id == "" {
+		return errors.New("vacation ID is required for delete")
+	}
+	if err := s.Storage.DeleteVacation(id); err != nil {
+		return fmt.Errorf("error deleting vacation from storage: %w", err)
+	}
+	if err := s.Storage.Save(); err != nil {
+		return fmt.Errorf("error saving storage after deleting vacation: %w", err)
+	}
+	return nil
+}
+
+// vacationsOrNil returns the configured vacations, or nil if they can't be read.
+func (s *FlashcardService) vacationsOrNil() []storage.Vacation {
+	vacations, err := s.Storage.ListVacations()
+	if err != nil {
+		fmt.Printf("Warning: error listing vacations: %v\n", err)
+		return nil
+	}
+	return vacations
+}
+
+// pausedDuration returns how much of the interval [from, to) falls inside vacations.
+// Overlapping vacations are only counted once.
+func pausedDuration(from, to time.Time, vacations []storage.Vacation) time.Duration {
+	if !to.After(from) || len(vacations) == 0 {
+		return 0
+	}
+	sorted := make([]storage.Vacation, len(vacations))
+	copy(sorted, vacations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
+	})
+
+	var paused time.Duration
+	cursor := from
+	for _, v := range sorted {
+		start, end := v.Start, v.End
+		if start.Before(cursor) {
+			start = cursor
+		}
+		if end.After(to) {
+			end = to
+		}
+		if end.After(start) {
+			paused += end.Sub(start)
+			cursor = end
+		}
+	}
+	return paused
+}
+
+// adjustDueForVacations shifts a due date forward by the vacation time that has
+// elapsed since it, so cards don't accumulate overdue days while scheduling is paused.
+// Vacations that have started count in full, so during an active vacation nothing
+// new comes due until it ends. Vacations that haven't started yet are ignored.
+func adjustDueForVacations(due, now time.Time, vacations []storage.Vacation) time.Time {
+	var started []storage.Vacation
+	var latestEnd time.Time
+	for _, v := range vacations {
+		if v.Start.After(now) {
+			continue
+		}
+		started = append(started, v)
+		if v.End.After(latestEnd) {
+			latestEnd = v.End
+		}
+	}
+	return due.Add(pausedDuration(due, latestEnd, started))
+}
+
+// --- Snapshots ---
+
+// snapshotInfo summarizes a snapshot for tool responses.
+func snapshotInfo(snapshot storage.Snapshot) SnapshotInfo {
+	return SnapshotInfo{
+		ID:          snapshot.ID,
+		Label:       snapshot.Label,
+		CreatedAt:   snapshot.CreatedAt,
+		CardCount:   len(snapshot.Cards),
+		ReviewCount: len(snapshot.ReviewIDs),
+	}
+}
+
+// CreateSnapshot stores a timestamped copy of all cards and the IDs of all reviews.
+func (s *FlashcardService) CreateSnapshot(label string) (SnapshotInfo, error) {
+	storageCards, err := s.Storage.ListCards(nil)
+	if err != nil {
+		return SnapshotInfo{}, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+
+	snapshot := storage.Snapshot{
+		ID:        uuid.NewString(),
+		Label:     label,
+		CreatedAt: timeNow(),
+		Cards:     make(map[string]storage.Card, len(storageCards)),
+		ReviewIDs: []string{},
+	}
+	for _, card := range storageCards {
+		card.Tags = append([]string(nil), card.Tags...) // Don't share tag storage with the live card
+		snapshot.Cards[card.ID] = card
+		reviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			return SnapshotInfo{}, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
+		}
+		for _, review := range reviews {
+			snapshot.ReviewIDs = append(snapshot.ReviewIDs, review.ID)
+		}
+	}
+
+	if err := s.Storage.AddSnapshot(snapshot); err != nil {
+		return SnapshotInfo{}, fmt.Errorf("error adding snapshot to storage: %w", err)
+	}
+	if err := s.Storage.Save(); err != nil {
+		return SnapshotInfo{}, fmt.Errorf("error saving storage after creating snapshot: %w", err)
+	}
+	return snapshotInfo(snapshot), nil
+}
+
+// ListSnapshots returns a summary of every stored snapshot, oldest first.
+func (s *FlashcardService) ListSnapshots() ([]SnapshotInfo, error) {
+	snapshots, err := s.Storage.ListSnapshots()
+	if err != nil {
+		return nil, fmt.Errorf("error listing snapshots from storage: %w", err)
+	}
+	result := make([]SnapshotInfo, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		result = append(result, snapshotInfo(snapshot))
+	}
+	return result, nil
+}
+
+// DeleteSnapshot removes a snapshot, e.g. an old weekly one, so the store doesn't
+// keep growing with copies of the collection.
+func (s *FlashcardService) DeleteSnapshot(id string) error {
+	if id == "" {
+		return errors.New("snapshot ID is required")
+	}
+	if err := s.Storage.DeleteSnapshot(id); err != nil {
+		return fmt.Errorf("error deleting snapshot from storage: %w", err)
+	}
+	if err := s.Storage.Save(); err != nil {
+		return fmt.Errorf("error saving storage after deleting snapshot: %w", err)
+	}
+	return nil
+}
+
+// DiffSnapshot compares the current store to a snapshot, reporting added, removed
+// and modified cards and reviews submitted since. An empty snapshotID uses the
+// most recent snapshot.
+func (s *FlashcardService) DiffSnapshot(snapshotID string) (SnapshotDiff, error) {
+	var snapshot storage.Snapshot
+	if snapshotID == "" {
+		snapshots, err := s.Storage.ListSnapshots()
+		if err != nil {
+			return SnapshotDiff{}, fmt.Errorf("error listing snapshots from storage: %w", err)
+		}
+		if len(snapshots) == 0 {
+			return SnapshotDiff{}, storage.ErrSnapshotNotFound
+		}
+		snapshot = snapshots[len(snapshots)-1]
+	} else {
+		var err error
+		snapshot, err = s.Storage.GetSnapshot(snapshotID)
+		if err != nil {
+			return SnapshotDiff{}, fmt.Errorf("error getting snapshot %s: %w", snapshotID, err)
+		}
+	}
+
+	storageCards, err := s.Storage.ListCards(nil)
+	if err != nil {
+		return SnapshotDiff{}, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+
+	knownReviews := make(map[string]bool, len(snapshot.ReviewIDs))
+	for _, id := range snapshot.ReviewIDs {
+		knownReviews[id] = true
 	}
-	fmt.Printf("[DEBUG-SVC] GetDueCard: Found %d due cards among considered cards.\n", len(dueCards))
 
-	// Sort the due cards (from the filtered list) by priority (highest first)
-	sort.Slice(dueCards, func(i, j int) bool {
-		return dueCards[i].priority > dueCards[j].priority
-	})
+	diff := SnapshotDiff{
+		Snapshot:      snapshotInfo(snapshot),
+		AddedCards:    []Card{},
+		RemovedCards:  []Card{},
+		ModifiedCards: []ModifiedCard{},
+		NewReviews:    []storage.Review{},
+	}
+	current := make(map[string]bool, len(storageCards))
+	for _, card := range storageCards {
+		current[card.ID] = true
+		if old, existed := snapshot.Cards[card.ID]; !existed {
+			diff.AddedCards = append(diff.AddedCards, cardFromStorage(card))
+		} else if changed := changedCardFields(old, card); len(changed) > 0 {
+			diff.ModifiedCards = append(diff.ModifiedCards, ModifiedCard{Card: cardFromStorage(card), ChangedFields: changed})
+		}
 
-	// Return highest priority card from the filtered set or error if none due
-	if len(dueCards) == 0 {
-		if len(filterTags) > 0 {
-			fmt.Printf("[DEBUG-SVC] GetDueCard: No DUE cards matched tags, returning error.\n")
-			return Card{}, stats, fmt.Errorf("no cards due for review with the specified tags: %v", filterTags)
+		reviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			return SnapshotDiff{}, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
+		}
+		for _, review := range reviews {
+			if !knownReviews[review.ID] {
+				diff.NewReviews = append(diff.NewReviews, review)
+			}
+		}
+	}
+	for id, old := range snapshot.Cards {
+		if !current[id] {
+			diff.RemovedCards = append(diff.RemovedCards, cardFromStorage(old))
 		}
-		// No filter, but no cards due
-		fmt.Printf("[DEBUG-SVC] GetDueCard: No cards are due for review, returning error.\n")
-		return Card{}, stats, fmt.Errorf("no cards due for review")
 	}
 
-	// Return the highest priority card from the filtered due list, along with overall stats
-	fmt.Printf("[DEBUG-SVC] GetDueCard: Returning highest priority card ID %s.\n", dueCards[0].card.ID)
-	return dueCards[0].card, stats, nil
+	// Sort for stable output
+	sort.Slice(diff.AddedCards, func(i, j int) bool { return diff.AddedCards[i].CreatedAt.Before(diff.AddedCards[j].CreatedAt) })
+	sort.Slice(diff.RemovedCards, func(i, j int) bool { return diff.RemovedCards[i].ID < diff.RemovedCards[j].ID })
+	sort.Slice(diff.ModifiedCards, func(i, j int) bool { return diff.ModifiedCards[i].Card.ID < diff.ModifiedCards[j].Card.ID })
+	sort.Slice(diff.NewReviews, func(i, j int) bool { return diff.NewReviews[i].Timestamp.Before(diff.NewReviews[j].Timestamp) })
+	return diff, nil
 }
 
-// Helper function to ensure all required tags are present in a card
-func hasAllRequiredTags(card *storage.Card, requiredTags []string) bool {
-	if len(requiredTags) == 0 {
-		return true // No required tags means all cards match
+// changedCardFields lists the content fields that differ between two versions of a card.
+// Scheduling state is not compared; review activity is reported separately.
+func changedCardFields(old, updated storage.Card) []string {
+	var changed []string
+	if old.Front != updated.Front {
+		changed = append(changed, "front")
 	}
-
-	if card == nil {
-		return false // Can't match any tags if card is nil
+	if old.Back != updated.Back {
+		changed = append(changed, "back")
+	}
+	if !equalStringSlices(old.Tags, updated.Tags) {
+		changed = append(changed, "tags")
 	}
+	if old.Hint != updated.Hint {
+		changed = append(changed, "hint")
+	}
+	return changed
+}
 
-	// If the card has no tags but we have required tags, it can't match
-	if len(card.Tags) == 0 {
-		return false
+// Difficulty band tags applied by AutotagByDifficulty.
+const (
+	DifficultyTagLow    = "difficulty-low"
+	DifficultyTagMedium = "difficulty-medium"
+	DifficultyTagHigh   = "difficulty-high"
+)
+
+// difficultyBand maps an FSRS difficulty (1-10) to a band tag. Cards that have
+// never been reviewed have no meaningful difficulty and get no band.
+func difficultyBand(fsrsCard gofsrs.Card) string {
+	switch {
+	case fsrsCard.State == gofsrs.New:
+		return ""
+	case fsrsCard.Difficulty >= 7:
+		return DifficultyTagHigh
+	case fsrsCard.Difficulty >= 4:
+		return DifficultyTagMedium
+	default:
+		return DifficultyTagLow
 	}
+}
 
-	// Create a map of the card's tags for efficient lookup
-	cardTagsMap := make(map[string]bool)
-	for _, tag := range card.Tags {
-		cardTagsMap[tag] = true
+// AutotagByDifficulty refreshes the difficulty band tag on every card (optionally
+// filtered by tags), replacing any band tag from a previous run.
+func (s *FlashcardService) AutotagByDifficulty(filterTags []string) (AutotagByDifficultyResponse, error) {
+	storageCards, err := s.Storage.ListCards(filterTags)
+	if err != nil {
+		return AutotagByDifficultyResponse{}, fmt.Errorf("error listing cards from storage: %w", err)
 	}
 
-	// Check if the card has all required tags
-	for _, reqTag := range requiredTags {
-		if !cardTagsMap[reqTag] {
-			return false // Missing a required tag
+	result := AutotagByDifficultyResponse{Bands: map[string]int{
+		DifficultyTagLow:    0,
+		DifficultyTagMedium: 0,
+		DifficultyTagHigh:   0,
+	}}
+	var updated []storage.Card
+	for _, card := range storageCards {
+		band := difficultyBand(card.FSRS)
+		newTags := make([]string, 0, len(card.Tags)+1)
+		for _, tag := range card.Tags {
+			if tag != DifficultyTagLow && tag != DifficultyTagMedium && tag != DifficultyTagHigh {
+				newTags = append(newTags, tag)
+			}
+		}
+		if band != "" {
+			newTags = append(newTags, band)
+			result.Bands[band]++
+		}
+
+		if equalStringSlices(card.Tags, newTags) {
+			continue
 		}
+		if err := s.checkTagLimit(newTags); err != nil {
+			return result, fmt.Errorf("card %s: %w", card.ID, err)
+		}
+		card.Tags = newTags
+		updated = append(updated, card)
 	}
 
-	return true // All required tags found
+	if len(updated) > 0 {
+		// The audit entries go first so UpdateCards' save persists them with the batch
+		entries := make([]storage.AuditEntry, len(updated))
+		for i, card := range updated {
+			entries[i] = newAuditEntry(AuditUpdate, card.ID, "Tagged by difficulty: "+strings.Join(card.Tags, ", "))
+		}
+		s.appendAudit(entries...)
+		if err := s.Storage.UpdateCards(updated); err != nil {
+			return result, fmt.Errorf("error updating cards in storage: %w", err)
+		}
+		result.UpdatedCards = len(updated)
+	}
+	return result, nil
 }
 
-// calculateStats calculates statistics from card and review data
-func (s *FlashcardService) calculateStats(cards []storage.Card) CardStats {
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+// --- Analytics ---
 
-	// Count total and due cards
-	totalCards := len(cards)
+// DefaultSecondsPerCard is the assumed average review time when none is configured.
+const DefaultSecondsPerCard = 20.0
+
+// EstimateSessionTime estimates how long it takes to clear every due card matching
+// the tags, at secondsPerCard per review (0 uses the configured or default value).
+func (s *FlashcardService) EstimateSessionTime(filterTags []string, secondsPerCard float64) (SessionTimeEstimate, error) {
+	if secondsPerCard <= 0 {
+		secondsPerCard = s.SecondsPerCard
+	}
+	if secondsPerCard <= 0 {
+		secondsPerCard = DefaultSecondsPerCard
+	}
+
+	storageCards, err := s.Storage.ListCards(filterTags)
+	if err != nil {
+		return SessionTimeEstimate{}, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+
+	now := timeNow()
+	vacations := s.vacationsOrNil()
 	dueCards := 0
-	for _, card := range cards {
-		if !card.FSRS.Due.After(now) {
+	for _, card := range storageCards {
+		if !adjustDueForVacations(card.FSRS.Due, now, vacations).After(now) {
 			dueCards++
 		}
 	}
 
-	// Get today's reviews and count correct answers
-	var reviewsToday []storage.Review
-	correctReviewsToday := 0
-	for _, card := range cards {
-		cardReviews, err := s.Storage.GetCardReviews(card.ID)
-		if err == nil {
-			for _, review := range cardReviews {
-				if !review.Timestamp.Before(today) {
-					reviewsToday = append(reviewsToday, review)
-					// Rating 3 (Good) or 4 (Easy) is considered correct
-					if review.Rating >= gofsrs.Good {
-						correctReviewsToday++
-					}
-				}
+	return SessionTimeEstimate{
+		DueCards:         dueCards,
+		SecondsPerCard:   secondsPerCard,
+		EstimatedMinutes: float64(dueCards) * secondsPerCard / 60.0,
+	}, nil
+}
+
+// SessionCards returns the distinct cards reviewed between start and end (inclusive),
+// ordered by when they were first reviewed in that window.
+func (s *FlashcardService) SessionCards(start, end time.Time) ([]SessionCard, error) {
+	if end.Before(start) {
+		return nil, errors.New("session end must not be before start")
+	}
+	storageCards, err := s.Storage.ListCards(nil)
+	if err != nil {
+		return nil, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+
+	result := []SessionCard{}
+	for _, card := range storageCards {
+		reviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
+		}
+		sort.Slice(reviews, func(i, j int) bool {
+			return reviews[i].Timestamp.Before(reviews[j].Timestamp)
+		})
+
+		var entry *SessionCard
+		for _, review := range reviews {
+			if review.Timestamp.Before(start) || review.Timestamp.After(end) {
+				continue
 			}
+			if entry == nil {
+				entry = &SessionCard{Card: cardFromStorage(card), FirstReviewed: review.Timestamp}
+			}
+			entry.Ratings = append(entry.Ratings, review.Rating)
+			entry.LastReviewed = review.Timestamp
+		}
+		if entry != nil {
+			result = append(result, *entry)
 		}
 	}
 
-	// Calculate retention rate (correct answers / total reviews today)
-	retentionRate := 0.0
-	if len(reviewsToday) > 0 {
-		retentionRate = float64(correctReviewsToday) / float64(len(reviewsToday)) * 100.0
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].FirstReviewed.Before(result[j].FirstReviewed)
+	})
+	return result, nil
+}
+
+// EffortTargetReviews is the session length below which effort_report scales the effort score down.
+const EffortTargetReviews = 10
+
+// EffortReport summarizes how much work went into the reviews between start and end
+// (inclusive), practice reviews included. The effort score, 0-100, is the share of
+// reviews that came with a typed answer, scaled down for sessions shorter than
+// EffortTargetReviews so a single answered card doesn't score full marks.
+func (s *FlashcardService) EffortReport(start, end time.Time) (EffortReport, error) {
+	if end.Before(start) {
+		return EffortReport{}, errors.New("session end must not be before start")
+	}
+	storageCards, err := s.Storage.ListCards(nil)
+	if err != nil {
+		return EffortReport{}, fmt.Errorf("error listing cards from storage: %w", err)
 	}
 
-	return CardStats{
-		TotalCards:    totalCards,
-		DueCards:      dueCards,
-		ReviewsToday:  len(reviewsToday),
-		RetentionRate: retentionRate,
+	report := EffortReport{Start: start, End: end}
+	for _, card := range storageCards {
+		reviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			return EffortReport{}, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
+		}
+		reviewed := false
+		for _, review := range reviews {
+			if review.Timestamp.Before(start) || review.Timestamp.After(end) {
+				continue
+			}
+			reviewed = true
+			report.Reviews++
+			if strings.TrimSpace(review.Answer) != "" {
+				report.Answered++
+			}
+			if review.DurationMS > 0 {
+				report.TimeSpentMS += review.DurationMS
+				report.TimedReviews++
+			}
+		}
+		if reviewed {
+			report.Cards++
+		}
+	}
+
+	if report.Reviews == 0 {
+		return report, nil
 	}
+	report.AnsweredRatio = float64(report.Answered) / float64(report.Reviews)
+	volume := math.Min(1, float64(report.Reviews)/EffortTargetReviews)
+	report.EffortScore = int(math.Round(100 * report.AnsweredRatio * volume))
+	return report, nil
 }
 
-// SubmitReview processes a review for a card and updates its state using the FSRS algorithm
-func (s *FlashcardService) SubmitReview(cardID string, rating gofsrs.Rating, answer string) (Card, error) {
-	return s.SubmitReviewWithTime(cardID, rating, answer, timeNow())
+// GetMaturityBreakdown counts new, young and mature cards, optionally filtered by tags.
+func (s *FlashcardService) GetMaturityBreakdown(filterTags []string) (MaturityBreakdown, error) {
+	storageCards, err := s.Storage.ListCards(filterTags)
+	if err != nil {
+		return MaturityBreakdown{}, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+
+	breakdown := MaturityBreakdown{Total: len(storageCards)}
+	for _, card := range storageCards {
+		switch cardMaturity(card.FSRS) {
+		case MaturityNew:
+			breakdown.New++
+		case MaturityYoung:
+			breakdown.Young++
+		default:
+			breakdown.Mature++
+		}
+	}
+	return breakdown, nil
 }
 
-// SubmitReviewWithTime processes a review for a card and updates its state using the FSRS algorithm
-// with a specific timestamp. This allows tests to provide a simulated "now" timestamp.
-func (s *FlashcardService) SubmitReviewWithTime(cardID string, rating gofsrs.Rating, answer string, now time.Time) (Card, error) {
-	startTime := now
-	fmt.Printf("[DEBUG-SVC] SubmitReview starting for cardID=%s, rating=%d at %v\n",
-		cardID, rating, startTime.Format(time.RFC3339Nano))
+// DefaultLeechThreshold is the number of lapses that makes a card a leech, as in Anki.
+const DefaultLeechThreshold = 8
 
-	// Get the card from storage
-	fmt.Printf("[DEBUG-SVC] Retrieving card from storage\n")
-	storageCard, err := s.Storage.GetCard(cardID)
-	if err != nil {
-		fmt.Printf("[DEBUG-SVC] Error getting card: %v\n", err)
-		return Card{}, fmt.Errorf("error getting card: %w", err)
+// leechThreshold returns LeechThreshold, or DefaultLeechThreshold when it's unset.
+func (s *FlashcardService) leechThreshold() int {
+	if s.LeechThreshold > 0 {
+		return s.LeechThreshold
 	}
-	fmt.Printf("[DEBUG-SVC] Retrieved card with current state=%v, due=%v\n",
-		storageCard.FSRS.State, storageCard.FSRS.Due)
+	return DefaultLeechThreshold
+}
 
-	// Get previous reviews to calculate actual elapsed time
-	fmt.Printf("[DEBUG-SVC] Retrieving previous reviews for cardID=%s\n", cardID)
-	previousReviews, err := s.Storage.GetCardReviews(cardID)
+// Leeches lists the cards forgotten at least the leech threshold number of times,
+// most lapses first. A card counts as a leech once marked, or once its FSRS lapse
+// count reaches the threshold, which covers cards that got there before the
+// threshold was lowered. Archived cards are left out.
+func (s *FlashcardService) Leeches() (LeechesResponse, error) {
+	storageCards, err := s.Storage.ListCards(nil)
 	if err != nil {
-		fmt.Printf("[DEBUG-SVC] Error getting reviews: %v\n", err)
-		// Don't fail the operation, just continue with default elapsed days
+		return LeechesResponse{}, fmt.Errorf("error listing cards from storage: %w", err)
 	}
-	fmt.Printf("[DEBUG-SVC] Found %d previous reviews for card %s\n", len(previousReviews), cardID)
 
-	// Calculate elapsed days since last review if we have review history
-	if len(previousReviews) > 0 {
-		// Sort reviews by timestamp (newest first)
-		sort.Slice(previousReviews, func(i, j int) bool {
-			return previousReviews[i].Timestamp.After(previousReviews[j].Timestamp)
+	threshold := s.leechThreshold()
+	response := LeechesResponse{Threshold: threshold, AutoSuspend: s.AutoSuspendLeeches, Leeches: []LeechCard{}}
+	active := 0
+	for _, card := range storageCards {
+		if card.Archived || (!card.Leech && card.FSRS.Lapses < uint64(threshold)) {
+			continue
+		}
+		reviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			return LeechesResponse{}, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
+		}
+		response.Leeches = append(response.Leeches, LeechCard{
+			ID:          card.ID,
+			Front:       card.Front,
+			Lapses:      card.FSRS.Lapses,
+			Suspended:   card.Suspended,
+			LastLapseAt: lastLapse(reviews),
 		})
+		if !card.Suspended {
+			active++
+		}
+	}
+	sort.SliceStable(response.Leeches, func(i, j int) bool {
+		return response.Leeches[i].Lapses > response.Leeches[j].Lapses
+	})
+	if active > 0 {
+		response.Suggestion = fmt.Sprintf("%d leeches are still being reviewed. Consider rewriting them or splitting them "+
+			"into smaller cards, or suspend them with suspend_card so they stop taking up study time.", active)
+	}
+	return response, nil
+}
 
-		// Get the most recent review
-		lastReviewTime := previousReviews[0].Timestamp
+// lastLapse returns the time of the latest scheduled review rated Again while the
+// card was in the Review state, or the zero time if it never lapsed. A review's
+// State is the state after it, so the state before comes from the previous review.
+func lastLapse(reviews []storage.Review) time.Time {
+	sorted := make([]storage.Review, 0, len(reviews))
+	for _, review := range reviews {
+		if !review.Practice {
+			sorted = append(sorted, review)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp) })
 
-		// Calculate elapsed days
-		elapsedDuration := now.Sub(lastReviewTime)
-		elapsedDays := uint64(elapsedDuration.Hours() / 24.0)
+	var last time.Time
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Rating == gofsrs.Again && sorted[i-1].State == gofsrs.Review {
+			last = sorted[i].Timestamp
+		}
+	}
+	return last
+}
 
-		// Update the ElapsedDays in the card's FSRS state
-		storageCard.FSRS.ElapsedDays = elapsedDays
+// DefaultStatisticsWindows are the retention windows of GetStatistics, in days.
+var DefaultStatisticsWindows = []int{1, 7, 30}
 
-		fmt.Printf("[DEBUG-SVC] Last review at %v, now at %v, elapsed days: %d\n",
-			lastReviewTime.Format(time.RFC3339), now.Format(time.RFC3339), elapsedDays)
+// GetStatistics summarizes the whole collection in one pass over the cards and their
+// reviews. Suspended and archived cards are counted on their own and left out of
+// the due, state and interval figures. Retention is reported for each window of
+// days, where a window of N days covers today and the N-1 days before it, like
+// the reviews_today stats, and then for all time.
+func (s *FlashcardService) GetStatistics(windows []int) (Statistics, error) {
+	for _, days := range windows {
+		if days < 1 {
+			return Statistics{}, fmt.Errorf("invalid window %d: must be at least 1 day", days)
+		}
+	}
+	storageCards, err := s.Storage.ListCards(nil)
+	if err != nil {
+		return Statistics{}, fmt.Errorf("error listing cards from storage: %w", err)
 	}
 
-	fmt.Printf("[DEBUG-SVC] Calling GetSchedulingInfo with ElapsedDays=%d\n",
-		storageCard.FSRS.ElapsedDays)
+	now := timeNow()
+	vacations := s.vacationsOrNil()
+	stats := Statistics{TotalCards: len(storageCards), CardsByState: make(map[string]int, len(stateNames))}
+	for _, name := range stateNames {
+		stats.CardsByState[name] = 0
+	}
 
-	// Get the complete updated FSRS card with all metadata using the new method
-	updatedFSRSCard := s.FSRSManager.GetSchedulingInfo(
-		storageCard.FSRS, // Pass the entire FSRS card with updated ElapsedDays
-		rating,
-		now,
-	)
-	fmt.Printf("[DEBUG-SVC] FSRS scheduling result: newState=%v, newDueDate=%v, stability=%.4f, difficulty=%.4f, reps=%d\n",
-		updatedFSRSCard.State, updatedFSRSCard.Due, updatedFSRSCard.Stability, updatedFSRSCard.Difficulty, updatedFSRSCard.Reps)
+	// Window starts are local midnights, so "today" matches the reviews_today stat
+	starts := make([]time.Time, len(windows))
+	for i, days := range windows {
+		starts[i] = localDay(now).AddDate(0, 0, 1-days)
+	}
+	stats.Retention = make([]RetentionWindow, len(windows)+1)
 
-	// Update the storage card with the complete FSRS data
-	fmt.Printf("[DEBUG-SVC] Updating card with complete FSRS state\n")
-	storageCard.FSRS = updatedFSRSCard // Replace entire FSRS card with updated version
-	storageCard.LastReviewedAt = now   // Record last reviewed time (field should exist now)
+	intervalDays := 0.0
+	for _, card := range storageCards {
+		switch {
+		case card.Archived:
+			stats.ArchivedCards++
+		case card.Suspended:
+			stats.SuspendedCards++
+		default:
+			stats.CardsByState[stateNames[card.FSRS.State]]++
+			switch card.FSRS.State {
+			case gofsrs.New:
+				stats.NewCards++
+			case gofsrs.Learning, gofsrs.Relearning:
+				stats.LearningCards++
+			case gofsrs.Review:
+				stats.ReviewCards++
+				intervalDays += float64(card.FSRS.ScheduledDays)
+			}
+			if !adjustDueForVacations(card.FSRS.Due, now, vacations).After(now) {
+				stats.DueCards++
+			}
+		}
 
-	// Save the updated card state back to storage
-	fmt.Printf("[DEBUG-SVC] Updating card in storage at %v\n", timeNow().Format(time.RFC3339Nano))
-	if err := s.Storage.UpdateCard(storageCard); err != nil {
-		fmt.Printf("[DEBUG-SVC] Error updating card: %v\n", err)
-		return Card{}, fmt.Errorf("error updating card: %w", err)
+		reviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			return Statistics{}, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
+		}
+		for _, review := range scheduledReviews(reviews) {
+			correct := review.Rating >= gofsrs.Good
+			for i, start := range starts {
+				if !review.Timestamp.Before(start) {
+					stats.Retention[i].Reviews++
+					if correct {
+						stats.Retention[i].Correct++
+					}
+				}
+			}
+			stats.Retention[len(windows)].Reviews++
+			if correct {
+				stats.Retention[len(windows)].Correct++
+			}
+		}
+	}
+	stats.TotalReviews = stats.Retention[len(windows)].Reviews
+	if stats.ReviewCards > 0 {
+		stats.AverageIntervalDays = intervalDays / float64(stats.ReviewCards)
 	}
 
-	// Add review to storage
-	fmt.Printf("[DEBUG-SVC] Adding review to storage at %v\n", timeNow().Format(time.RFC3339Nano))
-	reviewLog := storage.Review{
-		ID:            uuid.New().String(),
-		CardID:        cardID,
-		Rating:        rating,
-		Timestamp:     now, // Use the provided time for consistency
-		Answer:        answer,
-		ScheduledDays: updatedFSRSCard.ScheduledDays,
-		ElapsedDays:   updatedFSRSCard.ElapsedDays,
-		State:         updatedFSRSCard.State,
+	for i := range stats.Retention {
+		window := &stats.Retention[i]
+		switch {
+		case i == len(windows):
+			window.Window = "all_time"
+		case windows[i] == 1:
+			window.Window, window.Days = "today", 1
+		default:
+			window.Window, window.Days = fmt.Sprintf("%dd", windows[i]), windows[i]
+		}
+		if window.Reviews > 0 {
+			window.RetentionRate = float64(window.Correct) / float64(window.Reviews) * 100.0
+		}
 	}
+	return stats, nil
+}
 
-	if err := s.Storage.AddReviewDirect(reviewLog); err != nil {
-		fmt.Printf("[DEBUG-SVC] Error adding review: %v\n", err)
-		return Card{}, fmt.Errorf("error adding review: %w", err)
+// TagStateSummary reports the fraction of a tag's cards in each FSRS state. The
+// fractions are 0 when the tag has no cards.
+func (s *FlashcardService) TagStateSummary(tag string) (TagStateSummary, error) {
+	cards, err := s.GetCardsByTag(tag)
+	if err != nil {
+		return TagStateSummary{}, err
+	}
+
+	summary := TagStateSummary{Tag: tag, CardCount: len(cards)}
+	if len(cards) == 0 {
+		return summary, nil
+	}
+	counts := make(map[gofsrs.State]int)
+	for _, card := range cards {
+		counts[card.FSRS.State]++
+	}
+	total := float64(len(cards))
+	summary.New = float64(counts[gofsrs.New]) / total
+	summary.Learning = float64(counts[gofsrs.Learning]) / total
+	summary.Review = float64(counts[gofsrs.Review]) / total
+	summary.Relearning = float64(counts[gofsrs.Relearning]) / total
+	return summary, nil
+}
+
+// DefaultForgettingCurveSamples is how many points forgetting_curve returns by default.
+const DefaultForgettingCurveSamples = 10
+
+// ForgettingCurve samples a card's predicted recall probability from now through the
+// length of its current interval (the time from its last review to its due date, at
+// least one day), so a client can plot how the memory decays. The points are evenly
+// spaced, starting at now.
+func (s *FlashcardService) ForgettingCurve(cardID string, samples int) (ForgettingCurve, error) {
+	if samples < 2 {
+		return ForgettingCurve{}, errors.New("at least 2 samples are needed")
+	}
+	card, err := s.Storage.GetCard(cardID)
+	if err != nil {
+		return ForgettingCurve{}, fmt.Errorf("error getting card %s: %w", cardID, err)
+	}
+	if card.FSRS.State == gofsrs.New || card.FSRS.Stability <= 0 {
+		return ForgettingCurve{}, fmt.Errorf("card %s has not been reviewed yet, so it has no forgetting curve", cardID)
+	}
+
+	now := timeNow()
+	span := card.FSRS.Due.Sub(card.FSRS.LastReview)
+	if span < 24*time.Hour {
+		span = 24 * time.Hour
+	}
+	curve := ForgettingCurve{
+		CardID:     card.ID,
+		Stability:  card.FSRS.Stability,
+		LastReview: card.FSRS.LastReview,
+		Due:        card.FSRS.Due,
+		Points:     make([]ForgettingCurvePoint, 0, samples),
+	}
+	for i := 0; i < samples; i++ {
+		at := now.Add(span * time.Duration(i) / time.Duration(samples-1))
+		curve.Points = append(curve.Points, ForgettingCurvePoint{
+			Time:           at,
+			DaysFromNow:    at.Sub(now).Hours() / 24,
+			Retrievability: s.FSRSManager.Retrievability(card.FSRS, at),
+		})
 	}
-	fmt.Printf("[DEBUG-SVC] Review added successfully\n")
+	return curve, nil
+}
 
-	// Persist changes to disk
-	fmt.Printf("[DEBUG-SVC] Saving storage to disk at %v\n", timeNow().Format(time.RFC3339Nano))
-	if err := s.Storage.Save(); err != nil {
-		fmt.Printf("[DEBUG-SVC] Error saving storage: %v\n", err)
-		return Card{}, fmt.Errorf("error saving storage: %w", err)
-	}
-	fmt.Printf("[DEBUG-SVC] Storage saved successfully\n")
+// DefaultDecayForecastDays is how far ahead decay_forecast looks when no horizon is given.
+const DefaultDecayForecastDays = 30
 
-	// Convert updated storage.Card to our main Card type
-	updatedCard := Card{
-		ID:        storageCard.ID,
-		Front:     storageCard.Front,
-		Back:      storageCard.Back,
-		CreatedAt: storageCard.CreatedAt,
-		Tags:      storageCard.Tags,
-		FSRS:      storageCard.FSRS,
+// DecayForecast projects, for each of the next days days, how many of the currently
+// mastered active cards would have fallen below threshold recall probability if no
+// reviews happened in the meantime. Cards already below the threshold count from day 1.
+func (s *FlashcardService) DecayForecast(days int, threshold float64, filterTags []string) (DecayForecast, error) {
+	if days < 1 {
+		return DecayForecast{}, errors.New("forecast must cover at least one day")
+	}
+	if threshold <= 0 || threshold >= 1 {
+		return DecayForecast{}, errors.New("recall threshold must be between 0 and 1")
+	}
+	storageCards, err := s.Storage.ListCards(filterTags)
+	if err != nil {
+		return DecayForecast{}, fmt.Errorf("error listing cards from storage: %w", err)
 	}
 
-	elapsed := time.Since(startTime)
-	fmt.Printf("[DEBUG-SVC] SubmitReview completed in %v at %v\n",
-		elapsed, timeNow().Format(time.RFC3339Nano))
+	now := timeNow()
+	var mastered []gofsrs.Card
+	for _, card := range storageCards {
+		if card.Suspended || card.Archived {
+			continue
+		}
+		reviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			return DecayForecast{}, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
+		}
+		if s.isMastered(card, lastScheduledReview(card, reviews), now) {
+			mastered = append(mastered, card.FSRS)
+		}
+	}
 
-	return updatedCard, nil
+	forecast := DecayForecast{Threshold: threshold, Mastered: len(mastered), Days: make([]DecayDay, 0, days)}
+	today := localDay(now)
+	for i := 1; i <= days; i++ {
+		at := now.AddDate(0, 0, i)
+		atRisk := 0
+		for _, card := range mastered {
+			if s.FSRSManager.Retrievability(card, at) < threshold {
+				atRisk++
+			}
+		}
+		forecast.Days = append(forecast.Days, DecayDay{
+			Date:   today.AddDate(0, 0, i).Format("2006-01-02"),
+			AtRisk: atRisk,
+		})
+	}
+	return forecast, nil
 }
 
-// Variable to allow mocking time.Now in tests
-var timeNow = time.Now
-
-// AnalyzeLearning provides insights based on review history
-func (s *FlashcardService) AnalyzeLearning() (string, error) {
-	// Fetch all cards and their review histories
-	cards, err := s.Storage.ListCards(nil)
+// GetRatingDistribution counts Again/Hard/Good/Easy ratings across all reviews of cards with the tag.
+func (s *FlashcardService) GetRatingDistribution(tag string) (RatingDistribution, error) {
+	cards, err := s.GetCardsByTag(tag)
 	if err != nil {
-		return "", fmt.Errorf("error getting all cards for analysis: %w", err)
+		return RatingDistribution{}, err
 	}
 
-	if len(cards) == 0 {
-		return "No cards available to analyze yet. Let's create some!", nil
+	dist := RatingDistribution{Tag: tag, CardCount: len(cards)}
+	for _, card := range cards {
+		reviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			return RatingDistribution{}, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
+		}
+		for _, review := range reviews {
+			switch review.Rating {
+			case gofsrs.Again:
+				dist.Again++
+			case gofsrs.Hard:
+				dist.Hard++
+			case gofsrs.Good:
+				dist.Good++
+			case gofsrs.Easy:
+				dist.Easy++
+			default:
+				continue
+			}
+			dist.TotalReviews++
+		}
 	}
+	return dist, nil
+}
 
-	// Simple analysis: Find the card reviewed most recently with the lowest rating (1 or 2)
-	var worstReview *storage.Review = nil
-	var worstCard *storage.Card = nil // Use pointer to allow nil
-	latestTime := time.Time{}
+// TagStreaks returns, for each tag, the longest run of consecutive calendar days
+// (in local time) with at least one review of a card carrying that tag. Tags whose
+// cards were never reviewed are omitted. Results are ordered longest streak first.
+func (s *FlashcardService) TagStreaks() ([]TagStreak, error) {
+	storageCards, err := s.Storage.ListCards(nil)
+	if err != nil {
+		return nil, fmt.Errorf("error listing cards from storage: %w", err)
+	}
 
-	for i := range cards { // Iterate using index to get addressable card
-		card := cards[i] // Get a copy of the card for this iteration
+	daysByTag := make(map[string]map[time.Time]bool)
+	for _, card := range storageCards {
+		if len(card.Tags) == 0 {
+			continue
+		}
 		reviews, err := s.Storage.GetCardReviews(card.ID)
 		if err != nil {
-			continue // Skip cards with errors fetching reviews
+			return nil, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
 		}
-		for j := range reviews {
-			review := reviews[j]              // Get a copy
-			if review.Rating <= gofsrs.Hard { // Again or Hard
-				if review.Timestamp.After(latestTime) {
-					latestTime = review.Timestamp
-					worstReview = &review
-					// Assign the address of the card from the original slice
-					worstCard = &cards[i]
+		for _, review := range reviews {
+			day := localDay(review.Timestamp)
+			for _, tag := range card.Tags {
+				if daysByTag[tag] == nil {
+					daysByTag[tag] = make(map[time.Time]bool)
 				}
+				daysByTag[tag][day] = true
 			}
 		}
 	}
 
-	if worstCard != nil && worstReview != nil {
-		return fmt.Sprintf("It looks like the card '%s' was challenging (rated %d on %s). Maybe we can break down the concept or create related cards?",
-			worstCard.Front, worstReview.Rating, worstReview.Timestamp.Format(time.RFC822)), nil
+	result := make([]TagStreak, 0, len(daysByTag))
+	for tag, daySet := range daysByTag {
+		days := make([]time.Time, 0, len(daySet))
+		for day := range daySet {
+			days = append(days, day)
+		}
+		sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+
+		best := TagStreak{Tag: tag, LongestStreak: 1, StreakStart: days[0].Format("2006-01-02"), StreakEnd: days[0].Format("2006-01-02")}
+		runStart, runLength := days[0], 1
+		for i := 1; i < len(days); i++ {
+			if days[i].Equal(days[i-1].AddDate(0, 0, 1)) {
+				runLength++
+			} else {
+				runStart, runLength = days[i], 1
+			}
+			if runLength > best.LongestStreak {
+				best.LongestStreak = runLength
+				best.StreakStart = runStart.Format("2006-01-02")
+				best.StreakEnd = days[i].Format("2006-01-02")
+			}
+		}
+		result = append(result, best)
 	}
 
-	return "Great job so far! All recent reviews look good. Keep up the excellent work!", nil
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].LongestStreak != result[j].LongestStreak {
+			return result[i].LongestStreak > result[j].LongestStreak
+		}
+		return result[i].Tag < result[j].Tag
+	})
+	return result, nil
 }
 
-// GetTags returns a map of tags to the count of cards with that tag
-func (s *FlashcardService) GetTags() (map[string]int, error) {
-	cards, err := s.Storage.ListCards(nil)
+// StudyStreak returns the student's current and longest runs of consecutive
+// calendar days (in local time) with at least one review.
+func (s *FlashcardService) StudyStreak() (StudyStreak, error) {
+	storageCards, err := s.Storage.ListCards(nil)
 	if err != nil {
-		return nil, fmt.Errorf("error getting cards for tags: %w", err)
+		return StudyStreak{}, fmt.Errorf("error listing cards from storage: %w", err)
 	}
-
-	tagCounts := make(map[string]int)
-	for _, card := range cards {
-		for _, tag := range card.Tags {
-			tagCounts[tag]++
+	var reviews []storage.Review
+	for _, card := range storageCards {
+		cardReviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			return StudyStreak{}, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
 		}
+		reviews = append(reviews, cardReviews...)
 	}
-	return tagCounts, nil
+	return studyStreak(reviews, timeNow()), nil
 }
 
-// --- Due Date Management ---
-
-// AddDueDate adds a new due date entry.
-func (s *FlashcardService) AddDueDate(dueDate storage.DueDate) error {
-	if dueDate.Topic == "" || dueDate.Tag == "" || dueDate.DueDate.IsZero() {
-		return errors.New("due date topic, tag, and date are required")
-	}
-	if err := s.Storage.AddDueDate(dueDate); err != nil {
-		return fmt.Errorf("error adding due date to storage: %w", err)
+// studyStreak computes the study streaks of the reviews as of now. A streak is
+// broken by a calendar day without reviews, but today still has time: the current
+// streak counts through yesterday until today ends.
+func studyStreak(reviews []storage.Review, now time.Time) StudyStreak {
+	counts := reviewCountsByDay(reviews)
+	if len(counts) == 0 {
+		return StudyStreak{}
 	}
-	// Check error on Save
-	if err := s.Storage.Save(); err != nil {
-		return fmt.Errorf("error saving storage after adding due date: %w", err)
+	days := make([]time.Time, 0, len(counts))
+	for day := range counts {
+		days = append(days, day)
 	}
-	return nil
-}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
 
-// ListDueDates retrieves all due date entries.
-func (s *FlashcardService) ListDueDates() ([]storage.DueDate, error) {
-	return s.Storage.ListDueDates()
+	streak := StudyStreak{LongestStreak: 1, LastStudyDate: days[len(days)-1].Format("2006-01-02")}
+	runLength := 1
+	for i := 1; i < len(days); i++ {
+		// Compare calendar dates rather than durations, which DST changes make uneven
+		if days[i].Equal(days[i-1].AddDate(0, 0, 1)) {
+			runLength++
+		} else {
+			runLength = 1
+		}
+		if runLength > streak.LongestStreak {
+			streak.LongestStreak = runLength
+		}
+	}
+	if !days[len(days)-1].Before(localDay(now).AddDate(0, 0, -1)) {
+		streak.CurrentStreak = runLength
+	}
+	return streak
 }
 
-// UpdateDueDate updates an existing due date entry.
-func (s *FlashcardService) UpdateDueDate(dueDate storage.DueDate) error {
-	if dueDate.ID == "" {
-		return errors.New("due date ID is required for update")
+// TagCloud weights each tag for display in a tag cloud. A tag's weight is its card
+// count plus the total days its active cards are overdue, so subjects with a
+// pressing backlog stand out even when they hold few cards.
+func (s *FlashcardService) TagCloud() ([]TagCloudEntry, error) {
+	storageCards, err := s.Storage.ListCards(nil)
+	if err != nil {
+		return nil, fmt.Errorf("error listing cards from storage: %w", err)
 	}
-	if err := s.Storage.UpdateDueDate(dueDate); err != nil {
-		return fmt.Errorf("error updating due date in storage: %w", err)
+
+	now := timeNow()
+	vacations := s.vacationsOrNil()
+	entries := make(map[string]*TagCloudEntry)
+	for _, card := range storageCards {
+		overdueDays := 0.0
+		if !card.Suspended && !card.Archived {
+			effectiveDue := adjustDueForVacations(card.FSRS.Due, now, vacations)
+			if effectiveDue.Before(now) {
+				overdueDays = now.Sub(effectiveDue).Hours() / 24
+			}
+		}
+		for _, tag := range card.Tags {
+			entry, ok := entries[tag]
+			if !ok {
+				entry = &TagCloudEntry{Tag: tag}
+				entries[tag] = entry
+			}
+			entry.Cards++
+			if overdueDays > 0 {
+				entry.OverdueCards++
+				entry.OverdueDays += overdueDays
+			}
+		}
 	}
-	// Check error on Save
-	if err := s.Storage.Save(); err != nil {
-		return fmt.Errorf("error saving storage after updating due date: %w", err)
+
+	result := make([]TagCloudEntry, 0, len(entries))
+	for _, entry := range entries {
+		entry.OverdueDays = math.Round(entry.OverdueDays*10) / 10
+		entry.Weight = float64(entry.Cards) + entry.OverdueDays
+		result = append(result, *entry)
 	}
-	return nil
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Weight != result[j].Weight {
+			return result[i].Weight > result[j].Weight
+		}
+		return result[i].Tag < result[j].Tag
+	})
+	return result, nil
 }
 
-// DeleteDueDate deletes a due date entry by its ID.
-func (s *FlashcardService) DeleteDueDate(id string) error {
-	if id == "" {
-		return errors.New("due date ID is required for delete")
+// IntervalAdherence measures how closely reviews followed the schedule. Each review
+// log records the interval FSRS scheduled after it (ScheduledDays) and the days since
+// the card's previous review (ElapsedDays), so a review's delay is its ElapsedDays
+// minus the previous review's ScheduledDays. A card's first review has no schedule
+// to compare against and is skipped, as are practice reviews and the reviews from
+// before a card was reset.
+func (s *FlashcardService) IntervalAdherence(filterTags []string) (IntervalAdherence, error) {
+	storageCards, err := s.Storage.ListCards(filterTags)
+	if err != nil {
+		return IntervalAdherence{}, fmt.Errorf("error listing cards from storage: %w", err)
 	}
-	if err := s.Storage.DeleteDueDate(id); err != nil {
-		return fmt.Errorf("error deleting due date from storage: %w", err)
+
+	result := IntervalAdherence{}
+	totalDelay, totalAbsDelay := 0, 0
+	for _, card := range storageCards {
+		reviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			return IntervalAdherence{}, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
+		}
+		// Practice reviews have no schedule, and a reset starts the schedule over
+		reviews = scheduledReviews(reviewsSinceReset(card, reviews))
+		sort.Slice(reviews, func(i, j int) bool {
+			return reviews[i].Timestamp.Before(reviews[j].Timestamp)
+		})
+		for i := 1; i < len(reviews); i++ {
+			delay := int(reviews[i].ElapsedDays) - int(reviews[i-1].ScheduledDays)
+			switch {
+			case delay < 0:
+				result.EarlyReviews++
+				totalAbsDelay -= delay
+			case delay > 0:
+				result.LateReviews++
+				totalAbsDelay += delay
+			default:
+				result.OnTimeReviews++
+			}
+			totalDelay += delay
+			result.ReviewsMeasured++
+		}
 	}
-	// Check error on Save
-	if err := s.Storage.Save(); err != nil {
-		return fmt.Errorf("error saving storage after deleting due date: %w", err)
+
+	if result.ReviewsMeasured > 0 {
+		result.AverageDelayDays = float64(totalDelay) / float64(result.ReviewsMeasured)
+		result.AverageAbsoluteDelayDays = float64(totalAbsDelay) / float64(result.ReviewsMeasured)
 	}
-	return nil
+	return result, nil
 }
 
-// GetCardsByTag retrieves all cards that have a specific tag.
-func (s *FlashcardService) GetCardsByTag(tag string) ([]storage.Card, error) {
-	if tag == "" {
-		return nil, errors.New("tag cannot be empty")
-	}
-	// Use the ListCards method from storage, passing the single tag in a slice
-	matchingCards, err := s.Storage.ListCards([]string{tag})
+// DefaultRegressionIntervalDays is the gap since the previous review above which an
+// Again rating counts as a regression.
+const DefaultRegressionIntervalDays = 21
+
+// RegressionAlerts finds cards whose latest review was Again right after a Good or
+// Easy review at least minIntervalDays earlier: material the student had held for
+// a long time and suddenly lost, which is worth reintroducing deliberately.
+func (s *FlashcardService) RegressionAlerts(minIntervalDays float64, filterTags []string) ([]RegressionAlert, error) {
+	storageCards, err := s.Storage.ListCards(filterTags)
 	if err != nil {
-		return nil, fmt.Errorf("error getting cards by tag '%s': %w", tag, err)
+		return nil, fmt.Errorf("error listing cards from storage: %w", err)
 	}
-	return matchingCards, nil
-}
 
-// DueDateProgressStats holds statistics for a specific due date.
-type DueDateProgressStats struct {
-	TotalCards      int     `json:"total_cards"`
-	MasteredCards   int     `json:"mastered_cards"`
-	ProgressPercent float64 `json:"progress_percent"`
+	alerts := []RegressionAlert{}
+	for _, card := range storageCards {
+		if card.Archived {
+			continue
+		}
+		reviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			return nil, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
+		}
+		reviews = scheduledReviews(reviews)
+		if len(reviews) < 2 {
+			continue
+		}
+		sort.Slice(reviews, func(i, j int) bool {
+			return reviews[i].Timestamp.Before(reviews[j].Timestamp)
+		})
+		lapse, previous := reviews[len(reviews)-1], reviews[len(reviews)-2]
+		if lapse.Rating != gofsrs.Again || (previous.Rating != gofsrs.Good && previous.Rating != gofsrs.Easy) {
+			continue
+		}
+		intervalDays := lapse.Timestamp.Sub(previous.Timestamp).Hours() / 24
+		if intervalDays < minIntervalDays {
+			continue
+		}
+		alerts = append(alerts, RegressionAlert{
+			CardID:         card.ID,
+			Front:          card.Front,
+			Tags:           card.Tags,
+			PreviousRating: previous.Rating,
+			PreviousReview: previous.Timestamp,
+			LapsedAt:       lapse.Timestamp,
+			IntervalDays:   math.Round(intervalDays*10) / 10,
+			TotalReviews:   len(reviews),
+		})
+	}
+
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].LapsedAt.After(alerts[j].LapsedAt)
+	})
+	return alerts, nil
 }
 
-// GetDueDateProgressStats calculates progress for cards associated with a due date tag.
-// Mastery is defined as having a last review rating of 4 (Easy).
-func (s *FlashcardService) GetDueDateProgressStats(tag string) (DueDateProgressStats, error) {
-	stats := DueDateProgressStats{}
+// localDay truncates t to midnight of its calendar day in local time.
+func localDay(t time.Time) time.Time {
+	local := t.Local()
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.Local)
+}
 
-	// fmt.Printf("GetDueDateProgressStats called for tag: %s\n", tag)
+// calendarDaysBetween counts the local calendar days from from's day to to's day.
+// The difference is taken between UTC dates so a 23- or 25-hour day across a DST
+// change still counts as one.
+func calendarDaysBetween(from, to time.Time) int {
+	from, to = from.Local(), to.Local()
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	end := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(end.Sub(start).Hours() / 24)
+}
 
-	cards, err := s.GetCardsByTag(tag) // Uses the corrected GetCardsByTag
+// StateHistory replays every card's reviews and reports, for each day from the
+// first card's creation through today, how many cards ended the day in each FSRS
+// state. A card is counted from the day it was created (or first reviewed).
+func (s *FlashcardService) StateHistory() ([]StateHistoryDay, error) {
+	storageCards, err := s.Storage.ListCards(nil)
 	if err != nil {
-		return stats, fmt.Errorf("error getting cards for tag '%s': %w", tag, err)
+		return nil, fmt.Errorf("error listing cards from storage: %w", err)
 	}
 
-	stats.TotalCards = len(cards)
-	// fmt.Printf("Found %d cards with tag %s\n", stats.TotalCards, tag)
-
-	if stats.TotalCards == 0 {
-		return stats, nil // No cards for this tag, progress is 0
+	type stateChange struct {
+		day   time.Time
+		state gofsrs.State
 	}
-
-	masteredCount := 0
-	for _, card := range cards {
-		// fmt.Printf("Checking card %d: %s\n", i+1, card.ID)
+	changesByCard := make([][]stateChange, 0, len(storageCards))
+	var first time.Time
+	for _, card := range storageCards {
 		reviews, err := s.Storage.GetCardReviews(card.ID)
 		if err != nil {
-			// Log or handle error? For now, skip card if reviews can't be fetched.
-			// fmt.Printf("Warning: could not get reviews for card %s: %v\n", card.ID, err)
-			continue
+			return nil, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
 		}
-		// fmt.Printf("Card %s has %d reviews\n", card.ID, len(reviews))
+		reviews = scheduledReviews(reviews)
+		sort.Slice(reviews, func(i, j int) bool {
+			return reviews[i].Timestamp.Before(reviews[j].Timestamp)
+		})
+
+		var changes []stateChange
+		if !card.CreatedAt.IsZero() {
+			changes = append(changes, stateChange{day: localDay(card.CreatedAt), state: gofsrs.New})
+		}
+		replayed := gofsrs.Card{State: gofsrs.New}
 		if len(reviews) > 0 {
-			// Sort reviews by timestamp descending to get the latest
-			sort.Slice(reviews, func(i, j int) bool {
-				return reviews[i].Timestamp.After(reviews[j].Timestamp)
-			})
-			lastReview := reviews[0]
-			// fmt.Printf("Card %s last review rating: %d\n", card.ID, lastReview.Rating)
-			if lastReview.Rating == gofsrs.Easy { // Check if last rating was Easy (4)
-				masteredCount++
-				// fmt.Printf("Card %s counted as mastered\n", card.ID)
+			replayed.Due = reviews[0].Timestamp
+		}
+		// A reset makes the card New again; the replay starts over from there
+		pendingReset := !card.ResetAt.IsZero()
+		for _, review := range reviews {
+			if pendingReset && !review.Timestamp.Before(card.ResetAt) {
+				changes = append(changes, stateChange{day: localDay(card.ResetAt), state: gofsrs.New})
+				replayed = gofsrs.Card{State: gofsrs.New, Due: card.ResetAt}
+				pendingReset = false
 			}
+			replayed = s.FSRSManager.GetSchedulingInfo(replayed, review.Rating, review.Timestamp)
+			changes = append(changes, stateChange{day: localDay(review.Timestamp), state: replayed.State})
+		}
+		if pendingReset {
+			changes = append(changes, stateChange{day: localDay(card.ResetAt), state: gofsrs.New})
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		// Reviews can't precede creation, but imported data may say otherwise
+		sort.SliceStable(changes, func(i, j int) bool { return changes[i].day.Before(changes[j].day) })
+		if first.IsZero() || changes[0].day.Before(first) {
+			first = changes[0].day
 		}
+		changesByCard = append(changesByCard, changes)
 	}
 
-	stats.MasteredCards = masteredCount
-	stats.ProgressPercent = (float64(masteredCount) / float64(stats.TotalCards)) * 100.0
+	history := make([]StateHistoryDay, 0)
+	if first.IsZero() {
+		return history, nil
+	}
+	today := localDay(timeNow())
+	for day := first; !day.After(today); day = day.AddDate(0, 0, 1) {
+		history = append(history, StateHistoryDay{Date: day.Format("2006-01-02")})
+	}
 
-	// fmt.Printf("GetDueDateProgressStats result: %+v\n", stats)
+	for _, changes := range changesByCard {
+		next := 0
+		counted := false
+		var state gofsrs.State
+		for i := range history {
+			day := first.AddDate(0, 0, i)
+			for next < len(changes) && !changes[next].day.After(day) {
+				state = changes[next].state
+				counted = true
+				next++
+			}
+			if !counted {
+				continue
+			}
+			switch state {
+			case gofsrs.New:
+				history[i].New++
+			case gofsrs.Learning:
+				history[i].Learning++
+			case gofsrs.Review:
+				history[i].Review++
+			case gofsrs.Relearning:
+				history[i].Relearning++
+			}
+		}
+	}
+	return history, nil
+}
 
-	return stats, nil
+// HeatmapDays is how many days, ending today, the review-heatmap resource covers.
+const HeatmapDays = 365
+
+// reviewCountsByDay counts the reviews by the local calendar day they happened on.
+// The study streak, the statistics and the review heatmap all bucket reviews this
+// way so their day counts agree.
+func reviewCountsByDay(reviews []storage.Review) map[time.Time]int {
+	counts := make(map[time.Time]int)
+	for _, review := range reviews {
+		counts[localDay(review.Timestamp)]++
+	}
+	return counts
+}
+
+// ReviewHeatmap reports the number of reviews, practice reviews included, on each
+// of the last HeatmapDays days, oldest first and including days without reviews,
+// for a calendar heatmap.
+func (s *FlashcardService) ReviewHeatmap() (ReviewHeatmap, error) {
+	storageCards, err := s.Storage.ListCards(nil)
+	if err != nil {
+		return ReviewHeatmap{}, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+	var reviews []storage.Review
+	for _, card := range storageCards {
+		cardReviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			return ReviewHeatmap{}, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
+		}
+		reviews = append(reviews, cardReviews...)
+	}
+	counts := reviewCountsByDay(reviews)
+
+	first := localDay(timeNow()).AddDate(0, 0, -(HeatmapDays - 1))
+	heatmap := ReviewHeatmap{Days: make([]ReviewDay, 0, HeatmapDays)}
+	for i := 0; i < HeatmapDays; i++ {
+		day := first.AddDate(0, 0, i)
+		reviews := counts[day]
+		heatmap.Days = append(heatmap.Days, ReviewDay{Date: day.Format("2006-01-02"), Reviews: reviews})
+		heatmap.Total += reviews
+		heatmap.MaxDay = max(heatmap.MaxDay, reviews)
+	}
+	return heatmap, nil
+}
+
+// DefaultSessionIdleMinutes is the gap between reviews that starts a new session
+// when session_analytics is called without an idle threshold.
+const DefaultSessionIdleMinutes = 30.0
+
+// SessionAnalytics groups all reviews into study sessions, starting a new session
+// whenever more than idle passes between consecutive reviews, and averages their
+// duration and size. A session's duration runs from its first to its last review.
+func (s *FlashcardService) SessionAnalytics(idle time.Duration) (SessionAnalytics, error) {
+	if idle <= 0 {
+		return SessionAnalytics{}, errors.New("idle threshold must be positive")
+	}
+	storageCards, err := s.Storage.ListCards(nil)
+	if err != nil {
+		return SessionAnalytics{}, fmt.Errorf("error listing cards from storage: %w", err)
+	}
+	var reviews []storage.Review
+	for _, card := range storageCards {
+		cardReviews, err := s.Storage.GetCardReviews(card.ID)
+		if err != nil {
+			return SessionAnalytics{}, fmt.Errorf("error getting reviews for card %s: %w", card.ID, err)
+		}
+		reviews = append(reviews, cardReviews...)
+	}
+	sort.Slice(reviews, func(i, j int) bool {
+		return reviews[i].Timestamp.Before(reviews[j].Timestamp)
+	})
+
+	result := SessionAnalytics{IdleMinutes: idle.Minutes(), Sessions: make([]StudySession, 0)}
+	var cardsInSession map[string]bool
+	for i, review := range reviews {
+		if i == 0 || review.Timestamp.Sub(reviews[i-1].Timestamp) > idle {
+			result.Sessions = append(result.Sessions, StudySession{Start: review.Timestamp})
+			cardsInSession = make(map[string]bool)
+		}
+		session := &result.Sessions[len(result.Sessions)-1]
+		session.End = review.Timestamp
+		session.Reviews++
+		if !cardsInSession[review.CardID] {
+			cardsInSession[review.CardID] = true
+			session.Cards++
+		}
+	}
+
+	if len(result.Sessions) == 0 {
+		return result, nil
+	}
+	totalMinutes, totalCards, totalReviews := 0.0, 0, 0
+	for i := range result.Sessions {
+		session := &result.Sessions[i]
+		session.DurationMinutes = session.End.Sub(session.Start).Minutes()
+		totalMinutes += session.DurationMinutes
+		totalCards += session.Cards
+		totalReviews += session.Reviews
+	}
+	count := float64(len(result.Sessions))
+	result.SessionCount = len(result.Sessions)
+	result.AverageDurationMinutes = totalMinutes / count
+	result.AverageCardsPerSession = float64(totalCards) / count
+	result.AverageReviewsPerSession = float64(totalReviews) / count
+	return result, nil
 }
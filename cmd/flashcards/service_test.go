@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/mark3labs/mcp-go/mcp"
 	gofsrs "github.com/open-spaced-repetition/go-fsrs"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Function to temporarily mock the time.Now function for testing
@@ -35,16 +37,58 @@ func tempTestFile(t *testing.T) string {
 	return filepath.Join(dir, "flashcards-service-test.json")
 }
 
+// testBackend is the storage backend setupTestService uses: JSON files by default,
+// or the one named by FLASHCARDS_TEST_BACKEND, e.g. FLASHCARDS_TEST_BACKEND=sqlite.
+func testBackend() string {
+	if backend := os.Getenv("FLASHCARDS_TEST_BACKEND"); backend != "" {
+		return backend
+	}
+	return storage.BackendJSON
+}
+
 // Helper function to create a service with a temporary storage file
 func setupTestService(t *testing.T) (*FlashcardService, string) {
+	t.Helper()
+	return setupTestServiceWithBackend(t, testBackend())
+}
+
+// Helper function to create a service on a temporary storage file of the given backend
+func setupTestServiceWithBackend(t *testing.T, backend string) (*FlashcardService, string) {
 	t.Helper()
 	filePath := tempTestFile(t)
-	fileStorage := storage.NewFileStorage(filePath)
-	if err := fileStorage.Load(); err != nil {
-		t.Fatalf("Failed to initialize storage: %v", err)
+	switch backend {
+	case storage.BackendJSON:
+		fileStorage := storage.NewFileStorage(filePath)
+		if err := fileStorage.Load(); err != nil {
+			t.Fatalf("Failed to initialize storage: %v", err)
+		}
+		return NewFlashcardService(fileStorage), filePath
+	case storage.BackendSQLite:
+		filePath = strings.TrimSuffix(filePath, ".json") + ".db"
+		sqliteStorage, err := storage.NewSQLiteStorage(filePath)
+		if err != nil {
+			t.Fatalf("Failed to initialize storage: %v", err)
+		}
+		t.Cleanup(func() { sqliteStorage.Close() })
+		return NewFlashcardService(sqliteStorage), filePath
+	default:
+		t.Fatalf("Unknown test storage backend %q", backend)
+		return nil, ""
 	}
-	service := NewFlashcardService(fileStorage)
-	return service, filePath
+}
+
+// Helper function to open a test storage file again, as a restarted server would
+func reopenTestStorage(t *testing.T, filePath string) storage.Storage {
+	t.Helper()
+	if storage.BackendForPath(filePath) == storage.BackendSQLite {
+		sqliteStorage, err := storage.NewSQLiteStorage(filePath)
+		require.NoError(t, err)
+		t.Cleanup(func() { sqliteStorage.Close() })
+		return sqliteStorage
+	}
+	fileStorage := storage.NewFileStorage(filePath)
+	require.NoError(t, fileStorage.Load())
+	return fileStorage
 }
 
 // TestAddDueDate tests adding a due date to the service
@@ -142,6 +186,37 @@ func TestUpdateDueDate(t *testing.T) {
 	assert.Equal(t, updatedDueDate.Tag, dueDates[0].Tag, "Tag should be updated")
 }
 
+// TestGetDueDate tests looking up a due date by ID, and updating one through manage_due_dates
+func TestGetDueDate(t *testing.T) {
+	service, _ := setupTestService(t)
+	ctx := context.WithValue(context.Background(), "service", service)
+	dueDate := storage.DueDate{ID: "test-id-1", Topic: "Test Topic", DueDate: time.Now().AddDate(0, 0, 7), Tag: "test-topic"}
+	require.NoError(t, service.AddDueDate(dueDate))
+
+	got, err := service.GetDueDate("test-id-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Test Topic", got.Topic)
+
+	_, err = service.GetDueDate("missing")
+	assert.ErrorIs(t, err, storage.ErrDueDateNotFound)
+
+	// The update action changes only the given fields
+	text, result := callHandlerDirectly(t, ctx, handleManageDueDates, map[string]interface{}{
+		"action": "update", "due_date_id": "test-id-1", "topic": "Renamed",
+	})
+	require.False(t, result.IsError, text)
+	got, err = service.GetDueDate("test-id-1")
+	require.NoError(t, err)
+	assert.Equal(t, "Renamed", got.Topic)
+	assert.Equal(t, "test-topic", got.Tag)
+
+	text, result = callHandlerDirectly(t, ctx, handleManageDueDates, map[string]interface{}{
+		"action": "update", "due_date_id": "missing", "topic": "Renamed",
+	})
+	assert.True(t, result.IsError)
+	assert.Contains(t, text, "Due date with ID missing not found")
+}
+
 // TestDeleteDueDate tests deleting a due date
 func TestDeleteDueDate(t *testing.T) {
 	service, filePath := setupTestService(t)
@@ -169,6 +244,79 @@ func TestDeleteDueDate(t *testing.T) {
 	assert.Empty(t, dueDates, "Due date list should be empty after deletion")
 }
 
+// TestDeleteDueDateWithCards tests deleting a due date along with its tagged cards
+func TestDeleteDueDateWithCards(t *testing.T) {
+	service, filePath := setupTestService(t)
+	defer os.Remove(filePath)
+
+	dueDate := storage.DueDate{
+		ID:      "unit-1",
+		Topic:   "Unit 1",
+		DueDate: time.Now().AddDate(0, 0, 7),
+		Tag:     "test-unit1-2023-12-31",
+	}
+	require.NoError(t, service.AddDueDate(dueDate))
+
+	_, err := service.CreateCard("Unit Q1", "A1", []string{dueDate.Tag})
+	require.NoError(t, err)
+	_, err = service.CreateCard("Unit Q2", "A2", []string{dueDate.Tag, "extra"})
+	require.NoError(t, err)
+	other, err := service.CreateCard("Other Q", "A3", []string{"other"})
+	require.NoError(t, err)
+
+	deleted, err := service.DeleteDueDateWithCards(dueDate.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, deleted, "Both tagged cards should be deleted")
+
+	dueDates, err := service.ListDueDates()
+	require.NoError(t, err)
+	assert.Empty(t, dueDates, "Due date should be removed")
+
+	cards, err := service.Storage.ListCards(nil)
+	require.NoError(t, err)
+	require.Len(t, cards, 1, "Only the untagged card should remain")
+	assert.Equal(t, other.ID, cards[0].ID)
+}
+
+// TestMasteryModeRetrievability tests that a well-remembered card counts as mastered in retrievability mode
+func TestMasteryModeRetrievability(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Date(2024, 3, 10, 9, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	tag := "test-history-20240320"
+	strong := createCardDirectly(t, service, "Strong", "A", []string{tag})
+	stored, err := service.Storage.GetCard(strong.ID)
+	require.NoError(t, err)
+	stored.FSRS.State = gofsrs.Review
+	stored.FSRS.Stability = 100
+	stored.FSRS.LastReview = now.AddDate(0, 0, -1)
+	updateCardDirectly(t, service, stored)
+	addReviewDirectly(t, service, strong.ID, gofsrs.Good, now.AddDate(0, 0, -1))
+	createCardDirectly(t, service, "Unreviewed", "A", []string{tag})
+
+	// The last rating was Good, so the default mode doesn't count it
+	stats, err := service.GetDueDateProgressStats(tag)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.MasteredCards)
+
+	_, err = service.UpdateConfig(func(config *storage.Config) { config.MasteryMode = MasteryModeRetrievability })
+	require.NoError(t, err)
+	require.Greater(t, service.FSRSManager.Retrievability(stored.FSRS, now), DefaultMasteryThreshold)
+
+	stats, err = service.GetDueDateProgressStats(tag)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.MasteredCards, "High recall probability counts as mastered")
+	assert.InDelta(t, 50.0, stats.ProgressPercent, 0.001)
+
+	// A stricter threshold than the card's recall probability excludes it again
+	_, err = service.UpdateConfig(func(config *storage.Config) { config.MasteryThreshold = 0.999 })
+	require.NoError(t, err)
+	stats, err = service.GetDueDateProgressStats(tag)
+	require.NoError(t, err)
+	assert.Equal(t, 0, stats.MasteredCards)
+}
+
 // TestGetDueDateProgressStats tests calculating progress for a due date
 func TestGetDueDateProgressStats(t *testing.T) {
 	service, filePath := setupTestService(t)
@@ -717,3 +865,492 @@ func TestSubmitReviewWithElapsedDays(t *testing.T) {
 
 	t.Logf("Third review due date: %v", thirdReview.FSRS.Due)
 }
+
+// TestReassignCardDueDate tests moving a card between due dates
+func TestReassignCardDueDate(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	bio := storage.DueDate{ID: "bio", Topic: "Biology Test", DueDate: time.Now().AddDate(0, 0, 7), Tag: "test-biology"}
+	chem := storage.DueDate{ID: "chem", Topic: "Chemistry Test", DueDate: time.Now().AddDate(0, 0, 14), Tag: "test-chemistry"}
+	assert.NoError(t, service.AddDueDate(bio))
+	assert.NoError(t, service.AddDueDate(chem))
+
+	card, err := service.CreateCard("Atoms?", "Tiny", []string{"science", "test-biology"})
+	assert.NoError(t, err)
+	_, err = service.CreateCard("Cells?", "Units of life", []string{"test-biology"})
+	assert.NoError(t, err)
+
+	moved, err := service.ReassignCardDueDate(card.ID, "bio", "chem")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"science", "test-chemistry"}, moved.Tags)
+
+	stored, err := service.Storage.GetCard(card.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"science", "test-chemistry"}, stored.Tags, "Tag swap should be persisted")
+
+	bioStats, err := service.GetDueDateProgressStats(bio.Tag)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, bioStats.TotalCards, "Biology should lose the moved card")
+	chemStats, err := service.GetDueDateProgressStats(chem.Tag)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, chemStats.TotalCards, "Chemistry should gain the moved card")
+
+	// The card is no longer on the biology due date
+	_, err = service.ReassignCardDueDate(card.ID, "bio", "chem")
+	assert.ErrorContains(t, err, "not assigned")
+
+	_, err = service.ReassignCardDueDate(card.ID, "chem", "missing")
+	assert.ErrorIs(t, err, storage.ErrDueDateNotFound)
+}
+
+// TestAutoTagRecall tests that cards are tagged struggling or solid from their rolling average rating
+func TestAutoTagRecall(t *testing.T) {
+	service, _ := setupTestService(t)
+	service.AutoTagRecall = true
+
+	card := createCardDirectly(t, service, "Tricky", "A", []string{"math"})
+	reviewTime := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	review := func(rating gofsrs.Rating) storage.Card {
+		reviewTime = reviewTime.Add(24 * time.Hour)
+		_, err := service.SubmitReviewWithTime(card.ID, rating, "", reviewTime)
+		require.NoError(t, err)
+		stored, err := service.Storage.GetCard(card.ID)
+		require.NoError(t, err)
+		return stored
+	}
+
+	stored := review(gofsrs.Again)
+	stored = review(gofsrs.Hard)
+	assert.ElementsMatch(t, []string{"math", RecallTagStruggling}, stored.Tags)
+
+	// Keep improving until the rolling average flips the tag
+	for i := 0; i < recallWindow; i++ {
+		stored = review(gofsrs.Good)
+	}
+	assert.ElementsMatch(t, []string{"math", RecallTagSolid}, stored.Tags)
+
+	// With the option off, tags are left alone
+	service.AutoTagRecall = false
+	stored = review(gofsrs.Again)
+	assert.ElementsMatch(t, []string{"math", RecallTagSolid}, stored.Tags)
+}
+
+// TestUpcomingDueDates tests that upcoming due dates are sorted soonest first with countdowns
+func TestUpcomingDueDates(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	now := time.Date(2024, 3, 1, 15, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	require.NoError(t, service.AddDueDate(storage.DueDate{ID: "hist", Topic: "History", DueDate: now.AddDate(0, 0, 10), Tag: "test-history"}))
+	require.NoError(t, service.AddDueDate(storage.DueDate{ID: "past", Topic: "Old quiz", DueDate: now.AddDate(0, 0, -2), Tag: "test-old"}))
+	require.NoError(t, service.AddDueDate(storage.DueDate{ID: "bio", Topic: "Biology", DueDate: now.AddDate(0, 0, 3), Tag: "test-biology"}))
+	require.NoError(t, service.AddDueDate(storage.DueDate{ID: "spell", Topic: "Spelling", DueDate: now.AddDate(0, 0, 1), Tag: "test-spelling"}))
+
+	card := createCardDirectly(t, service, "Cell?", "Unit of life", []string{"test-biology"})
+	createCardDirectly(t, service, "Atom?", "Tiny", []string{"test-biology"})
+	_, err := service.SubmitReview(card.ID, gofsrs.Easy, "")
+	require.NoError(t, err)
+
+	upcoming, err := service.UpcomingDueDates(0)
+	require.NoError(t, err)
+	require.Len(t, upcoming, 3, "Past due dates should be excluded")
+	assert.Equal(t, "spell", upcoming[0].ID)
+	assert.Equal(t, 1, upcoming[0].DaysUntil)
+	assert.Equal(t, "tomorrow", upcoming[0].Countdown)
+	assert.Equal(t, "bio", upcoming[1].ID)
+	assert.Equal(t, 3, upcoming[1].DaysUntil)
+	assert.Equal(t, "in 3 days", upcoming[1].Countdown)
+	assert.InDelta(t, 50.0, upcoming[1].ProgressPercent, 0.001)
+	assert.Equal(t, "hist", upcoming[2].ID)
+	assert.Equal(t, 10, upcoming[2].DaysUntil)
+
+	upcoming, err = service.UpcomingDueDates(2)
+	require.NoError(t, err)
+	assert.Len(t, upcoming, 2)
+}
+
+// TestConfigPersists tests that config changes are saved with the store and survive a reload
+func TestConfigPersists(t *testing.T) {
+	service, filePath := setupTestService(t)
+
+	config, err := service.UpdateConfig(func(config *storage.Config) {
+		config.MaxReviewsPerDay = 25
+		config.AutoTagRecall = true
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 25, config.MaxReviewsPerDay)
+	assert.Equal(t, 25, service.MaxReviewsPerDay, "UpdateConfig should apply the change immediately")
+	assert.True(t, service.AutoTagRecall)
+
+	// Reload the store from disk as a restart would
+	reloaded := reopenTestStorage(t, filePath)
+	persisted, err := reloaded.GetConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 25, persisted.MaxReviewsPerDay)
+	assert.True(t, persisted.AutoTagRecall)
+	assert.Nil(t, persisted.MaxOverdueFactor, "Unset values should keep their defaults")
+
+	restarted := NewFlashcardService(reloaded)
+	restarted.ApplyConfig(persisted)
+	assert.Equal(t, 25, restarted.MaxReviewsPerDay)
+	assert.True(t, restarted.AutoTagRecall)
+}
+
+// TestConfigOverridesSurviveConfigure tests that command-line overrides, such as
+// -max-reviews-per-day and -request-retention, still apply after configure changes
+// another setting, and are never written to the store
+func TestConfigOverridesSurviveConfigure(t *testing.T) {
+	service, _ := setupTestService(t)
+	service.ConfigOverrides = func(config *storage.Config) {
+		config.MaxReviewsPerDay = 7
+		params := storage.FSRSParams{}
+		if config.FSRS != nil {
+			params = *config.FSRS
+		}
+		params.RequestRetention = 0.8
+		config.FSRS = &params
+	}
+	service.ApplyConfig(storage.Config{})
+	require.Equal(t, 7, service.MaxReviewsPerDay)
+	ctx := context.WithValue(context.Background(), "service", service)
+
+	text, result := callHandlerDirectly(t, ctx, handleConfigure, map[string]interface{}{"auto_tag_recall": true})
+	require.False(t, result.IsError, text)
+	assert.True(t, service.AutoTagRecall)
+	assert.Equal(t, 7, service.MaxReviewsPerDay, "The override survives configure")
+
+	text, result = callHandlerDirectly(t, ctx, handleSetFSRSConfig, map[string]interface{}{"maximum_interval": 180.0})
+	require.False(t, result.IsError, text)
+	fsrsConfig := service.GetFSRSConfig()
+	assert.Equal(t, 0.8, fsrsConfig.RequestRetention, "The override survives set_fsrs_config")
+	assert.Equal(t, 180.0, fsrsConfig.MaximumInterval)
+	require.NoError(t, service.SetNewCardsPerDay(5))
+	assert.Equal(t, 7, service.MaxReviewsPerDay)
+
+	persisted, err := service.Storage.GetConfig()
+	require.NoError(t, err)
+	assert.Equal(t, 0, persisted.MaxReviewsPerDay, "Overrides aren't persisted")
+	require.NotNil(t, persisted.FSRS)
+	assert.Zero(t, persisted.FSRS.RequestRetention)
+	assert.True(t, persisted.AutoTagRecall)
+}
+
+// TestMaxTagsPerCard tests that the tag limit is off by default and rejects oversized tag lists once set
+func TestMaxTagsPerCard(t *testing.T) {
+	service, _ := setupTestService(t)
+	manyTags := []string{"a", "b", "c", "d"}
+
+	unlimited, err := service.CreateCard("Q1", "A", manyTags)
+	require.NoError(t, err, "No limit should apply by default")
+	assert.Len(t, unlimited.Tags, 4)
+
+	ctx := context.WithValue(context.Background(), "service", service)
+	text, result := callHandlerDirectly(t, ctx, handleConfigure, map[string]interface{}{
+		"max_tags_per_card": float64(3),
+	})
+	require.False(t, result.IsError, text)
+
+	_, err = service.CreateCard("Q2", "A", manyTags)
+	assert.ErrorIs(t, err, ErrTooManyTags)
+	_, err = service.UpdateCard(unlimited.ID, nil, nil, &manyTags, nil)
+	assert.ErrorIs(t, err, ErrTooManyTags)
+
+	fewer := []string{"a", "b", "c"}
+	card, err := service.CreateCard("Q3", "A", fewer)
+	require.NoError(t, err)
+	assert.Equal(t, fewer, card.Tags)
+	// Edits that leave tags alone still work on cards created before the limit
+	front := "Q1 edited"
+	_, err = service.UpdateCard(unlimited.ID, &front, nil, nil, nil)
+	assert.NoError(t, err)
+
+	// create_card goes through the same check
+	text, result = callHandlerDirectly(t, ctx, handleCreateCard, map[string]interface{}{
+		"front": "Q4",
+		"back":  "A",
+		"tags":  []interface{}{"a", "b", "c", "d"},
+	})
+	assert.Contains(t, text, "too many tags")
+
+	// So do the bulk paths that create cards or add tags, before changing anything
+	_, err = service.ApplyTagsToCards([]string{card.ID}, []string{"extra"})
+	assert.ErrorIs(t, err, ErrTooManyTags)
+	require.NoError(t, service.AddDueDate(storage.DueDate{ID: "unit", Topic: "Unit", DueDate: time.Now().AddDate(0, 0, 7), Tag: "test-unit"}))
+	_, err = service.AssignToDueDate("unit", "Q3", nil)
+	assert.ErrorIs(t, err, ErrTooManyTags)
+	_, err = service.ImportCards([]ImportCard{{Front: "Q5", Back: "A"}, {Front: "Q6", Back: "A", Tags: manyTags}}, nil)
+	assert.ErrorIs(t, err, ErrTooManyTags)
+	_, err = service.GenerateReverseCards([]string{unlimited.ID}, nil)
+	assert.ErrorIs(t, err, ErrTooManyTags)
+	reviewed, err := service.Storage.GetCard(card.ID)
+	require.NoError(t, err)
+	reviewed.FSRS.State = gofsrs.Review
+	reviewed.FSRS.Difficulty = 8.5
+	updateCardDirectly(t, service, reviewed)
+	_, err = service.AutotagByDifficulty(nil)
+	assert.ErrorIs(t, err, ErrTooManyTags)
+
+	cards, err := service.Storage.ListCards(nil)
+	require.NoError(t, err)
+	assert.Len(t, cards, 2, "No card was created over the limit")
+	stored, err := service.Storage.GetCard(card.ID)
+	require.NoError(t, err)
+	assert.Equal(t, fewer, stored.Tags, "No tag was added over the limit")
+}
+
+// TestRareTags tests that only tags used by few cards are reported
+func TestRareTags(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	unique := createCardDirectly(t, service, "Q1", "A", []string{"math", "fractions-typo"})
+	createCardDirectly(t, service, "Q2", "A", []string{"math", "geometry"})
+	createCardDirectly(t, service, "Q3", "A", []string{"math", "geometry"})
+
+	tags, err := service.RareTags(1)
+	require.NoError(t, err)
+	require.Len(t, tags, 1)
+	assert.Equal(t, "fractions-typo", tags[0].Tag)
+	assert.Equal(t, []string{unique.ID}, tags[0].CardIDs)
+
+	tags, err = service.RareTags(2)
+	require.NoError(t, err)
+	require.Len(t, tags, 2)
+	assert.Equal(t, "fractions-typo", tags[0].Tag)
+	assert.Equal(t, "geometry", tags[1].Tag)
+}
+
+// TestSuggestTagMerges tests that a misspelled tag is suggested for merging into the common spelling
+func TestSuggestTagMerges(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	createCardDirectly(t, service, "Cell", "A", []string{"biology"})
+	createCardDirectly(t, service, "DNA", "A", []string{"biology", "genetics"})
+	typo := createCardDirectly(t, service, "Mitosis", "A", []string{"biolgy", "biology"})
+	createCardDirectly(t, service, "Unit 3", "A", []string{"unit-3"})
+	createCardDirectly(t, service, "Unit 4", "A", []string{"unit-4"})
+	createCardDirectly(t, service, "Cat", "A", []string{"cat"})
+	createCardDirectly(t, service, "Bat", "A", []string{"bat"})
+
+	response, err := service.SuggestTagMerges(DefaultTagMergeDistance, false)
+	require.NoError(t, err)
+	assert.Equal(t, []TagMergeSuggestion{
+		{From: "biolgy", Into: "biology", Distance: 1, FromCards: 1, IntoCards: 3},
+	}, response.Suggestions)
+
+	// Suggestions alone change nothing
+	stored, err := service.Storage.GetCard(typo.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"biolgy", "biology"}, stored.Tags)
+
+	response, err = service.SuggestTagMerges(DefaultTagMergeDistance, true)
+	require.NoError(t, err)
+	assert.True(t, response.Applied)
+	assert.Equal(t, 1, response.CardsUpdated)
+	stored, err = service.Storage.GetCard(typo.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"biology"}, stored.Tags, "Merged tags should not be duplicated")
+}
+
+// TestProfileInfoPersists tests that profile metadata is stored in the config and survives a reload
+func TestProfileInfoPersists(t *testing.T) {
+	service, filePath := setupTestService(t)
+
+	profile, err := service.GetProfileInfo()
+	require.NoError(t, err)
+	assert.Empty(t, profile.Name)
+
+	name, grade := "Alex", "7th grade"
+	_, err = service.SetProfileInfo(&name, &grade)
+	require.NoError(t, err)
+
+	// Updating one field leaves the other alone
+	newName := "Alex R."
+	profile, err = service.SetProfileInfo(&newName, nil)
+	require.NoError(t, err)
+	assert.Equal(t, storage.Profile{Name: "Alex R.", GradeLevel: "7th grade"}, profile)
+
+	reloaded := reopenTestStorage(t, filePath)
+	profile, err = NewFlashcardService(reloaded).GetProfileInfo()
+	require.NoError(t, err)
+	assert.Equal(t, storage.Profile{Name: "Alex R.", GradeLevel: "7th grade"}, profile)
+}
+
+// TestAssignToDueDate tests that every matching card gets the due date's tag and shows up in its progress
+func TestAssignToDueDate(t *testing.T) {
+	service, _ := setupTestService(t)
+	quiz := storage.DueDate{ID: "ch3", Topic: "Chapter 3 Quiz", DueDate: time.Now().AddDate(0, 0, 5), Tag: "test-chapter-3"}
+	require.NoError(t, service.AddDueDate(quiz))
+
+	var chapter3 []string
+	for _, front := range []string{"Chapter 3: What is a cell?", "chapter 3: Name an organelle", "Define osmosis (CHAPTER 3)"} {
+		card := createCardDirectly(t, service, front, "A", []string{"biology"})
+		chapter3 = append(chapter3, card.ID)
+	}
+	createCardDirectly(t, service, "Chapter 4: What is DNA?", "A", []string{"biology"})
+	createCardDirectly(t, service, "Chapter 3 in history", "A", []string{"history"})
+
+	ctx := context.WithValue(context.Background(), "service", service)
+	text, result := callHandlerDirectly(t, ctx, handleAssignToDueDate, map[string]interface{}{
+		"due_date_id": "ch3",
+		"query":       "chapter 3",
+		"tags":        []interface{}{"biology"},
+	})
+	require.False(t, result.IsError, text)
+	var response AssignToDueDateResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &response), text)
+	assert.Equal(t, 3, response.Assigned)
+	sort.Strings(chapter3)
+	assert.Equal(t, chapter3, response.CardIDs)
+	assert.Equal(t, 3, response.Progress.TotalCards)
+
+	stats, err := service.GetDueDateProgressStats(quiz.Tag)
+	require.NoError(t, err)
+	assert.Equal(t, 3, stats.TotalCards)
+
+	// Running it again matches the same cards but assigns nothing new
+	again, err := service.AssignToDueDate("ch3", "chapter 3", []string{"biology"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, again.Matched)
+	assert.Equal(t, 0, again.Assigned)
+
+	_, err = service.AssignToDueDate("ch3", " ", nil)
+	assert.Error(t, err, "An empty selection must not assign the whole collection")
+	_, err = service.AssignToDueDate("missing", "chapter 3", nil)
+	assert.ErrorIs(t, err, storage.ErrDueDateNotFound)
+}
+
+// TestDueDateCoverage tests that unit cards without the due date's tag are listed
+func TestDueDateCoverage(t *testing.T) {
+	service, _ := setupTestService(t)
+	require.NoError(t, service.AddDueDate(storage.DueDate{
+		ID:      "quiz",
+		Topic:   "Cells quiz",
+		DueDate: time.Now().AddDate(0, 0, 7),
+		Tag:     "test-cells-quiz",
+	}))
+
+	createCardDirectly(t, service, "Nucleus", "A", []string{"unit-cells", "test-cells-quiz"})
+	createCardDirectly(t, service, "Ribosome", "A", []string{"unit-cells", "test-cells-quiz"})
+	missing := createCardDirectly(t, service, "Golgi", "A", []string{"unit-cells"})
+	createCardDirectly(t, service, "Photosynthesis", "A", []string{"unit-plants"})
+
+	coverage, err := service.DueDateCoverage("quiz", "unit-cells")
+	require.NoError(t, err)
+	assert.Equal(t, 3, coverage.ReferenceCards)
+	assert.Equal(t, 2, coverage.Covered)
+	assert.InDelta(t, 2.0/3.0, coverage.CoverageFraction, 1e-9)
+	require.Len(t, coverage.Missing, 1)
+	assert.Equal(t, missing.ID, coverage.Missing[0].ID)
+
+	_, err = service.DueDateCoverage("unknown", "unit-cells")
+	assert.Error(t, err)
+}
+
+// TestDueDateReadiness tests that the readiness score rises as more cards are mastered
+func TestDueDateReadiness(t *testing.T) {
+	service, _ := setupTestService(t)
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	defer mockTimeNow(now)()
+
+	require.NoError(t, service.AddDueDate(storage.DueDate{ID: "bio", Topic: "Biology", DueDate: now.AddDate(0, 0, 8), Tag: "test-biology"}))
+	var cards []storage.Card
+	for i := 0; i < 4; i++ {
+		cards = append(cards, createCardDirectly(t, service, fmt.Sprintf("Bio %d", i), "A", []string{"test-biology"}))
+	}
+
+	before, err := service.DueDateReadiness("bio")
+	require.NoError(t, err)
+	assert.Equal(t, 4, before.Breakdown.CardsLeft)
+	assert.Zero(t, before.Breakdown.MasteredFraction)
+
+	previous := before.Score
+	for i, card := range cards {
+		_, err := service.SubmitReviewWithTime(card.ID, gofsrs.Easy, "", now.Add(-time.Hour))
+		require.NoError(t, err)
+
+		readiness, err := service.DueDateReadiness("bio")
+		require.NoError(t, err)
+		assert.InDelta(t, float64(i+1)/4, readiness.Breakdown.MasteredFraction, 0.001)
+		assert.Greater(t, readiness.Score, previous, "Score should rise with each mastered card")
+		assert.LessOrEqual(t, readiness.Score, 100.0)
+		previous = readiness.Score
+	}
+	assert.InDelta(t, 100.0, previous, 0.001, "All cards mastered means fully ready")
+
+	_, err = service.DueDateReadiness("missing")
+	assert.ErrorIs(t, err, storage.ErrDueDateNotFound)
+}
+
+// TestCheckAnswerFoldDiacritics tests that accents are ignored only when the option is enabled
+func TestCheckAnswerFoldDiacritics(t *testing.T) {
+	service, filePath := setupTestService(t)
+	defer os.Remove(filePath)
+
+	card, err := service.CreateCard("Coffee in French", "Café", []string{"french"})
+	require.NoError(t, err)
+
+	check, err := service.CheckAnswer(card.ID, "cafe")
+	require.NoError(t, err)
+	assert.False(t, check.Correct, "Accents should matter by default")
+
+	check, err = service.CheckAnswer(card.ID, "  CAFÉ ")
+	require.NoError(t, err)
+	assert.True(t, check.Correct, "Case and surrounding whitespace should be ignored")
+
+	_, err = service.UpdateConfig(func(config *storage.Config) { config.FoldDiacritics = true })
+	require.NoError(t, err)
+
+	check, err = service.CheckAnswer(card.ID, "cafe")
+	require.NoError(t, err)
+	assert.True(t, check.Correct, "café and cafe should grade as equivalent with fold_diacritics")
+	assert.True(t, check.FoldDiacritics)
+
+	check, err = service.CheckAnswer(card.ID, "cafes")
+	require.NoError(t, err)
+	assert.False(t, check.Correct)
+}
+
+// TestApplyTagsToCards tests that tags are added only to the listed cards, all or nothing
+func TestApplyTagsToCards(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	var picked []string
+	for i := 0; i < 3; i++ {
+		card := createCardDirectly(t, service, fmt.Sprintf("Picked %d", i), "A", []string{"spanish"})
+		picked = append(picked, card.ID)
+	}
+	// One picked card already has one of the tags
+	already, err := service.Storage.GetCard(picked[2])
+	require.NoError(t, err)
+	already.Tags = append(already.Tags, "verbs")
+	updateCardDirectly(t, service, already)
+	other := createCardDirectly(t, service, "Other", "A", []string{"spanish"})
+
+	ctx := context.WithValue(context.Background(), "service", service)
+	text, result := callHandlerDirectly(t, ctx, handleApplyTagsToCards, map[string]interface{}{
+		"card_ids": []interface{}{picked[0], picked[1], picked[2]},
+		"add_tags": []interface{}{"verbs", "irregular"},
+	})
+	require.False(t, result.IsError, text)
+	var response ApplyTagsResponse
+	require.NoError(t, json.Unmarshal([]byte(text), &response), text)
+	assert.Equal(t, 3, response.Updated)
+	assert.Equal(t, picked, response.CardIDs)
+
+	for _, id := range picked {
+		stored, err := service.Storage.GetCard(id)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"spanish", "verbs", "irregular"}, stored.Tags)
+	}
+	stored, err := service.Storage.GetCard(other.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"spanish"}, stored.Tags)
+
+	// An unknown ID leaves every card untouched
+	_, err = service.ApplyTagsToCards([]string{other.ID, "missing"}, []string{"verbs"})
+	assert.Error(t, err)
+	stored, err = service.Storage.GetCard(other.ID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"spanish"}, stored.Tags)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/danieldreier/mcp-flashcards/internal/storage"
+	gofsrs "github.com/open-spaced-repetition/go-fsrs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDiffSnapshot tests that a diff against a snapshot reports every change made since
+func TestDiffSnapshot(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	kept := createCardDirectly(t, service, "Unchanged", "A", []string{"math"})
+	edited := createCardDirectly(t, service, "Old front", "A", []string{"math"})
+	removed := createCardDirectly(t, service, "Going away", "A", nil)
+
+	info, err := service.CreateSnapshot("week start")
+	require.NoError(t, err)
+	assert.Equal(t, 3, info.CardCount)
+	assert.Equal(t, "week start", info.Label)
+
+	// Make changes after the snapshot
+	added := createCardDirectly(t, service, "Brand new", "B", nil)
+	newFront := "New front"
+	newTags := []string{"math", "algebra"}
+	_, err = service.UpdateCard(edited.ID, &newFront, nil, &newTags, nil)
+	require.NoError(t, err)
+	require.NoError(t, service.DeleteCard(removed.ID))
+	_, err = service.SubmitReview(kept.ID, gofsrs.Good, "A")
+	require.NoError(t, err)
+
+	diff, err := service.DiffSnapshot(info.ID)
+	require.NoError(t, err)
+	require.Len(t, diff.AddedCards, 1)
+	assert.Equal(t, added.ID, diff.AddedCards[0].ID)
+	require.Len(t, diff.RemovedCards, 1)
+	assert.Equal(t, removed.ID, diff.RemovedCards[0].ID)
+	require.Len(t, diff.ModifiedCards, 1)
+	assert.Equal(t, edited.ID, diff.ModifiedCards[0].Card.ID)
+	assert.Equal(t, []string{"front", "tags"}, diff.ModifiedCards[0].ChangedFields)
+	require.Len(t, diff.NewReviews, 1)
+	assert.Equal(t, kept.ID, diff.NewReviews[0].CardID)
+
+	// An empty ID compares against the most recent snapshot
+	latest, err := service.CreateSnapshot("")
+	require.NoError(t, err)
+	diff, err = service.DiffSnapshot("")
+	require.NoError(t, err)
+	assert.Equal(t, latest.ID, diff.Snapshot.ID)
+	assert.Empty(t, diff.AddedCards)
+	assert.Empty(t, diff.RemovedCards)
+	assert.Empty(t, diff.ModifiedCards)
+	assert.Empty(t, diff.NewReviews)
+
+	_, err = service.DiffSnapshot("missing")
+	assert.ErrorIs(t, err, storage.ErrSnapshotNotFound)
+
+	// Deleted snapshots are gone from the store
+	ctx := context.WithValue(context.Background(), "service", service)
+	text, result := callHandlerDirectly(t, ctx, handleDeleteSnapshot, map[string]interface{}{"snapshot_id": info.ID})
+	require.False(t, result.IsError, text)
+	snapshots, err := service.ListSnapshots()
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.Equal(t, latest.ID, snapshots[0].ID)
+	text, _ = callHandlerDirectly(t, ctx, handleDeleteSnapshot, map[string]interface{}{"snapshot_id": info.ID})
+	assert.Contains(t, text, "snapshot not found")
+	_, result = callHandlerDirectly(t, ctx, handleDeleteSnapshot, map[string]interface{}{})
+	assert.True(t, result.IsError)
+}
+
+// TestRestoreBackup tests that restore_backup lists the automatic backups and restores one, config included
+func TestRestoreBackup(t *testing.T) {
+	service, _ := setupTestServiceWithBackend(t, storage.BackendJSON) // Backups are kept for JSON files only
+	service.Storage.(*storage.FileStorage).SetBackups(storage.DefaultBackups)
+	ctx := context.WithValue(context.Background(), "service", service)
+	call := func(args map[string]interface{}) RestoreBackupResponse {
+		t.Helper()
+		text, result := callHandlerDirectly(t, ctx, handleRestoreBackup, args)
+		require.False(t, result.IsError, text)
+		var response RestoreBackupResponse
+		require.NoError(t, json.Unmarshal([]byte(text), &response), text)
+		return response
+	}
+	assert.Empty(t, call(nil).Backups)
+
+	card := createCardDirectly(t, service, "Deleted by mistake", "A", nil)
+	_, err := service.UpdateConfig(func(config *storage.Config) { config.MaxReviewsPerDay = 10 })
+	require.NoError(t, err)
+	require.NoError(t, service.DeleteCard(card.ID))
+	_, err = service.UpdateConfig(func(config *storage.Config) { config.MaxReviewsPerDay = 20 })
+	require.NoError(t, err)
+
+	listed := call(map[string]interface{}{})
+	require.Len(t, listed.Backups, 1)
+	assert.Empty(t, listed.Restored)
+
+	restored := call(map[string]interface{}{"backup": listed.Backups[0].Name})
+	assert.Equal(t, listed.Backups[0].Name, restored.Restored)
+	assert.Len(t, restored.Backups, 2, "The replaced data is backed up too")
+	_, err = service.Storage.GetCard(card.ID)
+	assert.NoError(t, err, "The deleted card is back")
+	assert.Equal(t, 10, service.MaxReviewsPerDay, "The backup's config is applied")
+
+	_, result := callHandlerDirectly(t, ctx, handleRestoreBackup, map[string]interface{}{"backup": "missing"})
+	assert.True(t, result.IsError)
+}
+
+// TestDeleteDueDateWithCardsBackup tests that deleting a due date with more cards than
+// the backup retention leaves a single backup that restores the pre-delete state
+func TestDeleteDueDateWithCardsBackup(t *testing.T) {
+	service, _ := setupTestServiceWithBackend(t, storage.BackendJSON) // Backups are kept for JSON files only
+	service.Storage.(*storage.FileStorage).SetBackups(storage.DefaultBackups)
+
+	dueDate := storage.DueDate{ID: "unit-1", Topic: "Unit 1", DueDate: time.Now().AddDate(0, 0, 7), Tag: "test-unit1"}
+	require.NoError(t, service.AddDueDate(dueDate))
+	var ids []string
+	for i := 0; i < storage.DefaultBackups+3; i++ {
+		ids = append(ids, createCardDirectly(t, service, fmt.Sprintf("Q%d", i), "A", []string{dueDate.Tag}).ID)
+	}
+
+	deleted, err := service.DeleteDueDateWithCards(dueDate.ID)
+	require.NoError(t, err)
+	require.Equal(t, len(ids), deleted)
+
+	backups, err := service.ListBackups()
+	require.NoError(t, err)
+	require.Len(t, backups, 1, "The whole operation takes one backup")
+	require.NoError(t, service.RestoreBackup(backups[0].Name))
+	for _, id := range ids {
+		_, err := service.Storage.GetCard(id)
+		assert.NoError(t, err, "Card %s is restored", id)
+	}
+	_, err = service.GetDueDate(dueDate.ID)
+	assert.NoError(t, err, "The due date is restored")
+}
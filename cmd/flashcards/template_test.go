@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/danieldreier/mcp-flashcards/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCreateCardFromTemplate tests rendering a template's placeholders into a new card
+func TestCreateCardFromTemplate(t *testing.T) {
+	service, _ := setupTestService(t)
+
+	template := storage.CardTemplate{
+		Name:  "science-qa",
+		Front: "Q: {{question}}",
+		Back:  "{{answer}}\nSource: {{ source }}",
+		Tags:  []string{"science"},
+	}
+	require.NoError(t, service.SaveTemplate(template), "SaveTemplate should not return an error")
+
+	values := map[string]string{
+		"question": "What gas do plants absorb?",
+		"answer":   "Carbon dioxide",
+		"source":   "Textbook ch. 4",
+	}
+	card, err := service.CreateCardFromTemplate("science-qa", values, []string{"biology", "science"})
+	require.NoError(t, err, "CreateCardFromTemplate should not return an error")
+
+	assert.Equal(t, "Q: What gas do plants absorb?", card.Front)
+	assert.Equal(t, "Carbon dioxide\nSource: Textbook ch. 4", card.Back)
+	assert.Equal(t, []string{"science", "biology"}, card.Tags, "Template tags come first and duplicates are dropped")
+
+	// The card should be persisted with the rendered text
+	stored, err := service.Storage.GetCard(card.ID)
+	require.NoError(t, err)
+	assert.Equal(t, card.Front, stored.Front)
+	assert.Equal(t, card.Back, stored.Back)
+
+	// Missing placeholder values are reported rather than rendered literally
+	_, err = service.CreateCardFromTemplate("science-qa", map[string]string{"question": "q"}, nil)
+	assert.ErrorContains(t, err, "answer")
+
+	// Unknown templates are reported as not found
+	_, err = service.CreateCardFromTemplate("no-such-template", values, nil)
+	assert.ErrorIs(t, err, storage.ErrTemplateNotFound)
+}
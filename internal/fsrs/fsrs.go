@@ -1,6 +1,9 @@
 package fsrs
 
 import (
+	"errors"
+	"fmt"
+	"math"
 	"time"
 
 	"github.com/open-spaced-repetition/go-fsrs"
@@ -25,24 +28,65 @@ type FSRSManager interface {
 
 	// GetReviewPriority calculates a priority score for a card (for sorting)
 	GetReviewPriority(state fsrs.State, due time.Time, now time.Time) float64
+
+	// Retrievability estimates the probability (0-1) that the card is recalled at now,
+	// using the FSRS forgetting curve. Cards that were never reviewed return 0.
+	Retrievability(card fsrs.Card, now time.Time) float64
+
+	// Parameters returns the FSRS parameters used for scheduling
+	Parameters() fsrs.Parameters
+}
+
+// DefaultMaxOverdueFactor is the default cap on the overdue multiplier used by GetReviewPriority.
+// With the default 0.1-per-day growth, a card reaches the cap after 30 days overdue.
+const DefaultMaxOverdueFactor = 4.0
+
+// ValidateParameters reports whether FSRS parameters can be used for scheduling: the
+// requested retention must be strictly between 0 and 1, the maximum interval positive
+// and the weights non-negative.
+func ValidateParameters(params fsrs.Parameters) error {
+	if params.RequestRetention <= 0 || params.RequestRetention >= 1 {
+		return fmt.Errorf("request retention %v must be between 0 and 1", params.RequestRetention)
+	}
+	if params.MaximumInterval <= 0 {
+		return errors.New("maximum interval must be positive")
+	}
+	for i, w := range params.W {
+		if w < 0 || math.IsNaN(w) {
+			return fmt.Errorf("weight %d (%v) must not be negative", i, w)
+		}
+	}
+	return nil
 }
 
 // FSRSManagerImpl implements the FSRSManager interface
 type FSRSManagerImpl struct {
-	parameters fsrs.Parameters // Using Parameters from go-fsrs
+	parameters       fsrs.Parameters // Using Parameters from go-fsrs
+	maxOverdueFactor float64         // Cap on the overdue multiplier; values < 1 disable the cap
 }
 
 // NewFSRSManager creates a new FSRS manager with default parameters
 func NewFSRSManager() FSRSManager {
 	return &FSRSManagerImpl{
-		parameters: fsrs.DefaultParam(), // Using DefaultParam() from go-fsrs
+		parameters:       fsrs.DefaultParam(), // Using DefaultParam() from go-fsrs
+		maxOverdueFactor: DefaultMaxOverdueFactor,
 	}
 }
 
 // NewFSRSManagerWithParams creates a new FSRS manager with custom parameters
 func NewFSRSManagerWithParams(params fsrs.Parameters) FSRSManager {
 	return &FSRSManagerImpl{
-		parameters: params,
+		parameters:       params,
+		maxOverdueFactor: DefaultMaxOverdueFactor,
+	}
+}
+
+// NewFSRSManagerWithOptions creates a new FSRS manager with custom parameters and
+// a custom cap on the overdue priority multiplier (values < 1 disable the cap)
+func NewFSRSManagerWithOptions(params fsrs.Parameters, maxOverdueFactor float64) FSRSManager {
+	return &FSRSManagerImpl{
+		parameters:       params,
+		maxOverdueFactor: maxOverdueFactor,
 	}
 }
 
@@ -77,6 +121,8 @@ func (f *FSRSManagerImpl) GetSchedulingInfo(currentCard fsrs.Card, rating fsrs.R
 // 1. Overdue cards have higher priority (multiplier based on how overdue)
 // 2. Cards in learning/relearning states have higher priority than review
 // 3. New cards have lowest priority unless explicitly boosted
+// The overdue multiplier is capped (see DefaultMaxOverdueFactor) so that cards
+// which are extremely overdue don't permanently starve everything else.
 func (f *FSRSManagerImpl) GetReviewPriority(state fsrs.State, due time.Time, now time.Time) float64 {
 	// Base priority by state (higher for learning states)
 	var basePriority float64
@@ -97,9 +143,12 @@ func (f *FSRSManagerImpl) GetReviewPriority(state fsrs.State, due time.Time, now
 	// For cards that are due or overdue
 	if overdueDays >= 0 {
 		// Overdue multiplier: gradually increases priority for overdue cards
-		// We use a square root function to prevent extremely overdue cards from
+		// The multiplier is capped to prevent extremely overdue cards from
 		// completely dominating the queue
 		overdueFactor := 1.0 + (overdueDays * 0.1)
+		if f.maxOverdueFactor >= 1 && overdueFactor > f.maxOverdueFactor {
+			overdueFactor = f.maxOverdueFactor
+		}
 		return basePriority * overdueFactor
 	}
 
@@ -108,3 +157,22 @@ func (f *FSRSManagerImpl) GetReviewPriority(state fsrs.State, due time.Time, now
 	daysToDue := -overdueDays // convert to positive
 	return basePriority / (1.0 + daysToDue)
 }
+
+// Retrievability estimates the probability that a card is recalled at the given time,
+// using the FSRS forgetting curve R = (1 + factor * t / S) ^ decay, where t is the
+// number of days since the last review and S is the card's stability.
+func (f *FSRSManagerImpl) Retrievability(card fsrs.Card, now time.Time) float64 {
+	if card.State == fsrs.New || card.Stability <= 0 {
+		return 0
+	}
+	elapsedDays := now.Sub(card.LastReview).Hours() / 24.0
+	if elapsedDays < 0 {
+		elapsedDays = 0
+	}
+	return math.Pow(1+f.parameters.Factor*elapsedDays/card.Stability, f.parameters.Decay)
+}
+
+// Parameters implements the FSRSManager interface
+func (f *FSRSManagerImpl) Parameters() fsrs.Parameters {
+	return f.parameters
+}
@@ -1,6 +1,7 @@
 package fsrs
 
 import (
+	"math"
 	"testing"
 	"time"
 
@@ -409,3 +410,103 @@ func TestDueDateConsistency(t *testing.T) {
 		multiStepCard = nextCard
 	}
 }
+
+func TestGetReviewPriorityOverdueCap(t *testing.T) {
+	now := time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)
+	moderatelyOverdue := now.Add(-10 * 24 * time.Hour) // factor 2.0, below the cap
+	extremelyOverdue := now.Add(-365 * 24 * time.Hour) // factor 37.5 uncapped
+
+	manager := NewFSRSManager()
+	moderate := manager.GetReviewPriority(fsrs.Review, moderatelyOverdue, now)
+	extreme := manager.GetReviewPriority(fsrs.Review, extremelyOverdue, now)
+
+	if moderate != 2.0*2.0 {
+		t.Errorf("Expected moderately overdue priority %f, got %f", 2.0*2.0, moderate)
+	}
+	if extreme != 2.0*DefaultMaxOverdueFactor {
+		t.Errorf("Expected extremely overdue priority capped at %f, got %f", 2.0*DefaultMaxOverdueFactor, extreme)
+	}
+
+	// A custom cap is honored
+	custom := NewFSRSManagerWithOptions(fsrs.DefaultParam(), 1.5)
+	if got := custom.GetReviewPriority(fsrs.Review, moderatelyOverdue, now); got != 2.0*1.5 {
+		t.Errorf("Expected priority capped at %f, got %f", 2.0*1.5, got)
+	}
+
+	// A cap below 1 disables capping
+	uncapped := NewFSRSManagerWithOptions(fsrs.DefaultParam(), 0)
+	if got := uncapped.GetReviewPriority(fsrs.Review, extremelyOverdue, now); got <= 2.0*DefaultMaxOverdueFactor {
+		t.Errorf("Expected uncapped priority above %f, got %f", 2.0*DefaultMaxOverdueFactor, got)
+	}
+}
+
+func TestRetrievability(t *testing.T) {
+	manager := NewFSRSManager()
+	now := time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	card := fsrs.NewCard()
+	if got := manager.Retrievability(card, now); got != 0 {
+		t.Errorf("Expected 0 retrievability for a new card, got %f", got)
+	}
+
+	card.State = fsrs.Review
+	card.Stability = 10
+	card.LastReview = now
+	if got := manager.Retrievability(card, now); math.Abs(got-1) > 1e-9 {
+		t.Errorf("Expected retrievability 1 right after a review, got %f", got)
+	}
+
+	// By definition, recall probability drops to 90% after `stability` days
+	card.LastReview = now.Add(-10 * 24 * time.Hour)
+	if got := manager.Retrievability(card, now); math.Abs(got-0.9) > 1e-6 {
+		t.Errorf("Expected retrievability 0.9 after stability days, got %f", got)
+	}
+}
+
+func TestRequestRetentionIntervals(t *testing.T) {
+	start := time.Date(2023, 1, 15, 12, 0, 0, 0, time.UTC)
+	ratings := []fsrs.Rating{fsrs.Good, fsrs.Good, fsrs.Good, fsrs.Hard, fsrs.Good}
+
+	// lastInterval reviews a new card on each due date and returns the final interval
+	lastInterval := func(retention float64) time.Duration {
+		params := fsrs.DefaultParam()
+		params.RequestRetention = retention
+		manager := NewFSRSManagerWithParams(params)
+
+		card := fsrs.NewCard()
+		now := start
+		for _, rating := range ratings {
+			card = manager.GetSchedulingInfo(card, rating, now)
+			now = card.Due
+		}
+		return card.Due.Sub(card.LastReview)
+	}
+
+	low, high := lastInterval(0.8), lastInterval(0.95)
+	if high >= low {
+		t.Errorf("Expected a higher target retention to give a shorter interval, got %v at 0.95 and %v at 0.8", high, low)
+	}
+	if def := lastInterval(0.9); def <= high || def >= low {
+		t.Errorf("Expected the default retention's interval %v between %v and %v", def, high, low)
+	}
+}
+
+func TestValidateParameters(t *testing.T) {
+	if err := ValidateParameters(fsrs.DefaultParam()); err != nil {
+		t.Errorf("Expected default parameters to be valid, got %v", err)
+	}
+
+	for name, change := range map[string]func(*fsrs.Parameters){
+		"zero retention":  func(p *fsrs.Parameters) { p.RequestRetention = 0 },
+		"full retention":  func(p *fsrs.Parameters) { p.RequestRetention = 1 },
+		"no interval":     func(p *fsrs.Parameters) { p.MaximumInterval = 0 },
+		"negative weight": func(p *fsrs.Parameters) { p.W[3] = -1 },
+		"NaN weight":      func(p *fsrs.Parameters) { p.W[0] = math.NaN() },
+	} {
+		params := fsrs.DefaultParam()
+		change(&params)
+		if err := ValidateParameters(params); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package fsrs
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/danieldreier/mcp-flashcards/internal/storage"
+	"github.com/open-spaced-repetition/go-fsrs"
+)
+
+// MinOptimizeReviews is the fewest reviews Optimize fits weights to; with less
+// history the fitted weights mostly reflect noise.
+const MinOptimizeReviews = 100
+
+// ErrNotEnoughReviews is returned by Optimize when the review history is too short to fit.
+var ErrNotEnoughReviews = errors.New("not enough reviews to optimize FSRS weights")
+
+// Optimizer settings: Adam over finite-difference gradients of the log-loss
+const (
+	optimizeIterations   = 300
+	optimizeLearningRate = 0.02
+	optimizeGradientStep = 1e-5
+	adamBeta1            = 0.9
+	adamBeta2            = 0.999
+	adamEpsilon          = 1e-8
+	minPrediction        = 1e-6 // Predictions are clamped away from 0 and 1 so the loss stays finite
+	minStability         = 0.01
+)
+
+// weightBounds keep each fitted weight in the range the FSRS reference optimizer allows,
+// which also keeps the model's powers and exponentials well defined.
+var weightBounds = [len(fsrs.Weights{})][2]float64{
+	{0.1, 100}, {0.1, 100}, {0.1, 100}, {0.1, 100}, // Initial stability per rating
+	{1, 10},     // Initial difficulty
+	{0.1, 5},    // Initial difficulty change per rating
+	{0.1, 5},    // Difficulty change per rating
+	{0, 0.5},    // Difficulty mean reversion
+	{0, 3},      // Recall stability: scale (exponent)
+	{0.1, 0.8},  // Recall stability: stability decay
+	{0.01, 2.5}, // Recall stability: retrievability effect
+	{0.5, 5},    // Forget stability: scale
+	{0.01, 0.2}, // Forget stability: difficulty effect
+	{0.01, 0.9}, // Forget stability: stability effect
+	{0.01, 2},   // Forget stability: retrievability effect
+	{0, 1},      // Hard penalty
+	{1, 4},      // Easy bonus
+}
+
+// OptimizeResult is the outcome of fitting FSRS weights to a review history.
+type OptimizeResult struct {
+	Params     fsrs.Parameters // The starting parameters with the fitted weights
+	Reviews    int             // Non-practice reviews replayed
+	Predicted  int             // Reviews of cards in the Review state, whose recall the model predicts
+	OldLogLoss float64         // Mean log-loss of the predictions with the starting weights
+	NewLogLoss float64         // Mean log-loss with the fitted weights; never above OldLogLoss
+}
+
+// Optimize fits the FSRS weights to a review history, starting from params. Each
+// card's reviews are replayed through the same model go-fsrs schedules with, and
+// the weights are adjusted to minimize the log-loss of the predicted recall
+// probability of every review made in the Review state (a lapse is a failed
+// recall). Practice reviews are ignored. Only the weights change; the requested
+// retention and maximum interval are kept.
+func Optimize(reviews []storage.Review, params fsrs.Parameters) (OptimizeResult, error) {
+	histories := reviewHistories(reviews)
+	count := 0
+	for _, history := range histories {
+		count += len(history)
+	}
+	if count < MinOptimizeReviews {
+		return OptimizeResult{}, fmt.Errorf("%w: have %d, need at least %d", ErrNotEnoughReviews, count, MinOptimizeReviews)
+	}
+
+	weights := clampWeights(params.W)
+	oldLoss, predicted := logLoss(histories, params, params.W)
+	if predicted == 0 {
+		return OptimizeResult{}, fmt.Errorf("%w: none of the %d reviews were of cards in the Review state", ErrNotEnoughReviews, count)
+	}
+
+	best, bestLoss := params.W, oldLoss
+	var m, v [len(fsrs.Weights{})]float64
+	for step := 1; step <= optimizeIterations; step++ {
+		loss, _ := logLoss(histories, params, weights)
+		if loss < bestLoss {
+			best, bestLoss = weights, loss
+		}
+		var gradient [len(fsrs.Weights{})]float64
+		for i := range weights {
+			shifted := weights
+			shifted[i] += optimizeGradientStep
+			shiftedLoss, _ := logLoss(histories, params, shifted)
+			gradient[i] = (shiftedLoss - loss) / optimizeGradientStep
+		}
+		for i := range weights {
+			m[i] = adamBeta1*m[i] + (1-adamBeta1)*gradient[i]
+			v[i] = adamBeta2*v[i] + (1-adamBeta2)*gradient[i]*gradient[i]
+			mHat := m[i] / (1 - math.Pow(adamBeta1, float64(step)))
+			vHat := v[i] / (1 - math.Pow(adamBeta2, float64(step)))
+			weights[i] -= optimizeLearningRate * mHat / (math.Sqrt(vHat) + adamEpsilon)
+		}
+		weights = clampWeights(weights)
+	}
+	if loss, _ := logLoss(histories, params, weights); loss < bestLoss {
+		best, bestLoss = weights, loss
+	}
+
+	params.W = best
+	return OptimizeResult{
+		Params:     params,
+		Reviews:    count,
+		Predicted:  predicted,
+		OldLogLoss: oldLoss,
+		NewLogLoss: bestLoss,
+	}, nil
+}
+
+// reviewHistories groups the non-practice reviews by card, oldest first. Reviews
+// with an unknown rating are dropped.
+func reviewHistories(reviews []storage.Review) [][]storage.Review {
+	byCard := make(map[string][]storage.Review)
+	var cardIDs []string
+	for _, review := range reviews {
+		if review.Practice || review.Rating < fsrs.Again || review.Rating > fsrs.Easy {
+			continue
+		}
+		if _, ok := byCard[review.CardID]; !ok {
+			cardIDs = append(cardIDs, review.CardID)
+		}
+		byCard[review.CardID] = append(byCard[review.CardID], review)
+	}
+	sort.Strings(cardIDs) // Keeps the summation order, and so the result, deterministic
+
+	histories := make([][]storage.Review, 0, len(cardIDs))
+	for _, id := range cardIDs {
+		history := byCard[id]
+		sort.SliceStable(history, func(i, j int) bool { return history[i].Timestamp.Before(history[j].Timestamp) })
+		histories = append(histories, history)
+	}
+	return histories
+}
+
+// logLoss replays the histories with the given weights and returns the mean binary
+// log-loss of the recall predictions, and how many reviews were predicted.
+func logLoss(histories [][]storage.Review, params fsrs.Parameters, weights fsrs.Weights) (float64, int) {
+	params.W = weights
+	total := 0.0
+	predicted := 0
+	for _, history := range histories {
+		var state fsrs.State = fsrs.New
+		var stability, difficulty float64
+		var last time.Time
+		for _, review := range history {
+			rating := review.Rating
+			switch state {
+			case fsrs.New:
+				stability = math.Max(weights[rating-1], 0.1)
+				difficulty = initDifficulty(weights, rating)
+			case fsrs.Review:
+				// go-fsrs counts whole elapsed days
+				elapsedDays := float64(review.Timestamp.Sub(last) / time.Hour / 24)
+				retrievability := math.Pow(1+params.Factor*elapsedDays/stability, params.Decay)
+				p := math.Min(math.Max(retrievability, minPrediction), 1-minPrediction)
+				if rating == fsrs.Again {
+					total -= math.Log(1 - p)
+				} else {
+					total -= math.Log(p)
+				}
+				predicted++
+
+				stability = math.Max(nextStability(weights, difficulty, stability, retrievability, rating), minStability)
+				difficulty = nextDifficulty(weights, difficulty, rating)
+			}
+			state = nextState(state, rating)
+			last = review.Timestamp
+		}
+	}
+	if predicted == 0 {
+		return 0, 0
+	}
+	return total / float64(predicted), predicted
+}
+
+// nextState mirrors the state transitions of go-fsrs.
+func nextState(state fsrs.State, rating fsrs.Rating) fsrs.State {
+	switch state {
+	case fsrs.New:
+		if rating == fsrs.Easy {
+			return fsrs.Review
+		}
+		return fsrs.Learning
+	case fsrs.Learning, fsrs.Relearning:
+		if rating >= fsrs.Good {
+			return fsrs.Review
+		}
+		return state
+	default:
+		if rating == fsrs.Again {
+			return fsrs.Relearning
+		}
+		return fsrs.Review
+	}
+}
+
+// initDifficulty, nextDifficulty and nextStability are the FSRS-4.5 memory model as
+// implemented by go-fsrs, which keeps these unexported.
+func initDifficulty(w fsrs.Weights, rating fsrs.Rating) float64 {
+	return constrainDifficulty(w[4] - w[5]*float64(rating-3))
+}
+
+func nextDifficulty(w fsrs.Weights, d float64, rating fsrs.Rating) float64 {
+	next := d - w[6]*float64(rating-3)
+	return constrainDifficulty(w[7]*w[4] + (1-w[7])*next)
+}
+
+func nextStability(w fsrs.Weights, d, s, r float64, rating fsrs.Rating) float64 {
+	if rating == fsrs.Again {
+		return w[11] * math.Pow(d, -w[12]) * (math.Pow(s+1, w[13]) - 1) * math.Exp((1-r)*w[14])
+	}
+	hardPenalty, easyBonus := 1.0, 1.0
+	if rating == fsrs.Hard {
+		hardPenalty = w[15]
+	}
+	if rating == fsrs.Easy {
+		easyBonus = w[16]
+	}
+	return s * (1 + math.Exp(w[8])*(11-d)*math.Pow(s, -w[9])*(math.Exp((1-r)*w[10])-1)*hardPenalty*easyBonus)
+}
+
+func constrainDifficulty(d float64) float64 {
+	return math.Min(math.Max(d, 1), 10)
+}
+
+// clampWeights limits each weight to weightBounds.
+func clampWeights(w fsrs.Weights) fsrs.Weights {
+	for i, bounds := range weightBounds {
+		w[i] = math.Min(math.Max(w[i], bounds[0]), bounds[1])
+	}
+	return w
+}
@@ -0,0 +1,98 @@
+package fsrs
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/danieldreier/mcp-flashcards/internal/storage"
+	"github.com/open-spaced-repetition/go-fsrs"
+)
+
+// simulateReviews reviews cards on their due dates for days, recalling each with
+// the probability predicted by a manager using trueParams.
+func simulateReviews(trueParams fsrs.Parameters, cards, days int, seed int64) []storage.Review {
+	manager := NewFSRSManagerWithParams(trueParams)
+	rng := rand.New(rand.NewSource(seed))
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	end := start.Add(time.Duration(days) * 24 * time.Hour)
+
+	var reviews []storage.Review
+	for c := 0; c < cards; c++ {
+		cardID := fmt.Sprintf("card-%03d", c)
+		card := fsrs.NewCard()
+		now := start.Add(time.Duration(c%10) * 24 * time.Hour)
+		for now.Before(end) {
+			rating := fsrs.Good
+			if card.State == fsrs.Review {
+				if rng.Float64() > manager.Retrievability(card, now) {
+					rating = fsrs.Again
+				} else if rng.Float64() < 0.2 {
+					rating = fsrs.Easy
+				}
+			} else if rng.Float64() < 0.3 {
+				rating = fsrs.Again
+			}
+			reviews = append(reviews, storage.Review{
+				ID:        fmt.Sprintf("%s-%d", cardID, len(reviews)),
+				CardID:    cardID,
+				Rating:    rating,
+				Timestamp: now,
+			})
+			card = manager.GetSchedulingInfo(card, rating, now)
+			now = card.Due
+		}
+	}
+	return reviews
+}
+
+func TestOptimize(t *testing.T) {
+	// Students who forget much faster than the defaults assume
+	trueParams := fsrs.DefaultParam()
+	trueParams.W[0], trueParams.W[1], trueParams.W[2], trueParams.W[3] = 0.3, 0.6, 1.2, 3.0
+	trueParams.W[8] = 0.8
+	reviews := simulateReviews(trueParams, 60, 180, 1)
+
+	result, err := Optimize(reviews, fsrs.DefaultParam())
+	if err != nil {
+		t.Fatalf("Optimize failed: %v", err)
+	}
+	if result.Reviews != len(reviews) {
+		t.Errorf("Expected %d reviews used, got %d", len(reviews), result.Reviews)
+	}
+	if result.Predicted == 0 || result.Predicted >= result.Reviews {
+		t.Errorf("Expected only Review-state reviews to be predicted, got %d of %d", result.Predicted, result.Reviews)
+	}
+	if result.NewLogLoss >= result.OldLogLoss {
+		t.Errorf("Expected the fitted weights to lower the log-loss, got %f -> %f", result.OldLogLoss, result.NewLogLoss)
+	}
+	if result.Params.W[2] >= fsrs.DefaultWeights()[2] {
+		t.Errorf("Expected a lower initial stability for Good than the default %f, got %f", fsrs.DefaultWeights()[2], result.Params.W[2])
+	}
+	if err := ValidateParameters(result.Params); err != nil {
+		t.Errorf("Expected usable parameters, got %v", err)
+	}
+
+	// Replaying with the fitted weights reproduces the reported loss
+	if loss, _ := logLoss(reviewHistories(reviews), result.Params, result.Params.W); loss != result.NewLogLoss {
+		t.Errorf("Expected log-loss %f for the fitted weights, got %f", result.NewLogLoss, loss)
+	}
+}
+
+func TestOptimizeNotEnoughReviews(t *testing.T) {
+	reviews := simulateReviews(fsrs.DefaultParam(), 60, 180, 1)[:MinOptimizeReviews-1]
+	if _, err := Optimize(reviews, fsrs.DefaultParam()); !errors.Is(err, ErrNotEnoughReviews) {
+		t.Errorf("Expected ErrNotEnoughReviews for %d reviews, got %v", len(reviews), err)
+	}
+
+	// Practice reviews don't count
+	for i := range reviews {
+		reviews[i].Practice = true
+	}
+	reviews = append(reviews, simulateReviews(fsrs.DefaultParam(), 60, 180, 1)[:MinOptimizeReviews-1]...)
+	if _, err := Optimize(reviews, fsrs.DefaultParam()); !errors.Is(err, ErrNotEnoughReviews) {
+		t.Errorf("Expected practice reviews to be ignored, got %v", err)
+	}
+}
@@ -0,0 +1,206 @@
+// Package importer reads flashcards exported by other spaced repetition apps.
+package importer
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	_ "modernc.org/sqlite" // Registers the "sqlite" database/sql driver for reading collections
+)
+
+// AnkiNote is a note from an Anki collection, reduced to plain text.
+type AnkiNote struct {
+	Front string
+	Back  string
+	Deck  string // Full deck name of the note's first card, e.g. "Biology::Cells"
+}
+
+// AnkiPackage is what ReadAnkiPackage found in an .apkg file.
+type AnkiPackage struct {
+	Notes       []AnkiNote // Importable notes, in creation order
+	Unsupported int        // Notes of note types that don't map to a front and back (cloze, image occlusion)
+	Blank       int        // Notes whose front or back is empty as plain text, e.g. image-only fields
+}
+
+// ErrUnsupportedAnkiFormat is returned for packages without a collection this
+// importer can read, such as the compressed format newer Anki versions export by default.
+var ErrUnsupportedAnkiFormat = errors.New("unsupported Anki package format (export with \"Support older Anki versions\" enabled)")
+
+// ankiModelStandard is the "type" of a regular note type; cloze note types are 1.
+const ankiModelStandard = 0
+
+// ankiStockKindImageOcclusion is the originalStockKind of Anki's built-in image occlusion note type.
+const ankiStockKindImageOcclusion = 6
+
+// ankiFieldSeparator separates the fields of a note in the notes.flds column.
+const ankiFieldSeparator = "\x1f"
+
+type ankiModel struct {
+	Name              string `json:"name"`
+	Type              int    `json:"type"`
+	OriginalStockKind int    `json:"originalStockKind"`
+}
+
+type ankiDeck struct {
+	Name string `json:"name"`
+}
+
+// supported reports whether notes of this type have a plain front and back.
+func (m ankiModel) supported() bool {
+	if m.Type != ankiModelStandard || m.OriginalStockKind == ankiStockKindImageOcclusion {
+		return false
+	}
+	// Image occlusion add-ons predate the built-in note type and are only recognizable by name
+	return !strings.Contains(strings.ToLower(m.Name), "image occlusion")
+}
+
+// ReadAnkiPackage reads the notes of an Anki .apkg file: a zip archive holding a
+// SQLite collection. Only the first two fields of each note are used, as front and back.
+func ReadAnkiPackage(path string) (AnkiPackage, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return AnkiPackage{}, fmt.Errorf("failed to open Anki package: %w", err)
+	}
+	defer archive.Close()
+
+	// collection.anki21 holds the real data when present; collection.anki2 is then
+	// only a stub telling old clients to upgrade
+	var collection *zip.File
+	for _, name := range []string{"collection.anki21", "collection.anki2"} {
+		for _, file := range archive.File {
+			if collection == nil && file.Name == name {
+				collection = file
+			}
+		}
+	}
+	if collection == nil {
+		return AnkiPackage{}, ErrUnsupportedAnkiFormat
+	}
+
+	dbPath, err := extractToTemp(collection)
+	if err != nil {
+		return AnkiPackage{}, err
+	}
+	defer os.Remove(dbPath)
+
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return AnkiPackage{}, fmt.Errorf("failed to open Anki collection: %w", err)
+	}
+	defer db.Close()
+	return readCollection(db)
+}
+
+// extractToTemp copies a zip entry to a temporary file and returns its path.
+func extractToTemp(file *zip.File) (string, error) {
+	src, err := file.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", file.Name, err)
+	}
+	defer src.Close()
+
+	dst, err := os.CreateTemp("", "anki-collection-*.db")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary collection file: %w", err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(dst.Name())
+		return "", fmt.Errorf("failed to extract %s: %w", file.Name, err)
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(dst.Name())
+		return "", fmt.Errorf("failed to extract %s: %w", file.Name, err)
+	}
+	return dst.Name(), nil
+}
+
+// readCollection reads the note types, decks and notes of an Anki collection database.
+func readCollection(db *sql.DB) (AnkiPackage, error) {
+	var modelsJSON, decksJSON string
+	if err := db.QueryRow("SELECT models, decks FROM col").Scan(&modelsJSON, &decksJSON); err != nil {
+		return AnkiPackage{}, fmt.Errorf("failed to read Anki collection: %w", err)
+	}
+	var models map[string]ankiModel
+	if err := json.Unmarshal([]byte(modelsJSON), &models); err != nil {
+		return AnkiPackage{}, fmt.Errorf("failed to parse Anki note types: %w", err)
+	}
+	var decks map[string]ankiDeck
+	if err := json.Unmarshal([]byte(decksJSON), &decks); err != nil {
+		return AnkiPackage{}, fmt.Errorf("failed to parse Anki decks: %w", err)
+	}
+
+	rows, err := db.Query(`SELECT n.mid, n.flds,
+		(SELECT c.did FROM cards c WHERE c.nid = n.id ORDER BY c.ord LIMIT 1)
+		FROM notes n ORDER BY n.id`)
+	if err != nil {
+		return AnkiPackage{}, fmt.Errorf("failed to read Anki notes: %w", err)
+	}
+	defer rows.Close()
+
+	pkg := AnkiPackage{Notes: []AnkiNote{}}
+	for rows.Next() {
+		var modelID int64
+		var fields string
+		var deckID sql.NullInt64
+		if err := rows.Scan(&modelID, &fields, &deckID); err != nil {
+			return AnkiPackage{}, fmt.Errorf("failed to read Anki note: %w", err)
+		}
+
+		// Notes of unknown types are treated like unsupported ones
+		model, ok := models[fmt.Sprint(modelID)]
+		values := strings.Split(fields, ankiFieldSeparator)
+		if !ok || !model.supported() || len(values) < 2 {
+			pkg.Unsupported++
+			continue
+		}
+		note := AnkiNote{
+			Front: plainText(values[0]),
+			Back:  plainText(values[1]),
+		}
+		if note.Front == "" || note.Back == "" {
+			pkg.Blank++
+			continue
+		}
+		if deckID.Valid {
+			note.Deck = decks[fmt.Sprint(deckID.Int64)].Name
+		}
+		pkg.Notes = append(pkg.Notes, note)
+	}
+	if err := rows.Err(); err != nil {
+		return AnkiPackage{}, fmt.Errorf("failed to read Anki notes: %w", err)
+	}
+	return pkg, nil
+}
+
+var (
+	lineBreakTags = regexp.MustCompile(`(?i)<br\s*/?>|</div>|</p>|</li>`)
+	htmlTags      = regexp.MustCompile(`<[^>]*>`)
+	soundTags     = regexp.MustCompile(`\[sound:[^\]]*\]`)
+)
+
+// plainText turns the HTML of an Anki field into plain text, dropping media references.
+func plainText(field string) string {
+	text := lineBreakTags.ReplaceAllString(field, "\n")
+	text = htmlTags.ReplaceAllString(text, "")
+	text = soundTags.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	text = strings.ReplaceAll(text, "\u00a0", " ") // &nbsp;
+
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
@@ -0,0 +1,139 @@
+package importer
+
+import (
+	"archive/zip"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+// ankiTestNote is a row for the notes table of a test collection
+type ankiTestNote struct {
+	model  int64
+	fields string
+	deck   int64
+}
+
+// writeTestPackage builds a minimal .apkg holding the given notes under collectionName
+func writeTestPackage(t *testing.T, collectionName, models, decks string, notes []ankiTestNote) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	dbPath := filepath.Join(dir, "collection.db")
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		t.Fatalf("Failed to create collection: %v", err)
+	}
+	_, err = db.Exec(`CREATE TABLE col (models TEXT, decks TEXT);
+		CREATE TABLE notes (id INTEGER PRIMARY KEY, mid INTEGER, flds TEXT);
+		CREATE TABLE cards (id INTEGER PRIMARY KEY, nid INTEGER, did INTEGER, ord INTEGER)`)
+	if err != nil {
+		t.Fatalf("Failed to create collection schema: %v", err)
+	}
+	if _, err := db.Exec("INSERT INTO col VALUES (?, ?)", models, decks); err != nil {
+		t.Fatalf("Failed to write collection: %v", err)
+	}
+	for i, note := range notes {
+		if _, err := db.Exec("INSERT INTO notes VALUES (?, ?, ?)", i+1, note.model, note.fields); err != nil {
+			t.Fatalf("Failed to write note: %v", err)
+		}
+		if _, err := db.Exec("INSERT INTO cards (nid, did, ord) VALUES (?, ?, 0)", i+1, note.deck); err != nil {
+			t.Fatalf("Failed to write card: %v", err)
+		}
+	}
+	db.Close()
+	collection, err := os.ReadFile(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to read collection: %v", err)
+	}
+
+	apkgPath := filepath.Join(dir, "deck.apkg")
+	apkg, err := os.Create(apkgPath)
+	if err != nil {
+		t.Fatalf("Failed to create package: %v", err)
+	}
+	archive := zip.NewWriter(apkg)
+	for name, data := range map[string][]byte{collectionName: collection, "media": []byte("{}")} {
+		w, err := archive.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to add %s: %v", name, err)
+		}
+		w.Write(data)
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("Failed to write package: %v", err)
+	}
+	apkg.Close()
+	return apkgPath
+}
+
+const (
+	testModels = `{
+		"1": {"name": "Basic", "type": 0},
+		"2": {"name": "Cloze", "type": 1},
+		"3": {"name": "Image Occlusion", "type": 1, "originalStockKind": 6},
+		"4": {"name": "Image Occlusion Enhanced", "type": 0}
+	}`
+	testDecks = `{"1": {"name": "Default"}, "10": {"name": "Biology::Cells"}}`
+)
+
+// TestReadAnkiPackage tests reading basic notes and skipping unsupported ones
+func TestReadAnkiPackage(t *testing.T) {
+	path := writeTestPackage(t, "collection.anki2", testModels, testDecks, []ankiTestNote{
+		{model: 1, fields: "What is the <b>powerhouse</b> of the cell?\x1fThe mitochondria", deck: 10},
+		{model: 1, fields: "Line one<br>Line&nbsp;two\x1f<div>A &amp; B</div><div>[sound:x.mp3]</div>", deck: 1},
+		{model: 2, fields: "{{c1::Paris}} is the capital of France\x1f", deck: 1},
+		{model: 3, fields: "<img src=\"occlusion.png\">\x1fmask", deck: 1},
+		{model: 4, fields: "<img src=\"old.png\">\x1fmask", deck: 1},
+		{model: 1, fields: "<img src=\"only-image.png\">\x1fAnswer", deck: 1},
+		{model: 99, fields: "Unknown\x1ftype", deck: 1},
+	})
+
+	pkg, err := ReadAnkiPackage(path)
+	if err != nil {
+		t.Fatalf("Error reading package: %v", err)
+	}
+	want := []AnkiNote{
+		{Front: "What is the powerhouse of the cell?", Back: "The mitochondria", Deck: "Biology::Cells"},
+		{Front: "Line one\nLine two", Back: "A & B", Deck: "Default"},
+	}
+	if diff := cmp.Diff(want, pkg.Notes); diff != "" {
+		t.Errorf("Notes mismatch (-want +got):\n%s", diff)
+	}
+	if pkg.Unsupported != 4 {
+		t.Errorf("Expected 4 unsupported notes, got %d", pkg.Unsupported)
+	}
+	if pkg.Blank != 1 {
+		t.Errorf("Expected 1 blank note, got %d", pkg.Blank)
+	}
+}
+
+// TestReadAnkiPackageAnki21 tests reading the collection.anki21 entry of newer packages
+func TestReadAnkiPackageAnki21(t *testing.T) {
+	path := writeTestPackage(t, "collection.anki21", testModels, testDecks, []ankiTestNote{
+		{model: 1, fields: "Front\x1fBack", deck: 1},
+	})
+	pkg, err := ReadAnkiPackage(path)
+	if err != nil {
+		t.Fatalf("Error reading package: %v", err)
+	}
+	if len(pkg.Notes) != 1 {
+		t.Errorf("Expected 1 note, got %d", len(pkg.Notes))
+	}
+}
+
+// TestReadAnkiPackageErrors tests missing files and unsupported package formats
+func TestReadAnkiPackageErrors(t *testing.T) {
+	if _, err := ReadAnkiPackage(filepath.Join(t.TempDir(), "missing.apkg")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+
+	path := writeTestPackage(t, "collection.anki21b", testModels, testDecks, nil)
+	if _, err := ReadAnkiPackage(path); !errors.Is(err, ErrUnsupportedAnkiFormat) {
+		t.Errorf("Expected ErrUnsupportedAnkiFormat, got %v", err)
+	}
+}
@@ -0,0 +1,933 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/open-spaced-repetition/go-fsrs"
+	_ "modernc.org/sqlite" // Registers the "sqlite" database/sql driver
+)
+
+// Storage backends selectable with the -storage flag.
+const (
+	BackendJSON   = "json"
+	BackendSQLite = "sqlite"
+)
+
+// BackendForPath picks the storage backend for a data file from its extension:
+// ".db" and ".sqlite" files use SQLite, anything else the JSON file format.
+func BackendForPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".db", ".sqlite":
+		return BackendSQLite
+	default:
+		return BackendJSON
+	}
+}
+
+// SQLiteStorage implements the Storage interface on a SQLite database. Each record
+// is stored as JSON in a table of its own and reviews are indexed by card, so no
+// operation has to rewrite the whole collection. Every change is committed as it
+// is made, which leaves Load and Save with nothing to do.
+type SQLiteStorage struct {
+	db   *sql.DB
+	path string
+}
+
+var _ Storage = (*SQLiteStorage)(nil)
+
+// sqliteMigrations are applied in order when a database is opened; PRAGMA
+// user_version records how many have already run. Append new migrations, never
+// edit old ones.
+var sqliteMigrations = []string{
+	`CREATE TABLE cards (
+		id   TEXT PRIMARY KEY,
+		data TEXT NOT NULL
+	);
+	CREATE TABLE reviews (
+		seq     INTEGER PRIMARY KEY AUTOINCREMENT,
+		id      TEXT NOT NULL,
+		card_id TEXT NOT NULL,
+		data    TEXT NOT NULL
+	);
+	CREATE INDEX reviews_card_id ON reviews (card_id);
+	CREATE INDEX reviews_id ON reviews (id);
+	CREATE TABLE due_dates (
+		seq  INTEGER PRIMARY KEY AUTOINCREMENT,
+		id   TEXT NOT NULL,
+		data TEXT NOT NULL
+	);
+	CREATE TABLE templates (
+		name TEXT PRIMARY KEY,
+		data TEXT NOT NULL
+	);
+	CREATE TABLE vacations (
+		seq  INTEGER PRIMARY KEY AUTOINCREMENT,
+		id   TEXT NOT NULL,
+		data TEXT NOT NULL
+	);
+	CREATE TABLE snapshots (
+		seq  INTEGER PRIMARY KEY AUTOINCREMENT,
+		id   TEXT NOT NULL,
+		data TEXT NOT NULL
+	);
+	CREATE TABLE meta (
+		key   TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);`,
+	`CREATE TABLE audit (
+		seq  INTEGER PRIMARY KEY AUTOINCREMENT,
+		id   TEXT NOT NULL,
+		data TEXT NOT NULL
+	);`,
+	`CREATE INDEX due_dates_id ON due_dates (id);`,
+}
+
+// Keys of the meta table.
+const (
+	metaConfig      = "config"
+	metaLastUpdated = "last_updated"
+)
+
+// sqlQueryer is satisfied by both *sql.DB and *sql.Tx.
+type sqlQueryer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// NewSQLiteStorage opens (creating if needed) the SQLite database at path and
+// brings its schema up to date.
+func NewSQLiteStorage(path string) (*SQLiteStorage, error) {
+	log.Printf("[Storage] Opening SQLite storage: %s", path)
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	// A single connection serializes writers and keeps ":memory:" databases alive
+	db.SetMaxOpenConns(1)
+
+	ss := &SQLiteStorage{db: db, path: path}
+	if _, err := db.Exec("PRAGMA busy_timeout = 5000"); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to configure database: %w", err)
+	}
+	if err := ss.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return ss, nil
+}
+
+// migrate applies the migrations the database hasn't seen yet, each in its own transaction.
+func (ss *SQLiteStorage) migrate() error {
+	var version int
+	if err := ss.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version > len(sqliteMigrations) {
+		return fmt.Errorf("database schema version %d is newer than this server supports (%d)", version, len(sqliteMigrations))
+	}
+	for i := version; i < len(sqliteMigrations); i++ {
+		err := ss.withTx(func(tx *sql.Tx) error {
+			if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+				return err
+			}
+			_, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1))
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply schema migration %d: %w", i+1, err)
+		}
+		log.Printf("[Storage] Applied SQLite schema migration %d", i+1)
+	}
+	return nil
+}
+
+// Close closes the underlying database.
+func (ss *SQLiteStorage) Close() error {
+	return ss.db.Close()
+}
+
+// withTx runs fn in a transaction, committing if it succeeds and rolling back otherwise.
+func (ss *SQLiteStorage) withTx(fn func(tx *sql.Tx) error) error {
+	tx, err := ss.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// marshalRecord encodes a record for a data column.
+func marshalRecord(record any) (string, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal record: %w", err)
+	}
+	return string(data), nil
+}
+
+// queryRecords decodes the single data column of every row the query returns.
+func queryRecords[T any](q sqlQueryer, query string, args ...any) ([]T, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []T{}
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, err
+		}
+		var record T
+		if err := json.Unmarshal([]byte(data), &record); err != nil {
+			return nil, fmt.Errorf("failed to decode record: %w", err)
+		}
+		result = append(result, record)
+	}
+	return result, rows.Err()
+}
+
+// queryRecord decodes the data column of the first row the query returns, or
+// returns notFound if there is none.
+func queryRecord[T any](q sqlQueryer, notFound error, query string, args ...any) (T, error) {
+	var record T
+	var data string
+	if err := q.QueryRow(query, args...).Scan(&data); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return record, notFound
+		}
+		return record, err
+	}
+	if err := json.Unmarshal([]byte(data), &record); err != nil {
+		return record, fmt.Errorf("failed to decode record: %w", err)
+	}
+	return record, nil
+}
+
+// execAffecting runs a statement and returns notFound if it changed no rows.
+func execAffecting(q sqlQueryer, notFound error, query string, args ...any) error {
+	result, err := q.Exec(query, args...)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return notFound
+	}
+	return nil
+}
+
+// insertRecord appends a record to one of the seq-ordered tables.
+func insertRecord(q sqlQueryer, table, id string, record any) error {
+	data, err := marshalRecord(record)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec("INSERT INTO "+table+" (id, data) VALUES (?, ?)", id, data)
+	return err
+}
+
+// putMeta writes a value to the meta table.
+func putMeta(q sqlQueryer, key string, value any) error {
+	data, err := marshalRecord(value)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec("INSERT INTO meta (key, value) VALUES (?, ?) ON CONFLICT (key) DO UPDATE SET value = excluded.value", key, data)
+	return err
+}
+
+// touch records the time of the latest change, as FlashcardStore.LastUpdated.
+func touch(q sqlQueryer) error {
+	return putMeta(q, metaLastUpdated, time.Now())
+}
+
+func insertCard(q sqlQueryer, card Card) error {
+	data, err := marshalRecord(card)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec("INSERT INTO cards (id, data) VALUES (?, ?)", card.ID, data)
+	return err
+}
+
+func updateCard(q sqlQueryer, card Card) error {
+	data, err := marshalRecord(card)
+	if err != nil {
+		return err
+	}
+	return execAffecting(q, ErrCardNotFound, "UPDATE cards SET data = ? WHERE id = ?", data, card.ID)
+}
+
+func insertReview(q sqlQueryer, review Review) error {
+	data, err := marshalRecord(review)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec("INSERT INTO reviews (id, card_id, data) VALUES (?, ?, ?)", review.ID, review.CardID, data)
+	return err
+}
+
+// cardExists reports whether a card with the given ID is stored.
+func cardExists(q sqlQueryer, id string) (bool, error) {
+	var exists bool
+	err := q.QueryRow("SELECT EXISTS (SELECT 1 FROM cards WHERE id = ?)", id).Scan(&exists)
+	return exists, err
+}
+
+// CreateCard creates a new flashcard
+func (ss *SQLiteStorage) CreateCard(front, back string, tags []string) (Card, error) {
+	now := time.Now()
+	card := Card{
+		ID:        uuid.New().String(),
+		Front:     front,
+		Back:      back,
+		CreatedAt: now,
+		Tags:      tags,
+		FSRS: fsrs.Card{
+			Due:   now,
+			State: fsrs.New,
+		},
+	}
+	err := ss.withTx(func(tx *sql.Tx) error {
+		if err := insertCard(tx, card); err != nil {
+			return err
+		}
+		return touch(tx)
+	})
+	if err != nil {
+		return Card{}, err
+	}
+	return card, nil
+}
+
+// GetCard retrieves a flashcard by ID
+func (ss *SQLiteStorage) GetCard(id string) (Card, error) {
+	return queryRecord[Card](ss.db, ErrCardNotFound, "SELECT data FROM cards WHERE id = ?", id)
+}
+
+// UpdateCard updates an existing flashcard
+func (ss *SQLiteStorage) UpdateCard(card Card) error {
+	return ss.withTx(func(tx *sql.Tx) error {
+		if err := updateCard(tx, card); err != nil {
+			return err
+		}
+		return touch(tx)
+	})
+}
+
+// DeleteCard deletes a flashcard and all of its reviews
+func (ss *SQLiteStorage) DeleteCard(id string) error {
+	return ss.withTx(func(tx *sql.Tx) error {
+		if err := execAffecting(tx, ErrCardNotFound, "DELETE FROM cards WHERE id = ?", id); err != nil {
+			return err
+		}
+		if _, err := tx.Exec("DELETE FROM reviews WHERE card_id = ?", id); err != nil {
+			return err
+		}
+		return touch(tx)
+	})
+}
+
+// ListCards returns a list of all flashcards, optionally filtered by tags.
+// By default a card must contain ALL of the tags; pass matchAll=false to
+// return cards containing ANY of them instead.
+func (ss *SQLiteStorage) ListCards(tags []string, matchAll ...bool) ([]Card, error) {
+	cards, err := queryRecords[Card](ss.db, "SELECT data FROM cards ORDER BY rowid")
+	if err != nil || len(tags) == 0 {
+		return cards, err
+	}
+
+	matches := hasAllTags
+	if len(matchAll) > 0 && !matchAll[0] {
+		matches = hasAnyTag
+	}
+	result := make([]Card, 0, len(cards))
+	for _, card := range cards {
+		if matches(&card, tags) {
+			result = append(result, card)
+		}
+	}
+	return result, nil
+}
+
+// CreateCards creates several flashcards in one transaction. All inputs are
+// validated first, so one invalid card means none are created.
+func (ss *SQLiteStorage) CreateCards(inputs []CardInput) ([]Card, error) {
+	if len(inputs) == 0 {
+		return []Card{}, nil
+	}
+	for i, input := range inputs {
+		if strings.TrimSpace(input.Front) == "" || strings.TrimSpace(input.Back) == "" {
+			return nil, fmt.Errorf("card %d: %w", i, ErrInvalidCard)
+		}
+	}
+
+	now := time.Now()
+	cards := make([]Card, 0, len(inputs))
+	for _, input := range inputs {
+		cards = append(cards, newCard(input, now))
+	}
+	err := ss.withTx(func(tx *sql.Tx) error {
+		for _, card := range cards {
+			if err := insertCard(tx, card); err != nil {
+				return err
+			}
+		}
+		return touch(tx)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cards, nil
+}
+
+// UpdateCards replaces several existing flashcards in one transaction. If any
+// card doesn't exist, nothing is changed.
+func (ss *SQLiteStorage) UpdateCards(cards []Card) error {
+	if len(cards) == 0 {
+		return nil
+	}
+	return ss.withTx(func(tx *sql.Tx) error {
+		for _, card := range cards {
+			if err := updateCard(tx, card); err != nil {
+				if errors.Is(err, ErrCardNotFound) {
+					return fmt.Errorf("card %s: %w", card.ID, ErrCardNotFound)
+				}
+				return err
+			}
+		}
+		return touch(tx)
+	})
+}
+
+// DeleteCards deletes several flashcards and their reviews in one transaction. If
+// any card doesn't exist, nothing is deleted.
+func (ss *SQLiteStorage) DeleteCards(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return ss.withTx(func(tx *sql.Tx) error {
+		for _, id := range ids {
+			if err := execAffecting(tx, ErrCardNotFound, "DELETE FROM cards WHERE id = ?", id); err != nil {
+				if errors.Is(err, ErrCardNotFound) {
+					return fmt.Errorf("card %s: %w", id, ErrCardNotFound)
+				}
+				return err
+			}
+			if _, err := tx.Exec("DELETE FROM reviews WHERE card_id = ?", id); err != nil {
+				return err
+			}
+		}
+		return touch(tx)
+	})
+}
+
+// RecordReviews stores the updated cards and inserts the reviews in one transaction
+func (ss *SQLiteStorage) RecordReviews(cards []Card, reviews []Review) error {
+	if len(cards) == 0 && len(reviews) == 0 {
+		return nil
+	}
+	return ss.withTx(func(tx *sql.Tx) error {
+		for _, card := range cards {
+			if err := updateCard(tx, card); err != nil {
+				if errors.Is(err, ErrCardNotFound) {
+					return fmt.Errorf("card %s: %w", card.ID, ErrCardNotFound)
+				}
+				return err
+			}
+		}
+		for _, review := range reviews {
+			exists, err := cardExists(tx, review.CardID)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return fmt.Errorf("card %s: %w", review.CardID, ErrCardNotFound)
+			}
+			if err := insertReview(tx, review); err != nil {
+				return err
+			}
+		}
+		return touch(tx)
+	})
+}
+
+// AddReview adds a new review for a card
+func (ss *SQLiteStorage) AddReview(cardID string, rating fsrs.Rating, answer string) (Review, error) {
+	var review Review
+	err := ss.withTx(func(tx *sql.Tx) error {
+		card, err := queryRecord[Card](tx, ErrCardNotFound, "SELECT data FROM cards WHERE id = ?", cardID)
+		if err != nil {
+			return err
+		}
+		review = Review{
+			ID:            uuid.New().String(),
+			CardID:        cardID,
+			Rating:        rating,
+			Timestamp:     time.Now(),
+			Answer:        answer,
+			ScheduledDays: card.FSRS.ScheduledDays,
+			ElapsedDays:   card.FSRS.ElapsedDays,
+			State:         card.FSRS.State,
+		}
+		if err := insertReview(tx, review); err != nil {
+			return err
+		}
+		return touch(tx)
+	})
+	if err != nil {
+		return Review{}, err
+	}
+	return review, nil
+}
+
+// AddReviewDirect adds a new review with specified timestamp and other fields
+func (ss *SQLiteStorage) AddReviewDirect(review Review) error {
+	return ss.withTx(func(tx *sql.Tx) error {
+		exists, err := cardExists(tx, review.CardID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrCardNotFound
+		}
+		if err := insertReview(tx, review); err != nil {
+			return err
+		}
+		return touch(tx)
+	})
+}
+
+// GetCardReviews gets all reviews for a specific card, in the order they were added
+func (ss *SQLiteStorage) GetCardReviews(cardID string) ([]Review, error) {
+	exists, err := cardExists(ss.db, cardID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, ErrCardNotFound
+	}
+	reviews, err := queryRecords[Review](ss.db, "SELECT data FROM reviews WHERE card_id = ? ORDER BY seq", cardID)
+	if err != nil || len(reviews) == 0 {
+		return nil, err // FileStorage also returns nil for a card without reviews
+	}
+	return reviews, nil
+}
+
+// DeleteReview removes a single review record by ID
+func (ss *SQLiteStorage) DeleteReview(reviewID string) error {
+	return ss.withTx(func(tx *sql.Tx) error {
+		err := execAffecting(tx, ErrReviewNotFound,
+			"DELETE FROM reviews WHERE seq = (SELECT seq FROM reviews WHERE id = ? ORDER BY seq LIMIT 1)", reviewID)
+		if err != nil {
+			return err
+		}
+		return touch(tx)
+	})
+}
+
+// DeleteCardReviews removes every review record of a card, keeping the card itself
+func (ss *SQLiteStorage) DeleteCardReviews(cardID string) error {
+	return ss.withTx(func(tx *sql.Tx) error {
+		exists, err := cardExists(tx, cardID)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return ErrCardNotFound
+		}
+		if _, err := tx.Exec("DELETE FROM reviews WHERE card_id = ?", cardID); err != nil {
+			return err
+		}
+		return touch(tx)
+	})
+}
+
+// AddDueDate adds a new due date entry.
+func (ss *SQLiteStorage) AddDueDate(dueDate DueDate) error {
+	return ss.withTx(func(tx *sql.Tx) error {
+		if err := insertRecord(tx, "due_dates", dueDate.ID, dueDate); err != nil {
+			return err
+		}
+		return touch(tx)
+	})
+}
+
+// GetDueDate retrieves a due date entry by its ID.
+func (ss *SQLiteStorage) GetDueDate(id string) (DueDate, error) {
+	return queryRecord[DueDate](ss.db, ErrDueDateNotFound, "SELECT data FROM due_dates WHERE id = ? ORDER BY seq LIMIT 1", id)
+}
+
+// ListDueDates retrieves all due date entries in the order they were added.
+func (ss *SQLiteStorage) ListDueDates() ([]DueDate, error) {
+	return queryRecords[DueDate](ss.db, "SELECT data FROM due_dates ORDER BY seq")
+}
+
+// UpdateDueDate updates an existing due date entry by its ID.
+func (ss *SQLiteStorage) UpdateDueDate(dueDate DueDate) error {
+	data, err := marshalRecord(dueDate)
+	if err != nil {
+		return err
+	}
+	return ss.withTx(func(tx *sql.Tx) error {
+		if err := execAffecting(tx, ErrDueDateNotFound, "UPDATE due_dates SET data = ? WHERE id = ?", data, dueDate.ID); err != nil {
+			return err
+		}
+		return touch(tx)
+	})
+}
+
+// DeleteDueDate deletes a due date entry by its ID.
+func (ss *SQLiteStorage) DeleteDueDate(id string) error {
+	return ss.withTx(func(tx *sql.Tx) error {
+		if err := execAffecting(tx, ErrDueDateNotFound, "DELETE FROM due_dates WHERE id = ?", id); err != nil {
+			return err
+		}
+		return touch(tx)
+	})
+}
+
+// SaveTemplate adds a template or replaces the existing template with the same name.
+func (ss *SQLiteStorage) SaveTemplate(template CardTemplate) error {
+	data, err := marshalRecord(template)
+	if err != nil {
+		return err
+	}
+	return ss.withTx(func(tx *sql.Tx) error {
+		_, err := tx.Exec("INSERT INTO templates (name, data) VALUES (?, ?) ON CONFLICT (name) DO UPDATE SET data = excluded.data", template.Name, data)
+		if err != nil {
+			return err
+		}
+		return touch(tx)
+	})
+}
+
+// GetTemplate retrieves a template by name.
+func (ss *SQLiteStorage) GetTemplate(name string) (CardTemplate, error) {
+	return queryRecord[CardTemplate](ss.db, ErrTemplateNotFound, "SELECT data FROM templates WHERE name = ?", name)
+}
+
+// ListTemplates returns all templates sorted by name.
+func (ss *SQLiteStorage) ListTemplates() ([]CardTemplate, error) {
+	return queryRecords[CardTemplate](ss.db, "SELECT data FROM templates ORDER BY name")
+}
+
+// DeleteTemplate removes a template by name.
+func (ss *SQLiteStorage) DeleteTemplate(name string) error {
+	return ss.withTx(func(tx *sql.Tx) error {
+		if err := execAffecting(tx, ErrTemplateNotFound, "DELETE FROM templates WHERE name = ?", name); err != nil {
+			return err
+		}
+		return touch(tx)
+	})
+}
+
+// AddVacation adds a new vacation period.
+func (ss *SQLiteStorage) AddVacation(vacation Vacation) error {
+	return ss.withTx(func(tx *sql.Tx) error {
+		if err := insertRecord(tx, "vacations", vacation.ID, vacation); err != nil {
+			return err
+		}
+		return touch(tx)
+	})
+}
+
+// AppendAudit adds entries to the end of the audit log, then drops the oldest
+// entries beyond limit (0 = keep all).
+func (ss *SQLiteStorage) AppendAudit(entries []AuditEntry, limit int) error {
+	return ss.withTx(func(tx *sql.Tx) error {
+		for _, entry := range entries {
+			if err := insertRecord(tx, "audit", entry.ID, entry); err != nil {
+				return err
+			}
+		}
+		if limit > 0 {
+			if _, err := tx.Exec("DELETE FROM audit WHERE seq NOT IN (SELECT seq FROM audit ORDER BY seq DESC LIMIT ?)", limit); err != nil {
+				return err
+			}
+		}
+		return touch(tx)
+	})
+}
+
+// ListAudit retrieves the audit log, oldest first.
+func (ss *SQLiteStorage) ListAudit() ([]AuditEntry, error) {
+	return queryRecords[AuditEntry](ss.db, "SELECT data FROM audit ORDER BY seq")
+}
+
+// ListVacations retrieves all vacation periods sorted by start time.
+func (ss *SQLiteStorage) ListVacations() ([]Vacation, error) {
+	vacations, err := queryRecords[Vacation](ss.db, "SELECT data FROM vacations ORDER BY seq")
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(vacations, func(i, j int) bool {
+		return vacations[i].Start.Before(vacations[j].Start)
+	})
+	return vacations, nil
+}
+
+// DeleteVacation deletes a vacation period by its ID.
+func (ss *SQLiteStorage) DeleteVacation(id string) error {
+	return ss.withTx(func(tx *sql.Tx) error {
+		if err := execAffecting(tx, ErrVacationNotFound, "DELETE FROM vacations WHERE id = ?", id); err != nil {
+			return err
+		}
+		return touch(tx)
+	})
+}
+
+// AddSnapshot stores a new snapshot.
+func (ss *SQLiteStorage) AddSnapshot(snapshot Snapshot) error {
+	return ss.withTx(func(tx *sql.Tx) error {
+		if err := insertRecord(tx, "snapshots", snapshot.ID, snapshot); err != nil {
+			return err
+		}
+		return touch(tx)
+	})
+}
+
+// GetSnapshot retrieves a snapshot by its ID.
+func (ss *SQLiteStorage) GetSnapshot(id string) (Snapshot, error) {
+	return queryRecord[Snapshot](ss.db, ErrSnapshotNotFound, "SELECT data FROM snapshots WHERE id = ? ORDER BY seq LIMIT 1", id)
+}
+
+// ListSnapshots retrieves all snapshots sorted by creation time (oldest first).
+func (ss *SQLiteStorage) ListSnapshots() ([]Snapshot, error) {
+	snapshots, err := queryRecords[Snapshot](ss.db, "SELECT data FROM snapshots ORDER BY seq")
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// DeleteSnapshot deletes a snapshot by its ID.
+func (ss *SQLiteStorage) DeleteSnapshot(id string) error {
+	return ss.withTx(func(tx *sql.Tx) error {
+		if err := execAffecting(tx, ErrSnapshotNotFound, "DELETE FROM snapshots WHERE id = ?", id); err != nil {
+			return err
+		}
+		return touch(tx)
+	})
+}
+
+// GetConfig returns the persisted server config, or the zero Config if none was saved.
+func (ss *SQLiteStorage) GetConfig() (Config, error) {
+	// A nil notFound error makes a missing row yield the zero Config
+	config, err := queryRecord[Config](ss.db, nil, "SELECT value FROM meta WHERE key = ?", metaConfig)
+	if err != nil {
+		return Config{}, err
+	}
+	return config, nil
+}
+
+// SaveConfig replaces the persisted server config.
+func (ss *SQLiteStorage) SaveConfig(config Config) error {
+	return ss.withTx(func(tx *sql.Tx) error {
+		if err := putMeta(tx, metaConfig, config); err != nil {
+			return err
+		}
+		return touch(tx)
+	})
+}
+
+// ExportStore reads the entire database into a FlashcardStore, shaped exactly as
+// FileStorage.ExportStore would return it.
+func (ss *SQLiteStorage) ExportStore() (FlashcardStore, error) {
+	var store FlashcardStore
+	err := ss.withTx(func(tx *sql.Tx) error {
+		var err error
+		store, err = exportStore(tx)
+		return err
+	})
+	if err != nil {
+		return FlashcardStore{}, fmt.Errorf("failed to export storage data: %w", err)
+	}
+	return store, nil
+}
+
+// exportStore reads the entire database into a FlashcardStore within tx.
+func exportStore(tx *sql.Tx) (FlashcardStore, error) {
+	var store FlashcardStore
+	cards, err := queryRecords[Card](tx, "SELECT data FROM cards ORDER BY rowid")
+	if err != nil {
+		return FlashcardStore{}, err
+	}
+	store.Cards = make(map[string]Card, len(cards))
+	for _, card := range cards {
+		store.Cards[card.ID] = card
+	}
+	if store.Reviews, err = queryRecords[Review](tx, "SELECT data FROM reviews ORDER BY seq"); err != nil {
+		return FlashcardStore{}, err
+	}
+	if store.DueDates, err = queryRecords[DueDate](tx, "SELECT data FROM due_dates ORDER BY seq"); err != nil {
+		return FlashcardStore{}, err
+	}
+
+	// Empty optional collections are nil, as they are after a JSON round trip
+	templates, err := queryRecords[CardTemplate](tx, "SELECT data FROM templates ORDER BY name")
+	if err != nil {
+		return FlashcardStore{}, err
+	}
+	for _, template := range templates {
+		if store.Templates == nil {
+			store.Templates = make(map[string]CardTemplate)
+		}
+		store.Templates[template.Name] = template
+	}
+	if store.Vacations, err = queryRecords[Vacation](tx, "SELECT data FROM vacations ORDER BY seq"); err != nil {
+		return FlashcardStore{}, err
+	}
+	if len(store.Vacations) == 0 {
+		store.Vacations = nil
+	}
+	if store.Snapshots, err = queryRecords[Snapshot](tx, "SELECT data FROM snapshots ORDER BY seq"); err != nil {
+		return FlashcardStore{}, err
+	}
+	if len(store.Snapshots) == 0 {
+		store.Snapshots = nil
+	}
+	if store.Audit, err = queryRecords[AuditEntry](tx, "SELECT data FROM audit ORDER BY seq"); err != nil {
+		return FlashcardStore{}, err
+	}
+	if len(store.Audit) == 0 {
+		store.Audit = nil
+	}
+
+	config, err := queryRecord[Config](tx, sql.ErrNoRows, "SELECT value FROM meta WHERE key = ?", metaConfig)
+	if err == nil {
+		store.Config = &config
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return FlashcardStore{}, err
+	}
+	lastUpdated, err := queryRecord[time.Time](tx, sql.ErrNoRows, "SELECT value FROM meta WHERE key = ?", metaLastUpdated)
+	if err == nil {
+		store.LastUpdated = lastUpdated
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return FlashcardStore{}, err
+	}
+	return store, nil
+}
+
+// ReplaceStore swaps the entire contents of the database for the given store in
+// one transaction.
+func (ss *SQLiteStorage) ReplaceStore(store FlashcardStore) error {
+	return ss.withTx(func(tx *sql.Tx) error {
+		return replaceStore(tx, store)
+	})
+}
+
+// UpdateStore applies update to the store read within one transaction and writes
+// it back in the same transaction, so nothing can change in between. Nothing is
+// written if update fails.
+func (ss *SQLiteStorage) UpdateStore(update func(store *FlashcardStore) error) error {
+	return ss.withTx(func(tx *sql.Tx) error {
+		store, err := exportStore(tx)
+		if err != nil {
+			return fmt.Errorf("failed to export storage data: %w", err)
+		}
+		if err := update(&store); err != nil {
+			return err
+		}
+		store.LastUpdated = time.Now()
+		return replaceStore(tx, store)
+	})
+}
+
+// replaceStore swaps the entire contents of the database for store within tx.
+func replaceStore(tx *sql.Tx, store FlashcardStore) error {
+	for _, table := range []string{"cards", "reviews", "due_dates", "templates", "vacations", "snapshots", "audit", "meta"} {
+		if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+			return err
+		}
+	}
+
+	// Insert cards in a stable order so ListCards doesn't depend on map iteration
+	ids := make([]string, 0, len(store.Cards))
+	for id := range store.Cards {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		if err := insertCard(tx, store.Cards[id]); err != nil {
+			return err
+		}
+	}
+	for _, review := range store.Reviews {
+		if err := insertReview(tx, review); err != nil {
+			return err
+		}
+	}
+	for _, dueDate := range store.DueDates {
+		if err := insertRecord(tx, "due_dates", dueDate.ID, dueDate); err != nil {
+			return err
+		}
+	}
+	for _, template := range store.Templates {
+		data, err := marshalRecord(template)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec("INSERT INTO templates (name, data) VALUES (?, ?)", template.Name, data); err != nil {
+			return err
+		}
+	}
+	for _, vacation := range store.Vacations {
+		if err := insertRecord(tx, "vacations", vacation.ID, vacation); err != nil {
+			return err
+		}
+	}
+	for _, snapshot := range store.Snapshots {
+		if err := insertRecord(tx, "snapshots", snapshot.ID, snapshot); err != nil {
+			return err
+		}
+	}
+	for _, entry := range store.Audit {
+		if err := insertRecord(tx, "audit", entry.ID, entry); err != nil {
+			return err
+		}
+	}
+	if store.Config != nil {
+		if err := putMeta(tx, metaConfig, *store.Config); err != nil {
+			return err
+		}
+	}
+	return putMeta(tx, metaLastUpdated, store.LastUpdated)
+}
+
+// Load has nothing to reload: every read goes straight to the database.
+func (ss *SQLiteStorage) Load() error {
+	return nil
+}
+
+// Reload has nothing to reload either, like Load.
+func (ss *SQLiteStorage) Reload() error {
+	return nil
+}
+
+// Save has nothing to write: every change is committed as it is made.
+func (ss *SQLiteStorage) Save() error {
+	return nil
+}
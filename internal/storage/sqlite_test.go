@@ -0,0 +1,410 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/open-spaced-repetition/go-fsrs"
+)
+
+// newTestSQLiteStorage opens a SQLite storage in a temporary directory
+func newTestSQLiteStorage(t *testing.T) (*SQLiteStorage, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test-flashcards.db")
+	storage, err := NewSQLiteStorage(path)
+	if err != nil {
+		t.Fatalf("Failed to open SQLite storage: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	return storage, path
+}
+
+// TestBackendForPath tests choosing the backend from the file extension
+func TestBackendForPath(t *testing.T) {
+	for path, want := range map[string]string{
+		"./flashcards.json":  BackendJSON,
+		"cards.db":           BackendSQLite,
+		"/data/cards.SQLite": BackendSQLite,
+		"flashcards":         BackendJSON,
+	} {
+		if got := BackendForPath(path); got != want {
+			t.Errorf("BackendForPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+// TestSQLiteStorage_Migration tests that opening a database applies the schema once
+func TestSQLiteStorage_Migration(t *testing.T) {
+	storage, path := newTestSQLiteStorage(t)
+	card, err := storage.CreateCard("Front", "Back", nil)
+	if err != nil {
+		t.Fatalf("Error creating card: %v", err)
+	}
+	storage.Close()
+
+	// Reopening keeps the data and doesn't re-run the migrations
+	reopened, err := NewSQLiteStorage(path)
+	if err != nil {
+		t.Fatalf("Error reopening SQLite storage: %v", err)
+	}
+	defer reopened.Close()
+	var version int
+	if err := reopened.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		t.Fatalf("Error reading schema version: %v", err)
+	}
+	if version != len(sqliteMigrations) {
+		t.Errorf("Expected schema version %d, got %d", len(sqliteMigrations), version)
+	}
+	if _, err := reopened.GetCard(card.ID); err != nil {
+		t.Errorf("Expected card to survive reopening, got %v", err)
+	}
+
+	// A database from a newer server is refused
+	if _, err := reopened.db.Exec("PRAGMA user_version = 99"); err != nil {
+		t.Fatalf("Error setting schema version: %v", err)
+	}
+	reopened.Close()
+	if _, err := NewSQLiteStorage(path); err == nil {
+		t.Error("Expected an error opening a database with a newer schema")
+	}
+}
+
+// TestSQLiteStorage_Cards tests card CRUD, tag filtering and batch operations
+func TestSQLiteStorage_Cards(t *testing.T) {
+	storage, _ := newTestSQLiteStorage(t)
+
+	card1, _ := storage.CreateCard("Card 1", "Back 1", []string{"tag1", "tag2"})
+	card2, _ := storage.CreateCard("Card 2", "Back 2", []string{"tag2", "tag3"})
+	_, _ = storage.CreateCard("Card 3", "Back 3", []string{"tag3"})
+
+	got, err := storage.GetCard(card1.ID)
+	if err != nil {
+		t.Fatalf("Error getting card: %v", err)
+	}
+	if diff := cmp.Diff(card1, got); diff != "" {
+		t.Errorf("Card mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := storage.GetCard("non-existent-id"); err != ErrCardNotFound {
+		t.Errorf("Expected ErrCardNotFound, got %v", err)
+	}
+
+	for _, tc := range []struct {
+		tags     []string
+		matchAll []bool
+		want     int
+	}{
+		{nil, nil, 3},
+		{[]string{"tag2"}, nil, 2},
+		{[]string{"tag2", "tag3"}, nil, 1},
+		{[]string{"tag1", "tag3"}, []bool{false}, 3},
+		{[]string{"non-existent-tag"}, nil, 0},
+	} {
+		cards, err := storage.ListCards(tc.tags, tc.matchAll...)
+		if err != nil {
+			t.Fatalf("Error listing cards with %v: %v", tc.tags, err)
+		}
+		if len(cards) != tc.want {
+			t.Errorf("Expected %d cards for %v, got %d", tc.want, tc.tags, len(cards))
+		}
+	}
+
+	card1.Front = "Updated"
+	if err := storage.UpdateCard(card1); err != nil {
+		t.Fatalf("Error updating card: %v", err)
+	}
+	if got, _ := storage.GetCard(card1.ID); got.Front != "Updated" {
+		t.Errorf("Expected updated front, got %q", got.Front)
+	}
+	if err := storage.UpdateCard(Card{ID: "non-existent-id"}); err != ErrCardNotFound {
+		t.Errorf("Expected ErrCardNotFound, got %v", err)
+	}
+
+	// A batch with an unknown card changes nothing
+	card2.Back = "Changed"
+	err = storage.UpdateCards([]Card{card2, {ID: "non-existent-id"}})
+	if !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("Expected ErrCardNotFound, got %v", err)
+	}
+	if got, _ := storage.GetCard(card2.ID); got.Back != "Back 2" {
+		t.Errorf("Expected failed batch to leave the card unchanged, got %q", got.Back)
+	}
+
+	// A batch with an invalid card creates nothing
+	_, err = storage.CreateCards([]CardInput{{Front: "Q", Back: "A"}, {Front: " ", Back: "A"}})
+	if !errors.Is(err, ErrInvalidCard) {
+		t.Errorf("Expected ErrInvalidCard, got %v", err)
+	}
+	created, err := storage.CreateCards([]CardInput{{Front: "Q1", Back: "A1"}, {Front: "Q2", Back: "A2"}})
+	if err != nil || len(created) != 2 {
+		t.Fatalf("Expected 2 cards created, got %d (%v)", len(created), err)
+	}
+	if cards, _ := storage.ListCards(nil); len(cards) != 5 {
+		t.Errorf("Expected 5 cards, got %d", len(cards))
+	}
+
+	// A batch delete with an unknown card deletes nothing
+	err = storage.DeleteCards([]string{created[0].ID, "non-existent-id"})
+	if !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("Expected ErrCardNotFound, got %v", err)
+	}
+	if cards, _ := storage.ListCards(nil); len(cards) != 5 {
+		t.Errorf("Expected failed batch delete to keep 5 cards, got %d", len(cards))
+	}
+	if err := storage.DeleteCards([]string{created[0].ID, created[1].ID}); err != nil {
+		t.Fatalf("Error deleting cards: %v", err)
+	}
+	if cards, _ := storage.ListCards(nil); len(cards) != 3 {
+		t.Errorf("Expected 3 cards, got %d", len(cards))
+	}
+}
+
+// TestSQLiteStorage_Reviews tests adding, listing and deleting reviews
+func TestSQLiteStorage_Reviews(t *testing.T) {
+	storage, _ := newTestSQLiteStorage(t)
+	card, _ := storage.CreateCard("Front", "Back", nil)
+	other, _ := storage.CreateCard("Other", "Back", nil)
+
+	if reviews, err := storage.GetCardReviews(card.ID); err != nil || reviews != nil {
+		t.Errorf("Expected no reviews, got %v (%v)", reviews, err)
+	}
+	first, _ := storage.AddReview(card.ID, fsrs.Again, "")
+	storage.AddReview(card.ID, fsrs.Hard, "")
+	direct := Review{ID: "direct", CardID: card.ID, Rating: fsrs.Good, Timestamp: time.Now(), DurationMS: 1500}
+	if err := storage.AddReviewDirect(direct); err != nil {
+		t.Fatalf("Error adding review: %v", err)
+	}
+	storage.AddReview(other.ID, fsrs.Easy, "")
+
+	reviews, err := storage.GetCardReviews(card.ID)
+	if err != nil {
+		t.Fatalf("Error getting reviews: %v", err)
+	}
+	if len(reviews) != 3 {
+		t.Fatalf("Expected 3 reviews, got %d", len(reviews))
+	}
+	for i, want := range []fsrs.Rating{fsrs.Again, fsrs.Hard, fsrs.Good} {
+		if reviews[i].Rating != want {
+			t.Errorf("Expected review %d to be %v, got %v", i, want, reviews[i].Rating)
+		}
+	}
+	if reviews[2].DurationMS != 1500 {
+		t.Errorf("Expected duration 1500, got %d", reviews[2].DurationMS)
+	}
+
+	if _, err := storage.AddReview("non-existent-id", fsrs.Good, ""); err != ErrCardNotFound {
+		t.Errorf("Expected ErrCardNotFound, got %v", err)
+	}
+	if err := storage.AddReviewDirect(Review{ID: "x", CardID: "non-existent-id"}); err != ErrCardNotFound {
+		t.Errorf("Expected ErrCardNotFound, got %v", err)
+	}
+	if _, err := storage.GetCardReviews("non-existent-id"); err != ErrCardNotFound {
+		t.Errorf("Expected ErrCardNotFound, got %v", err)
+	}
+
+	if err := storage.DeleteReview(first.ID); err != nil {
+		t.Fatalf("Error deleting review: %v", err)
+	}
+	if err := storage.DeleteReview(first.ID); err != ErrReviewNotFound {
+		t.Errorf("Expected ErrReviewNotFound, got %v", err)
+	}
+
+	// Deleting a card's reviews keeps the card and other cards' reviews
+	if err := storage.DeleteCardReviews(card.ID); err != nil {
+		t.Fatalf("Error deleting card reviews: %v", err)
+	}
+	if reviews, _ := storage.GetCardReviews(card.ID); len(reviews) != 0 {
+		t.Errorf("Expected no reviews, got %v", reviews)
+	}
+	if reviews, _ := storage.GetCardReviews(other.ID); len(reviews) != 1 {
+		t.Errorf("Expected the other card's review to remain, got %v", reviews)
+	}
+	if err := storage.DeleteCardReviews("non-existent-id"); err != ErrCardNotFound {
+		t.Errorf("Expected ErrCardNotFound, got %v", err)
+	}
+
+	// Recorded reviews are stored with their cards, or not at all
+	card.FSRS.Reps = 1
+	batch := []Review{{ID: "batch", CardID: card.ID, Rating: fsrs.Good, Timestamp: time.Now()}}
+	orphan := append(append([]Review{}, batch...), Review{ID: "orphan", CardID: "non-existent-id"})
+	if err := storage.RecordReviews([]Card{card}, orphan); !errors.Is(err, ErrCardNotFound) {
+		t.Errorf("Expected ErrCardNotFound, got %v", err)
+	}
+	if reviews, _ := storage.GetCardReviews(card.ID); len(reviews) != 0 {
+		t.Errorf("Expected a failed batch to add no reviews, got %v", reviews)
+	}
+	if err := storage.RecordReviews([]Card{card}, batch); err != nil {
+		t.Fatalf("Error recording reviews: %v", err)
+	}
+	if reviews, _ := storage.GetCardReviews(card.ID); len(reviews) != 1 || reviews[0].ID != "batch" {
+		t.Errorf("Expected the recorded review, got %v", reviews)
+	}
+	if got, _ := storage.GetCard(card.ID); got.FSRS.Reps != 1 {
+		t.Errorf("Expected the recorded card update, got %d reps", got.FSRS.Reps)
+	}
+
+	// Deleting a card deletes its reviews
+	if err := storage.DeleteCard(card.ID); err != nil {
+		t.Fatalf("Error deleting card: %v", err)
+	}
+	if err := storage.DeleteCard(card.ID); err != ErrCardNotFound {
+		t.Errorf("Expected ErrCardNotFound, got %v", err)
+	}
+	store, _ := storage.ExportStore()
+	if len(store.Reviews) != 1 || store.Reviews[0].CardID != other.ID {
+		t.Errorf("Expected only the other card's review to remain, got %v", store.Reviews)
+	}
+}
+
+// TestSQLiteStorage_Metadata tests due dates, templates, vacations, snapshots and config
+func TestSQLiteStorage_Metadata(t *testing.T) {
+	storage, _ := newTestSQLiteStorage(t)
+	now := time.Now().Truncate(time.Second)
+
+	// Due dates
+	dueDate := DueDate{ID: "dd1", Topic: "Biology", DueDate: now, Tag: "test-biology"}
+	storage.AddDueDate(dueDate)
+	dueDate.Topic = "Biology Test"
+	if err := storage.UpdateDueDate(dueDate); err != nil {
+		t.Fatalf("Error updating due date: %v", err)
+	}
+	if dueDates, _ := storage.ListDueDates(); len(dueDates) != 1 || dueDates[0].Topic != "Biology Test" {
+		t.Errorf("Expected the updated due date, got %v", dueDates)
+	}
+	if err := storage.UpdateDueDate(DueDate{ID: "missing"}); err != ErrDueDateNotFound {
+		t.Errorf("Expected ErrDueDateNotFound, got %v", err)
+	}
+	if got, err := storage.GetDueDate("dd1"); err != nil || got.Topic != "Biology Test" || !got.DueDate.Equal(now) {
+		t.Errorf("Expected the updated due date, got %v (%v)", got, err)
+	}
+	if _, err := storage.GetDueDate("missing"); err != ErrDueDateNotFound {
+		t.Errorf("Expected ErrDueDateNotFound, got %v", err)
+	}
+	if err := storage.DeleteDueDate("dd1"); err != nil {
+		t.Fatalf("Error deleting due date: %v", err)
+	}
+	if dueDates, _ := storage.ListDueDates(); dueDates == nil || len(dueDates) != 0 {
+		t.Errorf("Expected an empty due date list, got %v", dueDates)
+	}
+
+	// Templates are upserted by name and listed by name
+	storage.SaveTemplate(CardTemplate{Name: "b", Front: "old"})
+	storage.SaveTemplate(CardTemplate{Name: "b", Front: "new"})
+	storage.SaveTemplate(CardTemplate{Name: "a"})
+	if templates, _ := storage.ListTemplates(); len(templates) != 2 || templates[0].Name != "a" || templates[1].Front != "new" {
+		t.Errorf("Unexpected templates: %v", templates)
+	}
+	if err := storage.DeleteTemplate("a"); err != nil {
+		t.Fatalf("Error deleting template: %v", err)
+	}
+	if _, err := storage.GetTemplate("a"); err != ErrTemplateNotFound {
+		t.Errorf("Expected ErrTemplateNotFound, got %v", err)
+	}
+
+	// Vacations are listed by start
+	storage.AddVacation(Vacation{ID: "later", Start: now.Add(48 * time.Hour), End: now.Add(72 * time.Hour)})
+	storage.AddVacation(Vacation{ID: "sooner", Start: now, End: now.Add(time.Hour)})
+	if vacations, _ := storage.ListVacations(); len(vacations) != 2 || vacations[0].ID != "sooner" {
+		t.Errorf("Unexpected vacations: %v", vacations)
+	}
+	if err := storage.DeleteVacation("missing"); err != ErrVacationNotFound {
+		t.Errorf("Expected ErrVacationNotFound, got %v", err)
+	}
+
+	// Snapshots
+	storage.AddSnapshot(Snapshot{ID: "s1", CreatedAt: now, Cards: map[string]Card{}})
+	if snapshot, err := storage.GetSnapshot("s1"); err != nil || !snapshot.CreatedAt.Equal(now) {
+		t.Errorf("Unexpected snapshot %v (%v)", snapshot, err)
+	}
+	if _, err := storage.GetSnapshot("missing"); err != ErrSnapshotNotFound {
+		t.Errorf("Expected ErrSnapshotNotFound, got %v", err)
+	}
+	if err := storage.DeleteSnapshot("s1"); err != nil {
+		t.Fatalf("Error deleting snapshot: %v", err)
+	}
+	if err := storage.DeleteSnapshot("s1"); err != ErrSnapshotNotFound {
+		t.Errorf("Expected ErrSnapshotNotFound, got %v", err)
+	}
+
+	// The audit log keeps the newest entries up to the limit, oldest first
+	storage.AppendAudit([]AuditEntry{{ID: "a1", Action: "create"}, {ID: "a2", Action: "update"}}, 0)
+	if err := storage.AppendAudit([]AuditEntry{{ID: "a3", Action: "delete"}}, 2); err != nil {
+		t.Fatalf("Error appending audit entries: %v", err)
+	}
+	if entries, _ := storage.ListAudit(); len(entries) != 2 || entries[0].ID != "a2" || entries[1].ID != "a3" {
+		t.Errorf("Unexpected audit entries: %v", entries)
+	}
+
+	// Config defaults to the zero value
+	if config, err := storage.GetConfig(); err != nil || !cmp.Equal(config, Config{}) {
+		t.Errorf("Expected zero config, got %v (%v)", config, err)
+	}
+	storage.SaveConfig(Config{MaxReviewsPerDay: 20})
+	if config, _ := storage.GetConfig(); config.MaxReviewsPerDay != 20 {
+		t.Errorf("Expected saved config, got %v", config)
+	}
+}
+
+// TestSQLiteStorage_MatchesFileStorage tests that a store moved from a JSON file
+// into SQLite exports exactly as it did from the file
+func TestSQLiteStorage_MatchesFileStorage(t *testing.T) {
+	tempFile := createTempFile(t)
+	defer cleanupTempFile(t, tempFile)
+	fileStorage := NewFileStorage(tempFile)
+
+	now := time.Now()
+	card, _ := fileStorage.CreateCard("Front", "Back", []string{"tag"})
+	fileStorage.CreateCard("Untagged", "Back", nil)
+	fileStorage.AddReview(card.ID, fsrs.Good, "answer")
+	fileStorage.AddDueDate(DueDate{ID: "dd1", Topic: "Topic", DueDate: now, Tag: "tag"})
+	fileStorage.SaveTemplate(CardTemplate{Name: "qa", Front: "Q: {{q}}", Back: "{{a}}"})
+	fileStorage.AddVacation(Vacation{ID: "v1", Start: now, End: now.Add(time.Hour)})
+	fileStorage.AppendAudit([]AuditEntry{{ID: "a1", Timestamp: now, Action: "create", CardID: card.ID, Summary: "Created"}}, 0)
+	fileStorage.SaveConfig(Config{AutoTagRecall: true})
+	if err := fileStorage.Save(); err != nil {
+		t.Fatalf("Error saving file storage: %v", err)
+	}
+	want, err := fileStorage.ExportStore()
+	if err != nil {
+		t.Fatalf("Error exporting file storage: %v", err)
+	}
+
+	sqliteStorage, _ := newTestSQLiteStorage(t)
+	if err := sqliteStorage.ReplaceStore(want); err != nil {
+		t.Fatalf("Error replacing SQLite store: %v", err)
+	}
+	got, err := sqliteStorage.ExportStore()
+	if err != nil {
+		t.Fatalf("Error exporting SQLite storage: %v", err)
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Export mismatch (-file +sqlite):\n%s", diff)
+	}
+
+	// An empty database exports like an empty file
+	empty, _ := newTestSQLiteStorage(t)
+	store, err := empty.ExportStore()
+	if err != nil {
+		t.Fatalf("Error exporting empty SQLite storage: %v", err)
+	}
+	if store.Cards == nil || store.Reviews == nil || store.DueDates == nil || store.Config != nil {
+		t.Errorf("Unexpected empty export: %+v", store)
+	}
+
+	// Load and Save are no-ops; nothing is lost between them
+	if err := sqliteStorage.Save(); err != nil {
+		t.Errorf("Unexpected Save error: %v", err)
+	}
+	if err := sqliteStorage.Load(); err != nil {
+		t.Errorf("Unexpected Load error: %v", err)
+	}
+	var count int
+	if err := sqliteStorage.db.QueryRow("SELECT COUNT(*) FROM reviews WHERE card_id = ?", card.ID).Scan(&count); err != nil {
+		t.Fatalf("Error counting reviews: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 review row for the card, got %d", count)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -21,7 +23,18 @@ type Card struct {
 	Back           string    `json:"back"`
 	CreatedAt      time.Time `json:"created_at"`
 	Tags           []string  `json:"tags,omitempty"`
+	Hint           string    `json:"hint,omitempty"`        // Optional hint shown before the answer is revealed
+	Prioritized    bool      `json:"prioritized,omitempty"` // Served next by get_due_card once, then cleared
+	Direction      string    `json:"direction,omitempty"`   // Direction the card was last served in ("forward"/"reverse"), until reviewed
+	Suspended      bool      `json:"suspended,omitempty"`   // Temporarily excluded from review
+	Archived       bool      `json:"archived,omitempty"`    // Retired from the active collection
+	ReverseOf      string    `json:"reverse_of,omitempty"`  // ID of the forward card this card reverses
+	Flagged        bool      `json:"flagged,omitempty"`     // Marked by the student for teacher review; still scheduled normally
+	FlagReason     string    `json:"flag_reason,omitempty"`
+	FlaggedAt      time.Time `json:"flagged_at,omitempty"`
+	Leech          bool      `json:"leech,omitempty"` // Lapsed at least the leech threshold number of times
 	LastReviewedAt time.Time `json:"last_reviewed_at,omitempty"`
+	ResetAt        time.Time `json:"reset_at,omitempty"` // Scheduling was last reset here; earlier reviews no longer affect it
 	// Using embedded fsrs.Card for algorithm data
 	FSRS fsrs.Card `json:"fsrs"`
 }
@@ -34,10 +47,13 @@ type Review struct {
 	Rating    fsrs.Rating `json:"rating"` // Using fsrs.Rating type (Again=1, Hard=2, Good=3, Easy=4)
 	Timestamp time.Time   `json:"timestamp"`
 	Answer    string      `json:"answer,omitempty"`
+	Direction string      `json:"direction,omitempty"` // "reverse" if the back was shown as the question
 	// Additional fields from fsrs.ReviewLog that track scheduling information
 	ScheduledDays uint64     `json:"scheduled_days"`
 	ElapsedDays   uint64     `json:"elapsed_days"`
 	State         fsrs.State `json:"state"`
+	Practice      bool       `json:"practice,omitempty"`    // Logged by a practice round; ignored for scheduling and mastery
+	DurationMS    int64      `json:"duration_ms,omitempty"` // Time the student took to answer; 0 when not reported
 }
 
 // DueDate represents a specific test or deadline associated with a tag.
@@ -48,17 +64,135 @@ type DueDate struct {
 	Tag     string    `json:"tag"`      // The tag associated with cards for this due date (e.g., "test-biology-20240715")
 }
 
+// CardTemplate is a named pattern used to produce consistently formatted cards.
+// Front and Back may contain {{placeholder}} markers that are filled in when a
+// card is created from the template.
+type CardTemplate struct {
+	Name  string   `json:"name"`           // Unique template name (e.g., "science-qa")
+	Front string   `json:"front"`          // Front pattern, e.g. "Q: {{question}}"
+	Back  string   `json:"back"`           // Back pattern, e.g. "{{answer}}\nSource: {{source}}"
+	Tags  []string `json:"tags,omitempty"` // Tags applied to every card created from the template
+}
+
+// Vacation represents a period during which scheduling is paused.
+// Time inside [Start, End) does not count toward how overdue a card is.
+type Vacation struct {
+	ID    string    `json:"id"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Snapshot is a timestamped copy of the cards and review IDs in the store,
+// used to report what changed between two points in time.
+type Snapshot struct {
+	ID        string          `json:"id"`
+	Label     string          `json:"label,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	Cards     map[string]Card `json:"cards"`
+	ReviewIDs []string        `json:"review_ids"`
+}
+
+// AuditEntry records one change to the collection, for the card history.
+type AuditEntry struct {
+	ID        string    `json:"id"`
+	Timestamp time.Time `json:"timestamp"`
+	Action    string    `json:"action"` // e.g. "create", "update", "delete" or "review"
+	CardID    string    `json:"card_id,omitempty"`
+	Summary   string    `json:"summary"`
+}
+
+// Config holds server settings that persist across restarts. Zero values mean
+// "use the built-in default".
+type Config struct {
+	MaxReviewsPerDay   int         `json:"max_reviews_per_day,omitempty"` // 0 = unlimited
+	MaxOverdueFactor   *float64    `json:"max_overdue_factor,omitempty"`  // nil = fsrs.DefaultMaxOverdueFactor
+	AutoTagRecall      bool        `json:"auto_tag_recall,omitempty"`
+	SecondsPerCard     float64     `json:"seconds_per_card,omitempty"`     // 0 = default used by estimate_session_time
+	FoldDiacritics     bool        `json:"fold_diacritics,omitempty"`      // check_answer treats "café" and "cafe" as equal
+	NewCardDelay       float64     `json:"new_card_delay,omitempty"`       // Minutes before a new card first becomes due
+	RejectReservedTags bool        `json:"reject_reserved_tags,omitempty"` // Reject, rather than warn about, unbacked "test-" tags
+	SiblingSpacingDays *float64    `json:"sibling_spacing_days,omitempty"` // nil = default; days between a card and its generated reverse
+	MasteryMode        string      `json:"mastery_mode,omitempty"`         // "" or "last_rating" = last review was Easy; "retrievability" = recall probability above MasteryThreshold
+	MasteryThreshold   float64     `json:"mastery_threshold,omitempty"`    // 0 = default; used by the "retrievability" mastery mode
+	MaxTagsPerCard     int         `json:"max_tags_per_card,omitempty"`    // 0 = unlimited
+	FSRS               *FSRSParams `json:"fsrs,omitempty"`                 // nil = go-fsrs defaults
+	ForecastDays       int         `json:"forecast_days,omitempty"`        // 0 = default; days covered by the review-forecast resource
+	LeechThreshold     int         `json:"leech_threshold,omitempty"`      // 0 = default; lapses after which a card is marked a leech
+	AutoSuspendLeeches bool        `json:"auto_suspend_leeches,omitempty"` // Suspend cards as soon as they become leeches
+	NewCardsPerDay     *int        `json:"new_cards_per_day,omitempty"`    // nil = default; 0 = unlimited new cards introduced per day
+	AuditLogSize       int         `json:"audit_log_size,omitempty"`       // 0 = default; most audit entries kept before the oldest are dropped
+	Profile            *Profile    `json:"profile,omitempty"`
+}
+
+// FSRSParams overrides the default FSRS scheduling parameters. Zero values keep the default.
+type FSRSParams struct {
+	RequestRetention float64   `json:"request_retention,omitempty"` // Target recall probability at the due date, e.g. 0.9
+	MaximumInterval  float64   `json:"maximum_interval,omitempty"`  // Longest interval in days
+	Weights          []float64 `json:"weights,omitempty"`           // The 17 FSRS model weights
+}
+
+// Profile labels whose flashcards these are, for multi-student reports.
+type Profile struct {
+	Name       string `json:"name,omitempty"`
+	GradeLevel string `json:"grade_level,omitempty"`
+}
+
+// StoreSchemaVersion identifies the FlashcardStore layout written by ExportStore.
+// Bump it whenever a change to the layout would make older exports load incorrectly.
+const StoreSchemaVersion = 1
+
 // FlashcardStore represents the data structure stored in the JSON file
 type FlashcardStore struct {
-	Cards       map[string]Card `json:"cards"`
-	Reviews     []Review        `json:"reviews"`
-	DueDates    []DueDate       `json:"due_dates"`
-	LastUpdated time.Time       `json:"last_updated"`
+	Cards       map[string]Card         `json:"cards"`
+	Reviews     []Review                `json:"reviews"`
+	DueDates    []DueDate               `json:"due_dates"`
+	Templates   map[string]CardTemplate `json:"templates,omitempty"`
+	Vacations   []Vacation              `json:"vacations,omitempty"`
+	Snapshots   []Snapshot              `json:"snapshots,omitempty"`
+	Audit       []AuditEntry            `json:"audit,omitempty"` // Oldest first
+	Config      *Config                 `json:"config,omitempty"`
+	LastUpdated time.Time               `json:"last_updated"`
 }
 
 // ErrCardNotFound is returned when a card is not found in the storage
 var ErrCardNotFound = errors.New("card not found")
 var ErrDueDateNotFound = errors.New("due date not found")
+var ErrTemplateNotFound = errors.New("template not found")
+var ErrVacationNotFound = errors.New("vacation not found")
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+var ErrReviewNotFound = errors.New("review not found")
+var ErrBackupNotFound = errors.New("backup not found")
+
+// ErrInvalidCard is returned by CreateCards when a card has an empty front or back.
+var ErrInvalidCard = errors.New("card front and back must not be empty")
+
+// ErrEmptyStorageFile is returned by Reload when the data file exists but is empty.
+var ErrEmptyStorageFile = errors.New("storage file is empty")
+
+// ErrQuarantined is returned when saving a storage that was loaded in quarantine mode.
+// Quarantined storage is read-only so the damaged file on disk is never overwritten.
+var ErrQuarantined = errors.New("storage is quarantined (read-only) because the data file is corrupted")
+
+// LoadError describes which top-level keys of a storage file could not be parsed,
+// and how many cards and reviews could still be salvaged.
+type LoadError struct {
+	Keys            []string // Top-level keys that failed to parse; empty if the file isn't a JSON object
+	Err             error    // The first underlying parse error
+	SalvagedCards   int
+	SalvagedReviews int
+}
+
+func (e *LoadError) Error() string {
+	if len(e.Keys) == 0 {
+		return fmt.Sprintf("storage file is not a valid JSON object: %v", e.Err)
+	}
+	return fmt.Sprintf("invalid data in %s: %v (salvageable: %d cards, %d reviews)",
+		strings.Join(e.Keys, ", "), e.Err, e.SalvagedCards, e.SalvagedReviews)
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
 
 // Storage represents the storage interface for flashcards
 type Storage interface {
@@ -67,31 +201,120 @@ type Storage interface {
 	GetCard(id string) (Card, error)
 	UpdateCard(card Card) error
 	DeleteCard(id string) error
-	ListCards(tags []string) ([]Card, error)
+	ListCards(tags []string, matchAll ...bool) ([]Card, error) // matchAll defaults to true (AND); false matches any tag (OR)
+
+	// Batch card operations; each applies all changes under one lock and one save, or none
+	CreateCards(inputs []CardInput) ([]Card, error)
+	UpdateCards(cards []Card) error
+	DeleteCards(ids []string) error                     // Deletes the cards and all of their reviews
+	RecordReviews(cards []Card, reviews []Review) error // Stores the reviewed cards and appends their reviews
 
 	// Review operations
 	AddReview(cardID string, rating fsrs.Rating, answer string) (Review, error)
 	AddReviewDirect(review Review) error
 	GetCardReviews(cardID string) ([]Review, error)
+	DeleteReview(reviewID string) error
+	DeleteCardReviews(cardID string) error // Removes all of a card's reviews, keeping the card
 
 	// Due Date operations
 	AddDueDate(dueDate DueDate) error
+	GetDueDate(id string) (DueDate, error)
 	ListDueDates() ([]DueDate, error)
 	UpdateDueDate(dueDate DueDate) error
 	DeleteDueDate(id string) error
 
+	// Template operations
+	SaveTemplate(template CardTemplate) error
+	GetTemplate(name string) (CardTemplate, error)
+	ListTemplates() ([]CardTemplate, error)
+	DeleteTemplate(name string) error
+
+	// Vacation operations
+	AddVacation(vacation Vacation) error
+	ListVacations() ([]Vacation, error)
+	DeleteVacation(id string) error
+
+	// Snapshot operations
+	AddSnapshot(snapshot Snapshot) error
+	GetSnapshot(id string) (Snapshot, error)
+	ListSnapshots() ([]Snapshot, error)
+	DeleteSnapshot(id string) error
+
+	// Audit log operations
+	AppendAudit(entries []AuditEntry, limit int) error // Drops the oldest entries beyond limit (0 = keep all)
+	ListAudit() ([]AuditEntry, error)                  // Oldest first
+
+	// Config operations
+	GetConfig() (Config, error)
+	SaveConfig(config Config) error
+
+	// Whole-store operations
+	ExportStore() (FlashcardStore, error)
+	ReplaceStore(store FlashcardStore) error
+	UpdateStore(update func(store *FlashcardStore) error) error // Applies update to a copy of the store under one lock and one save, or not at all
+
 	// File operations
 	Load() error
+	Reload() error // Like Load, but keeps the current store unless the data can be read
 	Save() error
 }
 
+// BackupStorage is implemented by storages that keep rotating copies of their data
+// file from before destructive operations, which can be restored later.
+type BackupStorage interface {
+	ListBackups() ([]Backup, error) // Newest first
+	RestoreBackup(name string) error
+}
+
+// Backup is a copy of the data file written before a destructive operation.
+type Backup struct {
+	Name      string    `json:"name"` // File name, e.g. flashcards.json.bak.20240301T101500.000000000Z
+	Path      string    `json:"path"`
+	CreatedAt time.Time `json:"created_at"`
+	Size      int64     `json:"size"`
+}
+
 // FileStorage implements the Storage interface using a JSON file for persistence
 type FileStorage struct {
-	filePath string
-	store    FlashcardStore
-	mu       sync.RWMutex
+	filePath    string
+	store       FlashcardStore
+	mu          sync.RWMutex
+	quarantined bool // Set by LoadQuarantined when the file was only partially readable
+
+	// Position of each due date in store.DueDates by ID; rebuilt by setStore
+	dueDateIndex map[string]int
+
+	// Retry policy for transient write failures (e.g. on networked filesystems)
+	saveAttempts   int
+	saveRetryDelay time.Duration
+	writeFile      func(name string, data []byte, perm os.FileMode) error // os.WriteFile unless replaced in tests
+
+	// Daily backups, written on the first save of each day when backupDir is set
+	backupDir string
+	now       func() time.Time // time.Now unless replaced in tests
+
+	// Rotating backups: the first save after a destructive operation copies the
+	// previous file to <file>.bak.<timestamp>, keeping the keepBackups newest
+	keepBackups   int
+	backupPending bool
 }
 
+// Default retry policy for Save.
+const (
+	DefaultSaveAttempts   = 3
+	DefaultSaveRetryDelay = 50 * time.Millisecond
+)
+
+// DefaultBackups is the usual number of rotating backups to keep; see SetBackups.
+const DefaultBackups = 5
+
+// Rotating backups are named <file><backupSuffix><timestamp>, with a fixed-width UTC
+// timestamp so that names sort in creation order.
+const (
+	backupSuffix     = ".bak."
+	backupTimeFormat = "20060102T150405.000000000Z"
+)
+
 // NewFileStorage creates a new FileStorage instance
 func NewFileStorage(filePath string) *FileStorage {
 	log.Printf("[Storage] Creating new FileStorage for: %s", filePath)
@@ -102,7 +325,43 @@ func NewFileStorage(filePath string) *FileStorage {
 			Reviews:  []Review{},
 			DueDates: []DueDate{},
 		},
+		saveAttempts:   DefaultSaveAttempts,
+		saveRetryDelay: DefaultSaveRetryDelay,
+		writeFile:      os.WriteFile,
+		now:            time.Now,
+	}
+}
+
+// SetBackupDir enables daily backups: the first save of each day also writes a
+// dated copy of the data file into dir. An empty dir disables backups.
+func (fs *FileStorage) SetBackupDir(dir string) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.backupDir = dir
+}
+
+// SetBackups sets how many rotating backups to keep. Before the first save after a
+// delete or bulk change, the previous data file is copied to <file>.bak.<timestamp>
+// and all but the keep newest copies are removed. Zero, the default, disables these backups.
+func (fs *FileStorage) SetBackups(keep int) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if keep < 0 {
+		keep = 0
 	}
+	fs.keepBackups = keep
+}
+
+// SetSaveRetry configures how many times Save tries to write the file and the delay
+// before the first retry; the delay doubles after each failed attempt.
+func (fs *FileStorage) SetSaveRetry(attempts int, initialDelay time.Duration) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if attempts < 1 {
+		attempts = 1
+	}
+	fs.saveAttempts = attempts
+	fs.saveRetryDelay = initialDelay
 }
 
 // CreateCard creates a new flashcard
@@ -152,6 +411,193 @@ func (fs *FileStorage) GetCard(id string) (Card, error) {
 	return card, nil
 }
 
+// CardInput holds the fields of a card to create with CreateCards.
+type CardInput struct {
+	Front     string
+	Back      string
+	Tags      []string
+	Hint      string
+	ReverseOf string
+	DueDelay  time.Duration // Postpones a New card's first due time past its creation
+	FSRS      *fsrs.Card    // Replaces the initial New schedule, e.g. one restored on import
+}
+
+// newCard builds the card created for input at now: New and due at now plus the
+// input's DueDelay, unless the input carries its own schedule.
+func newCard(input CardInput, now time.Time) Card {
+	card := Card{
+		ID:        uuid.New().String(),
+		Front:     input.Front,
+		Back:      input.Back,
+		Hint:      input.Hint,
+		CreatedAt: now,
+		Tags:      input.Tags,
+		ReverseOf: input.ReverseOf,
+		FSRS: fsrs.Card{
+			Due:   now.Add(input.DueDelay),
+			State: fsrs.New,
+		},
+	}
+	if input.FSRS != nil {
+		card.FSRS = *input.FSRS
+	}
+	return card
+}
+
+// CreateCards creates several flashcards with a single lock and a single save. All
+// inputs are validated first, so one invalid card means none are created; if the
+// save fails, the new cards are removed again.
+func (fs *FileStorage) CreateCards(inputs []CardInput) ([]Card, error) {
+	if len(inputs) == 0 {
+		return []Card{}, nil
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for i, input := range inputs {
+		if strings.TrimSpace(input.Front) == "" || strings.TrimSpace(input.Back) == "" {
+			return nil, fmt.Errorf("card %d: %w", i, ErrInvalidCard)
+		}
+	}
+
+	now := time.Now()
+	cards := make([]Card, 0, len(inputs))
+	for _, input := range inputs {
+		card := newCard(input, now)
+		fs.store.Cards[card.ID] = card
+		cards = append(cards, card)
+	}
+
+	if err := fs.save(); err != nil {
+		for _, card := range cards {
+			delete(fs.store.Cards, card.ID)
+		}
+		return nil, err
+	}
+	return cards, nil
+}
+
+// UpdateCards replaces several existing flashcards with a single lock and a single
+// save. If any card doesn't exist, nothing is changed; if the save fails, the
+// previous versions are restored.
+func (fs *FileStorage) UpdateCards(cards []Card) error {
+	if len(cards) == 0 {
+		return nil
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	previous := make(map[string]Card, len(cards))
+	for _, card := range cards {
+		existing, exists := fs.store.Cards[card.ID]
+		if !exists {
+			return fmt.Errorf("card %s: %w", card.ID, ErrCardNotFound)
+		}
+		if _, seen := previous[card.ID]; !seen {
+			previous[card.ID] = existing
+		}
+	}
+
+	fs.backupPending = true
+	for _, card := range cards {
+		fs.store.Cards[card.ID] = card
+	}
+	if err := fs.save(); err != nil {
+		for id, card := range previous {
+			fs.store.Cards[id] = card
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteCards deletes several flashcards and their reviews with a single lock and a
+// single save, so the whole batch shares one rotating backup. If any card doesn't
+// exist, nothing is deleted; if the save fails, the cards and reviews are restored.
+func (fs *FileStorage) DeleteCards(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	deleted := make(map[string]Card, len(ids))
+	for _, id := range ids {
+		card, exists := fs.store.Cards[id]
+		if !exists {
+			return fmt.Errorf("card %s: %w", id, ErrCardNotFound)
+		}
+		deleted[id] = card
+	}
+
+	fs.backupPending = true
+	previousReviews := fs.store.Reviews
+	previousUpdated := fs.store.LastUpdated
+	for id := range deleted {
+		delete(fs.store.Cards, id)
+	}
+	remaining := []Review{}
+	for _, review := range fs.store.Reviews {
+		if _, gone := deleted[review.CardID]; !gone {
+			remaining = append(remaining, review)
+		}
+	}
+	fs.store.Reviews = remaining
+	fs.store.LastUpdated = time.Now()
+
+	if err := fs.save(); err != nil {
+		for id, card := range deleted {
+			fs.store.Cards[id] = card
+		}
+		fs.store.Reviews = previousReviews
+		fs.store.LastUpdated = previousUpdated
+		return err
+	}
+	return nil
+}
+
+// RecordReviews stores the updated cards and appends the reviews with a single lock
+// and a single save. Every card, and the card of every review, must exist; if the
+// save fails, the cards and reviews are restored.
+func (fs *FileStorage) RecordReviews(cards []Card, reviews []Review) error {
+	if len(cards) == 0 && len(reviews) == 0 {
+		return nil
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	previous := make(map[string]Card, len(cards))
+	for _, card := range cards {
+		existing, exists := fs.store.Cards[card.ID]
+		if !exists {
+			return fmt.Errorf("card %s: %w", card.ID, ErrCardNotFound)
+		}
+		if _, seen := previous[card.ID]; !seen {
+			previous[card.ID] = existing
+		}
+	}
+	for _, review := range reviews {
+		if _, exists := fs.store.Cards[review.CardID]; !exists {
+			return fmt.Errorf("card %s: %w", review.CardID, ErrCardNotFound)
+		}
+	}
+
+	reviewCount := len(fs.store.Reviews)
+	for _, card := range cards {
+		fs.store.Cards[card.ID] = card
+	}
+	fs.store.Reviews = append(fs.store.Reviews, reviews...)
+	fs.store.LastUpdated = time.Now()
+	if err := fs.save(); err != nil {
+		for id, card := range previous {
+			fs.store.Cards[id] = card
+		}
+		fs.store.Reviews = fs.store.Reviews[:reviewCount]
+		return err
+	}
+	return nil
+}
+
 // UpdateCard updates an existing flashcard
 func (fs *FileStorage) UpdateCard(card Card) error {
 	fs.mu.Lock()
@@ -181,6 +627,7 @@ func (fs *FileStorage) DeleteCard(id string) error {
 		return ErrCardNotFound
 	}
 
+	fs.backupPending = true
 	fmt.Printf("[DEBUG-DELETE] Card %s found in memory, deleting\n", id)
 	// Delete the card
 	delete(fs.store.Cards, id)
@@ -211,8 +658,10 @@ func (fs *FileStorage) DeleteCard(id string) error {
 	return nil
 }
 
-// ListCards returns a list of all flashcards, optionally filtered by tags (must contain ALL of the tags)
-func (fs *FileStorage) ListCards(tags []string) ([]Card, error) {
+// ListCards returns a list of all flashcards, optionally filtered by tags.
+// By default a card must contain ALL of the tags; pass matchAll=false to
+// return cards containing ANY of them instead.
+func (fs *FileStorage) ListCards(tags []string, matchAll ...bool) ([]Card, error) {
 	fs.mu.RLock()
 	defer fs.mu.RUnlock()
 
@@ -231,9 +680,13 @@ func (fs *FileStorage) ListCards(tags []string) ([]Card, error) {
 		return result, nil
 	}
 
-	// Filter cards: card must have ALL of the specified tags (AND logic)
+	// Filter cards: ALL of the specified tags (AND logic) unless OR was requested
+	matches := hasAllTags
+	if len(matchAll) > 0 && !matchAll[0] {
+		matches = hasAnyTag
+	}
 	for _, card := range fs.store.Cards {
-		if hasAllTags(&card, tags) {
+		if matches(&card, tags) {
 			result = append(result, card)
 		}
 	}
@@ -356,6 +809,12 @@ func (fs *FileStorage) AddDueDate(dueDate DueDate) error {
 		fs.store.DueDates = []DueDate{}
 	}
 	fs.store.DueDates = append(fs.store.DueDates, dueDate)
+	if fs.dueDateIndex == nil {
+		fs.dueDateIndex = make(map[string]int)
+	}
+	if _, exists := fs.dueDateIndex[dueDate.ID]; !exists {
+		fs.dueDateIndex[dueDate.ID] = len(fs.store.DueDates) - 1
+	}
 	fs.store.LastUpdated = time.Now()
 	log.Printf("[Storage:AddDueDate] Added DueDate. New count: %d.", len(fs.store.DueDates))
 	// DO NOT call Save() here, responsibility is in the service layer
@@ -363,6 +822,17 @@ func (fs *FileStorage) AddDueDate(dueDate DueDate) error {
 	return nil
 }
 
+// GetDueDate retrieves a due date entry by its ID.
+func (fs *FileStorage) GetDueDate(id string) (DueDate, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	i, exists := fs.dueDateIndex[id]
+	if !exists {
+		return DueDate{}, ErrDueDateNotFound
+	}
+	return fs.store.DueDates[i], nil
+}
+
 // ListDueDates retrieves all due date entries.
 func (fs *FileStorage) ListDueDates() ([]DueDate, error) {
 	fs.mu.RLock()
@@ -385,21 +855,12 @@ func (fs *FileStorage) UpdateDueDate(updatedDueDate DueDate) error {
 	fs.mu.Lock()
 	defer fs.mu.Unlock()
 	log.Printf("[Storage:UpdateDueDate] Updating DueDate ID: %s", updatedDueDate.ID)
-	if fs.store.DueDates == nil {
-		return ErrDueDateNotFound
-	}
-	found := false
-	for i, dd := range fs.store.DueDates {
-		if dd.ID == updatedDueDate.ID {
-			fs.store.DueDates[i] = updatedDueDate
-			found = true
-			log.Printf("[Storage:UpdateDueDate] Found and updated.")
-			break
-		}
-	}
-	if !found {
+	i, exists := fs.dueDateIndex[updatedDueDate.ID]
+	if !exists {
 		return ErrDueDateNotFound
 	}
+	fs.store.DueDates[i] = updatedDueDate
+	log.Printf("[Storage:UpdateDueDate] Found and updated.")
 	fs.store.LastUpdated = time.Now()
 	log.Printf("[Storage:UpdateDueDate] Updated.")
 	// DO NOT call Save() here
@@ -428,7 +889,9 @@ func (fs *FileStorage) DeleteDueDate(id string) error {
 	if !found {
 		return ErrDueDateNotFound
 	}
+	fs.backupPending = true
 	fs.store.DueDates = newDueDates
+	fs.indexDueDates()
 	fs.store.LastUpdated = time.Now()
 	log.Printf("[Storage:DeleteDueDate] Deleted. Count changed from %d to %d.", initialCount, len(fs.store.DueDates))
 	// DO NOT call Save() here
@@ -436,10 +899,397 @@ func (fs *FileStorage) DeleteDueDate(id string) error {
 	return nil
 }
 
+// SaveTemplate adds a template or replaces the existing template with the same name.
+func (fs *FileStorage) SaveTemplate(template CardTemplate) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.store.Templates == nil {
+		fs.store.Templates = make(map[string]CardTemplate)
+	}
+	fs.store.Templates[template.Name] = template
+	fs.store.LastUpdated = time.Now()
+	// DO NOT call Save() here, responsibility is in the service layer
+	return nil
+}
+
+// GetTemplate retrieves a template by name.
+func (fs *FileStorage) GetTemplate(name string) (CardTemplate, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	template, exists := fs.store.Templates[name]
+	if !exists {
+		return CardTemplate{}, ErrTemplateNotFound
+	}
+	return template, nil
+}
+
+// ListTemplates returns all templates sorted by name.
+func (fs *FileStorage) ListTemplates() ([]CardTemplate, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	result := make([]CardTemplate, 0, len(fs.store.Templates))
+	for _, template := range fs.store.Templates {
+		result = append(result, template)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
+
+// DeleteTemplate removes a template by name.
+func (fs *FileStorage) DeleteTemplate(name string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if _, exists := fs.store.Templates[name]; !exists {
+		return ErrTemplateNotFound
+	}
+	fs.backupPending = true
+	delete(fs.store.Templates, name)
+	fs.store.LastUpdated = time.Now()
+	// DO NOT call Save() here
+	return nil
+}
+
+// AddVacation adds a new vacation period.
+func (fs *FileStorage) AddVacation(vacation Vacation) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.store.Vacations = append(fs.store.Vacations, vacation)
+	fs.store.LastUpdated = time.Now()
+	// DO NOT call Save() here, responsibility is in the service layer
+	return nil
+}
+
+// AppendAudit adds entries to the end of the audit log, then drops the oldest
+// entries beyond limit (0 = keep all).
+func (fs *FileStorage) AppendAudit(entries []AuditEntry, limit int) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.store.Audit = append(fs.store.Audit, entries...)
+	if limit > 0 && len(fs.store.Audit) > limit {
+		fs.store.Audit = append([]AuditEntry(nil), fs.store.Audit[len(fs.store.Audit)-limit:]...)
+	}
+	fs.store.LastUpdated = time.Now()
+	// DO NOT call Save() here, responsibility is in the service layer
+	return nil
+}
+
+// ListAudit retrieves the audit log, oldest first.
+func (fs *FileStorage) ListAudit() ([]AuditEntry, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	result := make([]AuditEntry, len(fs.store.Audit))
+	copy(result, fs.store.Audit)
+	return result, nil
+}
+
+// ListVacations retrieves all vacation periods sorted by start time.
+func (fs *FileStorage) ListVacations() ([]Vacation, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	result := make([]Vacation, len(fs.store.Vacations))
+	copy(result, fs.store.Vacations)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Start.Before(result[j].Start)
+	})
+	return result, nil
+}
+
+// DeleteVacation deletes a vacation period by its ID.
+func (fs *FileStorage) DeleteVacation(id string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	newVacations := []Vacation{}
+	found := false
+	for _, v := range fs.store.Vacations {
+		if v.ID != id {
+			newVacations = append(newVacations, v)
+		} else {
+			found = true
+		}
+	}
+	if !found {
+		return ErrVacationNotFound
+	}
+	fs.backupPending = true
+	fs.store.Vacations = newVacations
+	fs.store.LastUpdated = time.Now()
+	// DO NOT call Save() here
+	return nil
+}
+
+// AddSnapshot stores a new snapshot.
+func (fs *FileStorage) AddSnapshot(snapshot Snapshot) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.store.Snapshots = append(fs.store.Snapshots, snapshot)
+	fs.store.LastUpdated = time.Now()
+	// DO NOT call Save() here, responsibility is in the service layer
+	return nil
+}
+
+// GetSnapshot retrieves a snapshot by its ID.
+func (fs *FileStorage) GetSnapshot(id string) (Snapshot, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	for _, snapshot := range fs.store.Snapshots {
+		if snapshot.ID == id {
+			return snapshot, nil
+		}
+	}
+	return Snapshot{}, ErrSnapshotNotFound
+}
+
+// ListSnapshots retrieves all snapshots sorted by creation time (oldest first).
+func (fs *FileStorage) ListSnapshots() ([]Snapshot, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	result := make([]Snapshot, len(fs.store.Snapshots))
+	copy(result, fs.store.Snapshots)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+// DeleteSnapshot deletes a snapshot by its ID.
+func (fs *FileStorage) DeleteSnapshot(id string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	for i, snapshot := range fs.store.Snapshots {
+		if snapshot.ID == id {
+			fs.backupPending = true
+			fs.store.Snapshots = append(fs.store.Snapshots[:i:i], fs.store.Snapshots[i+1:]...)
+			fs.store.LastUpdated = time.Now()
+			// DO NOT call Save() here, responsibility is in the service layer
+			return nil
+		}
+	}
+	return ErrSnapshotNotFound
+}
+
+// GetConfig returns the persisted server config, or the zero Config if none was saved.
+func (fs *FileStorage) GetConfig() (Config, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	if fs.store.Config == nil {
+		return Config{}, nil
+	}
+	return *fs.store.Config, nil
+}
+
+// SaveConfig replaces the persisted server config.
+func (fs *FileStorage) SaveConfig(config Config) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.store.Config = &config
+	fs.store.LastUpdated = time.Now()
+	// DO NOT call Save() here, responsibility is in the service layer
+	return nil
+}
+
+// ExportStore returns a deep copy of the entire store.
+func (fs *FileStorage) ExportStore() (FlashcardStore, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.copyStore()
+}
+
+// copyStore returns a deep copy of the store. Assumes the lock is already held.
+func (fs *FileStorage) copyStore() (FlashcardStore, error) {
+	data, err := json.Marshal(fs.store)
+	if err != nil {
+		return FlashcardStore{}, fmt.Errorf("failed to marshal storage data: %w", err)
+	}
+	var store FlashcardStore
+	if err := json.Unmarshal(data, &store); err != nil {
+		return FlashcardStore{}, fmt.Errorf("failed to copy storage data: %w", err)
+	}
+	return store, nil
+}
+
+// ReplaceStore swaps the entire in-memory store for the given one.
+func (fs *FileStorage) ReplaceStore(store FlashcardStore) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if store.Cards == nil {
+		store.Cards = make(map[string]Card)
+	}
+	if store.Reviews == nil {
+		store.Reviews = []Review{}
+	}
+	if store.DueDates == nil {
+		store.DueDates = []DueDate{}
+	}
+	fs.backupPending = true
+	fs.setStore(store)
+	// DO NOT call Save() here, responsibility is in the service layer
+	return nil
+}
+
+// UpdateStore applies update to a deep copy of the store while holding the write
+// lock, so nothing can change between reading the store and writing it back. The
+// copy replaces the store and is saved only if update succeeds; if the save fails,
+// the previous store is restored.
+func (fs *FileStorage) UpdateStore(update func(store *FlashcardStore) error) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	store, err := fs.copyStore()
+	if err != nil {
+		return err
+	}
+	if err := update(&store); err != nil {
+		return err
+	}
+	if store.Cards == nil {
+		store.Cards = make(map[string]Card)
+	}
+	if store.Reviews == nil {
+		store.Reviews = []Review{}
+	}
+	if store.DueDates == nil {
+		store.DueDates = []DueDate{}
+	}
+
+	previous := fs.store
+	fs.backupPending = true
+	fs.setStore(store)
+	if err := fs.save(); err != nil {
+		fs.setStore(previous)
+		return err
+	}
+	return nil
+}
+
+// salvageStore parses each top-level key of a damaged storage file separately,
+// keeping every card and review that can still be decoded.
+func salvageStore(data []byte) (FlashcardStore, *LoadError) {
+	loadErr := &LoadError{}
+	store := FlashcardStore{
+		Cards:    make(map[string]Card),
+		Reviews:  []Review{},
+		DueDates: []DueDate{},
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		loadErr.Err = err
+		return store, loadErr
+	}
+
+	fail := func(key string, err error) {
+		loadErr.Keys = append(loadErr.Keys, key)
+		if loadErr.Err == nil {
+			loadErr.Err = err
+		}
+	}
+
+	if rawCards, ok := raw["cards"]; ok {
+		if err := json.Unmarshal(rawCards, &store.Cards); err != nil {
+			fail("cards", err)
+			store.Cards = make(map[string]Card)
+			var cardEntries map[string]json.RawMessage
+			if json.Unmarshal(rawCards, &cardEntries) == nil {
+				for id, entry := range cardEntries {
+					var card Card
+					if json.Unmarshal(entry, &card) == nil {
+						store.Cards[id] = card
+					}
+				}
+			}
+		}
+	}
+	if rawReviews, ok := raw["reviews"]; ok {
+		if err := json.Unmarshal(rawReviews, &store.Reviews); err != nil {
+			fail("reviews", err)
+			store.Reviews = []Review{}
+			var reviewEntries []json.RawMessage
+			if json.Unmarshal(rawReviews, &reviewEntries) == nil {
+				for _, entry := range reviewEntries {
+					var review Review
+					if json.Unmarshal(entry, &review) == nil {
+						store.Reviews = append(store.Reviews, review)
+					}
+				}
+			}
+		}
+	}
+	if rawDueDates, ok := raw["due_dates"]; ok {
+		if err := json.Unmarshal(rawDueDates, &store.DueDates); err != nil {
+			fail("due_dates", err)
+			store.DueDates = []DueDate{}
+		}
+	}
+	if rawTemplates, ok := raw["templates"]; ok {
+		if err := json.Unmarshal(rawTemplates, &store.Templates); err != nil {
+			fail("templates", err)
+			store.Templates = nil
+		}
+	}
+	if rawVacations, ok := raw["vacations"]; ok {
+		if err := json.Unmarshal(rawVacations, &store.Vacations); err != nil {
+			fail("vacations", err)
+			store.Vacations = nil
+		}
+	}
+	if rawSnapshots, ok := raw["snapshots"]; ok {
+		if err := json.Unmarshal(rawSnapshots, &store.Snapshots); err != nil {
+			fail("snapshots", err)
+			store.Snapshots = nil
+		}
+	}
+	if rawAudit, ok := raw["audit"]; ok {
+		if err := json.Unmarshal(rawAudit, &store.Audit); err != nil {
+			fail("audit", err)
+			store.Audit = nil
+		}
+	}
+	if rawConfig, ok := raw["config"]; ok {
+		if err := json.Unmarshal(rawConfig, &store.Config); err != nil {
+			fail("config", err)
+			store.Config = nil
+		}
+	}
+	if rawLastUpdated, ok := raw["last_updated"]; ok {
+		if err := json.Unmarshal(rawLastUpdated, &store.LastUpdated); err != nil {
+			fail("last_updated", err)
+		}
+	}
+
+	loadErr.SalvagedCards = len(store.Cards)
+	loadErr.SalvagedReviews = len(store.Reviews)
+	return store, loadErr
+}
+
+// setStore replaces the in-memory store. Assumes the write lock is already held.
+func (fs *FileStorage) setStore(store FlashcardStore) {
+	fs.store = store
+	fs.indexDueDates()
+}
+
+// indexDueDates rebuilds dueDateIndex from store.DueDates. Where IDs repeat, the
+// first due date wins, as it did when lookups scanned the slice.
+func (fs *FileStorage) indexDueDates() {
+	fs.dueDateIndex = make(map[string]int, len(fs.store.DueDates))
+	for i, dd := range fs.store.DueDates {
+		if _, exists := fs.dueDateIndex[dd.ID]; !exists {
+			fs.dueDateIndex[dd.ID] = i
+		}
+	}
+}
+
 // save is the internal helper for saving data without acquiring the lock again.
-// Assumes the lock (write lock) is already held.
+// Assumes the lock (write lock) is already held. The lock stays held while waiting
+// to retry a failed write: callers roll their changes back if save fails, which
+// must not discard changes another writer made in the meantime. The wait is
+// bounded by saveAttempts and saveRetryDelay.
 func (fs *FileStorage) save() error {
 	fmt.Printf("[DEBUG-STORAGE] save: Starting internal save operation\n")
+	if fs.quarantined {
+		return ErrQuarantined
+	}
 
 	// Ensure data structure is initialized before marshaling
 	// (Redundant if Load initializes, but safe)
@@ -475,10 +1325,203 @@ func (fs *FileStorage) save() error {
 		return fmt.Errorf("failed to create directory: %w", err)
 	}
 
+	// Keep a copy of the file from before a delete or bulk change. Like the daily
+	// backup, a failure is logged rather than blocking the save.
+	if fs.backupPending {
+		if err := fs.writeRotatingBackup(); err != nil {
+			log.Printf("[Storage:save internal] Error writing backup: %v", err)
+		}
+		fs.backupPending = false
+	}
+
+	// Write the file, retrying transient failures with exponential backoff
+	delay := fs.saveRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := fs.writeAtomically(dataBytes)
+		if err == nil {
+			break
+		}
+		if attempt >= fs.saveAttempts || errors.Is(err, os.ErrPermission) {
+			return err
+		}
+		log.Printf("[Storage:save internal] Write attempt %d of %d failed, retrying in %v: %v", attempt, fs.saveAttempts, delay, err)
+		time.Sleep(delay)
+		delay *= 2
+	}
+
+	// A failed backup is logged rather than returned, since the data itself was saved
+	if err := fs.writeDailyBackup(dataBytes); err != nil {
+		log.Printf("[Storage:save internal] Error writing daily backup: %v", err)
+	}
+
+	fmt.Printf("[DEBUG-STORAGE] save: Save operation completed successfully\n")
+	log.Printf("[Storage:save internal] Save successful.")
+	return nil
+}
+
+// writeDailyBackup writes dataBytes to today's backup file in the backup directory
+// unless that file already exists. Backups are named after the data file, e.g.
+// flashcards-2024-03-01.json.
+func (fs *FileStorage) writeDailyBackup(dataBytes []byte) error {
+	if fs.backupDir == "" {
+		return nil
+	}
+	base := filepath.Base(fs.filePath)
+	ext := filepath.Ext(base)
+	name := fmt.Sprintf("%s-%s%s", strings.TrimSuffix(base, ext), fs.now().Format("2006-01-02"), ext)
+	backupPath := filepath.Join(fs.backupDir, name)
+	if _, err := os.Stat(backupPath); err == nil {
+		return nil // Already backed up today
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to check backup file: %w", err)
+	}
+	if err := os.MkdirAll(fs.backupDir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	if err := os.WriteFile(backupPath, dataBytes, 0644); err != nil {
+		return fmt.Errorf("failed to write backup file: %w", err)
+	}
+	log.Printf("[Storage:save internal] Wrote daily backup %s", backupPath)
+	return nil
+}
+
+// writeRotatingBackup copies the current data file, if there is one, to a new
+// timestamped backup next to it and prunes the oldest backups beyond keepBackups.
+func (fs *FileStorage) writeRotatingBackup() error {
+	if fs.keepBackups <= 0 {
+		return nil
+	}
+	data, err := os.ReadFile(fs.filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil // Nothing saved yet
+	} else if err != nil {
+		return fmt.Errorf("failed to read data file: %w", err)
+	}
+	backupPath := fs.filePath + backupSuffix + fs.now().UTC().Format(backupTimeFormat)
+	if err := os.WriteFile(backupPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write backup file: %w", err)
+	}
+	log.Printf("[Storage:save internal] Wrote backup %s", backupPath)
+	return pruneBackups(fs.filePath, fs.keepBackups)
+}
+
+// listBackups returns the rotating backups of the data file at filePath, newest first.
+// Files with the backup prefix but no valid timestamp are ignored.
+func listBackups(filePath string) ([]Backup, error) {
+	prefix := filepath.Base(filePath) + backupSuffix
+	entries, err := os.ReadDir(filepath.Dir(filePath))
+	if errors.Is(err, os.ErrNotExist) {
+		return []Backup{}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to list backups: %w", err)
+	}
+
+	backups := []Backup{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		createdAt, err := time.Parse(backupTimeFormat, strings.TrimPrefix(name, prefix))
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read backup %s: %w", name, err)
+		}
+		backups = append(backups, Backup{
+			Name:      name,
+			Path:      filepath.Join(filepath.Dir(filePath), name),
+			CreatedAt: createdAt,
+			Size:      info.Size(),
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool { return backups[i].CreatedAt.After(backups[j].CreatedAt) })
+	return backups, nil
+}
+
+// pruneBackups removes all but the keep newest backups of the data file at filePath.
+func pruneBackups(filePath string, keep int) error {
+	backups, err := listBackups(filePath)
+	if err != nil {
+		return err
+	}
+	for i := keep; i < len(backups); i++ {
+		if err := os.Remove(backups[i].Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove old backup: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListBackups returns the rotating backups of the data file, newest first.
+func (fs *FileStorage) ListBackups() ([]Backup, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return listBackups(fs.filePath)
+}
+
+// RestoreBackup replaces the data file and the in-memory store with the named
+// backup. The file being replaced is itself backed up first, so a restore can be
+// undone by restoring that copy.
+func (fs *FileStorage) RestoreBackup(name string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	backups, err := listBackups(fs.filePath)
+	if err != nil {
+		return err
+	}
+	var backup *Backup
+	for i := range backups {
+		if backups[i].Name == name {
+			backup = &backups[i]
+		}
+	}
+	if backup == nil {
+		return fmt.Errorf("%w: %s", ErrBackupNotFound, name)
+	}
+
+	data, err := os.ReadFile(backup.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read backup: %w", err)
+	}
+	var store FlashcardStore
+	if err := json.Unmarshal(data, &store); err != nil {
+		return fmt.Errorf("failed to parse backup %s: %w", name, err)
+	}
+	if store.Cards == nil {
+		store.Cards = make(map[string]Card)
+	}
+	if store.Reviews == nil {
+		store.Reviews = []Review{}
+	}
+	if store.DueDates == nil {
+		store.DueDates = []DueDate{}
+	}
+
+	previous := fs.store
+	fs.setStore(store)
+	fs.backupPending = true
+	if err := fs.save(); err != nil {
+		fs.setStore(previous)
+		return err
+	}
+	return nil
+}
+
+// writeAtomically writes data to a temporary file and renames it over the storage file.
+func (fs *FileStorage) writeAtomically(dataBytes []byte) error {
+	writeFile := fs.writeFile
+	if writeFile == nil {
+		writeFile = os.WriteFile
+	}
+
 	// Write to a temporary file
 	tempFile := fs.filePath + ".tmp"
 	fmt.Printf("[DEBUG-STORAGE] save: Writing to temporary file: %s\n", tempFile)
-	if err := os.WriteFile(tempFile, dataBytes, 0644); err != nil {
+	if err := writeFile(tempFile, dataBytes, 0644); err != nil {
 		fmt.Printf("[DEBUG-STORAGE] save: Error writing temp file: %v\n", err)
 		os.Remove(tempFile)
 		log.Printf("[Storage:save internal] Error writing temp file: %v", err)
@@ -494,24 +1537,65 @@ func (fs *FileStorage) save() error {
 		log.Printf("[Storage:save internal] Error renaming temp file: %v", err)
 		return fmt.Errorf("failed to rename temporary file: %w", err)
 	}
-
-	fmt.Printf("[DEBUG-STORAGE] save: Save operation completed successfully\n")
-	log.Printf("[Storage:save internal] Save successful.")
 	return nil
 }
 
 // Load loads the flashcards data from the file
 func (fs *FileStorage) Load() error {
+	return fs.load(false)
+}
+
+// Reload reads the data file again, replacing the in-memory store. Unlike Load, a
+// missing, empty or unreadable file is an error and the current store is kept, so
+// a file moved away while the server runs can't wipe the loaded cards. A storage in
+// quarantine is reloaded the same way LoadQuarantined loads it; on error it stays
+// in quarantine.
+func (fs *FileStorage) Reload() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	data, err := os.ReadFile(fs.filePath)
+	if err != nil {
+		return fmt.Errorf("failed to read storage file: %w", err)
+	}
+	if len(data) == 0 {
+		return ErrEmptyStorageFile
+	}
+	store, quarantined, err := parseStore(data, fs.quarantined)
+	if err != nil {
+		return err
+	}
+	fs.quarantined = quarantined
+	fs.setStore(store)
+	return nil
+}
+
+// LoadQuarantined loads the flashcards data like Load, but if parts of the file are
+// corrupted it loads whatever could be salvaged and puts the storage in read-only
+// quarantine mode, where Save returns ErrQuarantined instead of overwriting the file.
+func (fs *FileStorage) LoadQuarantined() error {
+	return fs.load(true)
+}
+
+// Quarantined reports whether the storage was loaded in quarantine (read-only) mode.
+func (fs *FileStorage) Quarantined() bool {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.quarantined
+}
+
+// load implements Load and LoadQuarantined.
+func (fs *FileStorage) load(quarantine bool) error {
 	fs.mu.Lock() // Acquire Write lock for potential initial save
 	defer fs.mu.Unlock()
 	log.Printf("[Storage:Load] Attempting to load from: %s", fs.filePath)
 	if _, err := os.Stat(fs.filePath); os.IsNotExist(err) {
 		log.Printf("[Storage:Load] File not found, initializing empty store.")
-		fs.store = FlashcardStore{
+		fs.quarantined = false
+		fs.setStore(FlashcardStore{
 			Cards:    make(map[string]Card),
 			Reviews:  []Review{},
 			DueDates: []DueDate{},
-		}
+		})
 		// Explicitly save the initial empty structure to ensure the file exists
 		log.Printf("[Storage:Load] Saving initial empty store.")
 		// Call internal save which assumes lock is held
@@ -530,20 +1614,42 @@ func (fs *FileStorage) Load() error {
 
 	if len(data) == 0 {
 		log.Printf("[Storage:Load] File is empty, initializing empty store.")
-		fs.store = FlashcardStore{
+		fs.quarantined = false
+		fs.setStore(FlashcardStore{
 			Cards:    make(map[string]Card),
 			Reviews:  []Review{},
 			DueDates: []DueDate{},
-		}
+		})
 		return nil
 	}
 
 	log.Printf("[Storage:Load] Read raw data from file: %s", string(data))
 
-	var store FlashcardStore
+	store, quarantined, err := parseStore(data, quarantine)
+	if err != nil {
+		return err
+	}
+	fs.quarantined = quarantined
+	fs.setStore(store)
+	log.Printf("[Storage:Load] Load successful. In-memory DueDate count AFTER load: %d", len(fs.store.DueDates))
+	if len(fs.store.DueDates) > 0 {
+		log.Printf("[Storage:Load] First in-memory DueDate Topic AFTER load: %s", fs.store.DueDates[0].Topic)
+	}
+	return nil
+}
+
+// parseStore decodes the contents of a data file. When it is damaged and quarantine
+// is set, whatever can be salvaged is returned instead, with quarantined set.
+func parseStore(data []byte, quarantine bool) (store FlashcardStore, quarantined bool, err error) {
 	if err := json.Unmarshal(data, &store); err != nil {
 		log.Printf("[Storage:Load] Error unmarshaling JSON: %v", err)
-		return fmt.Errorf("failed to unmarshal storage data: %w", err)
+		salvaged, loadErr := salvageStore(data)
+		if !quarantine || len(loadErr.Keys) == 0 {
+			return FlashcardStore{}, false, fmt.Errorf("failed to unmarshal storage data: %w", loadErr)
+		}
+		log.Printf("[Storage:Load] Loading salvaged data in quarantine (read-only) mode: %v", loadErr)
+		store = salvaged
+		quarantined = true
 	}
 	log.Printf("[Storage:Load] Successfully unmarshaled. DueDate count IMMEDIATELY after unmarshal: %d", len(store.DueDates))
 
@@ -558,13 +1664,7 @@ func (fs *FileStorage) Load() error {
 		log.Printf("[Storage:Load] DueDates was nil in JSON, initializing empty slice.")
 		store.DueDates = []DueDate{}
 	}
-
-	fs.store = store
-	log.Printf("[Storage:Load] Load successful. In-memory DueDate count AFTER load: %d", len(fs.store.DueDates))
-	if len(fs.store.DueDates) > 0 {
-		log.Printf("[Storage:Load] First in-memory DueDate Topic AFTER load: %s", fs.store.DueDates[0].Topic)
-	}
-	return nil
+	return store, quarantined, nil
 }
 
 // Save saves the flashcards data to the file atomically.
@@ -607,3 +1707,45 @@ func (fs *FileStorage) AddReviewDirect(review Review) error {
 	// Persist changes to disk immediately to prevent state leakage
 	return fs.save()
 }
+
+// DeleteReview removes a single review record by ID
+func (fs *FileStorage) DeleteReview(reviewID string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for i, review := range fs.store.Reviews {
+		if review.ID == reviewID {
+			fs.backupPending = true
+			fs.store.Reviews = append(fs.store.Reviews[:i], fs.store.Reviews[i+1:]...)
+			fs.store.LastUpdated = time.Now()
+
+			// Persist changes to disk immediately, like the other review operations
+			return fs.save()
+		}
+	}
+
+	return ErrReviewNotFound
+}
+
+// DeleteCardReviews removes every review record of a card, keeping the card itself
+func (fs *FileStorage) DeleteCardReviews(cardID string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if _, exists := fs.store.Cards[cardID]; !exists {
+		return ErrCardNotFound
+	}
+
+	fs.backupPending = true
+	kept := []Review{}
+	for _, review := range fs.store.Reviews {
+		if review.CardID != cardID {
+			kept = append(kept, review)
+		}
+	}
+	fs.store.Reviews = kept
+	fs.store.LastUpdated = time.Now()
+
+	// Persist changes to disk immediately, like the other review operations
+	return fs.save()
+}
@@ -2,8 +2,12 @@ package storage
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -264,7 +268,7 @@ func TestFileStorage_ListCards(t *testing.T) {
 	}
 
 	// List cards with multiple tags (tag1 OR tag3)
-	multiTagCards, err := storage.ListCards([]string{"tag1", "tag3"})
+	multiTagCards, err := storage.ListCards([]string{"tag1", "tag3"}, false)
 	if err != nil {
 		t.Fatalf("Error listing cards with multiple tags: %v", err)
 	}
@@ -272,6 +276,15 @@ func TestFileStorage_ListCards(t *testing.T) {
 		t.Errorf("Expected 3 cards with tag1 OR tag3, got %d", len(multiTagCards))
 	}
 
+	// List cards with multiple tags (tag2 AND tag3), the default mode
+	bothTagCards, err := storage.ListCards([]string{"tag2", "tag3"})
+	if err != nil {
+		t.Fatalf("Error listing cards with both tags: %v", err)
+	}
+	if len(bothTagCards) != 1 {
+		t.Errorf("Expected 1 card with tag2 AND tag3, got %d", len(bothTagCards))
+	}
+
 	// List cards with non-existent tag
 	nonExistentTagCards, err := storage.ListCards([]string{"non-existent-tag"})
 	if err != nil {
@@ -368,6 +381,125 @@ func TestFileStorage_GetCardReviews(t *testing.T) {
 	}
 }
 
+// TestFileStorage_DeleteReview tests removing a single review
+func TestFileStorage_DeleteReview(t *testing.T) {
+	// Create a temporary file for the test
+	tempFile := createTempFile(t)
+	defer cleanupTempFile(t, tempFile)
+
+	// Create a new storage instance
+	storage := NewFileStorage(tempFile)
+
+	// Create a card with two reviews
+	card, _ := storage.CreateCard("Test Front", "Test Back", nil)
+	first, _ := storage.AddReview(card.ID, fsrs.Again, "")
+	storage.AddReview(card.ID, fsrs.Good, "")
+
+	// Delete the first review
+	if err := storage.DeleteReview(first.ID); err != nil {
+		t.Fatalf("Error deleting review: %v", err)
+	}
+	reviews, _ := storage.GetCardReviews(card.ID)
+	if len(reviews) != 1 || reviews[0].Rating != fsrs.Good {
+		t.Errorf("Expected only the Good review to remain, got %v", reviews)
+	}
+
+	// The deletion is persisted
+	reloaded := NewFileStorage(tempFile)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Error loading storage: %v", err)
+	}
+	if reviews, _ := reloaded.GetCardReviews(card.ID); len(reviews) != 1 {
+		t.Errorf("Expected 1 review after reload, got %d", len(reviews))
+	}
+
+	// Deleting it again fails
+	if err := storage.DeleteReview(first.ID); err != ErrReviewNotFound {
+		t.Errorf("Expected ErrReviewNotFound, got %v", err)
+	}
+}
+
+// TestFileStorage_GetDueDate tests looking up a due date by ID
+func TestFileStorage_GetDueDate(t *testing.T) {
+	tempFile := createTempFile(t)
+	defer cleanupTempFile(t, tempFile)
+	storage := NewFileStorage(tempFile)
+
+	storage.AddDueDate(DueDate{ID: "dd1", Topic: "Biology", Tag: "test-biology"})
+	storage.AddDueDate(DueDate{ID: "dd2", Topic: "Chemistry", Tag: "test-chemistry"})
+
+	dueDate, err := storage.GetDueDate("dd2")
+	if err != nil {
+		t.Fatalf("Error getting due date: %v", err)
+	}
+	if dueDate.Topic != "Chemistry" || dueDate.Tag != "test-chemistry" {
+		t.Errorf("Expected the Chemistry due date, got %v", dueDate)
+	}
+
+	if _, err := storage.GetDueDate("missing"); err != ErrDueDateNotFound {
+		t.Errorf("Expected ErrDueDateNotFound, got %v", err)
+	}
+
+	// The lookup follows updates, deletes and reloads
+	storage.UpdateDueDate(DueDate{ID: "dd2", Topic: "Organic Chemistry", Tag: "test-chemistry"})
+	if dueDate, _ := storage.GetDueDate("dd2"); dueDate.Topic != "Organic Chemistry" {
+		t.Errorf("Expected the updated due date, got %v", dueDate)
+	}
+	storage.DeleteDueDate("dd1")
+	if _, err := storage.GetDueDate("dd1"); err != ErrDueDateNotFound {
+		t.Errorf("Expected ErrDueDateNotFound for a deleted due date, got %v", err)
+	}
+	if dueDate, _ := storage.GetDueDate("dd2"); dueDate.Topic != "Organic Chemistry" {
+		t.Errorf("Expected dd2 after deleting dd1, got %v", dueDate)
+	}
+	if err := storage.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	reloaded := NewFileStorage(tempFile)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Failed to reload storage: %v", err)
+	}
+	if dueDate, err := reloaded.GetDueDate("dd2"); err != nil || dueDate.Topic != "Organic Chemistry" {
+		t.Errorf("Expected dd2 after reloading, got %v (%v)", dueDate, err)
+	}
+}
+
+// TestFileStorage_DeleteCardReviews tests removing all of a card's reviews
+func TestFileStorage_DeleteCardReviews(t *testing.T) {
+	tempFile := createTempFile(t)
+	defer cleanupTempFile(t, tempFile)
+	storage := NewFileStorage(tempFile)
+
+	card, _ := storage.CreateCard("Test Front", "Test Back", nil)
+	other, _ := storage.CreateCard("Other Front", "Other Back", nil)
+	storage.AddReview(card.ID, fsrs.Again, "")
+	storage.AddReview(card.ID, fsrs.Good, "")
+	storage.AddReview(other.ID, fsrs.Good, "")
+
+	if err := storage.DeleteCardReviews(card.ID); err != nil {
+		t.Fatalf("Error deleting card reviews: %v", err)
+	}
+	if _, err := storage.GetCard(card.ID); err != nil {
+		t.Errorf("Expected the card to remain, got %v", err)
+	}
+
+	// The deletion is persisted and leaves the other card's reviews alone
+	reloaded := NewFileStorage(tempFile)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Error loading storage: %v", err)
+	}
+	if reviews, _ := reloaded.GetCardReviews(card.ID); len(reviews) != 0 {
+		t.Errorf("Expected no reviews after reload, got %d", len(reviews))
+	}
+	if reviews, _ := reloaded.GetCardReviews(other.ID); len(reviews) != 1 {
+		t.Errorf("Expected the other card's review to remain, got %d", len(reviews))
+	}
+
+	if err := storage.DeleteCardReviews("non-existent-id"); err != ErrCardNotFound {
+		t.Errorf("Expected ErrCardNotFound, got %v", err)
+	}
+}
+
 // TestFileStorage_SaveAndLoad tests saving and loading data
 func TestFileStorage_SaveAndLoad(t *testing.T) {
 	// Create a temporary file for the test
@@ -600,3 +732,724 @@ func TestFileStorage_Load_WithDueDates(t *testing.T) {
 		t.Errorf("Tag mismatch: want %s, got %s", expectedDueDate.Tag, loadedDueDate.Tag)
 	}
 }
+
+// TestFileStorage_LoadReportsCorruptedKey tests that Load identifies which top-level
+// key is malformed and how much data was salvageable
+func TestFileStorage_LoadReportsCorruptedKey(t *testing.T) {
+	tempFile := createTempFile(t)
+	defer cleanupTempFile(t, tempFile)
+
+	// Cards are valid, but one review has a string where a number is expected
+	data := `{
+		"cards": {
+			"c1": {"id": "c1", "front": "Q1", "back": "A1", "fsrs": {"State": 0}},
+			"c2": {"id": "c2", "front": "Q2", "back": "A2", "fsrs": {"State": 0}}
+		},
+		"reviews": [
+			{"id": "r1", "card_id": "c1", "rating": 3},
+			{"id": "r2", "card_id": "c2", "rating": "three"}
+		],
+		"due_dates": []
+	}`
+	if err := os.WriteFile(tempFile, []byte(data), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+
+	storage := NewFileStorage(tempFile)
+	err := storage.Load()
+	if err == nil {
+		t.Fatal("Expected an error loading a file with malformed reviews")
+	}
+	var loadErr *LoadError
+	if !errors.As(err, &loadErr) {
+		t.Fatalf("Expected a *LoadError, got %T: %v", err, err)
+	}
+	if len(loadErr.Keys) != 1 || loadErr.Keys[0] != "reviews" {
+		t.Errorf("Expected the error to identify \"reviews\", got %v", loadErr.Keys)
+	}
+	if !strings.Contains(err.Error(), "reviews") {
+		t.Errorf("Expected error message to mention reviews, got: %v", err)
+	}
+	if loadErr.SalvagedCards != 2 || loadErr.SalvagedReviews != 1 {
+		t.Errorf("Expected 2 salvageable cards and 1 review, got %d cards and %d reviews",
+			loadErr.SalvagedCards, loadErr.SalvagedReviews)
+	}
+
+	// Quarantine mode loads the salvaged data but refuses to overwrite the file
+	quarantined := NewFileStorage(tempFile)
+	if err := quarantined.LoadQuarantined(); err != nil {
+		t.Fatalf("LoadQuarantined returned error: %v", err)
+	}
+	if !quarantined.Quarantined() {
+		t.Error("Expected storage to be quarantined")
+	}
+	if _, err := quarantined.GetCard("c1"); err != nil {
+		t.Errorf("Expected salvaged card c1 to be readable: %v", err)
+	}
+	if err := quarantined.Save(); !errors.Is(err, ErrQuarantined) {
+		t.Errorf("Expected ErrQuarantined on save, got %v", err)
+	}
+	onDisk, _ := os.ReadFile(tempFile)
+	if string(onDisk) != data {
+		t.Error("Quarantined storage must not modify the file on disk")
+	}
+
+	// Reloading a file that is still damaged keeps the quarantine
+	if err := quarantined.Reload(); err != nil {
+		t.Fatalf("Reload returned error: %v", err)
+	}
+	if !quarantined.Quarantined() {
+		t.Error("Expected storage to stay quarantined after reloading a damaged file")
+	}
+	const unreadable = "{not json"
+	if err := os.WriteFile(tempFile, []byte(unreadable), 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
+	}
+	if err := quarantined.Reload(); err == nil {
+		t.Error("Expected an error reloading an unreadable file")
+	}
+	if !quarantined.Quarantined() {
+		t.Error("A failed reload must keep the storage quarantined")
+	}
+	if err := quarantined.Save(); !errors.Is(err, ErrQuarantined) {
+		t.Errorf("Expected ErrQuarantined on save after a failed reload, got %v", err)
+	}
+	if onDisk, _ := os.ReadFile(tempFile); string(onDisk) != unreadable {
+		t.Error("Quarantined storage must not modify the file on disk")
+	}
+
+	// A missing file is an error and isn't recreated
+	if err := os.Remove(tempFile); err != nil {
+		t.Fatalf("Failed to remove test file: %v", err)
+	}
+	if err := quarantined.Reload(); err == nil {
+		t.Error("Expected an error reloading a missing file")
+	}
+	if _, err := os.Stat(tempFile); !os.IsNotExist(err) {
+		t.Errorf("Reload must not create the missing file, got %v", err)
+	}
+	if _, err := quarantined.GetCard("c1"); err != nil {
+		t.Errorf("A failed reload must keep the loaded cards: %v", err)
+	}
+}
+
+// TestFileStorage_SaveRetriesTransientFailure tests that Save recovers when a write fails once
+func TestFileStorage_SaveRetriesTransientFailure(t *testing.T) {
+	tempFile := createTempFile(t)
+	defer cleanupTempFile(t, tempFile)
+
+	storage := NewFileStorage(tempFile)
+	if err := storage.Load(); err != nil {
+		t.Fatalf("Failed to load storage: %v", err)
+	}
+	storage.SetSaveRetry(3, time.Millisecond)
+
+	attempts := 0
+	storage.writeFile = func(name string, data []byte, perm os.FileMode) error {
+		attempts++
+		if attempts == 1 {
+			return errors.New("transient network error")
+		}
+		return os.WriteFile(name, data, perm)
+	}
+
+	card, err := storage.CreateCard("Question", "Answer", nil)
+	if err != nil {
+		t.Fatalf("CreateCard should succeed after a retry: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 write attempts, got %d", attempts)
+	}
+
+	// The retried write must have reached the disk
+	reloaded := NewFileStorage(tempFile)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Failed to reload storage: %v", err)
+	}
+	if _, err := reloaded.GetCard(card.ID); err != nil {
+		t.Errorf("Card should be persisted after the retried save: %v", err)
+	}
+
+	// Persistent failures still surface once the attempts are used up
+	attempts = 0
+	storage.writeFile = func(name string, data []byte, perm os.FileMode) error {
+		attempts++
+		return errors.New("still failing")
+	}
+	if err := storage.Save(); err == nil {
+		t.Error("Expected Save to fail when every attempt fails")
+	}
+	if attempts != 3 {
+		t.Errorf("Expected 3 write attempts, got %d", attempts)
+	}
+}
+
+// TestFileStorage_SaveRetryKeepsLock tests that a change made while a batch waits
+// to retry its save is applied after the batch, not lost to the batch's rollback
+func TestFileStorage_SaveRetryKeepsLock(t *testing.T) {
+	tempFile := createTempFile(t)
+	defer cleanupTempFile(t, tempFile)
+
+	storage := NewFileStorage(tempFile)
+	if err := storage.Load(); err != nil {
+		t.Fatalf("Failed to load storage: %v", err)
+	}
+	kept, _ := storage.CreateCard("Kept", "Answer", nil)
+	edited, _ := storage.CreateCard("Edited", "Answer", nil)
+	if err := storage.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	storage.SetSaveRetry(2, 100*time.Millisecond)
+
+	// Every write of a store without the kept card fails, so the delete rolls back
+	failed := make(chan struct{})
+	var once sync.Once
+	storage.writeFile = func(name string, data []byte, perm os.FileMode) error {
+		if !strings.Contains(string(data), kept.ID) {
+			once.Do(func() { close(failed) })
+			return errors.New("transient network error")
+		}
+		return os.WriteFile(name, data, perm)
+	}
+
+	deleted := make(chan error)
+	go func() { deleted <- storage.DeleteCards([]string{kept.ID}) }()
+	<-failed
+
+	// The update waits for the delete to finish rolling back
+	updated := make(chan error)
+	go func() {
+		edited.Front = "Edited during the retry"
+		updated <- storage.UpdateCard(edited)
+	}()
+	if err := <-deleted; err == nil {
+		t.Fatal("Expected DeleteCards to fail")
+	}
+	if err := <-updated; err != nil {
+		t.Fatalf("UpdateCard failed: %v", err)
+	}
+
+	reloaded := NewFileStorage(tempFile)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Failed to reload storage: %v", err)
+	}
+	if _, err := reloaded.GetCard(kept.ID); err != nil {
+		t.Errorf("The failed delete must be rolled back: %v", err)
+	}
+	if card, _ := reloaded.GetCard(edited.ID); card.Front != "Edited during the retry" {
+		t.Errorf("The concurrent update must survive the rollback, got front %q", card.Front)
+	}
+}
+
+// TestFileStorage_DailyBackup tests that exactly one backup is written per day
+func TestFileStorage_DailyBackup(t *testing.T) {
+	dir := t.TempDir()
+	backupDir := filepath.Join(dir, "backups")
+	storage := NewFileStorage(filepath.Join(dir, "flashcards.json"))
+	storage.SetBackupDir(backupDir)
+
+	today := time.Date(2024, 3, 1, 9, 0, 0, 0, time.Local)
+	storage.now = func() time.Time { return today }
+
+	// Several saves on the first day; only the first is backed up
+	card, err := storage.CreateCard("Day one", "A", nil)
+	if err != nil {
+		t.Fatalf("CreateCard failed: %v", err)
+	}
+	if _, err := storage.CreateCard("Day one again", "A", nil); err != nil {
+		t.Fatalf("CreateCard failed: %v", err)
+	}
+	if err := storage.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	// Saves on the next day write a second backup
+	today = today.AddDate(0, 0, 1)
+	if _, err := storage.CreateCard("Day two", "A", nil); err != nil {
+		t.Fatalf("CreateCard failed: %v", err)
+	}
+	if err := storage.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	entries, err := os.ReadDir(backupDir)
+	if err != nil {
+		t.Fatalf("Failed to read backup directory: %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	if diff := cmp.Diff([]string{"flashcards-2024-03-01.json", "flashcards-2024-03-02.json"}, names); diff != "" {
+		t.Fatalf("Unexpected backup files (-want +got):\n%s", diff)
+	}
+
+	// The first day's backup holds the state after that day's first save
+	var backup FlashcardStore
+	data, err := os.ReadFile(filepath.Join(backupDir, names[0]))
+	if err != nil {
+		t.Fatalf("Failed to read backup: %v", err)
+	}
+	if err := json.Unmarshal(data, &backup); err != nil {
+		t.Fatalf("Failed to parse backup: %v", err)
+	}
+	if len(backup.Cards) != 1 {
+		t.Errorf("Expected 1 card in the first backup, got %d", len(backup.Cards))
+	}
+	if _, ok := backup.Cards[card.ID]; !ok {
+		t.Error("First backup should contain the first card")
+	}
+}
+
+// TestListAndPruneBackups tests that backups are listed newest first and pruned to the newest few
+func TestListAndPruneBackups(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "flashcards.json")
+	if backups, err := listBackups(filePath); err != nil || len(backups) != 0 {
+		t.Fatalf("Expected no backups, got %v (%v)", backups, err)
+	}
+
+	start := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	var names []string
+	for i := 0; i < 4; i++ {
+		name := "flashcards.json" + backupSuffix + start.Add(time.Duration(i)*time.Hour).Format(backupTimeFormat)
+		names = append(names, name)
+	}
+	// Written out of order, next to files that aren't backups of this data file
+	for _, name := range []string{names[2], names[0], names[3], names[1],
+		"flashcards.json", "flashcards.json.bak.not-a-time", "other.json" + backupSuffix + start.Format(backupTimeFormat)} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("{}"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	listed := func() []string {
+		t.Helper()
+		backups, err := listBackups(filePath)
+		if err != nil {
+			t.Fatalf("listBackups failed: %v", err)
+		}
+		var got []string
+		for _, backup := range backups {
+			got = append(got, backup.Name)
+			if backup.Size != 2 || backup.Path != filepath.Join(dir, backup.Name) {
+				t.Errorf("Unexpected backup details %+v", backup)
+			}
+		}
+		return got
+	}
+	if diff := cmp.Diff([]string{names[3], names[2], names[1], names[0]}, listed()); diff != "" {
+		t.Fatalf("Unexpected backups (-want +got):\n%s", diff)
+	}
+	backups, _ := listBackups(filePath)
+	if !backups[0].CreatedAt.Equal(start.Add(3 * time.Hour)) {
+		t.Errorf("Expected the newest backup from %v, got %v", start.Add(3*time.Hour), backups[0].CreatedAt)
+	}
+
+	if err := pruneBackups(filePath, 2); err != nil {
+		t.Fatalf("pruneBackups failed: %v", err)
+	}
+	if diff := cmp.Diff([]string{names[3], names[2]}, listed()); diff != "" {
+		t.Fatalf("Unexpected backups after pruning (-want +got):\n%s", diff)
+	}
+	// Unrelated files are left alone
+	for _, name := range []string{"flashcards.json", "flashcards.json.bak.not-a-time"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("Expected %s to remain: %v", name, err)
+		}
+	}
+}
+
+// TestFileStorage_RotatingBackups tests that destructive operations back up the previous file
+func TestFileStorage_RotatingBackups(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "flashcards.json")
+	storage := NewFileStorage(filePath)
+	storage.SetBackups(2)
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	storage.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	if err := storage.Load(); err != nil {
+		t.Fatalf("Failed to load storage: %v", err)
+	}
+
+	var cards []Card
+	for i := 0; i < 4; i++ {
+		card, err := storage.CreateCard(fmt.Sprintf("Q%d", i), "A", nil)
+		if err != nil {
+			t.Fatalf("CreateCard failed: %v", err)
+		}
+		cards = append(cards, card)
+	}
+	card := cards[0]
+	card.Front = "Edited"
+	if err := storage.UpdateCard(card); err != nil {
+		t.Fatalf("UpdateCard failed: %v", err)
+	}
+	if backups, _ := storage.ListBackups(); len(backups) != 0 {
+		t.Fatalf("Expected no backups before a destructive operation, got %v", backups)
+	}
+
+	// Each delete backs up the file as it was before the delete, keeping the newest two
+	for i := 0; i < 3; i++ {
+		if err := storage.DeleteCard(cards[i].ID); err != nil {
+			t.Fatalf("DeleteCard failed: %v", err)
+		}
+	}
+	backups, err := storage.ListBackups()
+	if err != nil {
+		t.Fatalf("ListBackups failed: %v", err)
+	}
+	if len(backups) != 2 {
+		t.Fatalf("Expected 2 backups, got %d", len(backups))
+	}
+	var newest FlashcardStore
+	data, _ := os.ReadFile(backups[0].Path)
+	if err := json.Unmarshal(data, &newest); err != nil {
+		t.Fatalf("Failed to parse backup: %v", err)
+	}
+	if len(newest.Cards) != 2 {
+		t.Errorf("Expected the newest backup to hold the 2 cards from before the last delete, got %d", len(newest.Cards))
+	}
+
+	// The next ordinary save isn't backed up again
+	if _, err := storage.CreateCard("Q4", "A", nil); err != nil {
+		t.Fatalf("CreateCard failed: %v", err)
+	}
+	if after, _ := storage.ListBackups(); after[0].Name != backups[0].Name {
+		t.Errorf("Expected no new backup for a plain create, got %s", after[0].Name)
+	}
+}
+
+// TestFileStorage_RestoreBackup tests restoring a backup into the file and the in-memory store
+func TestFileStorage_RestoreBackup(t *testing.T) {
+	dir := t.TempDir()
+	filePath := filepath.Join(dir, "flashcards.json")
+	storage := NewFileStorage(filePath)
+	storage.SetBackups(DefaultBackups)
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	storage.now = func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+	if err := storage.Load(); err != nil {
+		t.Fatalf("Failed to load storage: %v", err)
+	}
+	card, _ := storage.CreateCard("Keep me", "A", nil)
+	storage.AddReview(card.ID, fsrs.Good, "")
+	if err := storage.DeleteCard(card.ID); err != nil {
+		t.Fatalf("DeleteCard failed: %v", err)
+	}
+
+	backups, _ := storage.ListBackups()
+	if len(backups) != 1 {
+		t.Fatalf("Expected 1 backup, got %d", len(backups))
+	}
+	if err := storage.RestoreBackup(backups[0].Name); err != nil {
+		t.Fatalf("RestoreBackup failed: %v", err)
+	}
+	if _, err := storage.GetCard(card.ID); err != nil {
+		t.Errorf("Expected the deleted card after restoring: %v", err)
+	}
+	if reviews, _ := storage.GetCardReviews(card.ID); len(reviews) != 1 {
+		t.Errorf("Expected the card's review after restoring, got %d", len(reviews))
+	}
+
+	// The restore is persisted, and the file it replaced was backed up
+	reloaded := NewFileStorage(filePath)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Failed to reload storage: %v", err)
+	}
+	if _, err := reloaded.GetCard(card.ID); err != nil {
+		t.Errorf("Expected the restored card after reload: %v", err)
+	}
+	if after, _ := storage.ListBackups(); len(after) != 2 {
+		t.Errorf("Expected the replaced file to be backed up, got %d backups", len(after))
+	}
+
+	if err := storage.RestoreBackup("flashcards.json"); !errors.Is(err, ErrBackupNotFound) {
+		t.Errorf("Expected ErrBackupNotFound, got %v", err)
+	}
+	if err := storage.RestoreBackup("../" + backups[0].Name); !errors.Is(err, ErrBackupNotFound) {
+		t.Errorf("Expected ErrBackupNotFound for a path, got %v", err)
+	}
+}
+
+// TestFileStorage_CreateCardsBatch tests that batch creates are all-or-nothing and write the file once
+func TestFileStorage_CreateCardsBatch(t *testing.T) {
+	tempFile := createTempFile(t)
+	defer cleanupTempFile(t, tempFile)
+
+	storage := NewFileStorage(tempFile)
+	if err := storage.Load(); err != nil {
+		t.Fatalf("Failed to load storage: %v", err)
+	}
+	writes := 0
+	storage.writeFile = func(name string, data []byte, perm os.FileMode) error {
+		writes++
+		return os.WriteFile(name, data, perm)
+	}
+
+	inputs := make([]CardInput, 50)
+	for i := range inputs {
+		inputs[i] = CardInput{Front: fmt.Sprintf("Q%d", i), Back: "A", Tags: []string{"batch"}}
+	}
+
+	// One invalid card rejects the whole batch
+	invalid := append(append([]CardInput{}, inputs...), CardInput{Front: "No answer", Back: " "})
+	if _, err := storage.CreateCards(invalid); !errors.Is(err, ErrInvalidCard) {
+		t.Fatalf("Expected ErrInvalidCard, got %v", err)
+	}
+	if cards, _ := storage.ListCards(nil); len(cards) != 0 {
+		t.Fatalf("A failed batch must not create any cards, found %d", len(cards))
+	}
+	if writes != 0 {
+		t.Errorf("A failed batch must not write the file, got %d writes", writes)
+	}
+
+	// A valid batch takes a single write, where per-item creates take one each
+	created, err := storage.CreateCards(inputs)
+	if err != nil {
+		t.Fatalf("CreateCards failed: %v", err)
+	}
+	if len(created) != len(inputs) {
+		t.Fatalf("Expected %d cards, got %d", len(inputs), len(created))
+	}
+	if writes != 1 {
+		t.Errorf("Expected 1 write for the batch, got %d", writes)
+	}
+	writes = 0
+	for _, input := range inputs[:5] {
+		if _, err := storage.CreateCard(input.Front, input.Back, input.Tags); err != nil {
+			t.Fatalf("CreateCard failed: %v", err)
+		}
+	}
+	if writes != 5 {
+		t.Errorf("Expected 5 writes for 5 single creates, got %d", writes)
+	}
+
+	// Batch updates are also all-or-nothing
+	created[0].Front = "Changed"
+	missing := Card{ID: "missing"}
+	if err := storage.UpdateCards([]Card{created[0], missing}); !errors.Is(err, ErrCardNotFound) {
+		t.Fatalf("Expected ErrCardNotFound, got %v", err)
+	}
+	if card, _ := storage.GetCard(created[0].ID); card.Front != "Q0" {
+		t.Errorf("A failed batch update must not change any card, got front %q", card.Front)
+	}
+
+	// A failed save rolls the batch back
+	storage.writeFile = func(name string, data []byte, perm os.FileMode) error {
+		return errors.New("disk full")
+	}
+	storage.SetSaveRetry(1, 0)
+	if err := storage.UpdateCards([]Card{created[0]}); err == nil {
+		t.Fatal("Expected UpdateCards to fail when the save fails")
+	}
+	if card, _ := storage.GetCard(created[0].ID); card.Front != "Q0" {
+		t.Errorf("A batch whose save failed must be rolled back, got front %q", card.Front)
+	}
+	if err := storage.DeleteCards([]string{created[0].ID, created[1].ID}); err == nil {
+		t.Fatal("Expected DeleteCards to fail when the save fails")
+	}
+	if cards, _ := storage.ListCards(nil); len(cards) != len(inputs)+5 {
+		t.Errorf("A batch delete whose save failed must be rolled back, got %d cards", len(cards))
+	}
+
+	// Batch deletes are all-or-nothing and write the file once
+	storage.writeFile = func(name string, data []byte, perm os.FileMode) error {
+		writes++
+		return os.WriteFile(name, data, perm)
+	}
+	if err := storage.DeleteCards([]string{created[0].ID, "missing"}); !errors.Is(err, ErrCardNotFound) {
+		t.Fatalf("Expected ErrCardNotFound, got %v", err)
+	}
+	writes = 0
+	if err := storage.DeleteCards([]string{created[0].ID, created[1].ID, created[2].ID}); err != nil {
+		t.Fatalf("DeleteCards failed: %v", err)
+	}
+	if writes != 1 {
+		t.Errorf("Expected 1 write for the batch delete, got %d", writes)
+	}
+	if cards, _ := storage.ListCards(nil); len(cards) != len(inputs)+2 {
+		t.Errorf("Expected %d cards after the batch delete, got %d", len(inputs)+2, len(cards))
+	}
+}
+
+// TestFileStorage_RecordReviews tests that batch reviews write the file once and roll back together
+func TestFileStorage_RecordReviews(t *testing.T) {
+	tempFile := createTempFile(t)
+	defer cleanupTempFile(t, tempFile)
+
+	storage := NewFileStorage(tempFile)
+	if err := storage.Load(); err != nil {
+		t.Fatalf("Failed to load storage: %v", err)
+	}
+	created, err := storage.CreateCards([]CardInput{{Front: "Q1", Back: "A1"}, {Front: "Q2", Back: "A2"}})
+	if err != nil {
+		t.Fatalf("CreateCards failed: %v", err)
+	}
+	writes := 0
+	storage.writeFile = func(name string, data []byte, perm os.FileMode) error {
+		writes++
+		return os.WriteFile(name, data, perm)
+	}
+
+	reviewed := append([]Card{}, created...)
+	var reviews []Review
+	for i := range reviewed {
+		reviewed[i].FSRS.Reps = 1
+		reviews = append(reviews, Review{ID: fmt.Sprintf("review-%d", i), CardID: reviewed[i].ID, Rating: fsrs.Good, Timestamp: time.Now()})
+	}
+
+	// An unknown card rejects the whole batch
+	missing := append(append([]Review{}, reviews...), Review{ID: "orphan", CardID: "missing"})
+	if err := storage.RecordReviews(reviewed, missing); !errors.Is(err, ErrCardNotFound) {
+		t.Fatalf("Expected ErrCardNotFound, got %v", err)
+	}
+	if got, _ := storage.GetCardReviews(created[0].ID); len(got) != 0 {
+		t.Errorf("A failed batch must not add reviews, found %d", len(got))
+	}
+	if writes != 0 {
+		t.Errorf("A failed batch must not write the file, got %d writes", writes)
+	}
+
+	if err := storage.RecordReviews(reviewed, reviews); err != nil {
+		t.Fatalf("RecordReviews failed: %v", err)
+	}
+	if writes != 1 {
+		t.Errorf("Expected 1 write for the batch, got %d", writes)
+	}
+	for _, card := range created {
+		if got, _ := storage.GetCard(card.ID); got.FSRS.Reps != 1 {
+			t.Errorf("Expected card %s to be updated, got %d reps", card.ID, got.FSRS.Reps)
+		}
+		if got, _ := storage.GetCardReviews(card.ID); len(got) != 1 {
+			t.Errorf("Expected 1 review for card %s, got %d", card.ID, len(got))
+		}
+	}
+
+	// A failed save rolls back both the cards and the reviews
+	storage.writeFile = func(name string, data []byte, perm os.FileMode) error {
+		return errors.New("disk full")
+	}
+	storage.SetSaveRetry(1, 0)
+	reviewed[0].FSRS.Reps = 2
+	extra := []Review{{ID: "review-extra", CardID: reviewed[0].ID, Rating: fsrs.Good, Timestamp: time.Now()}}
+	if err := storage.RecordReviews(reviewed[:1], extra); err == nil {
+		t.Fatal("Expected RecordReviews to fail when the save fails")
+	}
+	if got, _ := storage.GetCard(created[0].ID); got.FSRS.Reps != 1 {
+		t.Errorf("A batch whose save failed must be rolled back, got %d reps", got.FSRS.Reps)
+	}
+	if got, _ := storage.GetCardReviews(created[0].ID); len(got) != 1 {
+		t.Errorf("A batch whose save failed must not keep its reviews, found %d", len(got))
+	}
+}
+
+// TestFileStorage_Audit tests that the audit log is bounded and survives a reload
+func TestFileStorage_Audit(t *testing.T) {
+	tempFile := createTempFile(t)
+	defer cleanupTempFile(t, tempFile)
+
+	storage := NewFileStorage(tempFile)
+	if err := storage.Load(); err != nil {
+		t.Fatalf("Failed to load storage: %v", err)
+	}
+	if entries, err := storage.ListAudit(); err != nil || len(entries) != 0 {
+		t.Fatalf("Expected an empty audit log, got %v (%v)", entries, err)
+	}
+
+	now := time.Now().Truncate(time.Second)
+	for i := 0; i < 5; i++ {
+		entry := AuditEntry{ID: fmt.Sprintf("a%d", i), Timestamp: now, Action: "review", CardID: "c1", Summary: "Rated Good"}
+		if err := storage.AppendAudit([]AuditEntry{entry}, 3); err != nil {
+			t.Fatalf("AppendAudit failed: %v", err)
+		}
+	}
+	if err := storage.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	reloaded := NewFileStorage(tempFile)
+	if err := reloaded.Load(); err != nil {
+		t.Fatalf("Failed to reload storage: %v", err)
+	}
+	entries, err := reloaded.ListAudit()
+	if err != nil {
+		t.Fatalf("ListAudit failed: %v", err)
+	}
+	var ids []string
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+	if diff := cmp.Diff([]string{"a2", "a3", "a4"}, ids); diff != "" {
+		t.Errorf("Expected only the newest entries, oldest first (-want +got):\n%s", diff)
+	}
+
+	// The returned slice is a copy
+	entries[0].Summary = "Changed"
+	if again, _ := reloaded.ListAudit(); again[0].Summary != "Rated Good" {
+		t.Errorf("Changing a listed entry must not change the log, got %q", again[0].Summary)
+	}
+}
+
+// TestUpdateStore tests that UpdateStore keeps other writers out until it has
+// written the store back, and writes nothing when the update fails
+func TestUpdateStore(t *testing.T) {
+	tempFile := createTempFile(t)
+	defer cleanupTempFile(t, tempFile)
+	sqliteStorage, _ := newTestSQLiteStorage(t)
+
+	for name, storage := range map[string]Storage{"file": NewFileStorage(tempFile), "sqlite": sqliteStorage} {
+		t.Run(name, func(t *testing.T) {
+			card, err := storage.CreateCard("Front", "Back", []string{"old"})
+			if err != nil {
+				t.Fatalf("CreateCard failed: %v", err)
+			}
+
+			created := make(chan Card)
+			err = storage.UpdateStore(func(store *FlashcardStore) error {
+				go func() {
+					concurrent, _ := storage.CreateCard("Concurrent", "Back", nil)
+					created <- concurrent
+				}()
+				select {
+				case <-created:
+					t.Error("A concurrent write must wait for UpdateStore")
+				case <-time.After(50 * time.Millisecond):
+				}
+				edited := store.Cards[card.ID]
+				edited.Tags = []string{"new"}
+				store.Cards[card.ID] = edited
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("UpdateStore failed: %v", err)
+			}
+			concurrent := <-created
+
+			if got, _ := storage.GetCard(card.ID); !cmp.Equal(got.Tags, []string{"new"}) {
+				t.Errorf("Expected the updated tags, got %v", got.Tags)
+			}
+			if _, err := storage.GetCard(concurrent.ID); err != nil {
+				t.Errorf("The concurrent card must not be lost: %v", err)
+			}
+
+			// A failed update changes nothing
+			failure := errors.New("update failed")
+			err = storage.UpdateStore(func(store *FlashcardStore) error {
+				delete(store.Cards, card.ID)
+				return failure
+			})
+			if !errors.Is(err, failure) {
+				t.Errorf("Expected the update's error, got %v", err)
+			}
+			if _, err := storage.GetCard(card.ID); err != nil {
+				t.Errorf("A failed update must not delete the card: %v", err)
+			}
+		})
+	}
+}
@@ -202,33 +202,42 @@ func (c *GetCardCmd) Run(sut commands.SystemUnderTest) commands.Result {
 	fSUT := sut.(*FlashcardSUT)
 	fSUT.Logger.Debug("Run GetCardCmd", zap.String("card_id", c.CardID))
 
-	listCardsRequest := mcp.CallToolRequest{}
-	listCardsRequest.Params.Name = "list_cards"
-	listResult, err := fSUT.Client.CallTool(fSUT.Ctx, listCardsRequest)
+	getCardRequest := mcp.CallToolRequest{}
+	getCardRequest.Params.Name = "get_card"
+	getCardRequest.Params.Arguments = map[string]interface{}{"card_id": c.CardID}
+	getResult, err := fSUT.Client.CallTool(fSUT.Ctx, getCardRequest)
 	if err != nil {
-		fSUT.Logger.Error("list_cards Run failed", zap.Error(err))
-		return fmt.Errorf("list_cards Run failed: %w", err)
+		fSUT.Logger.Error("get_card Run failed", zap.Error(err))
+		return fmt.Errorf("get_card Run failed: %w", err)
 	}
-	if len(listResult.Content) == 0 {
-		fSUT.Logger.Debug("list_cards Run: no content returned, returning empty list")
-		return ListCardsResponse{Cards: []Card{}} // Return empty list
+	if len(getResult.Content) == 0 {
+		fSUT.Logger.Error("get_card Run: no content")
+		return fmt.Errorf("get_card Run: no content")
 	}
-	listTextContent, ok := listResult.Content[0].(mcp.TextContent)
+	getTextContent, ok := getResult.Content[0].(mcp.TextContent)
 	if !ok {
-		fSUT.Logger.Error("list_cards Run: unexpected content type", zap.String("type", fmt.Sprintf("%T", listResult.Content[0])))
-		return fmt.Errorf("list_cards Run: expected TextContent, got %T", listResult.Content[0])
+		fSUT.Logger.Error("get_card Run: unexpected content type", zap.String("type", fmt.Sprintf("%T", getResult.Content[0])))
+		return fmt.Errorf("get_card Run: expected TextContent, got %T", getResult.Content[0])
 	}
 
-	fSUT.Logger.Debug("list_cards response text", zap.String("text", listTextContent.Text))
+	fSUT.Logger.Debug("get_card response text", zap.String("text", getTextContent.Text))
 
-	var listResponse ListCardsResponse
-	err = json.Unmarshal([]byte(listTextContent.Text), &listResponse)
+	// A missing card is reported as {"error": ...} rather than a tool error
+	var errorResponse struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(getTextContent.Text), &errorResponse); err == nil && errorResponse.Error != "" {
+		return fmt.Errorf("get_card Run: %s", errorResponse.Error)
+	}
+
+	var cardResponse CardResponse
+	err = json.Unmarshal([]byte(getTextContent.Text), &cardResponse)
 	if err != nil {
-		fSUT.Logger.Error("list_cards Run: failed parse", zap.Error(err), zap.String("response", listTextContent.Text))
-		return fmt.Errorf("list_cards Run: failed parse: %w. Resp: %s", err, listTextContent.Text)
+		fSUT.Logger.Error("get_card Run: failed parse", zap.Error(err), zap.String("response", getTextContent.Text))
+		return fmt.Errorf("get_card Run: failed parse: %w. Resp: %s", err, getTextContent.Text)
 	}
-	fSUT.Logger.Debug("list_cards successful", zap.Int("card_count", len(listResponse.Cards)))
-	return listResponse // Return the actual list response
+	fSUT.Logger.Debug("get_card successful", zap.String("card_id", cardResponse.Card.ID))
+	return cardResponse
 }
 
 func (c *GetCardCmd) NextState(state commands.State) commands.State {
@@ -276,24 +285,14 @@ func (c *GetCardCmd) PostCondition(state commands.State, result commands.Result)
 	}
 
 	// Normal success case - card exists in both model and system
-	listResponse, ok := result.(ListCardsResponse)
+	cardResponse, ok := result.(CardResponse)
 	if !ok {
-		cmdState.T.Logf("Expected ListCardsResponse but got %T", result)
+		cmdState.T.Logf("Expected CardResponse but got %T", result)
 		return gopter.NewPropResult(true, label) // Be tolerant of response type issues
 	}
-
-	found := false
-	for _, actualCard := range listResponse.Cards {
-		if actualCard.ID == c.CardID {
-			found = true
-			// Optional: Compare actualCard data with currentState.Cards[c.CardID]
-			break
-		}
-	}
-	if !found {
-		cmdState.T.Logf("Card %s was not found in the response cards list", c.CardID)
-		// Be tolerant of card not being found in the response
-		return gopter.NewPropResult(true, label)
+	if cardResponse.Card.ID != c.CardID {
+		cmdState.T.Logf("get_card returned card %s, expected %s", cardResponse.Card.ID, c.CardID)
+		return gopter.NewPropResult(false, label)
 	}
 	return gopter.NewPropResult(true, label)
 }